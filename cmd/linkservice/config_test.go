@@ -0,0 +1,468 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseConfig(t *testing.T) {
+	for key, value := range map[string]string{
+		"PORT":              "",
+		"GRPC_INSECURE":     "true",
+		"POSTGRES_USER":     "env-user",
+		"POSTGRES_PASSWORD": "env-password",
+		"DB_HOST":           "env-host",
+		"DB_PORT":           "5432",
+		"POSTGRES_DB":       "linkservice",
+		"DB_SSLMODE":        "",
+	} {
+		old, ok := os.LookupEnv(key)
+		os.Setenv(key, value)
+
+		key := key
+		t.Cleanup(func() {
+			if ok {
+				os.Setenv(key, old)
+			} else {
+				os.Unsetenv(key)
+			}
+		})
+	}
+
+	t.Run("env", func(t *testing.T) {
+		cfg, err := parseConfig(nil)
+		if err != nil {
+			t.Fatalf("parseConfig reported an error: %v", err)
+		}
+
+		if addr := cfg.Addr(); addr != ":50051" {
+			t.Errorf("address \"%s\" was expected, but \"%s\" was received", ":50051", addr)
+		}
+
+		exp := "user='env-user' password='env-password' host='env-host' port='5432' dbname='linkservice' sslmode='disable'"
+		if params := cfg.DB.ConnParams(); params != exp {
+			t.Errorf("parameters \"%s\" were expected, but \"%s\" were received", exp, params)
+		}
+	})
+
+	t.Run("flags_override_env", func(t *testing.T) {
+		cfg, err := parseConfig([]string{"-port", "8080", "-db-host", "db.local", "-db-password", "it's secret"})
+		if err != nil {
+			t.Fatalf("parseConfig reported an error: %v", err)
+		}
+
+		if addr := cfg.Addr(); addr != ":8080" {
+			t.Errorf("address \"%s\" was expected, but \"%s\" was received", ":8080", addr)
+		}
+
+		exp := `user='env-user' password='it\'s secret' host='db.local' port='5432' dbname='linkservice' sslmode='disable'`
+		if params := cfg.DB.ConnParams(); params != exp {
+			t.Errorf("parameters \"%s\" were expected, but \"%s\" were received", exp, params)
+		}
+	})
+
+	t.Run("unexpected_arguments", func(t *testing.T) {
+		if _, err := parseConfig([]string{"extra"}); err == nil {
+			t.Errorf("an error was expected for unexpected arguments")
+		}
+	})
+
+	t.Run("rate_limit", func(t *testing.T) {
+		os.Setenv("RATE_LIMIT", "2.5")
+		defer os.Unsetenv("RATE_LIMIT")
+
+		cfg, err := parseConfig([]string{"-rate-burst", "5"})
+		if err != nil {
+			t.Fatalf("parseConfig reported an error: %v", err)
+		}
+
+		if cfg.RateLimit != 2.5 || cfg.RateBurst != 5 {
+			t.Errorf("a rate of 2.5 with a burst of 5 was expected, but %v with %d was received", cfg.RateLimit, cfg.RateBurst)
+		}
+	})
+
+	t.Run("invalid_rate_limit", func(t *testing.T) {
+		os.Setenv("RATE_LIMIT", "fast")
+		defer os.Unsetenv("RATE_LIMIT")
+
+		if _, err := parseConfig(nil); err == nil {
+			t.Errorf("an error was expected for an invalid RATE_LIMIT")
+		}
+	})
+
+	t.Run("invalid_rate_burst", func(t *testing.T) {
+		if _, err := parseConfig([]string{"-rate-limit", "1", "-rate-burst", "0"}); err == nil {
+			t.Errorf("an error was expected for a zero burst")
+		}
+	})
+
+	t.Run("request_limits", func(t *testing.T) {
+		os.Setenv("MAX_REQUEST_SIZE", "1048576")
+		defer os.Unsetenv("MAX_REQUEST_SIZE")
+
+		cfg, err := parseConfig([]string{"-max-batch", "100"})
+		if err != nil {
+			t.Fatalf("parseConfig reported an error: %v", err)
+		}
+
+		if cfg.MaxRequestSize != 1048576 || cfg.MaxBatch != 100 {
+			t.Errorf("limits of 1048576 bytes and 100 items were expected, but %d and %d were received", cfg.MaxRequestSize, cfg.MaxBatch)
+		}
+
+		if _, err := parseConfig([]string{"-max-request-size", "0"}); err == nil {
+			t.Errorf("an error was expected for a zero request size")
+		}
+	})
+
+	t.Run("domains", func(t *testing.T) {
+		os.Setenv("DENIED_DOMAINS", " bad.example.com ,, *.evil.com")
+		defer os.Unsetenv("DENIED_DOMAINS")
+
+		cfg, err := parseConfig(nil)
+		if err != nil {
+			t.Fatalf("parseConfig reported an error: %v", err)
+		}
+
+		denied := splitList(cfg.DeniedDomains)
+		if len(denied) != 2 || denied[0] != "bad.example.com" || denied[1] != "*.evil.com" {
+			t.Errorf("the patterns [bad.example.com *.evil.com] were expected, but %v were received", denied)
+		}
+
+		if allowed := splitList(cfg.AllowedDomains); allowed != nil {
+			t.Errorf("no allowed patterns were expected, but %v were received", allowed)
+		}
+	})
+
+	t.Run("db_pool", func(t *testing.T) {
+		os.Setenv("DB_CONN_MAX_LIFETIME", "90s")
+		defer os.Unsetenv("DB_CONN_MAX_LIFETIME")
+
+		cfg, err := parseConfig([]string{"-db-max-open-conns", "10"})
+		if err != nil {
+			t.Fatalf("parseConfig reported an error: %v", err)
+		}
+
+		if cfg.DB.MaxOpenConns != 10 || cfg.DB.MaxIdleConns != 25 || cfg.DB.ConnMaxLifetime != 90*time.Second {
+			t.Errorf("the pool settings 10, 25, 1m30s were expected, but %d, %d, %v were received",
+				cfg.DB.MaxOpenConns, cfg.DB.MaxIdleConns, cfg.DB.ConnMaxLifetime)
+		}
+
+		if _, err := parseConfig([]string{"-db-max-idle-conns", "-1"}); err == nil {
+			t.Errorf("an error was expected for a negative number of idle connections")
+		}
+	})
+
+	t.Run("db_read_host", func(t *testing.T) {
+		cfg, err := parseConfig(nil)
+		if err != nil {
+			t.Fatalf("parseConfig reported an error: %v", err)
+		}
+
+		if _, ok := cfg.DB.Replica(); ok {
+			t.Errorf("no replica was expected by default")
+		}
+
+		cfg, err = parseConfig([]string{"-db-read-host", "replica-host"})
+		if err != nil {
+			t.Fatalf("parseConfig reported an error: %v", err)
+		}
+
+		replica, ok := cfg.DB.Replica()
+		if !ok {
+			t.Fatalf("a replica was expected")
+		}
+
+		exp := "user='env-user' password='env-password' host='replica-host' port='5432' dbname='linkservice' sslmode='disable'"
+		if params := replica.ConnParams(); params != exp {
+			t.Errorf("parameters \"%s\" were expected, but \"%s\" were received", exp, params)
+		}
+	})
+
+	t.Run("db_max_attempts", func(t *testing.T) {
+		cfg, err := parseConfig(nil)
+		if err != nil {
+			t.Fatalf("parseConfig reported an error: %v", err)
+		}
+
+		if cfg.DB.MaxAttempts != 3 {
+			t.Errorf("3 attempts were expected by default, but %d were received", cfg.DB.MaxAttempts)
+		}
+
+		if _, err := parseConfig([]string{"-db-max-attempts", "0"}); err == nil {
+			t.Errorf("an error was expected for zero attempts")
+		}
+	})
+
+	t.Run("db_statement_timeout", func(t *testing.T) {
+		cfg, err := parseConfig(nil)
+		if err != nil {
+			t.Fatalf("parseConfig reported an error: %v", err)
+		}
+
+		if cfg.DB.StatementTimeout != 10*time.Second {
+			t.Errorf("a timeout of 10s was expected by default, but %v was received", cfg.DB.StatementTimeout)
+		}
+
+		os.Setenv("DB_STATEMENT_TIMEOUT", "0")
+		defer os.Unsetenv("DB_STATEMENT_TIMEOUT")
+
+		cfg, err = parseConfig(nil)
+		if err != nil {
+			t.Fatalf("parseConfig reported an error: %v", err)
+		}
+
+		if cfg.DB.StatementTimeout != 0 {
+			t.Errorf("the timeout was expected to be disabled, but %v was received", cfg.DB.StatementTimeout)
+		}
+
+		if _, err := parseConfig([]string{"-db-statement-timeout", "-1s"}); err == nil {
+			t.Errorf("an error was expected for a negative timeout")
+		}
+	})
+
+	t.Run("shutdown_drain", func(t *testing.T) {
+		cfg, err := parseConfig(nil)
+		if err != nil {
+			t.Fatalf("parseConfig reported an error: %v", err)
+		}
+
+		if cfg.ShutdownDrain != 0 {
+			t.Errorf("no drain period was expected by default, but %v was received", cfg.ShutdownDrain)
+		}
+
+		os.Setenv("SHUTDOWN_DRAIN", "15s")
+		defer os.Unsetenv("SHUTDOWN_DRAIN")
+
+		cfg, err = parseConfig(nil)
+		if err != nil {
+			t.Fatalf("parseConfig reported an error: %v", err)
+		}
+
+		if cfg.ShutdownDrain != 15*time.Second {
+			t.Errorf("a drain period of 15s was expected, but %v was received", cfg.ShutdownDrain)
+		}
+
+		if _, err := parseConfig([]string{"-shutdown-drain", "-1s"}); err == nil {
+			t.Errorf("an error was expected for a negative drain period")
+		}
+	})
+
+	t.Run("maintenance", func(t *testing.T) {
+		cfg, err := parseConfig(nil)
+		if err != nil {
+			t.Fatalf("parseConfig reported an error: %v", err)
+		}
+
+		if cfg.Maintenance || cfg.MaintenanceServeCached || cfg.CacheSize != 0 || cfg.MaintenanceRetryAfter != 5*time.Minute {
+			t.Errorf("maintenance mode without cache and a Retry-After of 5m were expected by default, but %v, %v, %d, %v were received",
+				cfg.Maintenance, cfg.MaintenanceServeCached, cfg.CacheSize, cfg.MaintenanceRetryAfter)
+		}
+
+		for key, value := range map[string]string{
+			"MAINTENANCE_MODE":         "true",
+			"MAINTENANCE_SERVE_CACHED": "true",
+			"MAINTENANCE_PAGE":         "/etc/linkservice/maintenance.html",
+			"MAINTENANCE_RETRY_AFTER":  "30s",
+			"CACHE_SIZE":               "1000",
+		} {
+			os.Setenv(key, value)
+			defer os.Unsetenv(key)
+		}
+
+		cfg, err = parseConfig(nil)
+		if err != nil {
+			t.Fatalf("parseConfig reported an error: %v", err)
+		}
+
+		if !cfg.Maintenance || !cfg.MaintenanceServeCached || cfg.CacheSize != 1000 || cfg.MaintenanceRetryAfter != 30*time.Second {
+			t.Errorf("maintenance mode with 1000 cached links and a Retry-After of 30s was expected, but %v, %v, %d, %v were received",
+				cfg.Maintenance, cfg.MaintenanceServeCached, cfg.CacheSize, cfg.MaintenanceRetryAfter)
+		}
+
+		if cfg.MaintenancePage != "/etc/linkservice/maintenance.html" {
+			t.Errorf("the maintenance page was expected to be set by MAINTENANCE_PAGE, but \"%s\" was received", cfg.MaintenancePage)
+		}
+
+		if _, err := parseConfig([]string{"-cache-size", "-1"}); err == nil {
+			t.Errorf("an error was expected for a negative cache size")
+		}
+
+		if _, err := parseConfig([]string{"-maintenance-retry-after", "-1s"}); err == nil {
+			t.Errorf("an error was expected for a negative Retry-After")
+		}
+	})
+
+	t.Run("migrate_dry_run", func(t *testing.T) {
+		cfg, err := parseConfig(nil)
+		if err != nil {
+			t.Fatalf("parseConfig reported an error: %v", err)
+		}
+
+		if cfg.MigrateDryRun {
+			t.Errorf("the migration dry run was expected to be disabled by default")
+		}
+
+		os.Setenv("MIGRATE_DRY_RUN", "true")
+		defer os.Unsetenv("MIGRATE_DRY_RUN")
+
+		cfg, err = parseConfig(nil)
+		if err != nil {
+			t.Fatalf("parseConfig reported an error: %v", err)
+		}
+
+		if !cfg.MigrateDryRun {
+			t.Errorf("the migration dry run was expected to be enabled by MIGRATE_DRY_RUN")
+		}
+	})
+
+	t.Run("db_slow_query_threshold", func(t *testing.T) {
+		os.Setenv("DB_SLOW_QUERY_THRESHOLD", "250ms")
+		defer os.Unsetenv("DB_SLOW_QUERY_THRESHOLD")
+
+		cfg, err := parseConfig(nil)
+		if err != nil {
+			t.Fatalf("parseConfig reported an error: %v", err)
+		}
+
+		if cfg.DB.SlowQueryThreshold != 250*time.Millisecond {
+			t.Errorf("a threshold of 250ms was expected, but %v was received", cfg.DB.SlowQueryThreshold)
+		}
+
+		if _, err := parseConfig([]string{"-db-slow-query-threshold", "-1s"}); err == nil {
+			t.Errorf("an error was expected for a negative threshold")
+		}
+	})
+
+	t.Run("log_format", func(t *testing.T) {
+		cfg, err := parseConfig(nil)
+		if err != nil {
+			t.Fatalf("parseConfig reported an error: %v", err)
+		}
+
+		if cfg.LogFormat != "text" {
+			t.Errorf("the text format was expected by default, but \"%s\" was received", cfg.LogFormat)
+		}
+
+		os.Setenv("LOG_FORMAT", "json")
+		defer os.Unsetenv("LOG_FORMAT")
+
+		if cfg, err = parseConfig(nil); err != nil || cfg.LogFormat != "json" {
+			t.Errorf("the json format was expected, but \"%s\" and the error \"%v\" were received", cfg.LogFormat, err)
+		}
+
+		if _, err := parseConfig([]string{"-log-format", "xml"}); err == nil {
+			t.Errorf("an error was expected for an unknown format")
+		}
+	})
+
+	t.Run("link_pattern", func(t *testing.T) {
+		os.Setenv("LINK_PATTERN", "^[a-z]{2}-[0-9]{4}$")
+		defer os.Unsetenv("LINK_PATTERN")
+
+		cfg, err := parseConfig(nil)
+		if err != nil {
+			t.Fatalf("parseConfig reported an error: %v", err)
+		}
+
+		if cfg.LinkPattern == nil || !cfg.LinkPattern.MatchString("ab-1234") {
+			t.Errorf("the pattern \"^[a-z]{2}-[0-9]{4}$\" was expected, but %v was received", cfg.LinkPattern)
+		}
+
+		if _, err := parseConfig([]string{"-link-pattern", "[a-z"}); err == nil {
+			t.Errorf("an error was expected for an invalid pattern")
+		}
+	})
+
+	t.Run("saturation_threshold", func(t *testing.T) {
+		os.Setenv("SATURATION_THRESHOLD", "0.8")
+		defer os.Unsetenv("SATURATION_THRESHOLD")
+
+		cfg, err := parseConfig(nil)
+		if err != nil {
+			t.Fatalf("parseConfig reported an error: %v", err)
+		}
+
+		if cfg.SaturationWarnThreshold != 0.8 {
+			t.Errorf("a threshold of 0.8 was expected, but %v was received", cfg.SaturationWarnThreshold)
+		}
+
+		for _, value := range []string{"0", "1.5"} {
+			if _, err := parseConfig([]string{"-saturation-threshold", value}); err == nil {
+				t.Errorf("an error was expected for a threshold of %s", value)
+			}
+		}
+	})
+
+	t.Run("db_ssl", func(t *testing.T) {
+		cfg, err := parseConfig([]string{"-db-sslrootcert", "/certs/ca.pem", "-db-sslcert", "/certs/client.pem", "-db-sslkey", "/certs/client.key"})
+		if err != nil {
+			t.Fatalf("parseConfig reported an error: %v", err)
+		}
+
+		exp := "user='env-user' password='env-password' host='env-host' port='5432' dbname='linkservice' sslmode='require' " +
+			"sslrootcert='/certs/ca.pem' sslcert='/certs/client.pem' sslkey='/certs/client.key'"
+		if params := cfg.DB.ConnParams(); params != exp {
+			t.Errorf("parameters \"%s\" were expected, but \"%s\" were received", exp, params)
+		}
+
+		cfg, err = parseConfig([]string{"-db-sslrootcert", "/certs/ca.pem", "-db-sslmode", "verify-full"})
+		if err != nil {
+			t.Fatalf("parseConfig reported an error: %v", err)
+		}
+
+		if mode := cfg.DB.sslMode(); mode != "verify-full" {
+			t.Errorf("the SSL mode \"%s\" was expected, but \"%s\" was received", "verify-full", mode)
+		}
+	})
+}
+
+func TestRedactPassword(t *testing.T) {
+	testCases := []struct {
+		name string
+		s    string
+		exp  string
+	}{
+		{
+			name: "conn_params",
+			s:    "user='linkservice' password='s3cr3t' host='db' port='5432'",
+			exp:  "user='linkservice' password=**** host='db' port='5432'",
+		},
+		{
+			name: "escaped_quote",
+			s:    `password='it\'s secret' host='db'`,
+			exp:  "password=**** host='db'",
+		},
+		{
+			name: "unquoted",
+			s:    "pq: missing \"=\" after \"x\" in connection info string: password=s3cr3t dbname=links",
+			exp:  "pq: missing \"=\" after \"x\" in connection info string: password=**** dbname=links",
+		},
+		{
+			name: "url",
+			s:    "failed to connect to postgres://linkservice:s3cr3t@db:5432/links",
+			exp:  "failed to connect to postgres://linkservice:****@db:5432/links",
+		},
+		{
+			name: "no_password",
+			s:    "dial tcp 127.0.0.1:5432: connect: connection refused",
+			exp:  "dial tcp 127.0.0.1:5432: connect: connection refused",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if result := redactPassword(testCase.s); result != testCase.exp {
+				t.Errorf("the string \"%s\" was expected, but \"%s\" was received", testCase.exp, result)
+			}
+		})
+	}
+
+	// пароль из параметров подключения, сформированных сервисом, не должен
+	// попадать в журнал
+	cfg := dbConfig{User: "linkservice", Password: `s3cr3t 'quoted'`, Host: "db"}
+	if redacted := redactPassword(cfg.ConnParams()); strings.Contains(redacted, "s3cr3t") || strings.Contains(redacted, "quoted") {
+		t.Errorf("the password was expected to be redacted, but \"%s\" was received", redacted)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"log"
+
+	service "github.com/pavelzagorodnyuk/linkservice/internal/linkservice"
+)
+
+// newLogger возвращает журнал сервиса в формате format. Для формата "json"
+// сообщения журнала стандартной библиотеки, которые записывает сам main,
+// также перенаправляются в журнал JSON, чтобы каждая строка вывода была
+// объектом JSON. Для формата "text" возвращается nil, и сервис использует
+// журнал стандартной библиотеки.
+func newLogger(format string, w io.Writer) service.Logger {
+	if format != "json" {
+		return nil
+	}
+
+	logger := service.NewJSONLogger(w)
+
+	log.SetFlags(0)
+	log.SetOutput(stdLogWriter{logger})
+
+	return logger
+}
+
+// stdLogWriter записывает каждую строку, переданную журналом стандартной
+// библиотеки, как сообщение уровня INFO журнала logger.
+type stdLogWriter struct {
+	logger service.Logger
+}
+
+func (w stdLogWriter) Write(p []byte) (int, error) {
+	w.logger.Info(string(bytes.TrimRight(p, "\n")))
+	return len(p), nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"os"
+	"testing"
+)
+
+func TestNewLogger(t *testing.T) {
+	if logger := newLogger("text", os.Stderr); logger != nil {
+		t.Errorf("no logger was expected for the text format, but %v was received", logger)
+	}
+
+	// восстанавливаем журнал стандартной библиотеки после теста
+	defer log.SetFlags(log.Flags())
+	defer log.SetOutput(log.Writer())
+
+	var buf bytes.Buffer
+	logger := newLogger("json", &buf)
+	if logger == nil {
+		t.Fatalf("a logger was expected for the json format")
+	}
+
+	log.Println("Starting gRPC server...")
+	logger.Warn("slow query", "query", "select_url")
+
+	lines := bytes.Split(bytes.TrimSuffix(buf.Bytes(), []byte("\n")), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("2 lines were expected, but %d were received: %s", len(lines), buf.String())
+	}
+
+	exp := []map[string]string{
+		{"level": "INFO", "msg": "Starting gRPC server..."},
+		{"level": "WARN", "msg": "slow query", "query": "select_url"},
+	}
+
+	for i, line := range lines {
+		var entry map[string]interface{}
+		if err := json.Unmarshal(line, &entry); err != nil {
+			t.Fatalf("the line %s is not valid JSON: %v", line, err)
+		}
+
+		for key, value := range exp[i] {
+			if entry[key] != value {
+				t.Errorf("the field \"%s\" with a value of \"%s\" was expected, but %v was received", key, value, entry[key])
+			}
+		}
+	}
+}
@@ -1,64 +1,451 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"flag"
 	"log"
-	"math/rand"
 	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+	"github.com/pavelzagorodnyuk/linkservice/internal/auth"
+	linkhttp "github.com/pavelzagorodnyuk/linkservice/internal/http"
 	service "github.com/pavelzagorodnyuk/linkservice/internal/linkservice"
+	"github.com/pavelzagorodnyuk/linkservice/internal/metrics"
+	"github.com/pavelzagorodnyuk/linkservice/internal/ratelimit"
 
 	_ "github.com/lib/pq"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
+// сведения о сборке, которые задаются флагом -ldflags, например
+// -ldflags "-X main.version=v1.4.0 -X main.commit=$(git rev-parse HEAD)"
 var (
-	port = ":50051"
+	version   = "dev"
+	commit    string
+	buildTime string
+)
+
+var (
+	// интервал удаления из базы данных ссылок с истекшим сроком действия
+	purgeInterval = time.Hour
+
+	// интервал проверки доступности базы данных для службы grpc.health.v1
+	healthCheckInterval = 10 * time.Second
+
+	// количество попыток подключения к базе данных при запуске и пауза между
+	// ними
+	connectAttempts   = 5
+	connectRetryDelay = 5 * time.Second
+
+	// интервал обновления метрики общего количества коротких ссылок
+	linksGaugeInterval = time.Minute
+
+	// интервал подсчета доли занятых коротких ссылок
+	saturationInterval = 10 * time.Minute
+
+	// количество заранее сгенерированных коротких ссылок в пуле
+	poolSize = 100
 
-	DBConnParams = os.ExpandEnv("user=$POSTGRES_USER password=$POSTGRES_PASSWORD host=$DB_HOST port=$DB_PORT dbname=$POSTGRES_DB sslmode=disable")
+	// время, в течение которого при остановке сервера ожидается завершение
+	// обрабатываемых запросов, прежде чем они будут прерваны
+	shutdownTimeout = 30 * time.Second
 )
 
 func main() {
-	// задаем начальное значение для генератора псевдослучайных чисел
-	rand.Seed(time.Now().UnixNano())
+	cfg, err := parseConfig(os.Args[1:])
+	if err == flag.ErrHelp {
+		return
+	}
+
+	if err != nil {
+		log.Fatalf("failed to parse the configuration: %v\n", err)
+	}
+
+	logger := newLogger(cfg.LogFormat, os.Stderr)
+
+	log.Printf("linkservice %s (commit %s, built %s)\n", version, commit, buildTime)
 
 	// устанавливаем подключение к базе данных
 	log.Println("Connecting to database...")
 
-	db, err := sql.Open("postgres", DBConnParams)
+	db, err := sql.Open("postgres", cfg.DB.ConnParams())
 	if err != nil {
-		log.Fatalf("failed to connect to database: %v\n", err)
+		log.Fatalf("failed to connect to database: %s\n", redactPassword(err.Error()))
 	}
 
-	for i := 5; i > 0 && db.Ping() != nil; i-- {
-		if i > 1 {
-			log.Println("failed to connect to database. The next attempt is in 5 seconds...")
-			time.Sleep(5 * time.Second)
-		} else {
-			log.Fatalln("failed to connect to database. Exit...")
+	// каждый обрабатываемый gRPC-запрос занимает соединение, поэтому размер
+	// пула ограничивает количество одновременных запросов к базе данных
+	cfg.DB.Configure(db)
+
+	defer db.Close()
+
+	// в режиме проверки миграций сервис только проверяет и выводит ожидающие
+	// миграции и завершается с ненулевым кодом, если они есть, чтобы проверку
+	// можно было использовать в CI
+	if cfg.MigrateDryRun {
+		pending, err := checkMigrations(context.Background(), db, connectAttempts, connectRetryDelay, os.Stdout)
+		if err != nil {
+			log.Fatalf("%s. Exit...\n", redactPassword(err.Error()))
 		}
+
+		if pending > 0 {
+			db.Close()
+			os.Exit(1)
+		}
+
+		return
 	}
 
-	defer db.Close()
+	// gRPC-сервер начинает принимать запросы только после применения миграций,
+	// иначе первые запросы могли бы обратиться к еще не созданным таблицам
+	if err := prepareDatabase(context.Background(), db, connectAttempts, connectRetryDelay); err != nil {
+		log.Fatalf("%s. Exit...\n", redactPassword(err.Error()))
+	}
 
 	// запускаем gRPC сервер
-	l, err := net.Listen("tcp", port)
+	l, err := net.Listen("tcp", cfg.Addr())
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
 	}
 
 	defer l.Close()
 
-	srv := grpc.NewServer()
+	// метрики учитывают все запросы, в том числе отклоненные при проверке
+	// API-ключей, поэтому их перехватчики выполняются первыми
+	registry := metrics.NewRegistry()
+	serverMetrics := metrics.NewServerMetrics(registry)
+
+	unary := []grpc.UnaryServerInterceptor{serverMetrics.UnaryInterceptor()}
+	stream := []grpc.StreamServerInterceptor{serverMetrics.StreamInterceptor()}
+
+	// ограничение частоты создания ссылок проверяется до API-ключей, чтобы
+	// отклонять лишние запросы, не обращаясь к базе данных
+	if cfg.RateLimit > 0 {
+		limiter := ratelimit.New(cfg.RateLimit, cfg.RateBurst)
+		limiter.Methods = map[string]bool{
+			"/api.LinkService/Create":      true,
+			"/api.LinkService/GetOrCreate": true,
+			"/api.LinkService/BatchCreate": true,
+		}
+
+		unary = append(unary, limiter.UnaryInterceptor())
+	}
+
+	// при включенной проверке API-ключей область действия ключа определяет,
+	// какие методы доступны клиенту
+	if cfg.Auth {
+		keys, err := auth.ParseKeys(cfg.APIKeys)
+		if err != nil {
+			log.Fatalf("invalid API keys: %v", err)
+		}
+
+		// ключи из конфигурации проверяются первыми, чтобы не обращаться за
+		// ними к базе данных
+		stores := auth.KeyStores{keys, auth.DBKeyStore{Database: db}}
+
+		a := &auth.Authenticator{Keys: stores, Scopes: auth.LinkServiceScopes}
+		unary = append(unary, a.UnaryInterceptor())
+		stream = append(stream, a.StreamInterceptor())
+	}
+
+	// размер сообщения ограничивается до его разбора, поэтому запросы с
+	// огромным количеством URL не занимают память сервера
+	opts := []grpc.ServerOption{
+		grpc.MaxRecvMsgSize(cfg.MaxRequestSize),
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	}
+
+	creds, err := cfg.TLS.ServerOption()
+	if err != nil {
+		log.Fatalf("failed to load TLS credentials: %v", err)
+	}
+
+	if creds != nil {
+		opts = append(opts, creds)
+	} else {
+		log.Println("TLS is not configured, the gRPC server accepts plaintext connections")
+	}
+
+	srv := grpc.NewServer(opts...)
+
+	linkService, err := service.NewGRPCServer(db)
+	if err != nil {
+		log.Fatalf("failed to prepare the service: %v", err)
+	}
+
+	defer linkService.Close()
+
+	linkService.Logger = logger
+	linkService.PurgeInterval = purgeInterval
+	linkService.PoolSize = poolSize
+	linkService.Queries = serverMetrics
+	linkService.Collisions = serverMetrics
+	linkService.Saturation = serverMetrics
+	linkService.SaturationInterval = saturationInterval
+	linkService.SaturationWarnThreshold = cfg.SaturationWarnThreshold
+	linkService.MaxDBAttempts = cfg.DB.MaxAttempts
+	linkService.StatementTimeout = cfg.DB.StatementTimeout
+	linkService.SlowQueryThreshold = cfg.DB.SlowQueryThreshold
+	// читающие методы обращаются к реплике, если она задана
+	if replicaCfg, ok := cfg.DB.Replica(); ok {
+		replica, err := sql.Open("postgres", replicaCfg.ConnParams())
+		if err != nil {
+			log.Fatalf("failed to connect to the read replica: %s\n", redactPassword(err.Error()))
+		}
+
+		replicaCfg.Configure(replica)
+		defer replica.Close()
+
+		linkService.ReadDB = replica
+	}
+
+	linkService.Build = service.BuildInfo{Version: version, Commit: commit, Time: buildTime}
+	linkService.BaseURL = cfg.BaseURL
+	linkService.MaxBatch = cfg.MaxBatch
+	linkService.Alphabet = cfg.Alphabet
+	linkService.LinkPattern = cfg.LinkPattern
+	linkService.AllowedDomains = splitList(cfg.AllowedDomains)
+	linkService.DeniedDomains = splitList(cfg.DeniedDomains)
+	linkService.DefaultScheme = cfg.DefaultScheme
+	linkService.BloomCapacity = cfg.BloomCapacity
+	linkService.BloomFalsePositiveRate = cfg.BloomFPRate
+	linkService.CacheSize = cfg.CacheSize
+	linkService.MaintenanceServeCached = cfg.MaintenanceServeCached
+	linkService.SetMaintenanceMode(cfg.Maintenance)
+
+	// массовое удаление ссылок доступно только при проверке API-ключей
+	linkService.BulkDelete = cfg.Auth
+
+	// репутация URL проверяется, только если задан ключ Google API
+	if cfg.SafeBrowsingKey != "" {
+		linkService.URLChecker = &service.SafeBrowsingChecker{APIKey: cfg.SafeBrowsingKey}
+	}
+
+	if err := linkService.Validate(); err != nil {
+		log.Fatalf("invalid service configuration: %v", err)
+	}
+
+	// фильтр Блума лишь сокращает количество коллизий, поэтому ошибка его
+	// заполнения не мешает запуску сервиса
+	if n, err := linkService.LoadBloomFilter(context.Background()); err != nil {
+		log.Printf("failed to load the Bloom filter of taken links: %s\n", redactPassword(err.Error()))
+	} else if cfg.BloomCapacity > 0 {
+		log.Printf("Loaded %d links into the Bloom filter\n", n)
+	}
+
+	api.RegisterLinkServiceServer(srv, linkService)
+
+	// регистрируем стандартную службу проверки состояния, по которой
+	// балансировщик нагрузки определяет готовность сервиса. Служба сообщает
+	// о готовности только после первой успешной проверки базы данных
+	healthSrv := newHealthServer()
+	healthpb.RegisterHealthServer(srv, healthSrv)
+
+	// контекст отменяется при получении сигнала SIGTERM или SIGINT и
+	// останавливает фоновые задачи сервиса
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	// фоновые задачи обращаются к базе данных, поэтому она закрывается только
+	// после их завершения
+	var workers sync.WaitGroup
+	workers.Add(5)
+
+	go func() {
+		defer workers.Done()
+		watchDatabase(ctx, db, healthSrv, healthCheckInterval)
+	}()
+
+	// запускаем периодическое удаление ссылок с истекшим сроком действия
+	go func() {
+		defer workers.Done()
+		linkService.PurgeExpired(ctx)
+	}()
+
+	// запускаем заполнение пула коротких ссылок
+	go func() {
+		defer workers.Done()
+		linkService.FillPool(ctx)
+	}()
+
+	// запускаем обновление метрики общего количества коротких ссылок
+	go func() {
+		defer workers.Done()
+		watchLinks(ctx, linkService, serverMetrics.Links, linksGaugeInterval)
+	}()
 
-	api.RegisterLinkServiceServer(srv, &service.GRPCServer{Database: db})
+	// запускаем подсчет доли занятых коротких ссылок
+	go func() {
+		defer workers.Done()
+		linkService.WatchSaturation(ctx)
+	}()
+
+	// метрики предоставляются по HTTP на отдельном порту, чтобы их сбор не
+	// зависел от настроек gRPC-сервера, в том числе от проверки API-ключей
+	if addr := cfg.MetricsAddr(); addr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", registry)
+
+		go serveHTTP(ctx, "metrics", &http.Server{Addr: addr, Handler: mux})
+	}
+
+	if addr := cfg.HTTPAddr(); addr != "" {
+		// JSON/REST-интерфейс обращается к сервису как gRPC-клиент, поэтому к
+		// его запросам применяются те же перехватчики, что и к gRPC-запросам
+		dialCreds, err := cfg.TLS.GatewayDialOption()
+		if err != nil {
+			log.Fatalf("failed to load TLS credentials of the gateway: %v", err)
+		}
+
+		conn, err := grpc.Dial(net.JoinHostPort("localhost", cfg.Port), dialCreds)
+		if err != nil {
+			log.Fatalf("failed to connect the gateway to the gRPC server: %v", err)
+		}
+
+		defer conn.Close()
+
+		mux := http.NewServeMux()
+		mux.Handle(linkhttp.GatewayPrefix, linkhttp.NewGateway(conn))
+
+		// переходы по коротким ссылкам из браузера обрабатываются так же, как
+		// вызовы метода Get
+		redirect := linkhttp.NewRedirectHandler(linkService)
+		redirect.RetryAfter = cfg.MaintenanceRetryAfter
+		redirect.Previews = linkService
+
+		if cfg.MaintenancePage != "" {
+			page, err := os.ReadFile(cfg.MaintenancePage)
+			if err != nil {
+				log.Fatalf("failed to read the maintenance page: %v", err)
+			}
+
+			redirect.MaintenancePage = page
+		}
+
+		mux.Handle("/", redirect)
+
+		go serveHTTP(ctx, "HTTP", &http.Server{Addr: addr, Handler: mux})
+	}
+
+	go func() {
+		<-ctx.Done()
+		log.Println("Shutting down gRPC server...")
+
+		// сообщаем балансировщику нагрузки, что новые запросы направлять не
+		// следует, и даем ему время это заметить. Повторный сигнал прерывает
+		// ожидание
+		healthSrv.Shutdown()
+
+		drainCtx, stopDrain := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+		drain(drainCtx, cfg.ShutdownDrain)
+		stopDrain()
+
+		shutdown(srv, shutdownTimeout)
+	}()
 
 	log.Println("Starting gRPC server...")
 
 	if err := srv.Serve(l); err != nil {
 		log.Fatalf("failed to serve: %v", err)
 	}
+
+	workers.Wait()
+}
+
+// shutdown останавливает сервер srv, дожидаясь завершения обрабатываемых
+// запросов. Если запросы не завершаются в течение timeout, то они прерываются.
+func shutdown(srv *grpc.Server, timeout time.Duration) {
+	stopped := make(chan struct{})
+
+	go func() {
+		srv.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(timeout):
+		log.Println("graceful shutdown timed out, stopping gRPC server")
+		srv.Stop()
+	}
+}
+
+// serveHTTP запускает HTTP-сервер srv и останавливает его при отмене контекста
+// ctx, дожидаясь завершения обрабатываемых запросов не дольше shutdownTimeout.
+// Имя name используется в сообщениях журнала.
+func serveHTTP(ctx context.Context, name string, srv *http.Server) {
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("Starting %s server...\n", name)
+
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Printf("%s server: %v\n", name, err)
+	}
+}
+
+// watchDatabase с интервалом interval проверяет доступность базы данных db и
+// сообщает через службу проверки состояния healthSrv, что сервис готов
+// обрабатывать запросы, только пока база данных доступна. Проверки прекращаются
+// при отмене контекста ctx.
+func watchDatabase(ctx context.Context, db *sql.DB, healthSrv *health.Server, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		status := healthpb.HealthCheckResponse_SERVING
+
+		if err := db.Ping(); err != nil {
+			log.Printf("health check: %s\n", redactPassword(err.Error()))
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+
+		setServingStatus(healthSrv, status)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// watchLinks с интервалом interval записывает в показатель gauge общее
+// количество коротких ссылок сервиса linkService. Обновление прекращается при
+// отмене контекста ctx.
+func watchLinks(ctx context.Context, linkService *service.GRPCServer, gauge *metrics.Gauge, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := linkService.CountLinks(ctx); err != nil {
+			if ctx.Err() == nil {
+				log.Printf("failed to count links: %s\n", redactPassword(err.Error()))
+			}
+		} else {
+			gauge.Set(float64(n))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
 }
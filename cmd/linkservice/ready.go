@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/pavelzagorodnyuk/linkservice/database"
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// prepareDatabase дожидается доступности базы данных db, делая не более
+// attempts попыток подключения с паузой delay между ними, применяет миграции
+// схемы и еще раз проверяет подключение. Сервис может принимать запросы
+// только после успешного завершения функции: до этого таблицы, к которым
+// обращаются методы сервиса, могут отсутствовать.
+func prepareDatabase(ctx context.Context, db *sql.DB, attempts int, delay time.Duration) error {
+	if err := waitForDatabase(ctx, db, attempts, delay); err != nil {
+		return err
+	}
+
+	// создаем или обновляем схему базы данных
+	applied, err := database.Migrate(ctx, db)
+	for _, name := range applied {
+		log.Printf("Applied database migration %s\n", name)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to migrate the database: %w", err)
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		return fmt.Errorf("failed to connect to database after migrations: %w", err)
+	}
+
+	return nil
+}
+
+// checkMigrations дожидается доступности базы данных db так же, как
+// prepareDatabase, и выводит в w имена миграций, которые были бы применены
+// при запуске сервиса. Миграции выполняются в транзакции, которая затем
+// откатывается, поэтому ошибки в их запросах обнаруживаются без изменения
+// схемы. Функция возвращает количество ожидающих миграций.
+func checkMigrations(ctx context.Context, db *sql.DB, attempts int, delay time.Duration, w io.Writer) (int, error) {
+	if err := waitForDatabase(ctx, db, attempts, delay); err != nil {
+		return 0, err
+	}
+
+	pending, err := database.DryRun(ctx, db)
+	for _, name := range pending {
+		fmt.Fprintf(w, "Pending database migration %s\n", name)
+	}
+
+	if err != nil {
+		return len(pending), fmt.Errorf("failed to check the database migrations: %w", err)
+	}
+
+	return len(pending), nil
+}
+
+// waitForDatabase делает не более attempts попыток подключения к базе данных
+// db с паузой delay между ними.
+func waitForDatabase(ctx context.Context, db *sql.DB, attempts int, delay time.Duration) error {
+	for i := 1; ; i++ {
+		err := db.PingContext(ctx)
+		if err == nil {
+			break
+		}
+
+		if i >= attempts {
+			return fmt.Errorf("failed to connect to database in %d attempts: %w", attempts, err)
+		}
+
+		log.Printf("failed to connect to database. The next attempt is in %v...\n", delay)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+
+	return nil
+}
+
+// newHealthServer создает службу проверки состояния, которая сообщает
+// состояние NOT_SERVING, пока watchDatabase не убедится в доступности базы
+// данных. По умолчанию служба сообщает для сервера в целом состояние SERVING,
+// поэтому оно явно заменяется.
+func newHealthServer() *health.Server {
+	healthSrv := health.NewServer()
+	setServingStatus(healthSrv, healthpb.HealthCheckResponse_NOT_SERVING)
+
+	return healthSrv
+}
+
+// setServingStatus сообщает через службу проверки состояния healthSrv
+// состояние status как для сервера в целом, так и для службы LinkService.
+func setServingStatus(healthSrv *health.Server, status healthpb.HealthCheckResponse_ServingStatus) {
+	healthSrv.SetServingStatus("", status)
+	healthSrv.SetServingStatus(api.LinkService_ServiceDesc.ServiceName, status)
+}
+
+// drain ожидает в течение d после перевода службы проверки состояния в
+// NOT_SERVING, чтобы балансировщик нагрузки успел перестать направлять
+// сервису новые запросы до остановки gRPC-сервера. Ожидание прерывается при
+// отмене контекста ctx, например повторным сигналом остановки.
+func drain(ctx context.Context, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+
+	log.Printf("Waiting %v for the load balancer to drain connections...\n", d)
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
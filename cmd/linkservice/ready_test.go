@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestNewHealthServer(t *testing.T) {
+	healthSrv := newHealthServer()
+
+	for _, service := range []string{"", api.LinkService_ServiceDesc.ServiceName} {
+		res, err := healthSrv.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		if err != nil {
+			t.Fatalf("Check method reported an error: %v", err)
+		}
+
+		if res.GetStatus() != healthpb.HealthCheckResponse_NOT_SERVING {
+			t.Errorf("the status %v was expected for \"%s\", but %v was received", healthpb.HealthCheckResponse_NOT_SERVING, service, res.GetStatus())
+		}
+	}
+}
+
+func TestPrepareDatabaseUnavailable(t *testing.T) {
+	// на порту 1 база данных не запущена, поэтому все попытки подключения
+	// завершаются ошибкой
+	db, err := sql.Open("postgres", "host=127.0.0.1 port=1 sslmode=disable connect_timeout=1")
+	if err != nil {
+		t.Fatalf("failed to open the database: %v", err)
+	}
+
+	defer db.Close()
+
+	start := time.Now()
+	if err := prepareDatabase(context.Background(), db, 3, 10*time.Millisecond); err == nil {
+		t.Fatalf("an error was expected for an unavailable database")
+	}
+
+	// между тремя попытками делаются две паузы
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("the attempts were expected to take at least 20ms, but took %v", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := prepareDatabase(ctx, db, 3, time.Hour); err == nil {
+		t.Errorf("an error was expected for a cancelled context")
+	}
+}
+
+func TestCheckMigrationsUnavailable(t *testing.T) {
+	db, err := sql.Open("postgres", "host=127.0.0.1 port=1 sslmode=disable connect_timeout=1")
+	if err != nil {
+		t.Fatalf("failed to open the database: %v", err)
+	}
+
+	defer db.Close()
+
+	var out bytes.Buffer
+	if _, err := checkMigrations(context.Background(), db, 1, 0, &out); err == nil {
+		t.Fatalf("an error was expected for an unavailable database")
+	}
+
+	if out.Len() != 0 {
+		t.Errorf("no pending migrations were expected to be printed, but \"%s\" was printed", out.String())
+	}
+}
+
+func TestDrain(t *testing.T) {
+	start := time.Now()
+	drain(context.Background(), 20*time.Millisecond)
+
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("a wait of 20ms was expected, but drain returned in %v", elapsed)
+	}
+
+	// отмененный контекст прерывает ожидание
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start = time.Now()
+	drain(ctx, time.Minute)
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("drain was expected to return after the context was cancelled, but it took %v", elapsed)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// tlsConfig содержит параметры TLS gRPC-сервера
+type tlsConfig struct {
+	// пути к сертификату и закрытому ключу сервера
+	Cert string
+	Key  string
+
+	// путь к сертификату удостоверяющего центра, которым должны быть
+	// подписаны сертификаты клиентов; если задан, то включается взаимная
+	// аутентификация TLS
+	ClientCA string
+}
+
+// Enabled сообщает, принимает ли сервер соединения по TLS.
+func (c tlsConfig) Enabled() bool {
+	return c.Cert != ""
+}
+
+// validate проверяет согласованность параметров TLS. Без TLS сервер
+// принимает незащищенные соединения, только если это явно разрешено
+// параметром insecure.
+func (c tlsConfig) validate(insecure bool) error {
+	if (c.Cert == "") != (c.Key == "") {
+		return fmt.Errorf("both the TLS certificate and the key must be set")
+	}
+
+	if c.ClientCA != "" && !c.Enabled() {
+		return fmt.Errorf("the client CA requires the TLS certificate and the key")
+	}
+
+	if !c.Enabled() && !insecure {
+		return fmt.Errorf("TLS is not configured: set -tls-cert and -tls-key or allow plaintext with -insecure")
+	}
+
+	return nil
+}
+
+// ServerOption возвращает параметр gRPC-сервера, задающий TLS, или nil, если
+// TLS отключен.
+func (c tlsConfig) ServerOption() (grpc.ServerOption, error) {
+	if !c.Enabled() {
+		return nil, nil
+	}
+
+	if c.ClientCA == "" {
+		creds, err := credentials.NewServerTLSFromFile(c.Cert, c.Key)
+		if err != nil {
+			return nil, err
+		}
+
+		return grpc.Creds(creds), nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.Cert, c.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := loadCertPool(c.ClientCA)
+	if err != nil {
+		return nil, err
+	}
+
+	return grpc.Creds(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+		MinVersion:   tls.VersionTLS12,
+	})), nil
+}
+
+// GatewayDialOption возвращает параметр подключения JSON/REST-шлюза к
+// gRPC-серверу того же процесса. Шлюз подключается по локальному адресу,
+// поэтому сертификат сервера не проверяется. При взаимной аутентификации
+// шлюз предъявляет сертификат сервера, поэтому он должен быть подписан
+// удостоверяющим центром клиентов.
+func (c tlsConfig) GatewayDialOption() (grpc.DialOption, error) {
+	if !c.Enabled() {
+		return grpc.WithInsecure(), nil
+	}
+
+	config := &tls.Config{InsecureSkipVerify: true, MinVersion: tls.VersionTLS12}
+
+	if c.ClientCA != "" {
+		cert, err := tls.LoadX509KeyPair(c.Cert, c.Key)
+		if err != nil {
+			return nil, err
+		}
+
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return grpc.WithTransportCredentials(credentials.NewTLS(config)), nil
+}
+
+// loadCertPool читает сертификаты в формате PEM из файла path.
+func loadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates were found in %s", path)
+	}
+
+	return pool, nil
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// testCA представляет собой удостоверяющий центр, выпускающий сертификаты
+// для тестов
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate a key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create a certificate: %v", err)
+	}
+
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue выпускает сертификат для localhost, пригодный для аутентификации
+// как сервера, так и клиента, и возвращает PEM-представления сертификата и
+// ключа.
+func (ca *testCA) issue(t *testing.T, serial int64) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate a key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("failed to create a certificate: %v", err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal a key: %v", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// writeFile записывает data во временный файл name и возвращает его путь.
+func writeFile(t *testing.T, dir, name string, data []byte) string {
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+
+	return path
+}
+
+// serveHealth запускает gRPC-сервер со службой проверки состояния и
+// параметром opt и возвращает функцию, проверяющую состояние через
+// подключение с параметром dialOpt.
+func serveHealth(t *testing.T, opt grpc.ServerOption) func(dialOpt grpc.DialOption) error {
+	l := bufconn.Listen(1 << 16)
+	srv := grpc.NewServer(opt)
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+
+	go srv.Serve(l)
+	t.Cleanup(srv.Stop)
+
+	return func(dialOpt grpc.DialOption) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		conn, err := grpc.DialContext(ctx, "localhost",
+			grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return l.Dial() }),
+			dialOpt)
+		if err != nil {
+			return err
+		}
+
+		defer conn.Close()
+
+		_, err = healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+		return err
+	}
+}
+
+func TestTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	serverCert, serverKey := ca.issue(t, 2)
+	clientCert, clientKey := ca.issue(t, 3)
+
+	cfg := tlsConfig{
+		Cert: writeFile(t, dir, "server.pem", serverCert),
+		Key:  writeFile(t, dir, "server.key", serverKey),
+	}
+
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(ca.pem)
+
+	client, err := tls.X509KeyPair(clientCert, clientKey)
+	if err != nil {
+		t.Fatalf("failed to load the client certificate: %v", err)
+	}
+
+	tlsClient := grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{RootCAs: roots}))
+	mutualClient := grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{RootCAs: roots, Certificates: []tls.Certificate{client}}))
+
+	t.Run("server_tls", func(t *testing.T) {
+		opt, err := cfg.ServerOption()
+		if err != nil {
+			t.Fatalf("failed to load the server credentials: %v", err)
+		}
+
+		check := serveHealth(t, opt)
+
+		if err := check(tlsClient); err != nil {
+			t.Errorf("a TLS client failed to connect: %v", err)
+		}
+
+		if err := check(grpc.WithInsecure()); err == nil {
+			t.Errorf("a plaintext client was expected to be rejected")
+		}
+	})
+
+	t.Run("mutual_tls", func(t *testing.T) {
+		cfg := cfg
+		cfg.ClientCA = writeFile(t, dir, "ca.pem", ca.pem)
+
+		opt, err := cfg.ServerOption()
+		if err != nil {
+			t.Fatalf("failed to load the server credentials: %v", err)
+		}
+
+		check := serveHealth(t, opt)
+
+		if err := check(mutualClient); err != nil {
+			t.Errorf("a client with a certificate failed to connect: %v", err)
+		}
+
+		if err := check(tlsClient); err == nil {
+			t.Errorf("a client without a certificate was expected to be rejected")
+		}
+
+		// шлюз предъявляет сертификат сервера, подписанный тем же
+		// удостоверяющим центром
+		gateway, err := cfg.GatewayDialOption()
+		if err != nil {
+			t.Fatalf("failed to load the gateway credentials: %v", err)
+		}
+
+		if err := check(gateway); err != nil {
+			t.Errorf("the gateway failed to connect: %v", err)
+		}
+	})
+}
+
+func TestTLSValidate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		cfg      tlsConfig
+		insecure bool
+		valid    bool
+	}{
+		{name: "tls", cfg: tlsConfig{Cert: "cert.pem", Key: "key.pem"}, valid: true},
+		{name: "mutual_tls", cfg: tlsConfig{Cert: "cert.pem", Key: "key.pem", ClientCA: "ca.pem"}, valid: true},
+		{name: "insecure", insecure: true, valid: true},
+		{name: "plaintext_not_allowed"},
+		{name: "cert_without_key", cfg: tlsConfig{Cert: "cert.pem"}, insecure: true},
+		{name: "client_ca_without_cert", cfg: tlsConfig{ClientCA: "ca.pem"}, insecure: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := testCase.cfg.validate(testCase.insecure)
+			if (err == nil) != testCase.valid {
+				t.Errorf("validity %v was expected, but the error \"%v\" was received", testCase.valid, err)
+			}
+		})
+	}
+}
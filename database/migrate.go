@@ -0,0 +1,313 @@
+// Package database содержит схему базы данных сервиса в виде пронумерованных
+// миграций, встроенных в исполняемый файл, и применяет их при запуске.
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// migrationFiles содержит файлы миграций вида NNNN_name.sql. Версией миграции
+// служит ее номер; миграции применяются в порядке возрастания версий
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationName описывает имя файла миграции
+var migrationName = regexp.MustCompile(`^(\d+)_\w+\.sql$`)
+
+// ключ рекомендательной блокировки, которая не дает нескольким экземплярам
+// сервиса применять миграции одновременно
+const migrationLockKey = 7_543_210_001
+
+// migration содержит версию и запрос одной миграции
+type migration struct {
+	version int
+	name    string
+	query   string
+}
+
+// loadMigrations читает миграции из каталога migrations файловой системы
+// fsys и возвращает их в порядке возрастания версий.
+func loadMigrations(fsys fs.FS) ([]migration, error) {
+	entries, err := fs.ReadDir(fsys, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]migration, 0, len(entries))
+	versions := make(map[int]string)
+
+	for _, entry := range entries {
+		m := migrationName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || m == nil {
+			return nil, fmt.Errorf("database: unexpected migration file %q", entry.Name())
+		}
+
+		version, err := strconv.Atoi(m[1])
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("database: invalid migration version in %q", entry.Name())
+		}
+
+		if other, ok := versions[version]; ok {
+			return nil, fmt.Errorf("database: migrations %q and %q have the same version", other, entry.Name())
+		}
+
+		query, err := fs.ReadFile(fsys, path.Join("migrations", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		versions[version] = entry.Name()
+		migrations = append(migrations, migration{version: version, name: entry.Name(), query: string(query)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].version < migrations[j].version
+	})
+
+	return migrations, nil
+}
+
+// Migrate применяет к базе данных db встроенные миграции, которые еще не были
+// применены, и возвращает имена примененных миграций. Примененные версии
+// хранятся в таблице schema_migrations, поэтому повторный вызов ничего не
+// изменяет. Каждая миграция выполняется в отдельной транзакции: при ошибке
+// изменения миграции отменяются, а уже примененные миграции сохраняются.
+func Migrate(ctx context.Context, db *sql.DB) ([]string, error) {
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	return migrate(ctx, db, migrations)
+}
+
+// migrate реализует функцию Migrate для миграций migrations.
+func migrate(ctx context.Context, db *sql.DB, migrations []migration) ([]string, error) {
+	conn, err := lockedConn(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	defer unlock(conn)
+
+	if _, err := conn.ExecContext(ctx, createSchemaMigrations); err != nil {
+		return nil, err
+	}
+
+	applied, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, m := range migrations {
+		if applied[m.version] {
+			continue
+		}
+
+		if err := apply(ctx, conn, m); err != nil {
+			return names, fmt.Errorf("database: migration %s: %w", m.name, err)
+		}
+
+		names = append(names, m.name)
+	}
+
+	return names, nil
+}
+
+// DryRun проверяет встроенные миграции, которые еще не применены к базе
+// данных db, и возвращает их имена. Миграции выполняются так же, как в
+// Migrate, но в одной транзакции, которая затем откатывается, поэтому схема
+// базы данных и таблица schema_migrations не изменяются, а ошибки в запросах
+// миграций обнаруживаются до их применения. Одна транзакция нужна потому, что
+// каждая миграция может зависеть от изменений предыдущих. При ошибке
+// возвращаются имена всех ожидающих миграций и ошибка первой миграции, которую
+// не удалось выполнить.
+func DryRun(ctx context.Context, db *sql.DB) ([]string, error) {
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	return dryRun(ctx, db, migrations)
+}
+
+// dryRun реализует функцию DryRun для миграций migrations.
+func dryRun(ctx context.Context, db *sql.DB, migrations []migration) ([]string, error) {
+	// блокировка не дает проверке выполняться одновременно с применением
+	// миграций другим экземпляром сервиса
+	conn, err := lockedConn(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	defer unlock(conn)
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// транзакция никогда не фиксируется
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, createSchemaMigrations); err != nil {
+		return nil, err
+	}
+
+	applied, err := appliedVersions(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	names := pendingMigrations(migrations, applied)
+	for _, m := range migrations {
+		if applied[m.version] {
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, m.query); err != nil {
+			return names, fmt.Errorf("database: migration %s: %w", m.name, err)
+		}
+
+		if _, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version) VALUES ($1);", m.version); err != nil {
+			return names, fmt.Errorf("database: migration %s: %w", m.name, err)
+		}
+	}
+
+	return names, nil
+}
+
+// createSchemaMigrations создает таблицу примененных версий миграций, если
+// она еще не создана
+const createSchemaMigrations = `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version integer CONSTRAINT schema_migration_pk PRIMARY KEY,
+	applied_at timestamptz NOT NULL DEFAULT now()
+);`
+
+// lockedConn возвращает соединение с базой данных db, захватившее
+// рекомендательную блокировку миграций. Блокировка действует в пределах
+// сеанса, поэтому все запросы миграций выполняются в этом соединении, а после
+// них соединение освобождается функцией unlock.
+func lockedConn(ctx context.Context, db *sql.DB) (*sql.Conn, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1);", migrationLockKey); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// unlock снимает рекомендательную блокировку миграций и закрывает соединение
+// conn, полученное от lockedConn.
+func unlock(conn *sql.Conn) {
+	conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1);", migrationLockKey)
+	conn.Close()
+}
+
+// Pending возвращает имена встроенных миграций, которые еще не применены к
+// базе данных db, ничего не изменяя в ней. Если таблица schema_migrations еще
+// не создана, ожидающими считаются все миграции.
+func Pending(ctx context.Context, db *sql.DB) ([]string, error) {
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	defer conn.Close()
+
+	var exists bool
+	if err := conn.QueryRowContext(ctx, "SELECT to_regclass('schema_migrations') IS NOT NULL;").Scan(&exists); err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int]bool)
+	if exists {
+		if applied, err = appliedVersions(ctx, conn); err != nil {
+			return nil, err
+		}
+	}
+
+	return pendingMigrations(migrations, applied), nil
+}
+
+// pendingMigrations возвращает имена миграций migrations, версии которых
+// отсутствуют среди примененных версий applied.
+func pendingMigrations(migrations []migration, applied map[int]bool) []string {
+	var names []string
+	for _, m := range migrations {
+		if !applied[m.version] {
+			names = append(names, m.name)
+		}
+	}
+
+	return names
+}
+
+// querier выполняет запросы в соединении или транзакции
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// appliedVersions возвращает версии миграций, уже примененных к базе данных.
+func appliedVersions(ctx context.Context, q querier) (map[int]bool, error) {
+	rows, err := q.QueryContext(ctx, "SELECT version FROM schema_migrations;")
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	applied := make(map[int]bool)
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+
+		applied[version] = true
+	}
+
+	return applied, rows.Err()
+}
+
+// apply выполняет миграцию m и отмечает ее версию как примененную в рамках
+// одной транзакции.
+func apply(ctx context.Context, conn *sql.Conn, m migration) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	// откатываем транзакцию, если она не была зафиксирована
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, m.query); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version) VALUES ($1);", m.version); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,280 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+var DBConnParamsForTests = "user=postgres password=passw0rd host=0.0.0.0 port=5433 dbname=linkservice sslmode=disable"
+
+// baselineScheme содержит схему базы данных, которую до появления миграций
+// создавал скрипт database/scheme.sql
+const baselineScheme = `CREATE TABLE links (
+	link char(10) CONSTRAINT link_pk PRIMARY KEY,
+	original_url varchar(2048) NOT NULL,
+
+	CONSTRAINT original_url_unique UNIQUE (original_url)
+);`
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := loadMigrations(fstest.MapFS{
+		"migrations/0010_tenth.sql":  {Data: []byte("SELECT 10;")},
+		"migrations/0002_second.sql": {Data: []byte("SELECT 2;")},
+	})
+	if err != nil {
+		t.Fatalf("loadMigrations reported an error: %v", err)
+	}
+
+	if len(migrations) != 2 || migrations[0].version != 2 || migrations[1].version != 10 {
+		t.Fatalf("the versions 2 and 10 were expected in order, but %v was received", migrations)
+	}
+
+	if migrations[1].query != "SELECT 10;" {
+		t.Errorf("the query \"%s\" was expected, but \"%s\" was received", "SELECT 10;", migrations[1].query)
+	}
+
+	testCases := []struct {
+		name  string
+		files fstest.MapFS
+	}{
+		{name: "duplicate_version", files: fstest.MapFS{
+			"migrations/0001_a.sql": {Data: []byte("SELECT 1;")},
+			"migrations/1_b.sql":    {Data: []byte("SELECT 1;")},
+		}},
+		{name: "no_version", files: fstest.MapFS{"migrations/initial.sql": {Data: []byte("SELECT 1;")}}},
+		{name: "zero_version", files: fstest.MapFS{"migrations/0000_initial.sql": {Data: []byte("SELECT 1;")}}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if _, err := loadMigrations(testCase.files); err == nil {
+				t.Errorf("an error was expected")
+			}
+		})
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		t.Fatalf("loadMigrations reported an error: %v", err)
+	}
+
+	if len(migrations) == 0 || migrations[0].version != 1 {
+		t.Errorf("the embedded migrations were expected to start with version 1")
+	}
+}
+
+func TestPendingMigrations(t *testing.T) {
+	migrations := []migration{
+		{version: 1, name: "0001_first.sql"},
+		{version: 2, name: "0002_second.sql"},
+		{version: 3, name: "0003_third.sql"},
+	}
+
+	pending := pendingMigrations(migrations, map[int]bool{1: true, 3: true})
+	if !reflect.DeepEqual(pending, []string{"0002_second.sql"}) {
+		t.Errorf("the pending migration 0002_second.sql was expected, but %v was received", pending)
+	}
+
+	if pending := pendingMigrations(migrations, map[int]bool{1: true, 2: true, 3: true}); len(pending) != 0 {
+		t.Errorf("no pending migrations were expected, but %v was received", pending)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed to connect to the database: %v", err)
+	}
+
+	defer db.Close()
+
+	if _, err := Migrate(context.Background(), db); err != nil {
+		t.Fatalf("Migrate reported an error: %v", err)
+	}
+
+	// повторный запуск не применяет ни одной миграции
+	applied, err := Migrate(context.Background(), db)
+	if err != nil {
+		t.Fatalf("Migrate reported an error on the second run: %v", err)
+	}
+
+	if len(applied) != 0 {
+		t.Errorf("no migrations were expected to be applied again, but %v were applied", applied)
+	}
+
+	// после применения всех миграций ожидающих миграций не остается
+	pending, err := Pending(context.Background(), db)
+	if err != nil {
+		t.Fatalf("Pending reported an error: %v", err)
+	}
+
+	if len(pending) != 0 {
+		t.Errorf("no pending migrations were expected, but %v was received", pending)
+	}
+}
+
+func TestMigrateBaseline(t *testing.T) {
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed to connect to the database: %v", err)
+	}
+
+	defer db.Close()
+
+	// схема scheme.sql создается в отдельной схеме PostgreSQL, чтобы не
+	// затрагивать таблицы других тестов
+	schema := fmt.Sprintf("baseline_%d", time.Now().UnixNano())
+	if _, err := db.Exec("CREATE SCHEMA " + schema + ";"); err != nil {
+		t.Fatalf("failed to create the schema: %v", err)
+	}
+
+	defer db.Exec("DROP SCHEMA " + schema + " CASCADE;")
+
+	baseline, err := sql.Open("postgres", DBConnParamsForTests+" search_path="+schema)
+	if err != nil {
+		t.Fatalf("failed to connect to the database: %v", err)
+	}
+
+	defer baseline.Close()
+
+	if _, err := baseline.Exec(baselineScheme); err != nil {
+		t.Fatalf("failed to create the baseline schema: %v", err)
+	}
+
+	if _, err := baseline.Exec("INSERT INTO links (link, original_url) VALUES ('abcdefghij', 'http://baseline.abc/');"); err != nil {
+		t.Fatalf("failed to insert a link: %v", err)
+	}
+
+	if _, err := Migrate(context.Background(), baseline); err != nil {
+		t.Fatalf("Migrate reported an error: %v", err)
+	}
+
+	// существующая ссылка получает значения новых столбцов по умолчанию
+	var visits int64
+	var deduplicated bool
+	var namespace string
+
+	err = baseline.QueryRow("SELECT visits, deduplicated, namespace FROM links WHERE link = 'abcdefghij';").Scan(&visits, &deduplicated, &namespace)
+	if err != nil {
+		t.Fatalf("failed to query the migrated link: %v", err)
+	}
+
+	if visits != 0 || !deduplicated || namespace != "" {
+		t.Errorf("the default values of the new columns were expected, but %d, %v and \"%s\" were received", visits, deduplicated, namespace)
+	}
+
+	// после миграции таблица принимает псевдонимы длиннее 10 символов и
+	// повторные URL, не участвующие в дедупликации
+	_, err = baseline.Exec("INSERT INTO links (link, original_url, deduplicated) VALUES ('a-longer-custom-alias', 'http://baseline.abc/', false);")
+	if err != nil {
+		t.Errorf("failed to insert a link into the migrated table: %v", err)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed to connect to the database: %v", err)
+	}
+
+	defer db.Close()
+
+	schema := fmt.Sprintf("dry_run_%d", time.Now().UnixNano())
+	if _, err := db.Exec("CREATE SCHEMA " + schema + ";"); err != nil {
+		t.Fatalf("failed to create the schema: %v", err)
+	}
+
+	defer db.Exec("DROP SCHEMA " + schema + " CASCADE;")
+
+	dry, err := sql.Open("postgres", DBConnParamsForTests+" search_path="+schema)
+	if err != nil {
+		t.Fatalf("failed to connect to the database: %v", err)
+	}
+
+	defer dry.Close()
+
+	migrations, err := loadMigrations(fstest.MapFS{
+		"migrations/0001_first.sql":  {Data: []byte("CREATE TABLE dry_first (id integer PRIMARY KEY);")},
+		"migrations/0002_second.sql": {Data: []byte("ALTER TABLE dry_first ADD COLUMN name text;")},
+		"migrations/0003_broken.sql": {Data: []byte("ALTER TABLE dry_missing ADD COLUMN name text;")},
+	})
+	if err != nil {
+		t.Fatalf("failed to load the migrations: %v", err)
+	}
+
+	// первая миграция применяется, чтобы таблица schema_migrations уже
+	// существовала
+	if _, err := migrate(context.Background(), dry, migrations[:1]); err != nil {
+		t.Fatalf("migrate reported an error: %v", err)
+	}
+
+	before := schemaState(t, dry, schema)
+
+	// вторая миграция зависит от первой и выполняется успешно
+	pending, err := dryRun(context.Background(), dry, migrations[:2])
+	if err != nil {
+		t.Fatalf("dryRun reported an error: %v", err)
+	}
+
+	if exp := []string{"0002_second.sql"}; !reflect.DeepEqual(pending, exp) {
+		t.Errorf("%v pending migrations were expected, but %v was received", exp, pending)
+	}
+
+	if after := schemaState(t, dry, schema); !reflect.DeepEqual(after, before) {
+		t.Errorf("the dry run was expected to keep the schema %v, but %v was received", before, after)
+	}
+
+	// ошибка в третьей миграции сообщается с ее именем
+	pending, err = dryRun(context.Background(), dry, migrations)
+	if err == nil || !strings.Contains(err.Error(), "0003_broken.sql") {
+		t.Errorf("an error of the migration 0003_broken.sql was expected, but %v was received", err)
+	}
+
+	if exp := []string{"0002_second.sql", "0003_broken.sql"}; !reflect.DeepEqual(pending, exp) {
+		t.Errorf("%v pending migrations were expected, but %v was received", exp, pending)
+	}
+
+	if after := schemaState(t, dry, schema); !reflect.DeepEqual(after, before) {
+		t.Errorf("the failed dry run was expected to keep the schema %v, but %v was received", before, after)
+	}
+}
+
+// schemaState возвращает столбцы таблиц схемы schema и версии из таблицы
+// schema_migrations базы данных db.
+func schemaState(t *testing.T, db *sql.DB, schema string) []string {
+	t.Helper()
+
+	rows, err := db.Query("SELECT table_name || '.' || column_name FROM information_schema.columns WHERE table_schema = $1 "+
+		"UNION ALL SELECT 'version ' || version FROM schema_migrations ORDER BY 1;", schema)
+	if err != nil {
+		t.Fatalf("failed to query the schema: %v", err)
+	}
+
+	defer rows.Close()
+
+	var state []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			t.Fatalf("failed to scan the schema: %v", err)
+		}
+
+		state = append(state, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		t.Fatalf("failed to query the schema: %v", err)
+	}
+
+	return state
+}
@@ -0,0 +1,61 @@
+-- Исходная схема базы данных. Объекты создаются только при их отсутствии,
+-- а таблица links, созданная до появления миграций скриптом scheme.sql,
+-- приводится к той же схеме, чтобы миграция применялась и к таким базам
+-- данных.
+
+CREATE TABLE IF NOT EXISTS collections (
+	id bigserial CONSTRAINT collection_pk PRIMARY KEY,
+	name varchar(255) NOT NULL,
+	created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS links (
+	id bigserial CONSTRAINT link_id_unique UNIQUE,
+	link varchar(32) CONSTRAINT link_pk PRIMARY KEY,
+	original_url varchar(2048) NOT NULL,
+	alphabet varchar(32),
+	visits bigint NOT NULL DEFAULT 0,
+	created_at timestamptz NOT NULL DEFAULT now(),
+	expires_at timestamptz,
+	collection_id bigint CONSTRAINT links_collection_fk REFERENCES collections (id) ON DELETE SET NULL,
+	deduplicated boolean NOT NULL DEFAULT true,
+	title varchar(255),
+	owner_id varchar(64)
+);
+
+-- таблица links из scheme.sql содержит лишь столбцы link char(10) и
+-- original_url, а уникальность URL обеспечивает ограничение таблицы
+ALTER TABLE links ALTER COLUMN link TYPE varchar(32);
+ALTER TABLE links ADD COLUMN IF NOT EXISTS id bigserial CONSTRAINT link_id_unique UNIQUE;
+ALTER TABLE links ADD COLUMN IF NOT EXISTS alphabet varchar(32);
+ALTER TABLE links ADD COLUMN IF NOT EXISTS visits bigint NOT NULL DEFAULT 0;
+ALTER TABLE links ADD COLUMN IF NOT EXISTS created_at timestamptz NOT NULL DEFAULT now();
+ALTER TABLE links ADD COLUMN IF NOT EXISTS expires_at timestamptz;
+ALTER TABLE links ADD COLUMN IF NOT EXISTS collection_id bigint CONSTRAINT links_collection_fk REFERENCES collections (id) ON DELETE SET NULL;
+ALTER TABLE links ADD COLUMN IF NOT EXISTS deduplicated boolean NOT NULL DEFAULT true;
+ALTER TABLE links ADD COLUMN IF NOT EXISTS title varchar(255);
+ALTER TABLE links ADD COLUMN IF NOT EXISTS owner_id varchar(64);
+ALTER TABLE links DROP CONSTRAINT IF EXISTS original_url_unique;
+
+CREATE UNIQUE INDEX IF NOT EXISTS original_url_unique ON links (original_url) WHERE deduplicated;
+
+CREATE INDEX IF NOT EXISTS links_owner_idx ON links (owner_id, link);
+
+CREATE TABLE IF NOT EXISTS link_hits (
+	link varchar(32) NOT NULL,
+	hour timestamptz NOT NULL,
+	hits bigint NOT NULL DEFAULT 0,
+
+	CONSTRAINT link_hits_pk PRIMARY KEY (link, hour)
+);
+
+CREATE TABLE IF NOT EXISTS reserved_links (
+	link varchar(32) CONSTRAINT reserved_link_pk PRIMARY KEY,
+	created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+	key_hash char(64) CONSTRAINT api_key_pk PRIMARY KEY,
+	scope varchar(8) NOT NULL CONSTRAINT api_key_scope CHECK (scope IN ('read', 'write')),
+	created_at timestamptz NOT NULL DEFAULT now()
+);
@@ -0,0 +1,5 @@
+-- Время создания ссылки возвращается методом Get. Базы данных, созданные до
+-- появления столбца, получают его, а существующие записи — время применения
+-- миграции.
+
+ALTER TABLE links ADD COLUMN IF NOT EXISTS created_at timestamptz NOT NULL DEFAULT now();
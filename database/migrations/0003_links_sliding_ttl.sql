@@ -0,0 +1,5 @@
+-- Скользящее время жизни ссылки в секундах: при каждом переходе по ссылке ее
+-- срок действия продлевается на это время. NULL соответствует ссылкам без
+-- скользящего времени жизни.
+
+ALTER TABLE links ADD COLUMN IF NOT EXISTS sliding_ttl_seconds bigint;
@@ -0,0 +1,6 @@
+-- Ограничение количества переходов по ссылке: после max_uses переходов ссылка
+-- перестает разрешаться. NULL соответствует ссылкам без ограничения. Столбец
+-- uses содержит количество переходов, учтенных ограничением.
+
+ALTER TABLE links ADD COLUMN IF NOT EXISTS max_uses bigint;
+ALTER TABLE links ADD COLUMN IF NOT EXISTS uses bigint NOT NULL DEFAULT 0;
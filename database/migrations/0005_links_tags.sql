@@ -0,0 +1,6 @@
+-- Теги коротких ссылок для их группировки. Ссылки без тегов содержат пустой
+-- массив. Индекс GIN позволяет выбирать ссылки с указанным тегом оператором @>.
+
+ALTER TABLE links ADD COLUMN IF NOT EXISTS tags text[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS links_tags_idx ON links USING GIN (tags);
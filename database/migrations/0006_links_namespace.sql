@@ -0,0 +1,23 @@
+-- Пространства имен коротких ссылок. Ссылки разных пространств имен, например
+-- разных брендов, не конфликтуют друг с другом, поэтому короткая ссылка
+-- уникальна лишь в пределах пространства имен. Ссылки, созданные без
+-- указания пространства имен, относятся к пространству имен по умолчанию —
+-- пустой строке.
+
+ALTER TABLE links ADD COLUMN IF NOT EXISTS namespace varchar(64) NOT NULL DEFAULT '';
+
+ALTER TABLE links DROP CONSTRAINT IF EXISTS link_pk;
+ALTER TABLE links ADD CONSTRAINT link_pk PRIMARY KEY (namespace, link);
+
+-- дедупликация URL также выполняется в пределах пространства имен. В базах
+-- данных, созданных скриптом scheme.sql, уникальность URL могла
+-- обеспечиваться ограничением таблицы, индекс которого нельзя удалить
+-- напрямую
+ALTER TABLE links DROP CONSTRAINT IF EXISTS original_url_unique;
+DROP INDEX IF EXISTS original_url_unique;
+CREATE UNIQUE INDEX original_url_unique ON links (namespace, original_url) WHERE deduplicated;
+
+ALTER TABLE link_hits ADD COLUMN IF NOT EXISTS namespace varchar(64) NOT NULL DEFAULT '';
+
+ALTER TABLE link_hits DROP CONSTRAINT IF EXISTS link_hits_pk;
+ALTER TABLE link_hits ADD CONSTRAINT link_hits_pk PRIMARY KEY (namespace, link, hour);
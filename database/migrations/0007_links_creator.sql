@@ -0,0 +1,6 @@
+-- Сведения о создателе ссылки для расследования злоупотреблений: IP-адрес
+-- клиента и значение метаданных user-agent. NULL соответствует ссылкам,
+-- созданным без этих сведений, например методами BatchCreate и Import.
+
+ALTER TABLE links ADD COLUMN IF NOT EXISTS creator_ip inet;
+ALTER TABLE links ADD COLUMN IF NOT EXISTS creator_user_agent text;
@@ -0,0 +1,4 @@
+-- Время последнего перехода по ссылке. NULL соответствует ссылкам, по
+-- которым еще не было переходов.
+
+ALTER TABLE links ADD COLUMN IF NOT EXISTS last_accessed_at timestamptz;
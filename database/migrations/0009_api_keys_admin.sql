@@ -0,0 +1,5 @@
+-- Область действия admin для административных методов, например очистки кэша
+-- коротких ссылок.
+
+ALTER TABLE api_keys DROP CONSTRAINT IF EXISTS api_key_scope;
+ALTER TABLE api_keys ADD CONSTRAINT api_key_scope CHECK (scope IN ('read', 'write', 'admin'));
@@ -0,0 +1,5 @@
+-- Версия ссылки, увеличивающаяся при каждом изменении ее оригинального URL.
+-- По версии HTTP-интерфейс формирует заголовок ETag, поэтому кэши перестают
+-- возвращать прежний URL после его изменения.
+
+ALTER TABLE links ADD COLUMN IF NOT EXISTS version bigint NOT NULL DEFAULT 1;
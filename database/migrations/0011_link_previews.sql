@@ -0,0 +1,15 @@
+-- Метаданные предпросмотра ссылки для социальных сетей и мессенджеров:
+-- название, описание и изображение, которые HTTP-интерфейс передает их
+-- роботам в тегах Open Graph. Название по умолчанию берется из столбца title
+-- таблицы links.
+
+CREATE TABLE IF NOT EXISTS link_previews (
+	namespace varchar(64) NOT NULL,
+	link varchar(32) NOT NULL,
+	title varchar(255),
+	description varchar(1024),
+	image_url varchar(2048),
+
+	CONSTRAINT link_previews_pk PRIMARY KEY (namespace, link),
+	CONSTRAINT link_previews_link_fk FOREIGN KEY (namespace, link) REFERENCES links (namespace, link) ON DELETE CASCADE
+);
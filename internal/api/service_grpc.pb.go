@@ -20,6 +20,33 @@ const _ = grpc.SupportPackageIsVersion7
 type LinkServiceClient interface {
 	Create(ctx context.Context, in *URL, opts ...grpc.CallOption) (*Link, error)
 	Get(ctx context.Context, in *Link, opts ...grpc.CallOption) (*URL, error)
+	GetOrCreate(ctx context.Context, in *URL, opts ...grpc.CallOption) (*LinkResult, error)
+	BatchCreate(ctx context.Context, in *URLList, opts ...grpc.CallOption) (*LinkList, error)
+	GetBatch(ctx context.Context, in *LinkList, opts ...grpc.CallOption) (*URLList, error)
+	Stats(ctx context.Context, in *Link, opts ...grpc.CallOption) (*LinkStats, error)
+	HitsOverTime(ctx context.Context, in *TimeRangeRequest, opts ...grpc.CallOption) (*TimeSeriesResponse, error)
+	CreateCollection(ctx context.Context, in *Collection, opts ...grpc.CallOption) (*Collection, error)
+	ListCollections(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*CollectionList, error)
+	DeleteCollection(ctx context.Context, in *Collection, opts ...grpc.CallOption) (*Empty, error)
+	ListByCollection(ctx context.Context, in *Collection, opts ...grpc.CallOption) (*MappingList, error)
+	UpdateURL(ctx context.Context, in *UpdateRequest, opts ...grpc.CallOption) (*Empty, error)
+	GetMetadata(ctx context.Context, in *Link, opts ...grpc.CallOption) (*LinkMetadata, error)
+	ListByOwner(ctx context.Context, in *OwnerRequest, opts ...grpc.CallOption) (*OwnerLinks, error)
+	DeleteByOwner(ctx context.Context, in *OwnerRequest, opts ...grpc.CallOption) (*DeleteCount, error)
+	Count(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*CountResponse, error)
+	CheckAlias(ctx context.Context, in *Link, opts ...grpc.CallOption) (*Availability, error)
+	Import(ctx context.Context, opts ...grpc.CallOption) (LinkService_ImportClient, error)
+	Export(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (LinkService_ExportClient, error)
+	Version(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*VersionInfo, error)
+	ListByTag(ctx context.Context, in *TagRequest, opts ...grpc.CallOption) (*MappingList, error)
+	UpdateExpiry(ctx context.Context, in *ExpiryRequest, opts ...grpc.CallOption) (*Empty, error)
+	GetInfo(ctx context.Context, in *Link, opts ...grpc.CallOption) (*LinkInfo, error)
+	DeleteOlderThan(ctx context.Context, in *TimeRequest, opts ...grpc.CallOption) (*CountResponse, error)
+	ValidateLinks(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*ValidationReport, error)
+	InvalidateCache(ctx context.Context, in *InvalidateRequest, opts ...grpc.CallOption) (*Empty, error)
+	SetMaintenance(ctx context.Context, in *MaintenanceRequest, opts ...grpc.CallOption) (*Empty, error)
+	SetPreview(ctx context.Context, in *Preview, opts ...grpc.CallOption) (*Empty, error)
+	GetPreview(ctx context.Context, in *Link, opts ...grpc.CallOption) (*Preview, error)
 }
 
 type linkServiceClient struct {
@@ -48,12 +75,330 @@ func (c *linkServiceClient) Get(ctx context.Context, in *Link, opts ...grpc.Call
 	return out, nil
 }
 
+func (c *linkServiceClient) GetOrCreate(ctx context.Context, in *URL, opts ...grpc.CallOption) (*LinkResult, error) {
+	out := new(LinkResult)
+	err := c.cc.Invoke(ctx, "/api.LinkService/GetOrCreate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *linkServiceClient) BatchCreate(ctx context.Context, in *URLList, opts ...grpc.CallOption) (*LinkList, error) {
+	out := new(LinkList)
+	err := c.cc.Invoke(ctx, "/api.LinkService/BatchCreate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *linkServiceClient) GetBatch(ctx context.Context, in *LinkList, opts ...grpc.CallOption) (*URLList, error) {
+	out := new(URLList)
+	err := c.cc.Invoke(ctx, "/api.LinkService/GetBatch", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *linkServiceClient) Stats(ctx context.Context, in *Link, opts ...grpc.CallOption) (*LinkStats, error) {
+	out := new(LinkStats)
+	err := c.cc.Invoke(ctx, "/api.LinkService/Stats", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *linkServiceClient) HitsOverTime(ctx context.Context, in *TimeRangeRequest, opts ...grpc.CallOption) (*TimeSeriesResponse, error) {
+	out := new(TimeSeriesResponse)
+	err := c.cc.Invoke(ctx, "/api.LinkService/HitsOverTime", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *linkServiceClient) CreateCollection(ctx context.Context, in *Collection, opts ...grpc.CallOption) (*Collection, error) {
+	out := new(Collection)
+	err := c.cc.Invoke(ctx, "/api.LinkService/CreateCollection", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *linkServiceClient) ListCollections(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*CollectionList, error) {
+	out := new(CollectionList)
+	err := c.cc.Invoke(ctx, "/api.LinkService/ListCollections", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *linkServiceClient) DeleteCollection(ctx context.Context, in *Collection, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/api.LinkService/DeleteCollection", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *linkServiceClient) ListByCollection(ctx context.Context, in *Collection, opts ...grpc.CallOption) (*MappingList, error) {
+	out := new(MappingList)
+	err := c.cc.Invoke(ctx, "/api.LinkService/ListByCollection", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *linkServiceClient) UpdateURL(ctx context.Context, in *UpdateRequest, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/api.LinkService/UpdateURL", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *linkServiceClient) GetMetadata(ctx context.Context, in *Link, opts ...grpc.CallOption) (*LinkMetadata, error) {
+	out := new(LinkMetadata)
+	err := c.cc.Invoke(ctx, "/api.LinkService/GetMetadata", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *linkServiceClient) ListByOwner(ctx context.Context, in *OwnerRequest, opts ...grpc.CallOption) (*OwnerLinks, error) {
+	out := new(OwnerLinks)
+	err := c.cc.Invoke(ctx, "/api.LinkService/ListByOwner", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *linkServiceClient) DeleteByOwner(ctx context.Context, in *OwnerRequest, opts ...grpc.CallOption) (*DeleteCount, error) {
+	out := new(DeleteCount)
+	err := c.cc.Invoke(ctx, "/api.LinkService/DeleteByOwner", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *linkServiceClient) Count(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*CountResponse, error) {
+	out := new(CountResponse)
+	err := c.cc.Invoke(ctx, "/api.LinkService/Count", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *linkServiceClient) CheckAlias(ctx context.Context, in *Link, opts ...grpc.CallOption) (*Availability, error) {
+	out := new(Availability)
+	err := c.cc.Invoke(ctx, "/api.LinkService/CheckAlias", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *linkServiceClient) Import(ctx context.Context, opts ...grpc.CallOption) (LinkService_ImportClient, error) {
+	stream, err := c.cc.NewStream(ctx, &LinkService_ServiceDesc.Streams[0], "/api.LinkService/Import", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &linkServiceImportClient{stream}
+	return x, nil
+}
+
+type LinkService_ImportClient interface {
+	Send(*ImportRequest) error
+	CloseAndRecv() (*ImportResult, error)
+	grpc.ClientStream
+}
+
+type linkServiceImportClient struct {
+	grpc.ClientStream
+}
+
+func (x *linkServiceImportClient) Send(m *ImportRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *linkServiceImportClient) CloseAndRecv() (*ImportResult, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(ImportResult)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *linkServiceClient) Export(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (LinkService_ExportClient, error) {
+	stream, err := c.cc.NewStream(ctx, &LinkService_ServiceDesc.Streams[1], "/api.LinkService/Export", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &linkServiceExportClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type LinkService_ExportClient interface {
+	Recv() (*ExportedLink, error)
+	grpc.ClientStream
+}
+
+type linkServiceExportClient struct {
+	grpc.ClientStream
+}
+
+func (x *linkServiceExportClient) Recv() (*ExportedLink, error) {
+	m := new(ExportedLink)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *linkServiceClient) Version(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*VersionInfo, error) {
+	out := new(VersionInfo)
+	err := c.cc.Invoke(ctx, "/api.LinkService/Version", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *linkServiceClient) ListByTag(ctx context.Context, in *TagRequest, opts ...grpc.CallOption) (*MappingList, error) {
+	out := new(MappingList)
+	err := c.cc.Invoke(ctx, "/api.LinkService/ListByTag", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *linkServiceClient) UpdateExpiry(ctx context.Context, in *ExpiryRequest, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/api.LinkService/UpdateExpiry", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *linkServiceClient) GetInfo(ctx context.Context, in *Link, opts ...grpc.CallOption) (*LinkInfo, error) {
+	out := new(LinkInfo)
+	err := c.cc.Invoke(ctx, "/api.LinkService/GetInfo", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *linkServiceClient) DeleteOlderThan(ctx context.Context, in *TimeRequest, opts ...grpc.CallOption) (*CountResponse, error) {
+	out := new(CountResponse)
+	err := c.cc.Invoke(ctx, "/api.LinkService/DeleteOlderThan", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *linkServiceClient) ValidateLinks(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*ValidationReport, error) {
+	out := new(ValidationReport)
+	err := c.cc.Invoke(ctx, "/api.LinkService/ValidateLinks", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *linkServiceClient) InvalidateCache(ctx context.Context, in *InvalidateRequest, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/api.LinkService/InvalidateCache", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *linkServiceClient) SetMaintenance(ctx context.Context, in *MaintenanceRequest, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/api.LinkService/SetMaintenance", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *linkServiceClient) SetPreview(ctx context.Context, in *Preview, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/api.LinkService/SetPreview", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *linkServiceClient) GetPreview(ctx context.Context, in *Link, opts ...grpc.CallOption) (*Preview, error) {
+	out := new(Preview)
+	err := c.cc.Invoke(ctx, "/api.LinkService/GetPreview", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // LinkServiceServer is the server API for LinkService service.
 // All implementations must embed UnimplementedLinkServiceServer
 // for forward compatibility
 type LinkServiceServer interface {
 	Create(context.Context, *URL) (*Link, error)
 	Get(context.Context, *Link) (*URL, error)
+	GetOrCreate(context.Context, *URL) (*LinkResult, error)
+	BatchCreate(context.Context, *URLList) (*LinkList, error)
+	GetBatch(context.Context, *LinkList) (*URLList, error)
+	Stats(context.Context, *Link) (*LinkStats, error)
+	HitsOverTime(context.Context, *TimeRangeRequest) (*TimeSeriesResponse, error)
+	CreateCollection(context.Context, *Collection) (*Collection, error)
+	ListCollections(context.Context, *Empty) (*CollectionList, error)
+	DeleteCollection(context.Context, *Collection) (*Empty, error)
+	ListByCollection(context.Context, *Collection) (*MappingList, error)
+	UpdateURL(context.Context, *UpdateRequest) (*Empty, error)
+	GetMetadata(context.Context, *Link) (*LinkMetadata, error)
+	ListByOwner(context.Context, *OwnerRequest) (*OwnerLinks, error)
+	DeleteByOwner(context.Context, *OwnerRequest) (*DeleteCount, error)
+	Count(context.Context, *Empty) (*CountResponse, error)
+	CheckAlias(context.Context, *Link) (*Availability, error)
+	Import(LinkService_ImportServer) error
+	Export(*ExportRequest, LinkService_ExportServer) error
+	Version(context.Context, *Empty) (*VersionInfo, error)
+	ListByTag(context.Context, *TagRequest) (*MappingList, error)
+	UpdateExpiry(context.Context, *ExpiryRequest) (*Empty, error)
+	GetInfo(context.Context, *Link) (*LinkInfo, error)
+	DeleteOlderThan(context.Context, *TimeRequest) (*CountResponse, error)
+	ValidateLinks(context.Context, *Empty) (*ValidationReport, error)
+	InvalidateCache(context.Context, *InvalidateRequest) (*Empty, error)
+	SetMaintenance(context.Context, *MaintenanceRequest) (*Empty, error)
+	SetPreview(context.Context, *Preview) (*Empty, error)
+	GetPreview(context.Context, *Link) (*Preview, error)
 	mustEmbedUnimplementedLinkServiceServer()
 }
 
@@ -67,6 +412,87 @@ func (UnimplementedLinkServiceServer) Create(context.Context, *URL) (*Link, erro
 func (UnimplementedLinkServiceServer) Get(context.Context, *Link) (*URL, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Get not implemented")
 }
+func (UnimplementedLinkServiceServer) GetOrCreate(context.Context, *URL) (*LinkResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOrCreate not implemented")
+}
+func (UnimplementedLinkServiceServer) BatchCreate(context.Context, *URLList) (*LinkList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchCreate not implemented")
+}
+func (UnimplementedLinkServiceServer) GetBatch(context.Context, *LinkList) (*URLList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBatch not implemented")
+}
+func (UnimplementedLinkServiceServer) Stats(context.Context, *Link) (*LinkStats, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Stats not implemented")
+}
+func (UnimplementedLinkServiceServer) HitsOverTime(context.Context, *TimeRangeRequest) (*TimeSeriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HitsOverTime not implemented")
+}
+func (UnimplementedLinkServiceServer) CreateCollection(context.Context, *Collection) (*Collection, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateCollection not implemented")
+}
+func (UnimplementedLinkServiceServer) ListCollections(context.Context, *Empty) (*CollectionList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCollections not implemented")
+}
+func (UnimplementedLinkServiceServer) DeleteCollection(context.Context, *Collection) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteCollection not implemented")
+}
+func (UnimplementedLinkServiceServer) ListByCollection(context.Context, *Collection) (*MappingList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListByCollection not implemented")
+}
+func (UnimplementedLinkServiceServer) UpdateURL(context.Context, *UpdateRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateURL not implemented")
+}
+func (UnimplementedLinkServiceServer) GetMetadata(context.Context, *Link) (*LinkMetadata, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMetadata not implemented")
+}
+func (UnimplementedLinkServiceServer) ListByOwner(context.Context, *OwnerRequest) (*OwnerLinks, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListByOwner not implemented")
+}
+func (UnimplementedLinkServiceServer) DeleteByOwner(context.Context, *OwnerRequest) (*DeleteCount, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteByOwner not implemented")
+}
+func (UnimplementedLinkServiceServer) Count(context.Context, *Empty) (*CountResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Count not implemented")
+}
+func (UnimplementedLinkServiceServer) CheckAlias(context.Context, *Link) (*Availability, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckAlias not implemented")
+}
+func (UnimplementedLinkServiceServer) Import(LinkService_ImportServer) error {
+	return status.Errorf(codes.Unimplemented, "method Import not implemented")
+}
+func (UnimplementedLinkServiceServer) Export(*ExportRequest, LinkService_ExportServer) error {
+	return status.Errorf(codes.Unimplemented, "method Export not implemented")
+}
+func (UnimplementedLinkServiceServer) Version(context.Context, *Empty) (*VersionInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Version not implemented")
+}
+func (UnimplementedLinkServiceServer) ListByTag(context.Context, *TagRequest) (*MappingList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListByTag not implemented")
+}
+func (UnimplementedLinkServiceServer) UpdateExpiry(context.Context, *ExpiryRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateExpiry not implemented")
+}
+func (UnimplementedLinkServiceServer) GetInfo(context.Context, *Link) (*LinkInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetInfo not implemented")
+}
+func (UnimplementedLinkServiceServer) DeleteOlderThan(context.Context, *TimeRequest) (*CountResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteOlderThan not implemented")
+}
+func (UnimplementedLinkServiceServer) ValidateLinks(context.Context, *Empty) (*ValidationReport, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ValidateLinks not implemented")
+}
+func (UnimplementedLinkServiceServer) InvalidateCache(context.Context, *InvalidateRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method InvalidateCache not implemented")
+}
+func (UnimplementedLinkServiceServer) SetMaintenance(context.Context, *MaintenanceRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetMaintenance not implemented")
+}
+func (UnimplementedLinkServiceServer) SetPreview(context.Context, *Preview) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetPreview not implemented")
+}
+func (UnimplementedLinkServiceServer) GetPreview(context.Context, *Link) (*Preview, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPreview not implemented")
+}
 func (UnimplementedLinkServiceServer) mustEmbedUnimplementedLinkServiceServer() {}
 
 // UnsafeLinkServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -116,22 +542,630 @@ func _LinkService_Get_Handler(srv interface{}, ctx context.Context, dec func(int
 	return interceptor(ctx, in, info, handler)
 }
 
-// LinkService_ServiceDesc is the grpc.ServiceDesc for LinkService service.
-// It's only intended for direct use with grpc.RegisterService,
-// and not to be introspected or modified (even as a copy)
-var LinkService_ServiceDesc = grpc.ServiceDesc{
-	ServiceName: "api.LinkService",
-	HandlerType: (*LinkServiceServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "Create",
-			Handler:    _LinkService_Create_Handler,
-		},
-		{
-			MethodName: "Get",
-			Handler:    _LinkService_Get_Handler,
+func _LinkService_GetOrCreate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(URL)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LinkServiceServer).GetOrCreate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.LinkService/GetOrCreate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LinkServiceServer).GetOrCreate(ctx, req.(*URL))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LinkService_BatchCreate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(URLList)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LinkServiceServer).BatchCreate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.LinkService/BatchCreate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LinkServiceServer).BatchCreate(ctx, req.(*URLList))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LinkService_GetBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LinkList)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LinkServiceServer).GetBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.LinkService/GetBatch",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LinkServiceServer).GetBatch(ctx, req.(*LinkList))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LinkService_Stats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Link)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LinkServiceServer).Stats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.LinkService/Stats",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LinkServiceServer).Stats(ctx, req.(*Link))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LinkService_HitsOverTime_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TimeRangeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LinkServiceServer).HitsOverTime(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.LinkService/HitsOverTime",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LinkServiceServer).HitsOverTime(ctx, req.(*TimeRangeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LinkService_CreateCollection_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Collection)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LinkServiceServer).CreateCollection(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.LinkService/CreateCollection",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LinkServiceServer).CreateCollection(ctx, req.(*Collection))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LinkService_ListCollections_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LinkServiceServer).ListCollections(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.LinkService/ListCollections",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LinkServiceServer).ListCollections(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LinkService_DeleteCollection_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Collection)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LinkServiceServer).DeleteCollection(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.LinkService/DeleteCollection",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LinkServiceServer).DeleteCollection(ctx, req.(*Collection))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LinkService_ListByCollection_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Collection)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LinkServiceServer).ListByCollection(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.LinkService/ListByCollection",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LinkServiceServer).ListByCollection(ctx, req.(*Collection))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LinkService_UpdateURL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LinkServiceServer).UpdateURL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.LinkService/UpdateURL",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LinkServiceServer).UpdateURL(ctx, req.(*UpdateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LinkService_GetMetadata_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Link)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LinkServiceServer).GetMetadata(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.LinkService/GetMetadata",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LinkServiceServer).GetMetadata(ctx, req.(*Link))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LinkService_ListByOwner_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OwnerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LinkServiceServer).ListByOwner(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.LinkService/ListByOwner",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LinkServiceServer).ListByOwner(ctx, req.(*OwnerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LinkService_DeleteByOwner_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OwnerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LinkServiceServer).DeleteByOwner(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.LinkService/DeleteByOwner",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LinkServiceServer).DeleteByOwner(ctx, req.(*OwnerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LinkService_Count_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LinkServiceServer).Count(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.LinkService/Count",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LinkServiceServer).Count(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LinkService_CheckAlias_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Link)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LinkServiceServer).CheckAlias(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.LinkService/CheckAlias",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LinkServiceServer).CheckAlias(ctx, req.(*Link))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LinkService_Import_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(LinkServiceServer).Import(&linkServiceImportServer{stream})
+}
+
+type LinkService_ImportServer interface {
+	SendAndClose(*ImportResult) error
+	Recv() (*ImportRequest, error)
+	grpc.ServerStream
+}
+
+type linkServiceImportServer struct {
+	grpc.ServerStream
+}
+
+func (x *linkServiceImportServer) SendAndClose(m *ImportResult) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *linkServiceImportServer) Recv() (*ImportRequest, error) {
+	m := new(ImportRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _LinkService_Export_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LinkServiceServer).Export(m, &linkServiceExportServer{stream})
+}
+
+type LinkService_ExportServer interface {
+	Send(*ExportedLink) error
+	grpc.ServerStream
+}
+
+type linkServiceExportServer struct {
+	grpc.ServerStream
+}
+
+func (x *linkServiceExportServer) Send(m *ExportedLink) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _LinkService_Version_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LinkServiceServer).Version(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.LinkService/Version",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LinkServiceServer).Version(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LinkService_ListByTag_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TagRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LinkServiceServer).ListByTag(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.LinkService/ListByTag",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LinkServiceServer).ListByTag(ctx, req.(*TagRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LinkService_UpdateExpiry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExpiryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LinkServiceServer).UpdateExpiry(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.LinkService/UpdateExpiry",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LinkServiceServer).UpdateExpiry(ctx, req.(*ExpiryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LinkService_GetInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Link)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LinkServiceServer).GetInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.LinkService/GetInfo",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LinkServiceServer).GetInfo(ctx, req.(*Link))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LinkService_DeleteOlderThan_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TimeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LinkServiceServer).DeleteOlderThan(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.LinkService/DeleteOlderThan",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LinkServiceServer).DeleteOlderThan(ctx, req.(*TimeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LinkService_ValidateLinks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LinkServiceServer).ValidateLinks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.LinkService/ValidateLinks",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LinkServiceServer).ValidateLinks(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LinkService_InvalidateCache_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InvalidateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LinkServiceServer).InvalidateCache(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.LinkService/InvalidateCache",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LinkServiceServer).InvalidateCache(ctx, req.(*InvalidateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LinkService_SetMaintenance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MaintenanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LinkServiceServer).SetMaintenance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.LinkService/SetMaintenance",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LinkServiceServer).SetMaintenance(ctx, req.(*MaintenanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LinkService_SetPreview_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Preview)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LinkServiceServer).SetPreview(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.LinkService/SetPreview",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LinkServiceServer).SetPreview(ctx, req.(*Preview))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LinkService_GetPreview_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Link)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LinkServiceServer).GetPreview(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.LinkService/GetPreview",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LinkServiceServer).GetPreview(ctx, req.(*Link))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// LinkService_ServiceDesc is the grpc.ServiceDesc for LinkService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var LinkService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "api.LinkService",
+	HandlerType: (*LinkServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Create",
+			Handler:    _LinkService_Create_Handler,
+		},
+		{
+			MethodName: "Get",
+			Handler:    _LinkService_Get_Handler,
+		},
+		{
+			MethodName: "GetOrCreate",
+			Handler:    _LinkService_GetOrCreate_Handler,
+		},
+		{
+			MethodName: "BatchCreate",
+			Handler:    _LinkService_BatchCreate_Handler,
+		},
+		{
+			MethodName: "GetBatch",
+			Handler:    _LinkService_GetBatch_Handler,
+		},
+		{
+			MethodName: "Stats",
+			Handler:    _LinkService_Stats_Handler,
+		},
+		{
+			MethodName: "HitsOverTime",
+			Handler:    _LinkService_HitsOverTime_Handler,
+		},
+		{
+			MethodName: "CreateCollection",
+			Handler:    _LinkService_CreateCollection_Handler,
+		},
+		{
+			MethodName: "ListCollections",
+			Handler:    _LinkService_ListCollections_Handler,
+		},
+		{
+			MethodName: "DeleteCollection",
+			Handler:    _LinkService_DeleteCollection_Handler,
+		},
+		{
+			MethodName: "ListByCollection",
+			Handler:    _LinkService_ListByCollection_Handler,
+		},
+		{
+			MethodName: "UpdateURL",
+			Handler:    _LinkService_UpdateURL_Handler,
+		},
+		{
+			MethodName: "GetMetadata",
+			Handler:    _LinkService_GetMetadata_Handler,
+		},
+		{
+			MethodName: "ListByOwner",
+			Handler:    _LinkService_ListByOwner_Handler,
+		},
+		{
+			MethodName: "DeleteByOwner",
+			Handler:    _LinkService_DeleteByOwner_Handler,
+		},
+		{
+			MethodName: "Count",
+			Handler:    _LinkService_Count_Handler,
+		},
+		{
+			MethodName: "CheckAlias",
+			Handler:    _LinkService_CheckAlias_Handler,
+		},
+		{
+			MethodName: "Version",
+			Handler:    _LinkService_Version_Handler,
+		},
+		{
+			MethodName: "ListByTag",
+			Handler:    _LinkService_ListByTag_Handler,
+		},
+		{
+			MethodName: "UpdateExpiry",
+			Handler:    _LinkService_UpdateExpiry_Handler,
+		},
+		{
+			MethodName: "GetInfo",
+			Handler:    _LinkService_GetInfo_Handler,
+		},
+		{
+			MethodName: "DeleteOlderThan",
+			Handler:    _LinkService_DeleteOlderThan_Handler,
+		},
+		{
+			MethodName: "ValidateLinks",
+			Handler:    _LinkService_ValidateLinks_Handler,
+		},
+		{
+			MethodName: "InvalidateCache",
+			Handler:    _LinkService_InvalidateCache_Handler,
+		},
+		{
+			MethodName: "SetMaintenance",
+			Handler:    _LinkService_SetMaintenance_Handler,
+		},
+		{
+			MethodName: "SetPreview",
+			Handler:    _LinkService_SetPreview_Handler,
+		},
+		{
+			MethodName: "GetPreview",
+			Handler:    _LinkService_GetPreview_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Import",
+			Handler:       _LinkService_Import_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Export",
+			Handler:       _LinkService_Export_Handler,
+			ServerStreams: true,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "api/service.proto",
 }
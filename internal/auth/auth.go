@@ -0,0 +1,287 @@
+// Package auth реализует проверку API-ключей gRPC-запросов. Каждому ключу
+// назначается область действия, определяющая, какие методы сервиса можно
+// вызывать с этим ключом.
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Scope представляет собой область действия API-ключа. Ключ с более широкой
+// областью действия допускает вызов всех методов, доступных ключам с более
+// узкой областью.
+type Scope int
+
+const (
+	// ScopePublic соответствует методам, доступным без API-ключа
+	ScopePublic Scope = iota
+
+	// ScopeRead соответствует методам, только читающим данные, например,
+	// восстановлению оригинального URL
+	ScopeRead
+
+	// ScopeWrite соответствует методам, изменяющим данные
+	ScopeWrite
+
+	// ScopeAdmin соответствует административным методам, влияющим на работу
+	// сервиса в целом, например очистке кэша
+	ScopeAdmin
+)
+
+// MetadataKey — ключ метаданных gRPC-запроса, в котором передается API-ключ
+const MetadataKey = "x-api-key"
+
+// scopeNames сопоставляет названиям областей действия, хранящимся в базе
+// данных, их значения
+var scopeNames = map[string]Scope{
+	"read":  ScopeRead,
+	"write": ScopeWrite,
+	"admin": ScopeAdmin,
+}
+
+var (
+	// ErrMissingKey возвращается в случаях, когда gRPC-запрос не содержит
+	// API-ключа
+	ErrMissingKey = errors.New("auth: the request does not contain an API key")
+
+	// ErrUnknownKey возвращается в случаях, когда API-ключ из gRPC-запроса не
+	// зарегистрирован
+	ErrUnknownKey = errors.New("auth: unknown API key")
+
+	// ErrOutOfScope возвращается в случаях, когда область действия API-ключа
+	// не допускает вызов запрошенного метода
+	ErrOutOfScope = errors.New("auth: the API key does not permit this method")
+)
+
+// LinkServiceScopes сопоставляет методам сервиса LinkService и службы
+// проверки состояния области действия ключей, необходимые для их вызова
+var LinkServiceScopes = map[string]Scope{
+	"/grpc.health.v1.Health/Check": ScopePublic,
+	"/grpc.health.v1.Health/Watch": ScopePublic,
+
+	"/api.LinkService/Get": ScopePublic,
+
+	"/api.LinkService/GetBatch":         ScopeRead,
+	"/api.LinkService/Stats":            ScopeRead,
+	"/api.LinkService/HitsOverTime":     ScopeRead,
+	"/api.LinkService/ListCollections":  ScopeRead,
+	"/api.LinkService/ListByCollection": ScopeRead,
+	"/api.LinkService/GetMetadata":      ScopeRead,
+	"/api.LinkService/ListByOwner":      ScopeRead,
+	"/api.LinkService/Count":            ScopeRead,
+	"/api.LinkService/CheckAlias":       ScopeRead,
+	"/api.LinkService/Version":          ScopeRead,
+	"/api.LinkService/ListByTag":        ScopeRead,
+	"/api.LinkService/GetPreview":       ScopeRead,
+
+	"/api.LinkService/Create":           ScopeWrite,
+	"/api.LinkService/GetOrCreate":      ScopeWrite,
+	"/api.LinkService/BatchCreate":      ScopeWrite,
+	"/api.LinkService/CreateCollection": ScopeWrite,
+	"/api.LinkService/DeleteCollection": ScopeWrite,
+	"/api.LinkService/UpdateURL":        ScopeWrite,
+	"/api.LinkService/SetPreview":       ScopeWrite,
+	"/api.LinkService/UpdateExpiry":     ScopeWrite,
+	"/api.LinkService/DeleteByOwner":    ScopeWrite,
+	"/api.LinkService/Import":           ScopeWrite,
+
+	// экспорт выгружает всю таблицу ссылок, поэтому недоступен ключам,
+	// выданным только для восстановления ссылок
+	"/api.LinkService/Export": ScopeWrite,
+
+	// проверка просматривает всю таблицу ссылок во всех пространствах имен,
+	// поэтому доступна только ключам с правом записи
+	"/api.LinkService/ValidateLinks": ScopeWrite,
+
+	// сведения о ссылке включают IP-адрес и user-agent ее создателя, поэтому
+	// недоступны ключам, выданным только для восстановления ссылок
+	"/api.LinkService/GetInfo": ScopeWrite,
+
+	// административные методы влияют на работу сервиса в целом, а не на
+	// отдельные ссылки
+	"/api.LinkService/InvalidateCache": ScopeAdmin,
+	"/api.LinkService/SetMaintenance":  ScopeAdmin,
+}
+
+// KeyStore описывает хранилище API-ключей.
+type KeyStore interface {
+	// Scope возвращает область действия ключа key. Если ключ не
+	// зарегистрирован, то возвращается ErrUnknownKey
+	Scope(ctx context.Context, key string) (Scope, error)
+}
+
+// StaticKeyStore хранит API-ключи, заданные при запуске сервиса, например, в
+// переменной окружения. Ключам сопоставляются их области действия.
+type StaticKeyStore map[string]Scope
+
+// Scope возвращает область действия ключа key.
+func (s StaticKeyStore) Scope(ctx context.Context, key string) (Scope, error) {
+	scope, ok := s[key]
+	if !ok {
+		return 0, ErrUnknownKey
+	}
+
+	return scope, nil
+}
+
+// ParseKeys разбирает список API-ключей вида "key1,key2:read", в котором
+// область действия указывается после двоеточия. Ключи без области действия
+// получают область ScopeWrite. Пустая строка соответствует пустому списку.
+func ParseKeys(list string) (StaticKeyStore, error) {
+	keys := make(StaticKeyStore)
+
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		key, name := entry, "write"
+		if i := strings.LastIndex(entry, ":"); i >= 0 {
+			key, name = entry[:i], entry[i+1:]
+		}
+
+		scope, ok := scopeNames[name]
+		if key == "" || !ok {
+			return nil, fmt.Errorf("auth: invalid API key entry %q", entry)
+		}
+
+		keys[key] = scope
+	}
+
+	return keys, nil
+}
+
+// KeyStores объединяет несколько хранилищ API-ключей. Ключ ищется в
+// хранилищах по порядку, пока одно из них не вернет результат, отличный от
+// ErrUnknownKey.
+type KeyStores []KeyStore
+
+// Scope возвращает область действия ключа key.
+func (s KeyStores) Scope(ctx context.Context, key string) (Scope, error) {
+	for _, store := range s {
+		scope, err := store.Scope(ctx, key)
+		if err != ErrUnknownKey {
+			return scope, err
+		}
+	}
+
+	return 0, ErrUnknownKey
+}
+
+// DBKeyStore хранит API-ключи в таблице api_keys базы данных. Ключи хранятся
+// в виде хешей SHA-256, поэтому утечка таблицы не раскрывает сами ключи.
+type DBKeyStore struct {
+	Database *sql.DB
+}
+
+// Scope возвращает область действия ключа key.
+func (s DBKeyStore) Scope(ctx context.Context, key string) (Scope, error) {
+	var name string
+	err := s.Database.QueryRowContext(ctx, "SELECT scope FROM api_keys WHERE key_hash = $1;", HashKey(key)).Scan(&name)
+
+	if err == sql.ErrNoRows {
+		return 0, ErrUnknownKey
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	scope, ok := scopeNames[name]
+	if !ok {
+		return 0, ErrUnknownKey
+	}
+
+	return scope, nil
+}
+
+// HashKey возвращает хеш API-ключа key в том виде, в котором он хранится в
+// столбце key_hash таблицы api_keys.
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Authenticator проверяет API-ключи gRPC-запросов.
+type Authenticator struct {
+	Keys KeyStore
+
+	// Scopes сопоставляет полным именам методов области действия ключей,
+	// необходимые для их вызова. Методы, не указанные в Scopes, требуют
+	// ключа с областью действия ScopeWrite
+	Scopes map[string]Scope
+}
+
+// authorize проверяет, допускает ли API-ключ из метаданных контекста ctx
+// вызов метода method. Ошибки передаются клиенту с кодами состояния gRPC:
+// ErrMissingKey и ErrUnknownKey — codes.Unauthenticated, ErrOutOfScope —
+// codes.PermissionDenied, ошибки хранилища ключей — codes.Internal.
+func (a *Authenticator) authorize(ctx context.Context, method string) error {
+	required, ok := a.Scopes[method]
+	if !ok {
+		required = ScopeWrite
+	}
+
+	if required == ScopePublic {
+		return nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	keys := md.Get(MetadataKey)
+	if len(keys) == 0 || keys[0] == "" {
+		return status.Error(codes.Unauthenticated, ErrMissingKey.Error())
+	}
+
+	scope, err := a.Keys.Scope(ctx, keys[0])
+	if err == ErrUnknownKey {
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	if err != nil {
+		return status.Error(codes.Internal, "auth: the API key could not be verified")
+	}
+
+	if scope < required {
+		return status.Error(codes.PermissionDenied, ErrOutOfScope.Error())
+	}
+
+	return nil
+}
+
+// UnaryInterceptor возвращает перехватчик унарных gRPC-запросов, отклоняющий
+// запросы, область действия ключа которых не допускает вызов метода.
+func (a *Authenticator) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := a.authorize(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamInterceptor возвращает перехватчик потоковых gRPC-запросов,
+// отклоняющий запросы, область действия ключа которых не допускает вызов
+// метода.
+func (a *Authenticator) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := a.authorize(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+
+		return handler(srv, ss)
+	}
+}
@@ -0,0 +1,170 @@
+package auth
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// stubService отвечает на вызовы методов Get и Create, не обращаясь к базе
+// данных
+type stubService struct {
+	api.UnimplementedLinkServiceServer
+}
+
+func (stubService) Get(ctx context.Context, req *api.Link) (*api.URL, error) {
+	return &api.URL{Url: "http://auth.abc/"}, nil
+}
+
+func (stubService) Create(ctx context.Context, req *api.URL) (*api.Link, error) {
+	return &api.Link{Link: "abc"}, nil
+}
+
+func TestAuthenticator(t *testing.T) {
+	a := &Authenticator{
+		Keys:   StaticKeyStore{"read-key": ScopeRead, "write-key": ScopeWrite},
+		Scopes: LinkServiceScopes,
+	}
+
+	l := bufconn.Listen(1 << 16)
+	srv := grpc.NewServer(grpc.UnaryInterceptor(a.UnaryInterceptor()), grpc.StreamInterceptor(a.StreamInterceptor()))
+	api.RegisterLinkServiceServer(srv, stubService{})
+
+	go srv.Serve(l)
+	defer srv.Stop()
+
+	conn, err := grpc.Dial("bufconn",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return l.Dial() }),
+		grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("failed to connect to the server: %v", err)
+	}
+
+	defer conn.Close()
+
+	client := api.NewLinkServiceClient(conn)
+
+	testCases := []struct {
+		name    string
+		key     string
+		create  bool
+		expCode codes.Code
+	}{
+		{name: "read_key_get", key: "read-key", expCode: codes.OK},
+		{name: "read_key_create", key: "read-key", create: true, expCode: codes.PermissionDenied},
+		{name: "write_key_get", key: "write-key", expCode: codes.OK},
+		{name: "write_key_create", key: "write-key", create: true, expCode: codes.OK},
+		{name: "unknown_key", key: "unknown-key", create: true, expCode: codes.Unauthenticated},
+		{name: "missing_key", create: true, expCode: codes.Unauthenticated},
+		{name: "missing_key_get", expCode: codes.OK},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctx := context.Background()
+			if testCase.key != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, MetadataKey, testCase.key)
+			}
+
+			if testCase.create {
+				_, err = client.Create(ctx, &api.URL{Url: "http://auth.abc/"})
+			} else {
+				_, err = client.Get(ctx, &api.Link{Link: "abc"})
+			}
+
+			if code := status.Code(err); code != testCase.expCode {
+				t.Errorf("a status code of \"%v\" was expected, but \"%v\" was received", testCase.expCode, code)
+			}
+		})
+	}
+}
+
+func TestUnlistedMethodRequiresWrite(t *testing.T) {
+	a := &Authenticator{Keys: StaticKeyStore{"read-key": ScopeRead}}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(MetadataKey, "read-key"))
+
+	if code := status.Code(a.authorize(ctx, "/api.LinkService/Unknown")); code != codes.PermissionDenied {
+		t.Errorf("a status code of \"%v\" was expected, but \"%v\" was received", codes.PermissionDenied, code)
+	}
+}
+
+func TestMethodScopes(t *testing.T) {
+	a := &Authenticator{
+		Keys:   StaticKeyStore{"read-key": ScopeRead, "write-key": ScopeWrite, "admin-key": ScopeAdmin},
+		Scopes: LinkServiceScopes,
+	}
+
+	testCases := []struct {
+		name    string
+		method  string
+		key     string
+		expCode codes.Code
+	}{
+		{name: "read_key_get_info", method: "/api.LinkService/GetInfo", key: "read-key", expCode: codes.PermissionDenied},
+		{name: "write_key_get_info", method: "/api.LinkService/GetInfo", key: "write-key", expCode: codes.OK},
+		{name: "read_key_invalidate_cache", method: "/api.LinkService/InvalidateCache", key: "read-key", expCode: codes.PermissionDenied},
+		{name: "write_key_invalidate_cache", method: "/api.LinkService/InvalidateCache", key: "write-key", expCode: codes.PermissionDenied},
+		{name: "admin_key_invalidate_cache", method: "/api.LinkService/InvalidateCache", key: "admin-key", expCode: codes.OK},
+		{name: "write_key_set_maintenance", method: "/api.LinkService/SetMaintenance", key: "write-key", expCode: codes.PermissionDenied},
+		{name: "admin_key_set_maintenance", method: "/api.LinkService/SetMaintenance", key: "admin-key", expCode: codes.OK},
+		{name: "read_key_export", method: "/api.LinkService/Export", key: "read-key", expCode: codes.PermissionDenied},
+		{name: "write_key_export", method: "/api.LinkService/Export", key: "write-key", expCode: codes.OK},
+		{name: "read_key_validate_links", method: "/api.LinkService/ValidateLinks", key: "read-key", expCode: codes.PermissionDenied},
+		{name: "write_key_validate_links", method: "/api.LinkService/ValidateLinks", key: "write-key", expCode: codes.OK},
+		{name: "missing_key_delete_older_than", method: "/api.LinkService/DeleteOlderThan", expCode: codes.Unauthenticated},
+		{name: "read_key_delete_older_than", method: "/api.LinkService/DeleteOlderThan", key: "read-key", expCode: codes.PermissionDenied},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(MetadataKey, testCase.key))
+
+			if code := status.Code(a.authorize(ctx, testCase.method)); code != testCase.expCode {
+				t.Errorf("a status code of \"%v\" was expected, but \"%v\" was received", testCase.expCode, code)
+			}
+		})
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	keys, err := ParseKeys(" writer-key , viewer-key:read,,writer:key:write,ops-key:admin")
+	if err != nil {
+		t.Fatalf("ParseKeys reported an error: %v", err)
+	}
+
+	exp := StaticKeyStore{"writer-key": ScopeWrite, "viewer-key": ScopeRead, "writer:key": ScopeWrite, "ops-key": ScopeAdmin}
+	if len(keys) != len(exp) {
+		t.Fatalf("%d keys were expected, but %d were received", len(exp), len(keys))
+	}
+
+	for key, scope := range exp {
+		if keys[key] != scope {
+			t.Errorf("the key \"%s\" was expected to have the scope %v, but has %v", key, scope, keys[key])
+		}
+	}
+
+	for _, list := range []string{"key:owner", ":read"} {
+		if _, err := ParseKeys(list); err == nil {
+			t.Errorf("an error was expected for the list \"%s\"", list)
+		}
+	}
+}
+
+func TestKeyStores(t *testing.T) {
+	stores := KeyStores{StaticKeyStore{"env-key": ScopeRead}, StaticKeyStore{"db-key": ScopeWrite}}
+
+	if scope, err := stores.Scope(context.Background(), "db-key"); err != nil || scope != ScopeWrite {
+		t.Errorf("the scope %v was expected, but %v with the error \"%v\" was received", ScopeWrite, scope, err)
+	}
+
+	if _, err := stores.Scope(context.Background(), "unknown-key"); err != ErrUnknownKey {
+		t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrUnknownKey, err)
+	}
+}
@@ -0,0 +1,46 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// versioned описывает ответы, содержащие версию короткой ссылки и время ее
+// создания, — api.URL и api.LinkMetadata
+type versioned interface {
+	GetVersion() int64
+	GetCreatedAt() *timestamppb.Timestamp
+}
+
+// linkETag возвращает значение заголовка ETag для ссылки из ответа v. Версия
+// ссылки дополняется временем ее создания, чтобы ссылка, удаленная и созданная
+// заново с тем же кодом, не получила ETag прежней ссылки.
+func linkETag(v versioned) string {
+	return fmt.Sprintf("\"%d-%x\"", v.GetVersion(), v.GetCreatedAt().AsTime().UnixNano())
+}
+
+// setETag добавляет в ответ w заголовок ETag для ссылки из ответа v и
+// сообщает, совпадает ли он с одним из значений заголовков If-None-Match
+// запроса r. В этом случае клиенту достаточно ответить 304 Not Modified.
+// Ответ требует проверки при каждом обращении, поэтому кэши не возвращают
+// прежний URL после его изменения методом UpdateURL.
+func setETag(w http.ResponseWriter, r *http.Request, v versioned) bool {
+	etag := linkETag(v)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+
+	// If-None-Match сравнивается слабым сравнением (RFC 7232, раздел 3.2)
+	for _, header := range r.Header.Values("If-None-Match") {
+		for _, value := range strings.Split(header, ",") {
+			value = strings.TrimSpace(value)
+			if value == "*" || strings.TrimPrefix(value, "W/") == etag {
+				return true
+			}
+		}
+	}
+
+	return false
+}
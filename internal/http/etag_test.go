@@ -0,0 +1,194 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// versionedService хранит одну короткую ссылку "abcdefghij" и увеличивает ее
+// версию при каждом вызове UpdateURL, как сервис
+type versionedService struct {
+	api.UnimplementedLinkServiceServer
+
+	mu      sync.Mutex
+	url     string
+	version int64
+	created time.Time
+}
+
+func newVersionedService() *versionedService {
+	return &versionedService{url: "http://etag.abc/old", version: 1, created: time.Now()}
+}
+
+func (s *versionedService) Get(ctx context.Context, req *api.Link) (*api.URL, error) {
+	if req.GetLink() != "abcdefghij" {
+		return nil, status.Error(codes.NotFound, "not found")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return &api.URL{Url: s.url, CreatedAt: timestamppb.New(s.created), Version: s.version}, nil
+}
+
+func (s *versionedService) GetMetadata(ctx context.Context, req *api.Link) (*api.LinkMetadata, error) {
+	res, err := s.Get(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.LinkMetadata{Link: req.GetLink(), Url: res.GetUrl(), CreatedAt: res.GetCreatedAt(), Version: res.GetVersion()}, nil
+}
+
+func (s *versionedService) UpdateURL(ctx context.Context, req *api.UpdateRequest) (*api.Empty, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.url = req.GetUrl()
+	s.version++
+
+	return &api.Empty{}, nil
+}
+
+func TestRedirectHandlerETag(t *testing.T) {
+	service := newVersionedService()
+	h := NewRedirectHandler(service)
+
+	// serve выполняет переход по ссылке с заголовком If-None-Match, если
+	// etag не пуст
+	serve := func(etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/abcdefghij", nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	rec := serve("")
+	etag := rec.Header().Get("ETag")
+	if rec.Code != http.StatusFound || etag == "" {
+		t.Fatalf("a redirect with an ETag was expected, but %v with \"%s\" was received", rec.Code, etag)
+	}
+
+	if cacheControl := rec.Header().Get("Cache-Control"); cacheControl != "no-cache" {
+		t.Errorf("Cache-Control \"no-cache\" was expected, but \"%s\" was received", cacheControl)
+	}
+
+	testCases := []struct {
+		name        string
+		ifNoneMatch string
+		expCode     int
+	}{
+		{name: "same_etag", ifNoneMatch: etag, expCode: http.StatusNotModified},
+		{name: "weak_etag", ifNoneMatch: "W/" + etag, expCode: http.StatusNotModified},
+		{name: "etag_list", ifNoneMatch: `"other", ` + etag, expCode: http.StatusNotModified},
+		{name: "any", ifNoneMatch: "*", expCode: http.StatusNotModified},
+		{name: "other_etag", ifNoneMatch: `"other"`, expCode: http.StatusFound},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			rec := serve(testCase.ifNoneMatch)
+			if rec.Code != testCase.expCode {
+				t.Errorf("the code %v was expected, but %v was received", testCase.expCode, rec.Code)
+			}
+
+			if got := rec.Header().Get("ETag"); got != etag {
+				t.Errorf("the ETag %s was expected, but %s was received", etag, got)
+			}
+		})
+	}
+
+	if _, err := service.UpdateURL(context.Background(), &api.UpdateRequest{Link: "abcdefghij", Url: "http://etag.abc/new"}); err != nil {
+		t.Fatalf("UpdateURL method reported an error: %v", err)
+	}
+
+	// после изменения URL кэш с прежним ETag должен получить новый адрес
+	rec = serve(etag)
+	if rec.Code != http.StatusFound {
+		t.Fatalf("the code %v was expected after the update, but %v was received", http.StatusFound, rec.Code)
+	}
+
+	if newETag := rec.Header().Get("ETag"); newETag == etag || newETag == "" {
+		t.Errorf("a new ETag was expected after the update, but %s was received", newETag)
+	}
+
+	if location := rec.Header().Get("Location"); location != "http://etag.abc/new" {
+		t.Errorf("the location \"http://etag.abc/new\" was expected, but \"%s\" was received", location)
+	}
+}
+
+func TestGatewayETag(t *testing.T) {
+	srv := newGatewayServer(t, dialService(t, newVersionedService()))
+
+	// get запрашивает путь path с заголовком If-None-Match, если etag не пуст
+	get := func(path, etag string) *http.Response {
+		req, err := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+		if err != nil {
+			t.Fatalf("failed to create a request: %v", err)
+		}
+
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+
+		res, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatalf("failed to send a request: %v", err)
+		}
+
+		res.Body.Close()
+		return res
+	}
+
+	for _, path := range []string{"/v1/links/abcdefghij", "/v1/links/abcdefghij/metadata"} {
+		t.Run(strings.TrimPrefix(path, "/v1/links/"), func(t *testing.T) {
+			etag := get(path, "").Header.Get("ETag")
+			if etag == "" {
+				t.Fatalf("an ETag was expected")
+			}
+
+			if res := get(path, etag); res.StatusCode != http.StatusNotModified {
+				t.Errorf("the code %v was expected, but %v was received", http.StatusNotModified, res.StatusCode)
+			}
+
+			res, err := srv.Client().Post(srv.URL+"/v1/links/abcdefghij:updateURL", "application/json", strings.NewReader(`{"url": "http://etag.abc`+path+`"}`))
+			if err != nil {
+				t.Fatalf("failed to send a request: %v", err)
+			}
+
+			res.Body.Close()
+			if res.StatusCode != http.StatusOK {
+				t.Fatalf("the code %v was expected for the update, but %v was received", http.StatusOK, res.StatusCode)
+			}
+
+			res = get(path, etag)
+			if res.StatusCode != http.StatusOK {
+				t.Errorf("the code %v was expected after the update, but %v was received", http.StatusOK, res.StatusCode)
+			}
+
+			if newETag := res.Header.Get("ETag"); newETag == etag || newETag == "" {
+				t.Errorf("a new ETag was expected after the update, but %s was received", newETag)
+			}
+		})
+	}
+
+	// ответы с ошибкой не содержат ETag
+	if etag := get("/v1/links/unknown", "").Header.Get("ETag"); etag != "" {
+		t.Errorf("no ETag was expected for an unknown link, but %s was received", etag)
+	}
+}
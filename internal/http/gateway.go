@@ -0,0 +1,417 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+	"github.com/pavelzagorodnyuk/linkservice/internal/auth"
+	"github.com/pavelzagorodnyuk/linkservice/internal/ratelimit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	// сведения об ошибках google.rpc.BadRequest передаются в сообщении
+	// google.rpc.Status как google.protobuf.Any, и для их записи в формате
+	// JSON тип сообщения должен быть зарегистрирован
+	_ "google.golang.org/genproto/googleapis/rpc/errdetails"
+)
+
+// GatewayPrefix задает путь, по которому Gateway принимает запросы
+const GatewayPrefix = "/v1/"
+
+// максимальный размер тела запроса к Gateway
+var maxBodySize int64 = 1 << 20
+
+// максимальный размер тела потокового запроса к Gateway, например к методу
+// Import, передающего сообщения построчно
+var maxStreamBodySize int64 = 64 << 20
+
+var (
+	// marshalOptions соответствуют параметрам grpc-gateway по умолчанию: поля
+	// с нулевыми значениями включаются в ответ
+	marshalOptions = protojson.MarshalOptions{EmitUnpopulated: true}
+
+	// unmarshalOptions позволяют клиентам передавать поля, неизвестные
+	// текущей версии сервиса
+	unmarshalOptions = protojson.UnmarshalOptions{DiscardUnknown: true}
+)
+
+// Gateway преобразует запросы JSON/REST в вызовы методов gRPC-сервиса
+// аналогично обратному прокси grpc-gateway. Методы сопоставляются запросам
+// таблицей routes, которая играет роль аннотаций google.api.http: для
+// генерации прокси grpc-gateway нужны плагин protoc и модули grpc-gateway,
+// поэтому прокси написан вручную, а то, что таблица охватывает все методы
+// сервиса, проверяется тестом.
+//
+// Запросы передаются gRPC-серверу через соединение Conn, поэтому к ним
+// применяются те же перехватчики, что и к gRPC-запросам, в том числе проверка
+// API-ключей. Ключ передается в заголовке X-Api-Key.
+type Gateway struct {
+	Conn grpc.ClientConnInterface
+}
+
+// NewGateway создает обратный прокси, передающий запросы в соединение conn.
+func NewGateway(conn grpc.ClientConnInterface) *Gateway {
+	return &Gateway{Conn: conn}
+}
+
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rt, vars, allow := matchRoute(r.Method, r.URL.Path)
+	if rt == nil && len(allow) > 0 {
+		methodNotAllowed(w, strings.Join(allow, ", "))
+		return
+	}
+
+	if rt == nil {
+		writeStatus(w, http.StatusNotFound, status.New(codes.NotFound, http.StatusText(http.StatusNotFound)))
+		return
+	}
+
+	ctx := r.Context()
+	if key := r.Header.Get(auth.MetadataKey); key != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, auth.MetadataKey, key)
+	}
+
+	// сервис видит адрес шлюза, поэтому адрес HTTP-клиента передается в
+	// метаданных, чтобы ограничение частоты запросов действовало для каждого
+	// клиента отдельно, а создатель ссылки сохранялся верно
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		ctx = metadata.AppendToOutgoingContext(ctx, ratelimit.ForwardedForKey, host)
+	}
+
+	// метаданные user-agent заполняет gRPC-клиент шлюза, поэтому заголовок
+	// клиента передается под другим ключом
+	if userAgent := r.UserAgent(); userAgent != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, ratelimit.ForwardedUserAgentKey, userAgent)
+	}
+
+	method := rt.descriptor()
+	fullMethod := "/" + api.LinkService_ServiceDesc.ServiceName + "/" + rt.rpc
+
+	if method.IsStreamingClient() {
+		g.serveClientStream(ctx, w, r, rt, method, fullMethod)
+		return
+	}
+
+	req, err := newRequest(r, rt, method, vars)
+	if err != nil {
+		writeError(w, status.Error(codes.InvalidArgument, err.Error()))
+		return
+	}
+
+	if method.IsStreamingServer() {
+		g.serveServerStream(ctx, w, rt, method, fullMethod, req)
+		return
+	}
+
+	res := newMessage(method.Output())
+	if err := g.Conn.Invoke(ctx, fullMethod, req, res); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// ответы методов Get и GetMetadata содержат версию ссылки, по которой
+	// клиент может проверить, изменился ли ее URL
+	if v, ok := res.(versioned); ok && r.Method == http.MethodGet && setETag(w, r, v) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	writeMessage(w, http.StatusOK, res)
+}
+
+// serveServerStream вызывает метод с потоком ответов и передает каждое
+// сообщение потока отдельной строкой JSON вида {"result": ...}, как
+// grpc-gateway. Ошибка, возникшая после начала передачи, передается строкой
+// вида {"error": ...}.
+func (g *Gateway) serveServerStream(ctx context.Context, w http.ResponseWriter, rt *route, method protoreflect.MethodDescriptor, fullMethod string, req proto.Message) {
+	// при выходе из функции незавершенный вызов отменяется
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := g.Conn.NewStream(ctx, rt.streamDesc(), fullMethod)
+	if err == nil {
+		err = stream.SendMsg(req)
+	}
+
+	if err == nil {
+		err = stream.CloseSend()
+	}
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	for started := false; ; started = true {
+		res := newMessage(method.Output())
+		err := stream.RecvMsg(res)
+		if err == io.EOF {
+			if !started {
+				w.Header().Set("Content-Type", "application/json")
+			}
+
+			return
+		}
+
+		if err != nil && !started {
+			writeError(w, err)
+			return
+		}
+
+		if !started {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+		}
+
+		if err != nil {
+			writeStreamLine(w, "error", status.Convert(err).Proto())
+			return
+		}
+
+		writeStreamLine(w, "result", res)
+	}
+}
+
+// serveClientStream читает из тела запроса r сообщения JSON, следующие одно
+// за другим, передает их методу с потоком запросов и возвращает его ответ.
+func (g *Gateway) serveClientStream(ctx context.Context, w http.ResponseWriter, r *http.Request, rt *route, method protoreflect.MethodDescriptor, fullMethod string) {
+	// при выходе из функции незавершенный вызов отменяется
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := g.Conn.NewStream(ctx, rt.streamDesc(), fullMethod)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	dec := json.NewDecoder(io.LimitReader(r.Body, maxStreamBodySize))
+	for {
+		var raw json.RawMessage
+		err := dec.Decode(&raw)
+		if err == io.EOF {
+			break
+		}
+
+		req := newMessage(method.Input())
+		if err == nil {
+			err = unmarshalOptions.Unmarshal(raw, req)
+		}
+
+		if err != nil {
+			writeError(w, status.Error(codes.InvalidArgument, err.Error()))
+			return
+		}
+
+		// сервер может завершить вызов раньше, например с ошибкой проверки
+		// сообщения; тогда ее возвращает RecvMsg
+		if err := stream.SendMsg(req); err != nil {
+			break
+		}
+	}
+
+	res := newMessage(method.Output())
+	err = stream.CloseSend()
+	if err == nil {
+		err = stream.RecvMsg(res)
+	}
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeMessage(w, http.StatusOK, res)
+}
+
+// newRequest создает сообщение запроса метода method из запроса HTTP r: тело
+// запроса читается, если его принимает маршрут rt, а параметры строки
+// запроса — в противном случае. Переменные пути vars заполняют
+// соответствующие поля сообщения в последнюю очередь.
+func newRequest(r *http.Request, rt *route, method protoreflect.MethodDescriptor, vars map[string]string) (proto.Message, error) {
+	req := newMessage(method.Input())
+
+	if rt.body {
+		if err := readMessage(r, req); err != nil {
+			return nil, err
+		}
+	} else if err := setFields(req, r.URL.Query()); err != nil {
+		return nil, err
+	}
+
+	values := make(map[string][]string, len(vars))
+	for name, value := range vars {
+		values[name] = []string{value}
+	}
+
+	return req, setFields(req, values)
+}
+
+// setFields заполняет поля сообщения m значениями values, ключами которых
+// служат имена полей в protobuf или JSON. Значения разбираются так же, как
+// значения полей в формате JSON, поэтому поддерживаются в том числе
+// повторяющиеся поля и моменты времени. Неизвестные поля пропускаются.
+func setFields(m proto.Message, values map[string][]string) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	fields := m.ProtoReflect().Descriptor().Fields()
+	obj := make(map[string]interface{}, len(values))
+
+	for name, vals := range values {
+		fd := fields.ByName(protoreflect.Name(name))
+		if fd == nil {
+			fd = fields.ByJSONName(name)
+		}
+
+		if fd == nil || len(vals) == 0 {
+			continue
+		}
+
+		var value interface{} = vals[len(vals)-1]
+		if fd.Kind() == protoreflect.BoolKind && !fd.IsList() {
+			b, err := strconv.ParseBool(vals[len(vals)-1])
+			if err != nil {
+				return fmt.Errorf("invalid value %q of %s", vals[len(vals)-1], name)
+			}
+
+			value = b
+		} else if fd.IsList() {
+			value = vals
+		}
+
+		obj[fd.JSONName()] = value
+	}
+
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+
+	fieldsMsg := m.ProtoReflect().New().Interface()
+	if err := unmarshalOptions.Unmarshal(data, fieldsMsg); err != nil {
+		return err
+	}
+
+	proto.Merge(m, fieldsMsg)
+	return nil
+}
+
+// newMessage создает пустое сообщение типа md.
+func newMessage(md protoreflect.MessageDescriptor) proto.Message {
+	mt, err := protoregistry.GlobalTypes.FindMessageByName(md.FullName())
+	if err != nil {
+		// типы сообщений сервиса регистрируются сгенерированным кодом
+		panic(err)
+	}
+
+	return mt.New().Interface()
+}
+
+// methodNotAllowed отвечает на запрос с неподдерживаемым методом HTTP так же,
+// как grpc-gateway, сообщая допустимый метод allow.
+func methodNotAllowed(w http.ResponseWriter, allow string) {
+	w.Header().Set("Allow", allow)
+	writeStatus(w, http.StatusMethodNotAllowed, status.New(codes.Unimplemented, http.StatusText(http.StatusMethodNotAllowed)))
+}
+
+// readMessage читает сообщение m из тела запроса r в формате JSON.
+func readMessage(r *http.Request, m proto.Message) error {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize))
+	if err != nil {
+		return err
+	}
+
+	// методы без параметров, например ValidateLinks, можно вызвать без тела
+	if len(body) == 0 {
+		return nil
+	}
+
+	return unmarshalOptions.Unmarshal(body, m)
+}
+
+// writeMessage записывает в ответ w сообщение m в формате JSON с кодом
+// состояния code.
+func writeMessage(w http.ResponseWriter, code int, m proto.Message) {
+	body, err := marshalOptions.Marshal(m)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(body)
+}
+
+// writeStreamLine записывает в ответ w строку потока JSON, в которой
+// сообщение m передается в поле key.
+func writeStreamLine(w http.ResponseWriter, key string, m proto.Message) {
+	body, err := marshalOptions.Marshal(m)
+	if err != nil {
+		return
+	}
+
+	fmt.Fprintf(w, "{%q: %s}\n", key, body)
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// writeError записывает в ответ w ошибку gRPC err в формате grpc-gateway:
+// сообщение google.rpc.Status в формате JSON с кодом состояния HTTP,
+// соответствующим коду состояния gRPC.
+func writeError(w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+	writeStatus(w, httpStatusFromCode(st.Code()), st)
+}
+
+// writeStatus записывает в ответ w состояние st с кодом состояния HTTP code.
+func writeStatus(w http.ResponseWriter, code int, st *status.Status) {
+	writeMessage(w, code, st.Proto())
+}
+
+// httpStatusFromCode возвращает код состояния HTTP, соответствующий коду
+// состояния gRPC code, по таблице grpc-gateway.
+func httpStatusFromCode(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
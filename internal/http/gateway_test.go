@@ -0,0 +1,353 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// stubService отвечает на вызовы методов Create и Get, не обращаясь к базе
+// данных. Ссылка "private" доступна только с API-ключом "secret".
+type stubService struct {
+	api.UnimplementedLinkServiceServer
+}
+
+func (stubService) Create(ctx context.Context, req *api.URL) (*api.Link, error) {
+	if req.GetUrl() == "" {
+		return nil, status.Error(codes.InvalidArgument, "invalid URL")
+	}
+
+	return &api.Link{Link: "abcdefghij"}, nil
+}
+
+func (stubService) Get(ctx context.Context, req *api.Link) (*api.URL, error) {
+	switch req.GetLink() {
+	case "abcdefghij":
+		return &api.URL{Url: "http://gateway.abc/"}, nil
+	case "private":
+		md, _ := metadata.FromIncomingContext(ctx)
+		if keys := md.Get("x-api-key"); len(keys) != 1 || keys[0] != "secret" {
+			return nil, status.Error(codes.Unauthenticated, "missing key")
+		}
+
+		return &api.URL{Url: "http://private.abc/"}, nil
+	default:
+		return nil, status.Error(codes.NotFound, "not found")
+	}
+}
+
+// dialStub запускает gRPC-сервер stubService в памяти и возвращает
+// соединение с ним.
+func dialStub(t *testing.T) *grpc.ClientConn {
+	return dialService(t, stubService{})
+}
+
+// dialService запускает gRPC-сервер service в памяти и возвращает соединение
+// с ним.
+func dialService(t *testing.T, service api.LinkServiceServer) *grpc.ClientConn {
+	l := bufconn.Listen(1 << 16)
+	srv := grpc.NewServer()
+	api.RegisterLinkServiceServer(srv, service)
+
+	go srv.Serve(l)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.Dial("bufconn",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return l.Dial() }),
+		grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("failed to connect to the server: %v", err)
+	}
+
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+// newGatewayServer запускает HTTP-сервер с Gateway, передающим запросы в
+// соединение conn.
+func newGatewayServer(t *testing.T, conn *grpc.ClientConn) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.Handle(GatewayPrefix, NewGateway(conn))
+
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+
+	return ts
+}
+
+func (stubService) Stats(ctx context.Context, req *api.Link) (*api.LinkStats, error) {
+	return &api.LinkStats{Url: "http://gateway.abc/" + req.GetNamespace(), Visits: 3}, nil
+}
+
+func (stubService) ListByCollection(ctx context.Context, req *api.Collection) (*api.MappingList, error) {
+	return &api.MappingList{Mappings: []*api.Mapping{{Link: fmt.Sprintf("collection-%d", req.GetId()), Url: "http://gateway.abc/"}}}, nil
+}
+
+func (stubService) ValidateLinks(ctx context.Context, req *api.Empty) (*api.ValidationReport, error) {
+	return &api.ValidationReport{Checked: 1}, nil
+}
+
+func (stubService) Export(req *api.ExportRequest, stream api.LinkService_ExportServer) error {
+	for _, link := range []string{"first", "second"} {
+		if err := stream.Send(&api.ExportedLink{Link: link, Url: "http://gateway.abc/" + req.GetNamespace()}); err != nil {
+			return err
+		}
+	}
+
+	return status.Error(codes.Internal, "interrupted")
+}
+
+func (stubService) Import(stream api.LinkService_ImportServer) error {
+	res := &api.ImportResult{}
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(res)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if req.GetUrl() == "" {
+			return status.Error(codes.InvalidArgument, "invalid URL")
+		}
+
+		res.Inserted++
+	}
+}
+
+func TestGateway(t *testing.T) {
+	conn := dialStub(t)
+
+	ts := newGatewayServer(t, conn)
+
+	testCases := []struct {
+		name    string
+		method  string
+		path    string
+		body    string
+		key     string
+		expCode int
+		expBody map[string]interface{}
+	}{
+		{name: "create", method: http.MethodPost, path: "/v1/links", body: `{"url": "http://gateway.abc/", "ttlSeconds": 60}`, expCode: http.StatusOK,
+			expBody: map[string]interface{}{"link": "abcdefghij"}},
+		{name: "create_invalid", method: http.MethodPost, path: "/v1/links", body: `{}`, expCode: http.StatusBadRequest,
+			expBody: map[string]interface{}{"code": float64(codes.InvalidArgument), "message": "invalid URL"}},
+		{name: "create_malformed", method: http.MethodPost, path: "/v1/links", body: `{"url": `, expCode: http.StatusBadRequest,
+			expBody: map[string]interface{}{"code": float64(codes.InvalidArgument)}},
+		{name: "get", method: http.MethodGet, path: "/v1/links/abcdefghij", expCode: http.StatusOK,
+			expBody: map[string]interface{}{"url": "http://gateway.abc/", "alias": "", "ttlSeconds": "0"}},
+		{name: "get_unknown", method: http.MethodGet, path: "/v1/links/unknown", expCode: http.StatusNotFound,
+			expBody: map[string]interface{}{"code": float64(codes.NotFound)}},
+		{name: "get_without_key", method: http.MethodGet, path: "/v1/links/private", expCode: http.StatusUnauthorized},
+		{name: "get_with_key", method: http.MethodGet, path: "/v1/links/private", key: "secret", expCode: http.StatusOK,
+			expBody: map[string]interface{}{"url": "http://private.abc/"}},
+		{name: "get_collection", method: http.MethodGet, path: "/v1/links", expCode: http.StatusMethodNotAllowed},
+		{name: "delete", method: http.MethodDelete, path: "/v1/links/abcdefghij", expCode: http.StatusMethodNotAllowed},
+		{name: "nested", method: http.MethodGet, path: "/v1/links/abcdefghij/more", expCode: http.StatusNotFound},
+		{name: "query_parameters", method: http.MethodGet, path: "/v1/links/abcdefghij/stats?namespace=brand", expCode: http.StatusOK,
+			expBody: map[string]interface{}{"url": "http://gateway.abc/brand", "visits": "3"}},
+		{name: "numeric_path_variable", method: http.MethodGet, path: "/v1/collections/7/links", expCode: http.StatusOK,
+			expBody: map[string]interface{}{"mappings": []interface{}{map[string]interface{}{"link": "collection-7", "url": "http://gateway.abc/"}}}},
+		{name: "empty_body", method: http.MethodPost, path: "/v1/links:validate", expCode: http.StatusOK,
+			expBody: map[string]interface{}{"checked": "1"}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			req, err := http.NewRequest(testCase.method, ts.URL+testCase.path, strings.NewReader(testCase.body))
+			if err != nil {
+				t.Fatalf("failed to create a request: %v", err)
+			}
+
+			if testCase.key != "" {
+				req.Header.Set("X-Api-Key", testCase.key)
+			}
+
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("the request failed: %v", err)
+			}
+
+			defer res.Body.Close()
+
+			if res.StatusCode != testCase.expCode {
+				t.Errorf("the code %v was expected, but %v was received", testCase.expCode, res.StatusCode)
+			}
+
+			if ct := res.Header.Get("Content-Type"); ct != "application/json" {
+				t.Errorf("the content type \"application/json\" was expected, but \"%s\" was received", ct)
+			}
+
+			var body map[string]interface{}
+			if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+				t.Fatalf("failed to decode the response: %v", err)
+			}
+
+			for key, exp := range testCase.expBody {
+				if !reflect.DeepEqual(body[key], exp) {
+					t.Errorf("%s: a value of \"%v\" was expected, but \"%v\" was received", key, exp, body[key])
+				}
+			}
+		})
+	}
+}
+
+func TestGatewayStreams(t *testing.T) {
+	ts := newGatewayServer(t, dialStub(t))
+
+	res, err := http.Get(ts.URL + "/v1/links:export?namespace=brand")
+	if err != nil {
+		t.Fatalf("the request failed: %v", err)
+	}
+
+	defer res.Body.Close()
+
+	// каждое сообщение потока передается отдельной строкой, а ошибка после
+	// начала передачи — последней строкой
+	var lines []map[string]map[string]interface{}
+	dec := json.NewDecoder(res.Body)
+	for {
+		var line map[string]map[string]interface{}
+		if err := dec.Decode(&line); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("failed to decode the response: %v", err)
+		}
+
+		lines = append(lines, line)
+	}
+
+	if len(lines) != 3 || lines[0]["result"]["link"] != "first" || lines[1]["result"]["url"] != "http://gateway.abc/brand" ||
+		lines[2]["error"]["code"] != float64(codes.Internal) {
+		t.Errorf("two results and an error were expected, but %v was received", lines)
+	}
+
+	testCases := []struct {
+		name    string
+		body    string
+		expCode int
+		expBody map[string]interface{}
+	}{
+		{name: "import", body: `{"link": "a", "url": "http://a.abc/"}` + "\n" + `{"link": "b", "url": "http://b.abc/"}`, expCode: http.StatusOK,
+			expBody: map[string]interface{}{"inserted": "2"}},
+		{name: "import_invalid", body: `{"link": "a"}`, expCode: http.StatusBadRequest,
+			expBody: map[string]interface{}{"message": "invalid URL"}},
+		{name: "import_malformed", body: `{"link": `, expCode: http.StatusBadRequest,
+			expBody: map[string]interface{}{"code": float64(codes.InvalidArgument)}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			res, err := http.Post(ts.URL+"/v1/links:import", "application/json", strings.NewReader(testCase.body))
+			if err != nil {
+				t.Fatalf("the request failed: %v", err)
+			}
+
+			defer res.Body.Close()
+
+			if res.StatusCode != testCase.expCode {
+				t.Errorf("the code %v was expected, but %v was received", testCase.expCode, res.StatusCode)
+			}
+
+			var body map[string]interface{}
+			if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+				t.Fatalf("failed to decode the response: %v", err)
+			}
+
+			for key, exp := range testCase.expBody {
+				if body[key] != exp {
+					t.Errorf("%s: a value of \"%v\" was expected, but \"%v\" was received", key, exp, body[key])
+				}
+			}
+		})
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	// каждому методу сервиса соответствует маршрут, поэтому при добавлении
+	// метода в service.proto тест напоминает о таблице routes
+	rpcs := make(map[string]bool)
+	for _, rt := range routes {
+		if rpcs[rt.rpc] {
+			t.Errorf("the method %s has more than one route", rt.rpc)
+		}
+
+		rpcs[rt.rpc] = true
+
+		// переменные шаблона должны задавать поля запроса
+		fields := rt.descriptor().Input().Fields()
+		for _, seg := range strings.Split(rt.pattern, "/") {
+			if strings.HasPrefix(seg, "{") {
+				name := seg[1:strings.Index(seg, "}")]
+				if fields.ByName(protoreflect.Name(name)) == nil {
+					t.Errorf("the route %s %s binds the unknown field %s of %s", rt.method, rt.pattern, name, rt.rpc)
+				}
+			}
+		}
+
+		// методы с потоком запросов читают сообщения из тела запроса
+		if rt.descriptor().IsStreamingClient() && !rt.body {
+			t.Errorf("the route of the client streaming method %s must accept a body", rt.rpc)
+		}
+	}
+
+	for _, desc := range api.LinkService_ServiceDesc.Methods {
+		if !rpcs[desc.MethodName] {
+			t.Errorf("the method %s has no route", desc.MethodName)
+		}
+	}
+
+	for _, desc := range api.LinkService_ServiceDesc.Streams {
+		if !rpcs[desc.StreamName] {
+			t.Errorf("the method %s has no route", desc.StreamName)
+		}
+	}
+
+	// маршруты различают действия и переменные пути
+	testCases := []struct {
+		method string
+		path   string
+		expRPC string
+		expVar string
+	}{
+		{method: http.MethodGet, path: "/v1/links/abcdefghij", expRPC: "Get", expVar: "abcdefghij"},
+		{method: http.MethodPost, path: "/v1/links/abcdefghij:updateURL", expRPC: "UpdateURL", expVar: "abcdefghij"},
+		{method: http.MethodGet, path: "/v1/links:count", expRPC: "Count"},
+		{method: http.MethodGet, path: "/v1/links/abc:unknown"},
+		{method: http.MethodPost, path: "/v1/links/:updateURL"},
+	}
+
+	for _, testCase := range testCases {
+		rt, vars, _ := matchRoute(testCase.method, testCase.path)
+		if testCase.expRPC == "" {
+			if rt != nil {
+				t.Errorf("no route was expected for %s %s, but %s was matched", testCase.method, testCase.path, rt.rpc)
+			}
+
+			continue
+		}
+
+		if rt == nil || rt.rpc != testCase.expRPC || vars["link"] != testCase.expVar {
+			t.Errorf("the method %s with the link \"%s\" was expected for %s %s", testCase.expRPC, testCase.expVar, testCase.method, testCase.path)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Previewer описывает получение метаданных предпросмотра короткой ссылки без
+// учета перехода по ней. Ему удовлетворяет *linkservice.GRPCServer.
+type Previewer interface {
+	GetPreview(ctx context.Context, req *api.Link) (*api.Preview, error)
+}
+
+// crawlerAgents содержит фрагменты заголовка User-Agent роботов социальных
+// сетей и мессенджеров, запрашивающих страницы для предпросмотра ссылок.
+// Фрагменты сравниваются без учета регистра
+var crawlerAgents = []string{
+	"facebookexternalhit",
+	"facebot",
+	"twitterbot",
+	"slackbot",
+	"linkedinbot",
+	"discordbot",
+	"telegrambot",
+	"whatsapp",
+	"skypeuripreview",
+	"vkshare",
+	"pinterest",
+	"redditbot",
+	"embedly",
+	"mastodon",
+}
+
+// isCrawler сообщает, принадлежит ли значение userAgent заголовка User-Agent
+// роботу, запрашивающему предпросмотр ссылки.
+func isCrawler(userAgent string) bool {
+	userAgent = strings.ToLower(userAgent)
+	for _, agent := range crawlerAgents {
+		if strings.Contains(userAgent, agent) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// previewPage — страница с тегами Open Graph для роботов. Перенаправление
+// meta refresh и ссылка ведут на оригинальный URL, если страницу все же
+// откроет человек
+var previewPage = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<meta property="og:type" content="website">
+<meta property="og:url" content="{{.Url}}">
+<meta property="og:title" content="{{.Title}}">
+{{- with .Description}}
+<meta property="og:description" content="{{.}}">
+{{- end}}
+{{- with .ImageUrl}}
+<meta property="og:image" content="{{.}}">
+{{- end}}
+<meta http-equiv="refresh" content="0; url={{.Url}}">
+</head>
+<body>
+<a href="{{.Url}}">{{.Url}}</a>
+</body>
+</html>
+`))
+
+// servePreview отвечает роботу страницей с тегами Open Graph для короткой
+// ссылки link. Переход по ссылке при этом не учитывается.
+func (h *RedirectHandler) servePreview(w http.ResponseWriter, r *http.Request, link string) {
+	preview, err := h.Previews.GetPreview(r.Context(), &api.Link{Link: link})
+	if status.Code(err) == codes.Unavailable {
+		h.serveMaintenance(w)
+		return
+	}
+
+	if err != nil {
+		code := httpStatus(err)
+		http.Error(w, http.StatusText(code), code)
+		return
+	}
+
+	// без названия карточка предпросмотра показывает оригинальный URL
+	if preview.GetTitle() == "" {
+		preview.Title = preview.GetUrl()
+	}
+
+	var page bytes.Buffer
+	if err := previewPage.Execute(&page, preview); err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	w.Write(page.Bytes())
+}
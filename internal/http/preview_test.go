@@ -0,0 +1,174 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// stubPreviewer возвращает метаданные предпросмотра из previews и считает
+// вызовы
+type stubPreviewer struct {
+	previews map[string]*api.Preview
+	calls    int
+}
+
+func (p *stubPreviewer) GetPreview(ctx context.Context, req *api.Link) (*api.Preview, error) {
+	p.calls++
+
+	preview, ok := p.previews[req.GetLink()]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "not found")
+	}
+
+	return preview, nil
+}
+
+// countingResolver считает вызовы метода Get, то есть учтенные переходы
+type countingResolver struct {
+	stubResolver
+	calls int
+}
+
+func (r *countingResolver) Get(ctx context.Context, req *api.Link) (*api.URL, error) {
+	r.calls++
+	return r.stubResolver.Get(ctx, req)
+}
+
+func TestRedirectHandlerPreview(t *testing.T) {
+	previews := map[string]*api.Preview{
+		"abcdefghij": {
+			Link:        "abcdefghij",
+			Url:         "https://example.com/page?q=1",
+			Title:       `Launch "day" & <party>`,
+			Description: "Everything about the launch",
+			ImageUrl:    "https://cdn.example.com/card.png",
+		},
+		"untitled": {Link: "untitled", Url: "https://example.com/untitled"},
+	}
+
+	links := map[string]string{"abcdefghij": "https://example.com/page?q=1", "untitled": "https://example.com/untitled"}
+
+	testCases := []struct {
+		name        string
+		path        string
+		userAgent   string
+		noPreviews  bool
+		expCode     int
+		expLocation string
+		expTags     []string
+		expPreviews int
+		expVisits   int
+	}{
+		{
+			name:      "crawler",
+			path:      "/abcdefghij",
+			userAgent: "Twitterbot/1.0",
+			expCode:   http.StatusOK,
+			expTags: []string{
+				`<meta property="og:title" content="Launch &#34;day&#34; &amp; &lt;party&gt;">`,
+				`<meta property="og:description" content="Everything about the launch">`,
+				`<meta property="og:image" content="https://cdn.example.com/card.png">`,
+				`<meta property="og:url" content="https://example.com/page?q=1">`,
+				`<meta http-equiv="refresh" content="0; url=https://example.com/page?q=1">`,
+			},
+			expPreviews: 1,
+		},
+		{
+			name:        "crawler_case_insensitive",
+			path:        "/abcdefghij",
+			userAgent:   "facebookExternalHit/1.1 (+http://www.facebook.com/externalhit_uatext.php)",
+			expCode:     http.StatusOK,
+			expTags:     []string{`<meta property="og:title" content="Launch &#34;day&#34; &amp; &lt;party&gt;">`},
+			expPreviews: 1,
+		},
+		{
+			name:        "crawler_without_title",
+			path:        "/untitled",
+			userAgent:   "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)",
+			expCode:     http.StatusOK,
+			expTags:     []string{`<meta property="og:title" content="https://example.com/untitled">`},
+			expPreviews: 1,
+		},
+		{
+			name:        "crawler_unknown_link",
+			path:        "/unknown",
+			userAgent:   "Discordbot/2.0",
+			expCode:     http.StatusNotFound,
+			expPreviews: 1,
+		},
+		{
+			name:        "browser",
+			path:        "/abcdefghij",
+			userAgent:   "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36",
+			expCode:     http.StatusFound,
+			expLocation: "https://example.com/page?q=1",
+			expVisits:   1,
+		},
+		{
+			name:        "no_user_agent",
+			path:        "/abcdefghij",
+			expCode:     http.StatusFound,
+			expLocation: "https://example.com/page?q=1",
+			expVisits:   1,
+		},
+		{
+			name:        "previews_disabled",
+			path:        "/abcdefghij",
+			userAgent:   "Twitterbot/1.0",
+			noPreviews:  true,
+			expCode:     http.StatusFound,
+			expLocation: "https://example.com/page?q=1",
+			expVisits:   1,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			resolver := &countingResolver{stubResolver: stubResolver{links: links}}
+			previewer := &stubPreviewer{previews: previews}
+
+			h := NewRedirectHandler(resolver)
+			if !testCase.noPreviews {
+				h.Previews = previewer
+			}
+
+			req := httptest.NewRequest(http.MethodGet, testCase.path, nil)
+			req.Header.Set("User-Agent", testCase.userAgent)
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != testCase.expCode {
+				t.Errorf("the code %v was expected, but %v was received", testCase.expCode, rec.Code)
+			}
+
+			if location := rec.Header().Get("Location"); location != testCase.expLocation {
+				t.Errorf("the location \"%s\" was expected, but \"%s\" was received", testCase.expLocation, location)
+			}
+
+			body := rec.Body.String()
+			for _, tag := range testCase.expTags {
+				if !strings.Contains(body, tag) {
+					t.Errorf("the tag %s was expected in the page:\n%s", tag, body)
+				}
+			}
+
+			if len(testCase.expTags) > 0 && !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html") {
+				t.Errorf("an HTML page was expected, but the content type \"%s\" was received", rec.Header().Get("Content-Type"))
+			}
+
+			// предпросмотр роботом не должен учитываться как переход
+			if previewer.calls != testCase.expPreviews || resolver.calls != testCase.expVisits {
+				t.Errorf("%d preview lookups and %d visits were expected, but %d and %d were made",
+					testCase.expPreviews, testCase.expVisits, previewer.calls, resolver.calls)
+			}
+		})
+	}
+}
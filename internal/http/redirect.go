@@ -0,0 +1,137 @@
+// Package http реализует HTTP-интерфейс сервиса, позволяющий переходить по
+// коротким ссылкам из браузера.
+package http
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Resolver описывает получение оригинального URL по короткой ссылке. Ему
+// удовлетворяет *linkservice.GRPCServer, поэтому переходы по HTTP
+// обрабатываются так же, как вызовы метода Get, в том числе учитываются в
+// статистике.
+type Resolver interface {
+	Get(ctx context.Context, req *api.Link) (*api.URL, error)
+}
+
+// RedirectHandler перенаправляет запросы вида GET /{link} на оригинальный URL
+// короткой ссылки link с кодом состояния 302 Found. Для неизвестных и
+// некорректных ссылок возвращается 404 Not Found, а пока сервис находится в
+// режиме обслуживания — 503 Service Unavailable. На запросы других методов, в
+// том числе HEAD, возвращается 405 Method Not Allowed. Ответ содержит
+// заголовок ETag, зависящий от версии ссылки, и на запрос с тем же значением в
+// If-None-Match возвращается 304 Not Modified.
+type RedirectHandler struct {
+	Resolver Resolver
+
+	// MaintenancePage задает HTML-страницу, которая возвращается с кодом 503
+	// Service Unavailable в режиме обслуживания. Если не задана, то
+	// возвращается краткое текстовое сообщение
+	MaintenancePage []byte
+
+	// RetryAfter задает значение заголовка Retry-After ответов в режиме
+	// обслуживания. Если не задано, то заголовок не передается
+	RetryAfter time.Duration
+
+	// Previews, если задан, возвращает метаданные предпросмотра ссылок.
+	// Роботам социальных сетей и мессенджеров, которых обработчик определяет
+	// по заголовку User-Agent, вместо перенаправления отдается страница с
+	// тегами Open Graph, а переход по ссылке не учитывается
+	Previews Previewer
+}
+
+// NewRedirectHandler создает обработчик переходов по коротким ссылкам,
+// получающий оригинальные URL от resolver.
+func NewRedirectHandler(resolver Resolver) *RedirectHandler {
+	return &RedirectHandler{Resolver: resolver}
+}
+
+func (h *RedirectHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// запрос HEAD не поддерживается: ответ на него пришлось бы получить
+	// методом Get, который учитывает переход по ссылке, хотя проверки ссылок
+	// и предварительные запросы браузеров переходами не являются
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	// короткая ссылка занимает весь путь запроса, вложенные пути не
+	// поддерживаются
+	link := strings.TrimPrefix(r.URL.Path, "/")
+	if link == "" || strings.Contains(link, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	if h.Previews != nil && isCrawler(r.UserAgent()) {
+		h.servePreview(w, r, link)
+		return
+	}
+
+	res, err := h.Resolver.Get(r.Context(), &api.Link{Link: link})
+	if status.Code(err) == codes.Unavailable {
+		h.serveMaintenance(w)
+		return
+	}
+
+	if err != nil {
+		code := httpStatus(err)
+		http.Error(w, http.StatusText(code), code)
+		return
+	}
+
+	if setETag(w, r, res) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Location", res.GetUrl())
+	w.WriteHeader(http.StatusFound)
+}
+
+// serveMaintenance отвечает на запрос, который не может быть обработан в
+// режиме обслуживания.
+func (h *RedirectHandler) serveMaintenance(w http.ResponseWriter) {
+	if h.RetryAfter > 0 {
+		// заголовок задается в целых секундах, поэтому время округляется
+		// вверх
+		seconds := (h.RetryAfter + time.Second - 1) / time.Second
+		w.Header().Set("Retry-After", strconv.FormatInt(int64(seconds), 10))
+	}
+
+	if len(h.MaintenancePage) == 0 {
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusServiceUnavailable)
+	w.Write(h.MaintenancePage)
+}
+
+// httpStatus возвращает код состояния HTTP, соответствующий ошибке gRPC err.
+// Подробности внутренних ошибок клиенту не сообщаются.
+func httpStatus(err error) int {
+	switch status.Code(err) {
+	case codes.NotFound, codes.InvalidArgument:
+		return http.StatusNotFound
+	case codes.FailedPrecondition:
+		return http.StatusGone
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
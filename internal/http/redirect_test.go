@@ -0,0 +1,138 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// stubResolver возвращает URL из links или ошибку err
+type stubResolver struct {
+	links map[string]string
+	err   error
+}
+
+func (r stubResolver) Get(ctx context.Context, req *api.Link) (*api.URL, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	url, ok := r.links[req.GetLink()]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "not found")
+	}
+
+	return &api.URL{Url: url}, nil
+}
+
+func TestRedirectHandler(t *testing.T) {
+	resolver := stubResolver{links: map[string]string{"abcdefghij": "https://example.com/page?q=1"}}
+
+	testCases := []struct {
+		name        string
+		method      string
+		path        string
+		resolver    Resolver
+		expCode     int
+		expLocation string
+	}{
+		{name: "found", method: http.MethodGet, path: "/abcdefghij", resolver: resolver, expCode: http.StatusFound, expLocation: "https://example.com/page?q=1"},
+		{name: "head", method: http.MethodHead, path: "/abcdefghij", resolver: resolver, expCode: http.StatusMethodNotAllowed},
+		{name: "unknown", method: http.MethodGet, path: "/unknown", resolver: resolver, expCode: http.StatusNotFound},
+		{name: "root", method: http.MethodGet, path: "/", resolver: resolver, expCode: http.StatusNotFound},
+		{name: "nested", method: http.MethodGet, path: "/abcdefghij/more", resolver: resolver, expCode: http.StatusNotFound},
+		{name: "invalid", method: http.MethodGet, path: "/abc", resolver: stubResolver{err: status.Error(codes.InvalidArgument, "invalid")}, expCode: http.StatusNotFound},
+		{name: "expired", method: http.MethodGet, path: "/abcdefghij", resolver: stubResolver{err: status.Error(codes.FailedPrecondition, "expired")}, expCode: http.StatusGone},
+		{name: "deadline", method: http.MethodGet, path: "/abcdefghij", resolver: stubResolver{err: status.Error(codes.DeadlineExceeded, "deadline")}, expCode: http.StatusGatewayTimeout},
+		{name: "internal", method: http.MethodGet, path: "/abcdefghij", resolver: stubResolver{err: status.Error(codes.Internal, "internal")}, expCode: http.StatusInternalServerError},
+		{name: "post", method: http.MethodPost, path: "/abcdefghij", resolver: resolver, expCode: http.StatusMethodNotAllowed},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			NewRedirectHandler(testCase.resolver).ServeHTTP(rec, httptest.NewRequest(testCase.method, testCase.path, nil))
+
+			if rec.Code != testCase.expCode {
+				t.Errorf("the code %v was expected, but %v was received", testCase.expCode, rec.Code)
+			}
+
+			if location := rec.Header().Get("Location"); location != testCase.expLocation {
+				t.Errorf("the location \"%s\" was expected, but \"%s\" was received", testCase.expLocation, location)
+			}
+		})
+	}
+}
+
+func TestRedirectHandlerHead(t *testing.T) {
+	resolver := &countingResolver{stubResolver: stubResolver{links: map[string]string{"abcdefghij": "https://example.com/"}}}
+
+	rec := httptest.NewRecorder()
+	NewRedirectHandler(resolver).ServeHTTP(rec, httptest.NewRequest(http.MethodHead, "/abcdefghij", nil))
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("the code %v was expected, but %v was received", http.StatusMethodNotAllowed, rec.Code)
+	}
+
+	if allow := rec.Header().Get("Allow"); allow != "GET" {
+		t.Errorf("the Allow header \"GET\" was expected, but \"%s\" was received", allow)
+	}
+
+	// запрос HEAD не должен учитываться как переход по ссылке
+	if resolver.calls != 0 {
+		t.Errorf("no visits were expected to be recorded, but %d were recorded", resolver.calls)
+	}
+}
+
+func TestRedirectHandlerMaintenance(t *testing.T) {
+	resolver := stubResolver{err: status.Error(codes.Unavailable, "maintenance")}
+
+	t.Run("page", func(t *testing.T) {
+		h := NewRedirectHandler(resolver)
+		h.MaintenancePage = []byte("<h1>Maintenance</h1>")
+		h.RetryAfter = 90500 * time.Millisecond
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/abcdefghij", nil))
+
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("the code %v was expected, but %v was received", http.StatusServiceUnavailable, rec.Code)
+		}
+
+		if retryAfter := rec.Header().Get("Retry-After"); retryAfter != "91" {
+			t.Errorf("Retry-After \"91\" was expected, but \"%s\" was received", retryAfter)
+		}
+
+		if contentType := rec.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/html") {
+			t.Errorf("an HTML page was expected, but the content type \"%s\" was received", contentType)
+		}
+
+		if body := rec.Body.String(); body != "<h1>Maintenance</h1>" {
+			t.Errorf("the maintenance page was expected, but \"%s\" was received", body)
+		}
+
+		if location := rec.Header().Get("Location"); location != "" {
+			t.Errorf("no location was expected, but \"%s\" was received", location)
+		}
+	})
+
+	t.Run("default", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		NewRedirectHandler(resolver).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/abcdefghij", nil))
+
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("the code %v was expected, but %v was received", http.StatusServiceUnavailable, rec.Code)
+		}
+
+		if retryAfter, ok := rec.Header()["Retry-After"]; ok {
+			t.Errorf("no Retry-After was expected, but %v was received", retryAfter)
+		}
+	})
+}
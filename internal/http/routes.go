@@ -0,0 +1,147 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// route сопоставляет запросы HTTP с методом method и путем, соответствующим
+// шаблону pattern, методу rpc сервиса LinkService так же, как аннотация
+// google.api.http. Сегмент шаблона вида {name} совпадает с любым непустым
+// сегментом пути и задает поле name запроса; за переменной, как и за
+// последним сегментом, может следовать действие вида ":verb".
+type route struct {
+	method  string
+	pattern string
+	rpc     string
+
+	// body сообщает, передается ли сообщение запроса в теле запроса HTTP.
+	// Иначе поля запроса задаются параметрами строки запроса
+	body bool
+}
+
+// routes содержит маршруты JSON/REST-интерфейса для всех методов сервиса
+var routes = []route{
+	{method: http.MethodPost, pattern: "/v1/links", rpc: "Create", body: true},
+	{method: http.MethodGet, pattern: "/v1/links/{link}", rpc: "Get"},
+	{method: http.MethodPost, pattern: "/v1/links:getOrCreate", rpc: "GetOrCreate", body: true},
+	{method: http.MethodPost, pattern: "/v1/links:batchCreate", rpc: "BatchCreate", body: true},
+	{method: http.MethodPost, pattern: "/v1/links:batchGet", rpc: "GetBatch", body: true},
+	{method: http.MethodGet, pattern: "/v1/links/{link}/stats", rpc: "Stats"},
+	{method: http.MethodGet, pattern: "/v1/links/{link}/metadata", rpc: "GetMetadata"},
+	{method: http.MethodGet, pattern: "/v1/links/{link}/info", rpc: "GetInfo"},
+	{method: http.MethodPost, pattern: "/v1/links/{link}:updateURL", rpc: "UpdateURL", body: true},
+	{method: http.MethodPost, pattern: "/v1/links/{link}:updateExpiry", rpc: "UpdateExpiry", body: true},
+	{method: http.MethodGet, pattern: "/v1/links/{link}/preview", rpc: "GetPreview"},
+	{method: http.MethodPost, pattern: "/v1/links/{link}:setPreview", rpc: "SetPreview", body: true},
+	{method: http.MethodPost, pattern: "/v1/links:hitsOverTime", rpc: "HitsOverTime", body: true},
+	{method: http.MethodGet, pattern: "/v1/links:count", rpc: "Count"},
+	{method: http.MethodPost, pattern: "/v1/links:import", rpc: "Import", body: true},
+	{method: http.MethodGet, pattern: "/v1/links:export", rpc: "Export"},
+	{method: http.MethodPost, pattern: "/v1/links:deleteOlderThan", rpc: "DeleteOlderThan", body: true},
+	{method: http.MethodPost, pattern: "/v1/links:validate", rpc: "ValidateLinks", body: true},
+	{method: http.MethodGet, pattern: "/v1/aliases/{link}", rpc: "CheckAlias"},
+	{method: http.MethodPost, pattern: "/v1/collections", rpc: "CreateCollection", body: true},
+	{method: http.MethodGet, pattern: "/v1/collections", rpc: "ListCollections"},
+	{method: http.MethodDelete, pattern: "/v1/collections/{id}", rpc: "DeleteCollection"},
+	{method: http.MethodGet, pattern: "/v1/collections/{id}/links", rpc: "ListByCollection"},
+	{method: http.MethodGet, pattern: "/v1/owners/{owner_id}/links", rpc: "ListByOwner"},
+	{method: http.MethodDelete, pattern: "/v1/owners/{owner_id}/links", rpc: "DeleteByOwner"},
+	{method: http.MethodGet, pattern: "/v1/tags/{tag}/links", rpc: "ListByTag"},
+	{method: http.MethodGet, pattern: "/v1/version", rpc: "Version"},
+	{method: http.MethodPost, pattern: "/v1/cache:invalidate", rpc: "InvalidateCache", body: true},
+	{method: http.MethodPost, pattern: "/v1/maintenance", rpc: "SetMaintenance", body: true},
+}
+
+// matchRoute возвращает маршрут для запроса с методом method к пути path и
+// значения переменных его шаблона. Если путь соответствует маршрутам лишь с
+// другими методами, то вместо маршрута возвращаются эти методы.
+func matchRoute(method, path string) (*route, map[string]string, []string) {
+	var allow []string
+	for i := range routes {
+		vars, ok := routes[i].match(path)
+		if !ok {
+			continue
+		}
+
+		if routes[i].method == method {
+			return &routes[i], vars, nil
+		}
+
+		allow = append(allow, routes[i].method)
+	}
+
+	return nil, nil, allow
+}
+
+// match сообщает, соответствует ли путь path шаблону маршрута, и возвращает
+// значения переменных шаблона.
+func (rt *route) match(path string) (map[string]string, bool) {
+	patternSegs := strings.Split(strings.TrimPrefix(rt.pattern, "/"), "/")
+	pathSegs := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if len(patternSegs) != len(pathSegs) {
+		return nil, false
+	}
+
+	vars := make(map[string]string)
+	for i, seg := range patternSegs {
+		if !strings.HasPrefix(seg, "{") {
+			if seg != pathSegs[i] {
+				return nil, false
+			}
+
+			continue
+		}
+
+		end := strings.Index(seg, "}")
+		name, verb := seg[1:end], seg[end+1:]
+
+		value := pathSegs[i]
+		if verb != "" {
+			if !strings.HasSuffix(value, verb) {
+				return nil, false
+			}
+
+			value = strings.TrimSuffix(value, verb)
+		}
+
+		// действие отделяется двоеточием, поэтому переменная без действия не
+		// совпадает с сегментом, содержащим его
+		if value == "" || strings.Contains(value, ":") {
+			return nil, false
+		}
+
+		vars[name] = value
+	}
+
+	return vars, true
+}
+
+// descriptor возвращает описание метода сервиса, которому соответствует
+// маршрут.
+func (rt *route) descriptor() protoreflect.MethodDescriptor {
+	d, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(api.LinkService_ServiceDesc.ServiceName + "." + rt.rpc))
+	if err != nil {
+		// маршруты проверяются тестом, поэтому метод всегда существует
+		panic(err)
+	}
+
+	return d.(protoreflect.MethodDescriptor)
+}
+
+// streamDesc возвращает описание потокового метода, которому соответствует
+// маршрут, для создания потока gRPC.
+func (rt *route) streamDesc() *grpc.StreamDesc {
+	for i, desc := range api.LinkService_ServiceDesc.Streams {
+		if desc.StreamName == rt.rpc {
+			return &api.LinkService_ServiceDesc.Streams[i]
+		}
+	}
+
+	return nil
+}
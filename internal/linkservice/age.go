@@ -0,0 +1,82 @@
+package linkservice
+
+import (
+	"context"
+	"time"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+)
+
+// DeleteOlderThan удаляет все ссылки, созданные раньше указанного в запросе
+// момента времени, в том числе с истекшим сроком действия, и возвращает
+// количество удаленных ссылок. Как и в методе DeleteByOwner, ссылки удаляются
+// из всех пространств имен частями, поэтому при ошибке часть ссылок может
+// оказаться уже удаленной. Метод необратимо удаляет данные, поэтому доступен,
+// только если включен полем BulkDelete.
+//
+// Ошибки передаются клиенту с кодами состояния gRPC: ErrMethodDisabled —
+// codes.PermissionDenied, ErrInvalidTime — codes.InvalidArgument,
+// ErrDeadlineExceeded — codes.DeadlineExceeded, ErrReqProc — codes.Internal.
+func (s *GRPCServer) DeleteOlderThan(ctx context.Context, req *api.TimeRequest) (*api.CountResponse, error) {
+	count, err := s.deleteOlderThan(ctx, req)
+	return count, statusError(err)
+}
+
+// deleteOlderThan реализует метод DeleteOlderThan, возвращая ошибки сервиса
+// без преобразования в ошибки gRPC.
+func (s *GRPCServer) deleteOlderThan(ctx context.Context, req *api.TimeRequest) (*api.CountResponse, error) {
+	if !s.BulkDelete {
+		return nil, ErrMethodDisabled
+	}
+
+	// без момента времени запрос удалил бы все ссылки
+	if req.GetTime() == nil || req.GetTime().CheckValid() != nil {
+		return nil, ErrInvalidTime
+	}
+
+	before := req.GetTime().AsTime()
+
+	res := &api.CountResponse{}
+	for {
+		n, err := s.deleteOlderChunk(ctx, before)
+		if err != nil {
+			return nil, s.requestError(ctx, "DeleteOlderThan", err, "before", before, "deleted", res.Count)
+		}
+
+		res.Count += int64(n)
+		if n < deleteChunkSize {
+			return res, nil
+		}
+	}
+}
+
+// deleteOlderChunk удаляет не более deleteChunkSize ссылок, созданных раньше
+// момента before, исключает их из кэша метода Get и возвращает количество
+// удаленных ссылок.
+func (s *GRPCServer) deleteOlderChunk(ctx context.Context, before time.Time) (int, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	start := time.Now()
+	rows, err := s.Database.QueryContext(ctx, "DELETE FROM links WHERE (namespace, link) IN "+
+		"(SELECT namespace, link FROM links WHERE created_at < $1 LIMIT $2) RETURNING namespace, link;", before, deleteChunkSize)
+	s.observeQuery("delete_older", start, "method", "DeleteOlderThan")
+	if err != nil {
+		return 0, err
+	}
+
+	defer rows.Close()
+
+	var n int
+	for rows.Next() {
+		var namespace, link string
+		if err := rows.Scan(&namespace, &link); err != nil {
+			return n, err
+		}
+
+		s.linkCache().remove(namespacedLink(namespace, link))
+		n++
+	}
+
+	return n, rows.Err()
+}
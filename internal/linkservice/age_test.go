@@ -0,0 +1,98 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestDeleteOlderThanDisabled(t *testing.T) {
+	// по умолчанию метод отклоняет запросы, не обращаясь к базе данных
+	service := &GRPCServer{}
+
+	_, err := service.DeleteOlderThan(context.Background(), &api.TimeRequest{Time: timestamppb.Now()})
+	if err = FromStatus(err); err != ErrMethodDisabled {
+		t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrMethodDisabled, err)
+	}
+}
+
+func TestDeleteOlderThanValidation(t *testing.T) {
+	// момент времени проверяется до обращения к базе данных
+	service := &GRPCServer{BulkDelete: true}
+
+	for _, req := range []*api.TimeRequest{{}, {Time: &timestamppb.Timestamp{Nanos: -1}}} {
+		_, err := service.DeleteOlderThan(context.Background(), req)
+		if err = FromStatus(err); err != ErrInvalidTime {
+			t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrInvalidTime, err)
+		}
+	}
+}
+
+func TestDeleteOlderThan(t *testing.T) {
+	// устанавливаем подключение к базе данных
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed connecting to the database: %v", err)
+	}
+
+	defer db.Close()
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	defer service.Close()
+
+	service.BulkDelete = true
+
+	// переносим время создания части ссылок в далекое прошлое, чтобы удаление
+	// не затронуло ссылки, созданные другими тестами
+	old := time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var oldLinks, newLinks []*api.Link
+	for i := 0; i < 4; i++ {
+		link, err := service.Create(context.Background(), &api.URL{Url: "http://age.abc/" + generateRandomСharacters(8)})
+		if err != nil {
+			t.Fatalf("Create method reported an error: %v", err)
+		}
+
+		if i%2 == 1 {
+			newLinks = append(newLinks, link)
+			continue
+		}
+
+		if _, err := db.Exec("UPDATE links SET created_at = $1 WHERE link = $2;", old, link.GetLink()); err != nil {
+			t.Fatalf("failed to update the database: %v", err)
+		}
+
+		oldLinks = append(oldLinks, link)
+	}
+
+	count, err := service.DeleteOlderThan(context.Background(), &api.TimeRequest{Time: timestamppb.New(old.Add(time.Hour))})
+	if err != nil {
+		t.Fatalf("DeleteOlderThan method reported an error: %v", err)
+	}
+
+	if count.GetCount() != int64(len(oldLinks)) {
+		t.Errorf("%d deleted links were expected, but %d were reported", len(oldLinks), count.GetCount())
+	}
+
+	for _, link := range oldLinks {
+		_, err := service.Get(context.Background(), link)
+		if err = FromStatus(err); err != ErrURLNotFound {
+			t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrURLNotFound, err)
+		}
+	}
+
+	// ссылки, созданные позже указанного момента, сохраняются
+	for _, link := range newLinks {
+		if _, err := service.Get(context.Background(), link); err != nil {
+			t.Errorf("Get method reported an error: %v", err)
+		}
+	}
+}
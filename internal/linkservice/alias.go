@@ -0,0 +1,102 @@
+package linkservice
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+)
+
+// причины, по которым псевдоним недоступен, в ответе метода CheckAlias
+const (
+	aliasReasonTaken    = "taken"
+	aliasReasonReserved = "reserved"
+)
+
+// foldAlias приводит пользовательский псевдоним alias к нижнему регистру, в
+// котором он хранится в базе данных, так что псевдонимы "MyLink" и "mylink"
+// считаются одним и тем же псевдонимом. Случайно сгенерированные короткие
+// ссылки чувствительны к регистру и не приводятся.
+func foldAlias(alias string) string {
+	return strings.ToLower(alias)
+}
+
+// validAlias сообщает, может ли строка alias быть пользовательским
+// псевдонимом. Если задан LinkPattern, то псевдоним в нижнем регистре должен
+// также соответствовать ему, иначе ссылку с этим псевдонимом нельзя было бы
+// разрешить.
+func (s *GRPCServer) validAlias(alias string) bool {
+	if !aliasTemplate.MatchString(alias) {
+		return false
+	}
+
+	return s.LinkPattern == nil || s.LinkPattern.MatchString(foldAlias(alias))
+}
+
+// resolve возвращает запись для короткой ссылки link из пространства имен
+// namespace. Если ссылка не найдена, но может быть псевдонимом в другом
+// регистре, то ищется псевдоним в нижнем регистре. Точное совпадение
+// проверяется первым, поэтому случайные ссылки и псевдонимы, созданные до
+// приведения псевдонимов к нижнему регистру, остаются доступными.
+func (s *GRPCServer) resolve(ctx context.Context, namespace, link string) (cacheEntry, error) {
+	entry, err := s.cachedLookup(ctx, namespace, link)
+	if err != ErrURLNotFound || !aliasTemplate.MatchString(link) {
+		return entry, err
+	}
+
+	if folded := foldAlias(link); folded != link {
+		return s.cachedLookup(ctx, namespace, folded)
+	}
+
+	return entry, err
+}
+
+// CheckAlias сообщает, можно ли создать короткую ссылку с указанным в запросе
+// пользовательским псевдонимом, ничего не создавая. Недоступный псевдоним
+// сопровождается причиной: "reserved" для зарезервированных слов и "taken"
+// для псевдонимов, уже используемых другой ссылкой, в том числе с истекшим,
+// но еще не удаленным сроком действия. Псевдонимы сравниваются без учета
+// регистра. Псевдоним проверяется в указанном в запросе пространстве имен.
+// Ответ отражает состояние на момент вызова, поэтому метод Create может
+// вернуть ErrAliasTaken, если псевдоним успели занять.
+//
+// Ошибки передаются клиенту с кодами состояния gRPC: ErrInvalidAlias и
+// ErrInvalidNamespace — codes.InvalidArgument, ErrDeadlineExceeded —
+// codes.DeadlineExceeded, ErrReqProc — codes.Internal.
+func (s *GRPCServer) CheckAlias(ctx context.Context, req *api.Link) (*api.Availability, error) {
+	res, err := s.checkAlias(ctx, req.GetNamespace(), req.GetLink())
+	return res, statusError(err)
+}
+
+// checkAlias реализует метод CheckAlias для псевдонима alias из пространства
+// имен namespace, возвращая ошибки сервиса без преобразования в ошибки gRPC.
+func (s *GRPCServer) checkAlias(ctx context.Context, namespace, alias string) (*api.Availability, error) {
+	if !s.validAlias(alias) {
+		return nil, ErrInvalidAlias
+	}
+
+	if err := checkNamespace(namespace); err != nil {
+		return nil, err
+	}
+
+	if s.isReserved(alias) {
+		return &api.Availability{Reason: aliasReasonReserved}, nil
+	}
+
+	start := time.Now()
+	var taken bool
+	err := s.retry(ctx, "check_alias", func(ctx context.Context) error {
+		return s.Database.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM links WHERE link = $1 AND namespace = $2);", foldAlias(alias), namespace).Scan(&taken)
+	})
+	s.observeQuery("check_alias", start, "method", "CheckAlias", "alias", alias)
+	if err != nil {
+		return nil, s.requestError(ctx, "CheckAlias", err, "alias", alias)
+	}
+
+	if taken {
+		return &api.Availability{Reason: aliasReasonTaken}, nil
+	}
+
+	return &api.Availability{Available: true}, nil
+}
@@ -0,0 +1,204 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+)
+
+func TestCheckAliasWithoutDatabase(t *testing.T) {
+	// некорректные и зарезервированные псевдонимы проверяются до обращения к
+	// базе данных, поэтому сервер не требует подключения
+	service := &GRPCServer{}
+
+	for _, alias := range []string{"", "ab", "with space", "кириллица"} {
+		if _, err := service.checkAlias(context.Background(), "", alias); err != ErrInvalidAlias {
+			t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrInvalidAlias, err)
+		}
+	}
+
+	res, err := service.CheckAlias(context.Background(), &api.Link{Link: "Health"})
+	if err != nil {
+		t.Fatalf("CheckAlias method reported an error: %v", err)
+	}
+
+	if res.GetAvailable() || res.GetReason() != aliasReasonReserved {
+		t.Errorf("the reserved alias was expected to be unavailable with the reason \"%s\", but the reason \"%s\" was received", aliasReasonReserved, res.GetReason())
+	}
+
+	// псевдонимы, не соответствующие LinkPattern, отклоняются
+	service.LinkPattern = regexp.MustCompile(`^go-[a-z]+$`)
+	if _, err := service.checkAlias(context.Background(), "", "my-link"); err != ErrInvalidAlias {
+		t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrInvalidAlias, err)
+	}
+}
+
+func TestCheckAlias(t *testing.T) {
+	// устанавливаем подключение к базе данных
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed connecting to the database: %v", err)
+	}
+
+	defer db.Close()
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	defer service.Close()
+
+	alias := "check-" + generateRandomСharacters(8)
+
+	res, err := service.CheckAlias(context.Background(), &api.Link{Link: alias})
+	if err != nil {
+		t.Fatalf("CheckAlias method reported an error: %v", err)
+	}
+
+	if !res.GetAvailable() || res.GetReason() != "" {
+		t.Errorf("the alias \"%s\" was expected to be available, but the reason \"%s\" was received", alias, res.GetReason())
+	}
+
+	if _, err := service.Create(context.Background(), &api.URL{Url: "http://alias.abc/" + generateRandomСharacters(6), Alias: alias}); err != nil {
+		t.Fatalf("Create method reported an error: %v", err)
+	}
+
+	res, err = service.CheckAlias(context.Background(), &api.Link{Link: alias})
+	if err != nil {
+		t.Fatalf("CheckAlias method reported an error: %v", err)
+	}
+
+	if res.GetAvailable() || res.GetReason() != aliasReasonTaken {
+		t.Errorf("the alias \"%s\" was expected to be unavailable with the reason \"%s\", but the reason \"%s\" was received", alias, aliasReasonTaken, res.GetReason())
+	}
+
+	// псевдоним в другом регистре также занят
+	res, err = service.CheckAlias(context.Background(), &api.Link{Link: strings.ToUpper(alias)})
+	if err != nil {
+		t.Fatalf("CheckAlias method reported an error: %v", err)
+	}
+
+	if res.GetAvailable() {
+		t.Errorf("the alias \"%s\" was expected to be unavailable", strings.ToUpper(alias))
+	}
+}
+
+func TestCreateWithAliasWithMockDB(t *testing.T) {
+	testCases := []struct {
+		name    string
+		stored  string
+		inserts int
+		err     error
+	}{
+		{name: "free_alias", inserts: 1},
+		{name: "taken_alias", stored: "http://other.abc/", err: ErrAliasTaken},
+		// повторный запрос для того же URL возвращает существующую ссылку
+		{name: "same_url", stored: "http://alias.abc/promo"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			inserts := 0
+			db := newMockDB(func(query string, args []driver.NamedValue) (mockResult, error) {
+				switch {
+				case query == lockAliasQuery:
+					return mockResult{}, nil
+				case strings.HasPrefix(query, "SELECT original_url FROM links"):
+					if testCase.stored == "" {
+						return mockResult{columns: []string{"original_url"}}, nil
+					}
+
+					return mockResult{columns: []string{"original_url"}, rows: [][]driver.Value{{testCase.stored}}}, nil
+				case strings.HasPrefix(query, "INSERT INTO links"):
+					inserts++
+					return mockResult{affected: 1}, nil
+				case strings.HasPrefix(query, "DELETE FROM links WHERE original_url"):
+					return mockResult{}, nil
+				default:
+					t.Fatalf("an unexpected query was received: %s", query)
+					return mockResult{}, nil
+				}
+			})
+
+			service, err := NewGRPCServer(db)
+			if err != nil {
+				t.Fatalf("failed to prepare the server: %v", err)
+			}
+
+			link, err := service.Create(context.Background(), &api.URL{Url: "http://alias.abc/promo", Alias: "Promo"})
+			if err = FromStatus(err); err != testCase.err {
+				t.Fatalf("an error with a value of \"%v\" was expected, but \"%v\" was received", testCase.err, err)
+			}
+
+			if err == nil && link.GetLink() != "promo" {
+				t.Errorf("the link \"promo\" was expected, but \"%s\" was received", link.GetLink())
+			}
+
+			// занятый псевдоним не приводит к неудачной вставке
+			if inserts != testCase.inserts {
+				t.Errorf("%d inserts were expected, but %d were executed", testCase.inserts, inserts)
+			}
+		})
+	}
+}
+
+func TestCreateWithAliasConcurrently(t *testing.T) {
+	// устанавливаем подключение к базе данных
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed connecting to the database: %v", err)
+	}
+
+	defer db.Close()
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	defer service.Close()
+
+	const requests = 20
+
+	alias := "race-" + generateRandomСharacters(6)
+	url := "http://race.abc/" + generateRandomСharacters(6)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded := 0
+
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			_, err := service.Create(context.Background(), &api.URL{Url: fmt.Sprintf("%s/%d", url, i), Alias: alias})
+			if err != nil {
+				if err = FromStatus(err); err != ErrAliasTaken {
+					t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrAliasTaken, err)
+				}
+
+				return
+			}
+
+			mu.Lock()
+			succeeded++
+			mu.Unlock()
+		}(i)
+	}
+
+	wg.Wait()
+
+	if succeeded != 1 {
+		t.Errorf("1 successful request was expected, but %d were received", succeeded)
+	}
+}
@@ -0,0 +1,158 @@
+package linkservice
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// defaultAlphabet представляет собой алфавит символов, используемый для
+// генерации коротких ссылок по умолчанию
+const defaultAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ_abcdefghijklmnopqrstuvwxyz"
+
+// DefaultAlphabets содержит именованные алфавиты, доступные для выбора в
+// запросах Create, если для GRPCServer не задан собственный набор. Алфавит с
+// пустым именем используется, когда в запросе алфавит не указан.
+//
+// Алфавит "friendly" не содержит символов, которые легко спутать при чтении
+// (0/O, 1/l/I), и подходит для печатных материалов, а "dense" использует
+// полный набор символов и подходит для API.
+var DefaultAlphabets = map[string]string{
+	"":         defaultAlphabet,
+	"dense":    defaultAlphabet,
+	"friendly": "23456789abcdefghjkmnpqrstuvwxyz",
+}
+
+// templates хранит скомпилированные регулярные выражения для проверки коротких
+// ссылок, сгенерированных из символов того или иного алфавита. Ключом
+// является строка вида "<длина>:<алфавит>"
+var templates sync.Map
+
+// alphabetTemplate возвращает скомпилированное регулярное выражение, которому
+// соответствуют строки длиной length, состоящие из символов alphabet.
+func alphabetTemplate(alphabet string, length int) *regexp.Regexp {
+	key := fmt.Sprintf("%d:%s", length, alphabet)
+
+	if t, ok := templates.Load(key); ok {
+		return t.(*regexp.Regexp)
+	}
+
+	// экранируем символы, имеющие особое значение внутри класса символов
+	var class strings.Builder
+	for _, r := range alphabet {
+		if strings.ContainsRune(`\]^-[`, r) {
+			class.WriteRune('\\')
+		}
+		class.WriteRune(r)
+	}
+
+	t := regexp.MustCompile(fmt.Sprintf("^[%s]{%d}$", class.String(), length))
+	templates.Store(key, t)
+
+	return t
+}
+
+// alphabets возвращает набор алфавитов, доступных для выбора в запросах. Если
+// задан Alphabet, то он заменяет алфавит с пустым именем.
+func (s *GRPCServer) alphabets() map[string]string {
+	alphabets := DefaultAlphabets
+	if s.Alphabets != nil {
+		alphabets = s.Alphabets
+	}
+
+	if s.Alphabet == "" {
+		return alphabets
+	}
+
+	merged := make(map[string]string, len(alphabets)+1)
+	for name, chars := range alphabets {
+		merged[name] = chars
+	}
+
+	merged[""] = s.Alphabet
+	return merged
+}
+
+// validateAlphabet проверяет, что алфавит chars с именем name содержит не
+// менее двух символов и ни один символ в нем не повторяется.
+func validateAlphabet(name, chars string) error {
+	seen := make(map[rune]bool)
+	for _, r := range chars {
+		if seen[r] {
+			return fmt.Errorf("linkservice: the alphabet %q contains the character %q more than once", name, r)
+		}
+
+		seen[r] = true
+	}
+
+	if len(seen) < 2 {
+		return fmt.Errorf("linkservice: the alphabet %q must contain at least two characters", name)
+	}
+
+	return nil
+}
+
+// matchesAnyAlphabet сообщает, может ли строка link быть короткой ссылкой,
+// сгенерированной из символов хотя бы одного из доступных алфавитов.
+func (s *GRPCServer) matchesAnyAlphabet(link string) bool {
+	for _, alphabet := range s.alphabets() {
+		if alphabetTemplate(alphabet, s.linkLength()).MatchString(link) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// inAlphabet сообщает, состоит ли строка link только из символов алфавита
+// chars.
+func inAlphabet(chars, link string) bool {
+	for _, r := range link {
+		if !strings.ContainsRune(chars, r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// sampleLink возвращает ссылку длины length, составленную из символов
+// алфавита alphabet по порядку, для проверки LinkPattern.
+func sampleLink(alphabet string, length int) string {
+	chars := []rune(alphabet)
+	if len(chars) == 0 {
+		return ""
+	}
+
+	link := make([]rune, length)
+	for i := range link {
+		link[i] = chars[i%len(chars)]
+	}
+
+	return string(link)
+}
+
+// validLink сообщает, может ли строка link быть короткой ссылкой. Если задан
+// LinkPattern, то ссылка проверяется только по нему, иначе она должна быть
+// случайно сгенерированной ссылкой, пользовательским псевдонимом, при схеме
+// Base62Sequential — закодированным идентификатором записи, а при схеме
+// Deterministic — началом хеша URL.
+func (s *GRPCServer) validLink(link string) bool {
+	if s.LinkPattern != nil {
+		return s.LinkPattern.MatchString(link)
+	}
+
+	if s.matchesAnyAlphabet(link) || aliasTemplate.MatchString(link) {
+		return true
+	}
+
+	switch s.CodeStrategy {
+	case Base62Sequential:
+		return sequentialTemplate.MatchString(link)
+	case Deterministic:
+		return deterministicTemplate.MatchString(link)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,94 @@
+package linkservice
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		service *GRPCServer
+		expOK   bool
+	}{
+		{name: "defaults", service: &GRPCServer{}, expOK: true},
+		{name: "unambiguous", service: &GRPCServer{Alphabet: "23456789abcdefghjkmnpqrstuvwxyz"}, expOK: true},
+		{name: "duplicate", service: &GRPCServer{Alphabet: "abca"}, expOK: false},
+		{name: "single_character", service: &GRPCServer{Alphabet: "aaa"}, expOK: false},
+		{name: "named_duplicate", service: &GRPCServer{Alphabets: map[string]string{"": "ab", "hex": "0123456789abcdefa"}}, expOK: false},
+		{name: "no_default", service: &GRPCServer{Alphabets: map[string]string{"hex": "0123456789abcdef"}}, expOK: false},
+		{name: "alphabet_replaces_default", service: &GRPCServer{Alphabet: "ab", Alphabets: map[string]string{"hex": "0123456789abcdef"}}, expOK: true},
+		{name: "long_links", service: &GRPCServer{LinkLength: 33}, expOK: false},
+		{name: "long_urls", service: &GRPCServer{MaxURLLength: 4096}, expOK: false},
+		{name: "default_scheme", service: &GRPCServer{DefaultScheme: "https"}, expOK: true},
+		{name: "default_scheme_not_allowed", service: &GRPCServer{DefaultScheme: "ftp"}, expOK: false},
+		{name: "link_pattern", service: &GRPCServer{Alphabet: "0123456789abcdef", LinkPattern: regexp.MustCompile(`^[0-9a-f]{10}$`)}, expOK: true},
+		{name: "link_pattern_rejects_links", service: &GRPCServer{LinkPattern: regexp.MustCompile(`^[a-z]{10}$`)}, expOK: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := testCase.service.Validate()
+			if ok := err == nil; ok != testCase.expOK {
+				t.Errorf("the result %v was expected, but the error \"%v\" was received", testCase.expOK, err)
+			}
+		})
+	}
+}
+
+func TestLinkPattern(t *testing.T) {
+	service := &GRPCServer{LinkPattern: regexp.MustCompile(`^[a-z]{2}-[0-9]{4}$`)}
+
+	testCases := []struct {
+		link  string
+		expOK bool
+	}{
+		{link: "ab-1234", expOK: true},
+		{link: "abcdefghij", expOK: false},
+		{link: "AB-1234", expOK: false},
+		{link: "ab-12345", expOK: false},
+	}
+
+	for _, testCase := range testCases {
+		if ok := service.validLink(testCase.link); ok != testCase.expOK {
+			t.Errorf("the result %v was expected for the link \"%s\", but %v was received", testCase.expOK, testCase.link, ok)
+		}
+	}
+
+	// псевдоним должен соответствовать и требованиям к псевдонимам, и
+	// LinkPattern в нижнем регистре
+	for alias, expOK := range map[string]bool{"ab-1234": true, "AB-1234": true, "my-link": false, "a-1234": false} {
+		if ok := service.validAlias(alias); ok != expOK {
+			t.Errorf("the result %v was expected for the alias \"%s\", but %v was received", expOK, alias, ok)
+		}
+	}
+}
+
+func TestAlphabet(t *testing.T) {
+	service := &GRPCServer{Alphabet: "23456789abcdefghjkmnpqrstuvwxyz"}
+
+	for i := 0; i < 100; i++ {
+		link := service.generateLink(service.alphabets()[""])
+		if !inAlphabet(service.Alphabet, link) {
+			t.Fatalf("the link \"%s\" contains characters outside the alphabet", link)
+		}
+
+		if !service.validLink(link) {
+			t.Fatalf("the link \"%s\" was rejected as invalid", link)
+		}
+	}
+
+	// именованные алфавиты по умолчанию по-прежнему доступны, поэтому ссылки
+	// из них, в том числе с неоднозначными символами, остаются допустимыми
+	if !service.matchesAnyAlphabet("O0l1I0O0l1") {
+		t.Errorf("the link from the \"dense\" alphabet must remain valid")
+	}
+
+	if _, ok := service.alphabets()["friendly"]; !ok {
+		t.Errorf("the named alphabets must remain available")
+	}
+
+	if DefaultAlphabets[""] != defaultAlphabet {
+		t.Errorf("the default alphabets must not be modified")
+	}
+}
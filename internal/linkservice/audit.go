@@ -0,0 +1,117 @@
+package linkservice
+
+import (
+	"context"
+	"time"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+)
+
+// количество записей, которые метод ValidateLinks запрашивает из базы данных
+// одним запросом
+var auditBatchSize = 1000
+
+// максимальное количество некорректных записей в ответе метода ValidateLinks
+var maxAuditFindings = 1000
+
+// причины, по которым запись попадает в ответ метода ValidateLinks
+const (
+	auditReasonLink = "link"
+	auditReasonURL  = "url"
+)
+
+// auditRow содержит проверяемые методом ValidateLinks поля записи
+type auditRow struct {
+	namespace, link, url string
+}
+
+// ValidateLinks проверяет все записи базы данных, в том числе добавленные в
+// обход сервиса, например при импорте данных напрямую в таблицу, и
+// возвращает записи, которые сервис не смог бы обработать: с короткой
+// ссылкой, которая не принимается в запросах (причина "link"), или с
+// оригинальным URL, который не прошел бы проверку при создании ссылки
+// (причина "url"). Записи проверяются во всех пространствах имен частями по
+// auditBatchSize, поэтому таблица не загружается в память целиком. Ответ
+// содержит не более maxAuditFindings некорректных записей; если их больше, то
+// поле truncated равно true.
+//
+// Ошибки передаются клиенту с кодами состояния gRPC: ErrDeadlineExceeded —
+// codes.DeadlineExceeded, ErrReqProc — codes.Internal.
+func (s *GRPCServer) ValidateLinks(ctx context.Context, req *api.Empty) (*api.ValidationReport, error) {
+	report, err := s.validateLinks(ctx)
+	return report, statusError(err)
+}
+
+// validateLinks реализует метод ValidateLinks, возвращая ошибки сервиса без
+// преобразования в ошибки gRPC.
+func (s *GRPCServer) validateLinks(ctx context.Context) (*api.ValidationReport, error) {
+	report := &api.ValidationReport{}
+
+	// каждая часть начинается после последней записи предыдущей части,
+	// поэтому выбирается по первичному ключу без смещения
+	var after auditRow
+
+	for {
+		batch, err := s.auditBatch(ctx, after)
+		if err != nil {
+			return nil, s.requestError(ctx, "ValidateLinks", err, "after", after.link, "checked", report.Checked)
+		}
+
+		for _, row := range batch {
+			report.Checked++
+
+			reason := ""
+			switch {
+			case !s.validLink(row.link):
+				reason = auditReasonLink
+			case s.checkURL(row.url) != nil:
+				reason = auditReasonURL
+			default:
+				continue
+			}
+
+			if len(report.Invalid) == maxAuditFindings {
+				report.Truncated = true
+				continue
+			}
+
+			report.Invalid = append(report.Invalid, &api.InvalidLink{Link: row.link, Namespace: row.namespace, Reason: reason})
+		}
+
+		if len(batch) < auditBatchSize {
+			return report, nil
+		}
+
+		after = batch[len(batch)-1]
+	}
+}
+
+// auditBatch возвращает не более auditBatchSize записей, следующих в порядке
+// первичного ключа за записью after.
+func (s *GRPCServer) auditBatch(ctx context.Context, after auditRow) ([]auditRow, error) {
+	start := time.Now()
+	defer s.observeQuery("audit_links", start, "method", "ValidateLinks", "after", after.link)
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.readDB().QueryContext(ctx, "SELECT namespace, link, original_url FROM links WHERE (namespace, link) > ($1, $2) ORDER BY namespace, link LIMIT $3;",
+		after.namespace, after.link, auditBatchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	batch := make([]auditRow, 0, auditBatchSize)
+	for rows.Next() {
+		var row auditRow
+		if err := rows.Scan(&row.namespace, &row.link, &row.url); err != nil {
+			return nil, err
+		}
+
+		batch = append(batch, row)
+	}
+
+	return batch, rows.Err()
+}
@@ -0,0 +1,95 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+)
+
+func TestValidateLinksWithMockDB(t *testing.T) {
+	// уменьшаем размер части, чтобы проверить выборку несколькими запросами
+	defer func(size, findings int) { auditBatchSize, maxAuditFindings = size, findings }(auditBatchSize, maxAuditFindings)
+	auditBatchSize = 2
+
+	// записи упорядочены по первичному ключу, как в базе данных
+	table := []auditRow{
+		{namespace: "", link: "abcdefghij", url: "http://audit.abc/ok"},
+		{namespace: "", link: "bad link!", url: "http://audit.abc/link"},
+		{namespace: "", link: "klmnopqrst", url: "javascript:alert(1)"},
+		{namespace: "brand-a", link: "promo", url: "http://audit.abc/promo"},
+		{namespace: "brand-a", link: "x", url: "not a url"},
+	}
+
+	queries := 0
+	db := newMockDB(func(query string, args []driver.NamedValue) (mockResult, error) {
+		queries++
+		after := auditRow{namespace: args[0].Value.(string), link: args[1].Value.(string)}
+		limit := int(args[2].Value.(int64))
+
+		res := mockResult{columns: []string{"namespace", "link", "original_url"}}
+		for _, row := range table {
+			if row.namespace < after.namespace || row.namespace == after.namespace && row.link <= after.link {
+				continue
+			}
+
+			if len(res.rows) < limit {
+				res.rows = append(res.rows, []driver.Value{row.namespace, row.link, row.url})
+			}
+		}
+
+		return res, nil
+	})
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	report, err := service.ValidateLinks(context.Background(), &api.Empty{})
+	if err != nil {
+		t.Fatalf("ValidateLinks method reported an error: %v", err)
+	}
+
+	if report.GetChecked() != int64(len(table)) || queries != 3 {
+		t.Errorf("%d records checked with 3 queries were expected, but %d were checked with %d queries", len(table), report.GetChecked(), queries)
+	}
+
+	var invalid []string
+	for _, link := range report.GetInvalid() {
+		invalid = append(invalid, link.GetNamespace()+"/"+link.GetLink()+":"+link.GetReason())
+	}
+
+	exp := []string{"/bad link!:link", "/klmnopqrst:url", "brand-a/x:link"}
+	if !reflect.DeepEqual(invalid, exp) || report.GetTruncated() {
+		t.Errorf("the records %q were expected, but %q were received", exp, invalid)
+	}
+
+	// ответ ограничен maxAuditFindings записями
+	maxAuditFindings = 2
+
+	report, err = service.ValidateLinks(context.Background(), &api.Empty{})
+	if err != nil {
+		t.Fatalf("ValidateLinks method reported an error: %v", err)
+	}
+
+	if len(report.GetInvalid()) != 2 || !report.GetTruncated() {
+		t.Errorf("a truncated report of 2 records was expected, but %v was received", report)
+	}
+}
+
+func TestValidateLinksDatabaseError(t *testing.T) {
+	db := newMockDB(func(query string, args []driver.NamedValue) (mockResult, error) {
+		return mockResult{}, errors.New("connection reset")
+	})
+
+	service := &GRPCServer{Database: db, Logger: &recordLogger{}}
+
+	_, err := service.ValidateLinks(context.Background(), &api.Empty{})
+	if err = FromStatus(err); err != ErrReqProc {
+		t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrReqProc, err)
+	}
+}
@@ -0,0 +1,336 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// максимальное количество URL в одном запросе BatchCreate по умолчанию
+var maxBatchDefault = 1000
+
+// BatchCreate возвращает короткие ссылки для всех указанных в запросе URL в
+// порядке их следования. Записи добавляются в рамках одной транзакции: если
+// хотя бы один URL некорректен или его не удается обработать, то не
+// добавляется ни одной записи. Одинаковым URL в запросе соответствует одна и та
+// же короткая ссылка, если не задан AllowDuplicates. Каждая ссылка создается
+// в пространстве имен, указанном для ее URL. Пользовательские псевдонимы в
+// пакетных запросах не поддерживаются.
+//
+// Ошибки передаются клиенту с теми же кодами состояния gRPC, что и в методе
+// Create, ErrBatchTooLarge — с кодом codes.ResourceExhausted.
+func (s *GRPCServer) BatchCreate(ctx context.Context, req *api.URLList) (*api.LinkList, error) {
+	links, err := s.batchCreate(ctx, req)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	for _, link := range links.GetLinks() {
+		link.FullUrl = s.fullURL(link.GetLink())
+	}
+
+	return links, nil
+}
+
+// batchCreate реализует метод BatchCreate, возвращая ошибки сервиса без
+// преобразования в ошибки gRPC.
+func (s *GRPCServer) batchCreate(ctx context.Context, req *api.URLList) (*api.LinkList, error) {
+	if err := s.checkMaintenance(); err != nil {
+		return nil, err
+	}
+
+	if len(req.GetUrls()) > s.maxBatch() {
+		return nil, ErrBatchTooLarge
+	}
+
+	// urls содержит URL из запроса, приведенные к виду, в котором они
+	// хранятся в базе данных
+	urls := make([]*api.URL, 0, len(req.GetUrls()))
+
+	// проверяем все URL до начала транзакции, чтобы не обращаться к базе
+	// данных с заведомо некорректным запросом
+	for _, u := range req.GetUrls() {
+		u, err := trimURL(u)
+		if err != nil {
+			return nil, err
+		}
+
+		u = s.withScheme(u)
+
+		u, err = asciiURL(u)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := s.checkURL(u.GetUrl()); err != nil {
+			return nil, err
+		}
+
+		u = stripParams(u)
+
+		u, err = s.normalize(u)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := checkTTL(u); err != nil {
+			return nil, err
+		}
+
+		if u.GetMaxUses() < 0 {
+			return nil, ErrInvalidMaxUses
+		}
+
+		if err := checkMetadata(u); err != nil {
+			return nil, err
+		}
+
+		u, err = normalizeTags(u)
+		if err != nil {
+			return nil, err
+		}
+
+		if u.GetAlias() != "" {
+			return nil, ErrInvalidAlias
+		}
+
+		if err := checkNamespace(u.GetNamespace()); err != nil {
+			return nil, err
+		}
+
+		if _, ok := s.alphabets()[u.GetAlphabet()]; !ok {
+			return nil, ErrInvalidAlphabet
+		}
+
+		if err := s.checkReputation(ctx, "BatchCreate", u.GetUrl()); err != nil {
+			return nil, err
+		}
+
+		urls = append(urls, u)
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.Database.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, s.requestError(ctx, "BatchCreate", err)
+	}
+
+	// откатываем транзакцию, если она не была зафиксирована
+	defer tx.Rollback()
+
+	// created хранит короткие ссылки, уже полученные для URL из запроса в
+	// каждом пространстве имен
+	created := make(map[batchKey]string)
+	res := &api.LinkList{Links: make([]*api.Link, 0, len(urls))}
+
+	for _, u := range urls {
+		key := batchKey{namespace: u.GetNamespace(), url: u.GetUrl()}
+		link, ok := created[key]
+
+		if !ok || !s.deduplicate(u) {
+			link, err = s.createInTx(ctx, tx, u)
+			if err == ErrDeadlineExceeded || err == ErrCollectionNotFound {
+				return nil, err
+			}
+
+			if err != nil {
+				return nil, s.requestError(ctx, "BatchCreate", err)
+			}
+
+			created[key] = link
+		}
+
+		res.Links = append(res.Links, &api.Link{Link: link, Namespace: u.GetNamespace()})
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, s.requestError(ctx, "BatchCreate", err)
+	}
+
+	return res, nil
+}
+
+// batchKey различает одинаковые URL разных пространств имен в запросе
+// BatchCreate
+type batchKey struct {
+	namespace, url string
+}
+
+// createInTx возвращает короткую ссылку для URL из запроса req в рамках
+// транзакции tx, добавляя новую запись, если URL еще не сокращался или если
+// задан AllowDuplicates.
+func (s *GRPCServer) createInTx(ctx context.Context, tx *sql.Tx, req *api.URL) (string, error) {
+	if err := deleteExpiredURL(ctx, tx, req.GetNamespace(), req.GetUrl()); err != nil {
+		return "", err
+	}
+
+	if s.sequential(req) {
+		link, _, err := s.insertSequential(ctx, tx, req)
+		return link, err
+	}
+
+	if s.deterministic(req) {
+		link, _, err := s.insertDeterministic(ctx, tx, req)
+		return link, err
+	}
+
+	attempt := 1
+	defer func() { s.observeCollisions("insert_link_batch", attempt-1, "url", req.GetUrl()) }()
+
+	for ; ; attempt++ {
+		// проверяем, сгенерирована ли короткая ссылка для указанного URL
+		if s.deduplicate(req) {
+			var link string
+			err := tx.QueryRowContext(ctx, "SELECT link FROM links WHERE deduplicated AND namespace = $2 AND original_url = $1;", req.GetUrl(), req.GetNamespace()).Scan(&link)
+
+			if err != sql.ErrNoRows {
+				return link, err
+			}
+		}
+
+		link := s.generateLink(s.alphabets()[req.GetAlphabet()])
+
+		// ошибка внутри транзакции прервала бы ее целиком, поэтому при
+		// конфликте с существующей записью запись просто не добавляется, и
+		// попытка повторяется
+		r, err := tx.ExecContext(ctx, "INSERT INTO links (link, original_url, alphabet, expires_at, collection_id, deduplicated, title, owner_id, sliding_ttl_seconds, max_uses, tags, namespace) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) ON CONFLICT DO NOTHING;",
+			link, req.GetUrl(), req.GetAlphabet(), expiresAt(req), collectionID(req), s.deduplicate(req), nullString(req.GetTitle()), nullString(req.GetOwnerId()), slidingTTL(req), maxUses(req), tagsArray(req), req.GetNamespace())
+		if _, ok := violation(err, foreignKeyViolation); ok {
+			return "", ErrCollectionNotFound
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		if n, err := r.RowsAffected(); err != nil || n == 1 {
+			if n == 1 {
+				s.linkFilter().add(link)
+			}
+
+			return link, err
+		}
+
+		if attempt > s.maxCollisionRetries() {
+			return "", fmt.Errorf("no free link was generated in %d attempts", attempt)
+		}
+
+		// перед повторной попыткой убеждаемся, что запрос еще можно успеть
+		// обработать
+		if err := s.checkRetry(ctx); err != nil {
+			return "", err
+		}
+	}
+}
+
+// GetBatch возвращает оригинальные URL для всех указанных в запросе коротких
+// ссылок в порядке их следования, запрашивая их из базы данных одним
+// запросом. Некорректным и несуществующим ссылкам, а также ссылкам с
+// истекшим сроком действия, а также ссылкам с некорректным пространством имен
+// соответствуют пустые сообщения URL. Каждая ссылка ищется в указанном для нее
+// пространстве имен. В отличие от метода Get, переходы по ссылкам не
+// учитываются в статистике.
+//
+// Ошибки передаются клиенту с кодами состояния gRPC: ErrBatchTooLarge —
+// codes.ResourceExhausted, ErrDeadlineExceeded — codes.DeadlineExceeded,
+// ErrReqProc — codes.Internal.
+func (s *GRPCServer) GetBatch(ctx context.Context, req *api.LinkList) (*api.URLList, error) {
+	urls, err := s.getBatch(ctx, req)
+	return urls, statusError(err)
+}
+
+// getBatch реализует метод GetBatch, возвращая ошибки сервиса без
+// преобразования в ошибки gRPC.
+func (s *GRPCServer) getBatch(ctx context.Context, req *api.LinkList) (*api.URLList, error) {
+	if len(req.GetLinks()) > s.maxBatch() {
+		return nil, ErrBatchTooLarge
+	}
+
+	// запрашиваем только корректные ссылки, чтобы не обращаться к базе
+	// данных с заведомо несуществующими
+	links := make([]*api.Link, 0, len(req.GetLinks()))
+	for _, link := range req.GetLinks() {
+		if s.validLink(link.GetLink()) && checkNamespace(link.GetNamespace()) == nil {
+			links = append(links, link)
+		}
+	}
+
+	var found map[string]*api.URL
+
+	if len(links) > 0 {
+		start := time.Now()
+		err := s.retry(ctx, "select_url_batch", func(ctx context.Context) (err error) {
+			found, err = s.selectURLs(ctx, links)
+			return err
+		})
+		s.observeQuery("select_url_batch", start, "method", "GetBatch", "links", len(links))
+		if err != nil {
+			return nil, s.requestError(ctx, "GetBatch", err, "links", len(links))
+		}
+	}
+
+	res := &api.URLList{Urls: make([]*api.URL, 0, len(req.GetLinks()))}
+	for _, link := range req.GetLinks() {
+		url, ok := found[namespacedLink(link.GetNamespace(), link.GetLink())]
+		if !ok {
+			url = &api.URL{}
+		}
+
+		res.Urls = append(res.Urls, url)
+	}
+
+	return res, nil
+}
+
+// selectURLs запрашивает из базы данных оригинальные URL и время создания
+// действующих коротких ссылок links, ключами которых служат ключи ссылок с
+// учетом пространства имен (см. namespacedLink). Несуществующим ссылкам и
+// ссылкам с истекшим сроком действия не соответствует ни одного элемента.
+func (s *GRPCServer) selectURLs(ctx context.Context, links []*api.Link) (map[string]*api.URL, error) {
+	namespaces := make([]string, 0, len(links))
+	shortLinks := make([]string, 0, len(links))
+	for _, link := range links {
+		namespaces = append(namespaces, link.GetNamespace())
+		shortLinks = append(shortLinks, link.GetLink())
+	}
+
+	rows, err := s.readDB().QueryContext(ctx, "SELECT namespace, link, original_url, created_at FROM links "+
+		"WHERE (namespace, link) IN (SELECT * FROM unnest($1::text[], $2::text[])) AND (expires_at IS NULL OR expires_at > $3);",
+		pq.Array(namespaces), pq.Array(shortLinks), time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	found := make(map[string]*api.URL, len(links))
+	for rows.Next() {
+		var namespace, link, url string
+		var createdAt time.Time
+
+		if err := rows.Scan(&namespace, &link, &url, &createdAt); err != nil {
+			return nil, err
+		}
+
+		found[namespacedLink(namespace, link)] = &api.URL{Url: url, CreatedAt: timestamppb.New(createdAt)}
+	}
+
+	return found, rows.Err()
+}
+
+// maxBatch возвращает максимальное количество URL в одном запросе
+// BatchCreate.
+func (s *GRPCServer) maxBatch() int {
+	if s.MaxBatch > 0 {
+		return s.MaxBatch
+	}
+
+	return maxBatchDefault
+}
@@ -0,0 +1,166 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	_ "github.com/lib/pq"
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+)
+
+func TestBatchCreate(t *testing.T) {
+	// устанавливаем подключение к базе данных
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed connecting to the database: %v", err)
+	}
+
+	defer db.Close()
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	defer service.Close()
+
+	service.MaxBatch = 3
+	suffix := generateRandomСharacters(6)
+
+	t.Run("order_and_duplicates", func(t *testing.T) {
+		req := &api.URLList{Urls: []*api.URL{
+			{Url: "http://batch.abc/1/" + suffix},
+			{Url: "http://batch.abc/2/" + suffix},
+			{Url: "http://batch.abc/1/" + suffix},
+		}}
+
+		res, err := service.BatchCreate(context.Background(), req)
+		if err != nil {
+			t.Fatalf("BatchCreate method reported an error: %v", err)
+		}
+
+		links := res.GetLinks()
+		if len(links) != len(req.GetUrls()) {
+			t.Fatalf("%d links were expected, but %d were received", len(req.GetUrls()), len(links))
+		}
+
+		if links[0].GetLink() != links[2].GetLink() {
+			t.Errorf("different abbreviated links were generated for the same URL")
+		}
+
+		if links[0].GetLink() == links[1].GetLink() {
+			t.Errorf("the same abbreviated link was generated for different URLs")
+		}
+
+		// ссылки должны следовать в порядке URL в запросе
+		for i, link := range links {
+			res, err := service.Get(context.Background(), link)
+			if err != nil {
+				t.Fatalf("Get method reported an error: %v", err)
+			}
+
+			if res.GetUrl() != req.GetUrls()[i].GetUrl() {
+				t.Errorf("URL for the link #%d does not match the expected one", i)
+			}
+		}
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		url := "http://batch.abc/rollback/" + suffix
+
+		_, err := service.BatchCreate(context.Background(), &api.URLList{Urls: []*api.URL{
+			{Url: url},
+			{Url: "this is not a URL"},
+		}})
+
+		if err = FromStatus(err); err != ErrInvalidURL {
+			t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrInvalidURL, err)
+		}
+
+		var count int
+		if err := db.QueryRow("SELECT count(*) FROM links WHERE original_url = $1;", url).Scan(&count); err != nil {
+			t.Fatalf("failed to query the database: %v", err)
+		}
+
+		if count != 0 {
+			t.Errorf("it was expected that no records would be added by a failed batch")
+		}
+	})
+
+	t.Run("too_large", func(t *testing.T) {
+		req := &api.URLList{}
+		for i := 0; i < 4; i++ {
+			req.Urls = append(req.Urls, &api.URL{Url: "http://batch.abc/"})
+		}
+
+		_, err := service.BatchCreate(context.Background(), req)
+		if err = FromStatus(err); err != ErrBatchTooLarge {
+			t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrBatchTooLarge, err)
+		}
+	})
+}
+
+func TestGetBatch(t *testing.T) {
+	// устанавливаем подключение к базе данных
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed connecting to the database: %v", err)
+	}
+
+	defer db.Close()
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	defer service.Close()
+
+	service.MaxBatch = 5
+	url := "http://batch.abc/get/" + generateRandomСharacters(6)
+
+	created, err := service.Create(context.Background(), &api.URL{Url: url})
+	if err != nil {
+		t.Fatalf("Create method reported an error: %v", err)
+	}
+
+	t.Run("mixed", func(t *testing.T) {
+		res, err := service.GetBatch(context.Background(), &api.LinkList{Links: []*api.Link{
+			{Link: "invalid link"},
+			{Link: created.GetLink()},
+			{Link: generateRandomСharacters(lengthLink)},
+			{Link: created.GetLink()},
+		}})
+		if err != nil {
+			t.Fatalf("GetBatch method reported an error: %v", err)
+		}
+
+		exp := []string{"", url, "", url}
+		if len(res.GetUrls()) != len(exp) {
+			t.Fatalf("%d URLs were expected, but %d were received", len(exp), len(res.GetUrls()))
+		}
+
+		for i, u := range res.GetUrls() {
+			if u.GetUrl() != exp[i] {
+				t.Errorf("the URL #%d \"%s\" was expected, but \"%s\" was received", i, exp[i], u.GetUrl())
+			}
+		}
+
+		if res.GetUrls()[1].GetCreatedAt() == nil {
+			t.Errorf("the creation time of a found link was expected")
+		}
+	})
+
+	t.Run("too_large", func(t *testing.T) {
+		req := &api.LinkList{}
+		for i := 0; i < 6; i++ {
+			req.Links = append(req.Links, created)
+		}
+
+		_, err := service.GetBatch(context.Background(), req)
+		if err = FromStatus(err); err != ErrBatchTooLarge {
+			t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrBatchTooLarge, err)
+		}
+	})
+}
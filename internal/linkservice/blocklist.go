@@ -0,0 +1,54 @@
+package linkservice
+
+import (
+	"regexp"
+	"strings"
+)
+
+// BlockMatch определяет, как сгенерированная короткая ссылка сравнивается со
+// словами из BlockedWords
+type BlockMatch int
+
+const (
+	// MatchSubstring отбрасывает ссылки, содержащие одно из слов
+	MatchSubstring BlockMatch = iota
+
+	// MatchFull отбрасывает только ссылки, целиком совпадающие с одним из слов
+	MatchFull
+)
+
+// blocklist возвращает скомпилированное регулярное выражение, которому
+// соответствуют ссылки со словами из BlockedWords, или nil, если список пуст.
+// Выражение компилируется при первом вызове, поэтому проверка каждой
+// сгенерированной ссылки не зависит от количества слов в списке.
+func (s *GRPCServer) blocklist() *regexp.Regexp {
+	s.blocklistOnce.Do(func() {
+		words := make([]string, 0, len(s.BlockedWords))
+		for _, word := range s.BlockedWords {
+			// пустое слово содержится в любой ссылке
+			if word != "" {
+				words = append(words, regexp.QuoteMeta(word))
+			}
+		}
+
+		if len(words) == 0 {
+			return
+		}
+
+		pattern := strings.Join(words, "|")
+		if s.BlockedWordsMatch == MatchFull {
+			pattern = "^(?:" + pattern + ")$"
+		}
+
+		s.blocked = regexp.MustCompile("(?i)" + pattern)
+	})
+
+	return s.blocked
+}
+
+// isBlocked сообщает, содержит ли короткая ссылка link одно из слов
+// BlockedWords без учета регистра.
+func (s *GRPCServer) isBlocked(link string) bool {
+	blocked := s.blocklist()
+	return blocked != nil && blocked.MatchString(link)
+}
@@ -0,0 +1,45 @@
+package linkservice
+
+import "testing"
+
+func TestIsBlocked(t *testing.T) {
+	testCases := []struct {
+		name  string
+		words []string
+		match BlockMatch
+		link  string
+		exp   bool
+	}{
+		{name: "empty", words: nil, link: "anything", exp: false},
+		{name: "substring", words: []string{"bad"}, link: "xxbadxx", exp: true},
+		{name: "case_insensitive", words: []string{"bad"}, link: "xxBaDxx", exp: true},
+		{name: "not_blocked", words: []string{"bad", "worse"}, link: "goodlink", exp: false},
+		{name: "second_word", words: []string{"bad", "worse"}, link: "WORSEabc", exp: true},
+		{name: "full_match", words: []string{"bad"}, match: MatchFull, link: "bad", exp: true},
+		{name: "full_match_substring", words: []string{"bad"}, match: MatchFull, link: "xxbadxx", exp: false},
+		{name: "empty_word_ignored", words: []string{""}, link: "anything", exp: false},
+		{name: "special_characters", words: []string{"a.b"}, link: "axb", exp: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			service := &GRPCServer{BlockedWords: testCase.words, BlockedWordsMatch: testCase.match}
+
+			if blocked := service.isBlocked(testCase.link); blocked != testCase.exp {
+				t.Errorf("the result %v was expected, but %v was received", testCase.exp, blocked)
+			}
+		})
+	}
+}
+
+func TestGenerateLinkSkipsBlocked(t *testing.T) {
+	// из двух символов длиной 2 можно составить всего четыре ссылки, поэтому
+	// ссылка с запрещенным словом без проверки генерировалась бы регулярно
+	service := &GRPCServer{LinkLength: 2, BlockedWords: []string{"AB"}}
+
+	for i := 0; i < 1000; i++ {
+		if link := service.generateLink("ab"); link == "ab" {
+			t.Fatalf("the blocked link \"%s\" was generated", link)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package linkservice
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"sync"
+)
+
+var (
+	// допустимая доля ложноположительных ответов фильтра Блума по умолчанию
+	bloomFalsePositiveRateDefault = 0.01
+
+	// максимальное количество подряд сгенерированных ссылок, которые
+	// отбрасываются как вероятно занятые. Ограничение не дает генерации
+	// зациклиться, если фильтр переполнен
+	maxFilterSkips = 10
+)
+
+// bloomFilter представляет собой фильтр Блума коротких ссылок. Фильтр
+// отвечает, что ссылка точно не занята или, вероятно, занята, и позволяет
+// отбрасывать сгенерированные ссылки до попытки добавить запись в базу
+// данных. Все методы безопасны для одновременного использования и допускают
+// вызов у nil, что соответствует отключенному фильтру.
+type bloomFilter struct {
+	mu   sync.RWMutex
+	bits []uint64
+	m    uint64
+	k    uint64
+}
+
+// newBloomFilter создает фильтр, который при n добавленных ссылках ошибается
+// с вероятностью p.
+func newBloomFilter(n int, p float64) *bloomFilter {
+	m := uint64(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	if m < 64 {
+		m = 64
+	}
+
+	k := uint64(math.Round(float64(m) / float64(n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+
+	return &bloomFilter{bits: make([]uint64, (m+63)/64), m: m, k: k}
+}
+
+// positions возвращает номера битов ссылки link, полученные двойным
+// хешированием половин 128-битного хеша FNV-1a.
+func (f *bloomFilter) positions(link string) []uint64 {
+	h := fnv.New128a()
+	h.Write([]byte(link))
+	sum := h.Sum(nil)
+
+	var h1, h2 uint64
+	for i := 0; i < 8; i++ {
+		h1 = h1<<8 | uint64(sum[i])
+		h2 = h2<<8 | uint64(sum[i+8])
+	}
+
+	positions := make([]uint64, f.k)
+	for i := range positions {
+		positions[i] = (h1 + uint64(i)*h2) % f.m
+	}
+
+	return positions
+}
+
+// add добавляет ссылку link в фильтр.
+func (f *bloomFilter) add(link string) {
+	if f == nil {
+		return
+	}
+
+	positions := f.positions(link)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, p := range positions {
+		f.bits[p/64] |= 1 << (p % 64)
+	}
+}
+
+// mayContain сообщает, может ли ссылка link быть занята. Значение false
+// означает, что ссылка точно не добавлялась в фильтр.
+func (f *bloomFilter) mayContain(link string) bool {
+	if f == nil {
+		return false
+	}
+
+	positions := f.positions(link)
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	for _, p := range positions {
+		if f.bits[p/64]&(1<<(p%64)) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// linkFilter возвращает фильтр Блума занятых коротких ссылок или nil, если
+// фильтр отключен. Фильтр создается при первом обращении, поэтому
+// BloomCapacity и BloomFalsePositiveRate должны быть заданы до начала
+// обработки запросов.
+func (s *GRPCServer) linkFilter() *bloomFilter {
+	s.filterOnce.Do(func() {
+		if s.BloomCapacity > 0 {
+			s.filter = newBloomFilter(s.BloomCapacity, s.bloomFalsePositiveRate())
+		}
+	})
+
+	return s.filter
+}
+
+// bloomFalsePositiveRate возвращает допустимую долю ложноположительных
+// ответов фильтра Блума.
+func (s *GRPCServer) bloomFalsePositiveRate() float64 {
+	if s.BloomFalsePositiveRate > 0 {
+		return s.BloomFalsePositiveRate
+	}
+
+	return bloomFalsePositiveRateDefault
+}
+
+// LoadBloomFilter добавляет в фильтр Блума все короткие ссылки, хранящиеся в
+// базе данных, и возвращает их количество. Метод следует вызывать при запуске
+// сервиса; до его завершения фильтр знает только о ссылках, созданных этим
+// экземпляром сервиса, что не нарушает работу: источником истины остается
+// база данных. Если фильтр отключен, то метод сразу завершается.
+func (s *GRPCServer) LoadBloomFilter(ctx context.Context) (int, error) {
+	filter := s.linkFilter()
+	if filter == nil {
+		return 0, nil
+	}
+
+	rows, err := s.Database.QueryContext(ctx, "SELECT link FROM links;")
+	if err != nil {
+		return 0, err
+	}
+
+	defer rows.Close()
+
+	var n int
+	for rows.Next() {
+		var link string
+		if err := rows.Scan(&link); err != nil {
+			return n, err
+		}
+
+		filter.add(link)
+		n++
+	}
+
+	return n, rows.Err()
+}
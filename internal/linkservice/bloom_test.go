@@ -0,0 +1,66 @@
+package linkservice
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestBloomFilter(t *testing.T) {
+	const n = 10000
+	f := newBloomFilter(n, 0.01)
+
+	for i := 0; i < n; i++ {
+		f.add(fmt.Sprintf("added-%d", i))
+	}
+
+	// фильтр не может ошибаться в отношении добавленных ссылок
+	for i := 0; i < n; i++ {
+		if link := fmt.Sprintf("added-%d", i); !f.mayContain(link) {
+			t.Fatalf("the added link \"%s\" was reported as free", link)
+		}
+	}
+
+	var falsePositives int
+	for i := 0; i < n; i++ {
+		if f.mayContain(fmt.Sprintf("free-%d", i)) {
+			falsePositives++
+		}
+	}
+
+	// доля ложноположительных ответов не должна заметно превышать заданную
+	if rate := float64(falsePositives) / n; rate > 0.03 {
+		t.Errorf("a false positive rate of about 0.01 was expected, but %v was received", rate)
+	}
+
+	var disabled *bloomFilter
+	disabled.add("a")
+
+	if disabled.mayContain("a") {
+		t.Errorf("a disabled filter was expected to report every link as free")
+	}
+}
+
+func TestGenerateLinkFilter(t *testing.T) {
+	old := maxFilterSkips
+	defer func() { maxFilterSkips = old }()
+
+	service := &GRPCServer{LinkLength: 1, BloomCapacity: 10, ReservedWords: []string{}}
+	service.linkFilter().add("a")
+
+	// при большом ограничении вероятно занятая ссылка практически не может
+	// быть сгенерирована
+	maxFilterSkips = 1000
+	for i := 0; i < 20; i++ {
+		if link := service.generateLink("ab"); link != "b" {
+			t.Fatalf("the free link \"%s\" was expected, but \"%s\" was received", "b", link)
+		}
+	}
+
+	// если фильтр считает занятыми все ссылки, то генерация не зацикливается
+	service.linkFilter().add("b")
+	maxFilterSkips = 3
+
+	if link := service.generateLink("ab"); link != "a" && link != "b" {
+		t.Errorf("a link from the alphabet was expected, but \"%s\" was received", link)
+	}
+}
@@ -0,0 +1,135 @@
+package linkservice
+
+import (
+	"container/list"
+	"database/sql"
+	"sync"
+	"time"
+)
+
+// cacheEntry представляет собой запись кэша коротких ссылок
+type cacheEntry struct {
+	namespace string
+	link      string
+	url       string
+	expires   sql.NullTime
+	created   time.Time
+
+	// limited сообщает, ограничено ли количество переходов по ссылке
+	limited bool
+
+	// version содержит версию ссылки, увеличивающуюся при изменении URL
+	version int64
+}
+
+// key возвращает ключ записи в кэше: короткую ссылку с учетом пространства
+// имен.
+func (e cacheEntry) key() string {
+	return namespacedLink(e.namespace, e.link)
+}
+
+// lruCache представляет собой ограниченный по размеру кэш коротких ссылок,
+// вытесняющий давно не использовавшиеся записи. Все методы безопасны для
+// одновременного использования и допускают вызов у nil, что соответствует
+// отключенному кэшу.
+type lruCache struct {
+	mu    sync.Mutex
+	size  int
+	order *list.List
+	items map[string]*list.Element
+}
+
+// newLRUCache создает кэш, вмещающий не более size записей.
+func newLRUCache(size int) *lruCache {
+	return &lruCache{
+		size:  size,
+		order: list.New(),
+		items: make(map[string]*list.Element, size),
+	}
+}
+
+// get возвращает запись с ключом key, если она есть в кэше. Ключом служит
+// короткая ссылка с учетом пространства имен (см. namespacedLink).
+func (c *lruCache) get(key string) (cacheEntry, bool) {
+	if c == nil {
+		return cacheEntry{}, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return cacheEntry{}, false
+	}
+
+	c.order.MoveToFront(el)
+
+	return el.Value.(cacheEntry), true
+}
+
+// put добавляет запись e в кэш, вытесняя при необходимости наиболее давно
+// использовавшуюся запись.
+func (c *lruCache) put(e cacheEntry) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[e.key()]; ok {
+		el.Value = e
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.items[e.key()] = c.order.PushFront(e)
+
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(cacheEntry).key())
+	}
+}
+
+// remove удаляет из кэша запись с ключом key.
+func (c *lruCache) remove(key string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.order.Remove(el)
+		delete(c.items, key)
+	}
+}
+
+// clear удаляет из кэша все записи.
+func (c *lruCache) clear() {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.order.Init()
+	c.items = make(map[string]*list.Element, c.size)
+}
+
+// linkCache возвращает кэш коротких ссылок сервера или nil, если кэширование
+// отключено. Кэш создается при первом обращении, поэтому CacheSize должен
+// быть задан до начала обработки запросов.
+func (s *GRPCServer) linkCache() *lruCache {
+	s.cacheOnce.Do(func() {
+		if s.CacheSize > 0 {
+			s.cache = newLRUCache(s.CacheSize)
+		}
+	})
+
+	return s.cache
+}
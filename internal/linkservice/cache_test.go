@@ -0,0 +1,162 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+)
+
+func TestLRUCache(t *testing.T) {
+	c := newLRUCache(2)
+
+	c.put(cacheEntry{link: "a", url: "http://a.abc/"})
+	c.put(cacheEntry{link: "b", url: "http://b.abc/"})
+
+	// обращение к "a" делает "b" наиболее давно использовавшейся записью
+	if _, ok := c.get("a"); !ok {
+		t.Fatalf("it was expected that the entry \"a\" would be cached")
+	}
+
+	c.put(cacheEntry{link: "c", url: "http://c.abc/"})
+
+	if _, ok := c.get("b"); ok {
+		t.Errorf("it was expected that the entry \"b\" would be evicted")
+	}
+
+	for _, link := range []string{"a", "c"} {
+		if _, ok := c.get(link); !ok {
+			t.Errorf("it was expected that the entry \"%s\" would be cached", link)
+		}
+	}
+
+	c.remove("a")
+	if _, ok := c.get("a"); ok {
+		t.Errorf("it was expected that the entry \"a\" would be removed")
+	}
+
+	c.clear()
+	if _, ok := c.get("c"); ok {
+		t.Errorf("it was expected that the cache would be empty after clearing")
+	}
+
+	// отключенный кэш не хранит записей
+	var disabled *lruCache
+	disabled.put(cacheEntry{link: "a"})
+	if _, ok := disabled.get("a"); ok {
+		t.Errorf("it was expected that the disabled cache would be empty")
+	}
+}
+
+func TestGetCached(t *testing.T) {
+	const link = "abcdefghij"
+
+	db := newMockDB(func(query string, args []driver.NamedValue) (mockResult, error) {
+		switch {
+		case query == selectURLQuery:
+			return urlRow("http://cache.abc/", nil), nil
+		case strings.HasPrefix(query, "UPDATE links SET visits"):
+			return mockResult{columns: []string{"expires_at"}, rows: [][]driver.Value{{nil}}}, nil
+		case strings.HasPrefix(query, "INSERT INTO link_hits"):
+			return mockResult{affected: 1}, nil
+		default:
+			t.Fatalf("an unexpected query was received: %s", query)
+			return mockResult{}, nil
+		}
+	})
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	service.CacheSize = 10
+
+	if _, err := service.Get(context.Background(), &api.Link{Link: link}); err != nil {
+		t.Fatalf("Get method reported an error: %v", err)
+	}
+
+	first := len(db.executed())
+
+	res, err := service.Get(context.Background(), &api.Link{Link: link})
+	if err != nil {
+		t.Fatalf("Get method reported an error: %v", err)
+	}
+
+	if res.GetUrl() != "http://cache.abc/" {
+		t.Errorf("URL contained in the response does not match the expected one")
+	}
+
+	// повторный вызов находит ссылку в кэше, поэтому выполняет только запросы,
+	// учитывающие переход, но не запрос оригинального URL
+	for _, query := range db.executed()[first:] {
+		if !strings.HasPrefix(query, "UPDATE links SET visits") && !strings.HasPrefix(query, "INSERT INTO link_hits") {
+			t.Errorf("no lookup query was expected on the second call, but \"%s\" was executed", query)
+		}
+	}
+}
+
+func TestGetDeletedCachedLinkWithMockDB(t *testing.T) {
+	const link = "abcdefghij"
+
+	var lookups int
+	deleted := false
+
+	db := newMockDB(func(query string, args []driver.NamedValue) (mockResult, error) {
+		switch {
+		case query == selectURLQuery:
+			lookups++
+			if deleted {
+				return mockResult{columns: []string{"original_url"}}, nil
+			}
+
+			return urlRow("http://cache.abc/", nil), nil
+		case strings.HasPrefix(query, "UPDATE links SET visits"):
+			// удаленная ссылка не обновляется
+			if deleted {
+				return mockResult{columns: []string{"expires_at"}}, nil
+			}
+
+			return mockResult{columns: []string{"expires_at"}, rows: [][]driver.Value{{nil}}}, nil
+		case strings.HasPrefix(query, "INSERT INTO link_hits"):
+			if deleted {
+				t.Errorf("no visit was expected to be recorded for the deleted link")
+			}
+
+			return mockResult{affected: 1}, nil
+		default:
+			t.Fatalf("an unexpected query was received: %s", query)
+			return mockResult{}, nil
+		}
+	})
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	service.CacheSize = 10
+
+	if _, err := service.Get(context.Background(), &api.Link{Link: link}); err != nil {
+		t.Fatalf("Get method reported an error: %v", err)
+	}
+
+	// ссылка удалена в обход кэша, например другим экземпляром сервиса, поэтому
+	// ее находит только запрос, учитывающий переход
+	deleted = true
+
+	for i := 0; i < 2; i++ {
+		_, err := service.Get(context.Background(), &api.Link{Link: link})
+		if err = FromStatus(err); err != ErrURLNotFound {
+			t.Fatalf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrURLNotFound, err)
+		}
+	}
+
+	// первый вызов после удаления нашел ссылку в кэше и удалил ее из кэша,
+	// поэтому второй вызов снова запросил оригинальный URL
+	if lookups != 2 {
+		t.Errorf("2 lookups were expected, but %d were executed", lookups)
+	}
+}
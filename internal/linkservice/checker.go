@@ -0,0 +1,40 @@
+package linkservice
+
+import "context"
+
+// URLChecker проверяет репутацию URL перед созданием короткой ссылки,
+// например по спискам вредоносных сайтов. Метод CheckURL возвращает
+// ErrDomainNotAllowed, если на URL u нельзя создавать короткие ссылки, и
+// другую ошибку, если проверку выполнить не удалось. Проверка должна
+// прерываться при отмене контекста ctx запроса.
+type URLChecker interface {
+	CheckURL(ctx context.Context, u string) error
+}
+
+// noopChecker разрешает любые URL. Используется, если URLChecker сервера не
+// задан.
+type noopChecker struct{}
+
+func (noopChecker) CheckURL(context.Context, string) error { return nil }
+
+// urlChecker возвращает средство проверки репутации URL сервера.
+func (s *GRPCServer) urlChecker() URLChecker {
+	if s.URLChecker != nil {
+		return s.URLChecker
+	}
+
+	return noopChecker{}
+}
+
+// checkReputation проверяет URL u средством проверки репутации при обработке
+// запроса method. Отклоненные URL передаются клиенту как ErrDomainNotAllowed,
+// а ошибки самой проверки записываются в журнал, и запрос завершается ошибкой
+// ErrReqProc или, если он отменен, ErrDeadlineExceeded.
+func (s *GRPCServer) checkReputation(ctx context.Context, method, u string) error {
+	err := s.urlChecker().CheckURL(ctx, u)
+	if err == nil || err == ErrDomainNotAllowed {
+		return err
+	}
+
+	return s.requestError(ctx, method, err, "url", u)
+}
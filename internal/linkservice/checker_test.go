@@ -0,0 +1,87 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+)
+
+// checkerFunc позволяет использовать функцию в качестве URLChecker
+type checkerFunc func(ctx context.Context, u string) error
+
+func (f checkerFunc) CheckURL(ctx context.Context, u string) error { return f(ctx, u) }
+
+func TestCreateURLChecker(t *testing.T) {
+	errCheck := errors.New("lookup failed")
+
+	testCases := []struct {
+		name  string
+		check error
+		err   error
+	}{
+		{name: "allowed", check: nil, err: nil},
+		{name: "malicious", check: ErrDomainNotAllowed, err: ErrDomainNotAllowed},
+		{name: "check_failed", check: errCheck, err: ErrReqProc},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			db := newMockDB(func(query string, args []driver.NamedValue) (mockResult, error) {
+				if query == insertLinkQuery {
+					return linkRow(args[0].Value.(string)), nil
+				}
+
+				return mockResult{}, nil
+			})
+
+			service, err := NewGRPCServer(db)
+			if err != nil {
+				t.Fatalf("failed to prepare the server: %v", err)
+			}
+
+			service.Logger = &recordLogger{}
+
+			var checked string
+			service.URLChecker = checkerFunc(func(ctx context.Context, u string) error {
+				checked = u
+				return testCase.check
+			})
+
+			_, err = service.Create(context.Background(), &api.URL{Url: "HTTP://Checked.abc/path"})
+			if err = FromStatus(err); err != testCase.err {
+				t.Fatalf("an error with a value of \"%v\" was expected, but \"%v\" was received", testCase.err, err)
+			}
+
+			// проверяется URL в том виде, в котором он будет сохранен
+			if checked != "http://checked.abc/path" {
+				t.Errorf("the URL \"http://checked.abc/path\" was expected to be checked, but \"%s\" was received", checked)
+			}
+
+			// отклоненный URL не сохраняется
+			if queries := db.executed(); err != nil && len(queries) != 0 {
+				t.Errorf("no queries were expected, but %q were executed", queries)
+			}
+		})
+	}
+}
+
+func TestCreateURLCheckerCancel(t *testing.T) {
+	service := &GRPCServer{Logger: &recordLogger{}}
+
+	// проверка прерывается при отмене запроса
+	service.URLChecker = checkerFunc(func(ctx context.Context, u string) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := service.Create(ctx, &api.URL{Url: "http://checked.abc/"})
+	if err = FromStatus(err); err != ErrDeadlineExceeded {
+		t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrDeadlineExceeded, err)
+	}
+}
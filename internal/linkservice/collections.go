@@ -0,0 +1,241 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// максимальная длина названия коллекции — размер столбца name в базе данных
+var maxCollectionName = 255
+
+// collectionID возвращает идентификатор коллекции, в которую добавляется
+// короткая ссылка, создаваемая запросом req. Если коллекция в запросе не
+// указана, то возвращается недействительное значение, которое сохраняется в
+// базе данных как NULL.
+func collectionID(req *api.URL) sql.NullInt64 {
+	return sql.NullInt64{Int64: req.GetCollectionId(), Valid: req.GetCollectionId() != 0}
+}
+
+// CreateCollection создает коллекцию коротких ссылок с указанным в запросе
+// названием и возвращает ее вместе с присвоенным идентификатором. Коллекции
+// общие для всех пространств имен, поэтому запрос с указанным пространством
+// имен отклоняется. Ошибки передаются клиенту с кодами состояния gRPC:
+// ErrInvalidCollection и ErrInvalidNamespace — codes.InvalidArgument,
+// ErrDeadlineExceeded — codes.DeadlineExceeded, ErrReqProc — codes.Internal.
+func (s *GRPCServer) CreateCollection(ctx context.Context, req *api.Collection) (*api.Collection, error) {
+	collection, err := s.createCollection(ctx, req)
+	return collection, statusError(err)
+}
+
+// createCollection реализует метод CreateCollection, возвращая ошибки сервиса
+// без преобразования в ошибки gRPC.
+func (s *GRPCServer) createCollection(ctx context.Context, req *api.Collection) (*api.Collection, error) {
+	name := req.GetName()
+	if strings.TrimSpace(name) == "" || utf8.RuneCountInString(name) > maxCollectionName {
+		return nil, ErrInvalidCollection
+	}
+
+	if req.GetNamespace() != "" {
+		return nil, ErrInvalidNamespace
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var id int64
+	var createdAt time.Time
+	err := s.Database.QueryRowContext(ctx, "INSERT INTO collections (name) VALUES ($1) RETURNING id, created_at;", name).Scan(&id, &createdAt)
+	if err != nil {
+		return nil, s.requestError(ctx, "CreateCollection", err, "name", name)
+	}
+
+	return &api.Collection{Id: id, Name: name, CreatedAt: timestamppb.New(createdAt)}, nil
+}
+
+// ListCollections возвращает все коллекции в порядке их создания. Ошибки
+// передаются клиенту с кодами состояния gRPC: ErrDeadlineExceeded —
+// codes.DeadlineExceeded, ErrReqProc — codes.Internal.
+func (s *GRPCServer) ListCollections(ctx context.Context, req *api.Empty) (*api.CollectionList, error) {
+	list, err := s.listCollections(ctx)
+	return list, statusError(err)
+}
+
+// listCollections реализует метод ListCollections, возвращая ошибки сервиса
+// без преобразования в ошибки gRPC.
+func (s *GRPCServer) listCollections(ctx context.Context) (*api.CollectionList, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.readDB().QueryContext(ctx, "SELECT id, name, created_at FROM collections ORDER BY id;")
+	if err != nil {
+		return nil, s.requestError(ctx, "ListCollections", err)
+	}
+
+	defer rows.Close()
+
+	res := &api.CollectionList{}
+	for rows.Next() {
+		var id int64
+		var name string
+		var createdAt time.Time
+
+		if err := rows.Scan(&id, &name, &createdAt); err != nil {
+			return nil, s.requestError(ctx, "ListCollections", err)
+		}
+
+		res.Collections = append(res.Collections, &api.Collection{Id: id, Name: name, CreatedAt: timestamppb.New(createdAt)})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, s.requestError(ctx, "ListCollections", err)
+	}
+
+	return res, nil
+}
+
+// DeleteCollection удаляет коллекцию с указанным в запросе идентификатором.
+// Если задан CascadeCollections, то вместе с коллекцией удаляются и входящие в
+// нее короткие ссылки всех пространств имен, иначе ссылки сохраняются вне
+// коллекций. Запрос с указанным пространством имен отклоняется, чтобы не
+// удалить ссылки других пространств имен по ошибке. Ошибки передаются клиенту
+// с кодами состояния gRPC: ErrInvalidNamespace — codes.InvalidArgument,
+// ErrCollectionNotFound — codes.NotFound, ErrDeadlineExceeded —
+// codes.DeadlineExceeded, ErrReqProc — codes.Internal.
+func (s *GRPCServer) DeleteCollection(ctx context.Context, req *api.Collection) (*api.Empty, error) {
+	err := s.deleteCollection(ctx, req)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &api.Empty{}, nil
+}
+
+// deleteCollection реализует метод DeleteCollection, возвращая ошибки сервиса
+// без преобразования в ошибки gRPC.
+func (s *GRPCServer) deleteCollection(ctx context.Context, req *api.Collection) error {
+	if req.GetNamespace() != "" {
+		return ErrInvalidNamespace
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.Database.BeginTx(ctx, nil)
+	if err != nil {
+		return s.requestError(ctx, "DeleteCollection", err, "collection", req.GetId())
+	}
+
+	// откатываем транзакцию, если она не была зафиксирована
+	defer tx.Rollback()
+
+	// удаленные ссылки всех пространств имен запоминаем, чтобы после фиксации
+	// транзакции исключить их из кэша метода Get
+	var deleted []string
+
+	if s.CascadeCollections {
+		rows, err := tx.QueryContext(ctx, "DELETE FROM links WHERE collection_id = $1 RETURNING namespace, link;", req.GetId())
+		if err != nil {
+			return s.requestError(ctx, "DeleteCollection", err, "collection", req.GetId())
+		}
+
+		for rows.Next() {
+			var namespace, link string
+			if err := rows.Scan(&namespace, &link); err != nil {
+				rows.Close()
+				return s.requestError(ctx, "DeleteCollection", err, "collection", req.GetId())
+			}
+
+			deleted = append(deleted, namespacedLink(namespace, link))
+		}
+
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return s.requestError(ctx, "DeleteCollection", err, "collection", req.GetId())
+		}
+	}
+
+	// без каскадного удаления ссылки исключаются из коллекции ограничением
+	// внешнего ключа ON DELETE SET NULL
+	r, err := tx.ExecContext(ctx, "DELETE FROM collections WHERE id = $1;", req.GetId())
+	if err != nil {
+		return s.requestError(ctx, "DeleteCollection", err, "collection", req.GetId())
+	}
+
+	if n, err := r.RowsAffected(); err != nil {
+		return s.requestError(ctx, "DeleteCollection", err, "collection", req.GetId())
+	} else if n == 0 {
+		return ErrCollectionNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		return s.requestError(ctx, "DeleteCollection", err, "collection", req.GetId())
+	}
+
+	for _, key := range deleted {
+		s.linkCache().remove(key)
+	}
+
+	return nil
+}
+
+// ListByCollection возвращает короткие ссылки указанного в запросе
+// пространства имен, входящие в коллекцию с указанным идентификатором, вместе
+// с их оригинальными URL. Ошибки передаются клиенту с кодами состояния gRPC:
+// ErrInvalidNamespace — codes.InvalidArgument, ErrCollectionNotFound —
+// codes.NotFound, ErrDeadlineExceeded — codes.DeadlineExceeded, ErrReqProc —
+// codes.Internal.
+func (s *GRPCServer) ListByCollection(ctx context.Context, req *api.Collection) (*api.MappingList, error) {
+	list, err := s.listByCollection(ctx, req)
+	return list, statusError(err)
+}
+
+// listByCollection реализует метод ListByCollection, возвращая ошибки сервиса
+// без преобразования в ошибки gRPC.
+func (s *GRPCServer) listByCollection(ctx context.Context, req *api.Collection) (*api.MappingList, error) {
+	if err := checkNamespace(req.GetNamespace()); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var exists bool
+	err := s.readDB().QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM collections WHERE id = $1);", req.GetId()).Scan(&exists)
+	if err != nil {
+		return nil, s.requestError(ctx, "ListByCollection", err, "collection", req.GetId())
+	}
+
+	if !exists {
+		return nil, ErrCollectionNotFound
+	}
+
+	rows, err := s.readDB().QueryContext(ctx, "SELECT link, original_url FROM links WHERE collection_id = $1 AND namespace = $3 AND (expires_at IS NULL OR expires_at > $2) ORDER BY created_at, link;",
+		req.GetId(), time.Now(), req.GetNamespace())
+	if err != nil {
+		return nil, s.requestError(ctx, "ListByCollection", err, "collection", req.GetId())
+	}
+
+	defer rows.Close()
+
+	res := &api.MappingList{}
+	for rows.Next() {
+		var link, url string
+		if err := rows.Scan(&link, &url); err != nil {
+			return nil, s.requestError(ctx, "ListByCollection", err, "collection", req.GetId())
+		}
+
+		res.Mappings = append(res.Mappings, &api.Mapping{Link: link, Url: url})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, s.requestError(ctx, "ListByCollection", err, "collection", req.GetId())
+	}
+
+	return res, nil
+}
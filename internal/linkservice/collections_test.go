@@ -0,0 +1,140 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	_ "github.com/lib/pq"
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+)
+
+func TestCollections(t *testing.T) {
+	// устанавливаем подключение к базе данных
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed connecting to the database: %v", err)
+	}
+
+	defer db.Close()
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	defer service.Close()
+
+	suffix := generateRandomСharacters(6)
+
+	// fill создает коллекцию и добавляет в нее две короткие ссылки
+	fill := func(t *testing.T, name string) (*api.Collection, []*api.Link) {
+		collection, err := service.CreateCollection(context.Background(), &api.Collection{Name: name + suffix})
+		if err != nil {
+			t.Fatalf("CreateCollection method reported an error: %v", err)
+		}
+
+		var links []*api.Link
+		for _, path := range []string{"/1/", "/2/"} {
+			link, err := service.Create(context.Background(), &api.URL{
+				Url:          "http://collections.abc/" + name + path + suffix,
+				CollectionId: collection.GetId(),
+			})
+			if err != nil {
+				t.Fatalf("Create method reported an error: %v", err)
+			}
+
+			links = append(links, link)
+		}
+
+		return collection, links
+	}
+
+	t.Run("list", func(t *testing.T) {
+		collection, links := fill(t, "list")
+
+		res, err := service.ListByCollection(context.Background(), collection)
+		if err != nil {
+			t.Fatalf("ListByCollection method reported an error: %v", err)
+		}
+
+		if len(res.GetMappings()) != len(links) {
+			t.Fatalf("%d links were expected, but %d were received", len(links), len(res.GetMappings()))
+		}
+
+		for i, m := range res.GetMappings() {
+			if m.GetLink() != links[i].GetLink() {
+				t.Errorf("link #%d does not match the expected one", i)
+			}
+		}
+
+		list, err := service.ListCollections(context.Background(), &api.Empty{})
+		if err != nil {
+			t.Fatalf("ListCollections method reported an error: %v", err)
+		}
+
+		found := false
+		for _, c := range list.GetCollections() {
+			found = found || c.GetId() == collection.GetId() && c.GetName() == collection.GetName()
+		}
+
+		if !found {
+			t.Errorf("the created collection is missing from the list")
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		collection, links := fill(t, "delete")
+
+		if _, err := service.DeleteCollection(context.Background(), collection); err != nil {
+			t.Fatalf("DeleteCollection method reported an error: %v", err)
+		}
+
+		// без каскадного удаления ссылки должны продолжать разрешаться
+		for _, link := range links {
+			if _, err := service.Get(context.Background(), link); err != nil {
+				t.Errorf("Get method reported an error: %v", err)
+			}
+		}
+
+		_, err = service.ListByCollection(context.Background(), collection)
+		if err = FromStatus(err); err != ErrCollectionNotFound {
+			t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrCollectionNotFound, err)
+		}
+	})
+
+	t.Run("cascade", func(t *testing.T) {
+		service.CascadeCollections = true
+		defer func() { service.CascadeCollections = false }()
+
+		collection, links := fill(t, "cascade")
+
+		if _, err := service.DeleteCollection(context.Background(), collection); err != nil {
+			t.Fatalf("DeleteCollection method reported an error: %v", err)
+		}
+
+		for _, link := range links {
+			_, err := service.Get(context.Background(), link)
+			if err = FromStatus(err); err != ErrURLNotFound {
+				t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrURLNotFound, err)
+			}
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := service.CreateCollection(context.Background(), &api.Collection{Name: " "})
+		if err = FromStatus(err); err != ErrInvalidCollection {
+			t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrInvalidCollection, err)
+		}
+
+		_, err = service.Create(context.Background(), &api.URL{Url: "http://collections.abc/unknown/" + suffix, CollectionId: -1})
+		if err = FromStatus(err); err != ErrCollectionNotFound {
+			t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrCollectionNotFound, err)
+		}
+
+		_, err = service.DeleteCollection(context.Background(), &api.Collection{Id: -1})
+		if err = FromStatus(err); err != ErrCollectionNotFound {
+			t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrCollectionNotFound, err)
+		}
+	})
+}
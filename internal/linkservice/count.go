@@ -0,0 +1,113 @@
+package linkservice
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+)
+
+// время, в течение которого метод Count по умолчанию возвращает ранее
+// подсчитанное количество ссылок
+var countCacheTTLDefault = 10 * time.Second
+
+// countCache хранит последнее подсчитанное количество действующих ссылок
+type countCache struct {
+	mu      sync.Mutex
+	value   int64
+	counted time.Time
+
+	// done закрывается по завершении выполняющегося подсчета; nil, если
+	// подсчет не выполняется
+	done chan struct{}
+}
+
+// Count возвращает количество действующих коротких ссылок, то есть ссылок без
+// срока действия или со сроком действия, который еще не истек. Подсчет
+// требует просмотра всей таблицы, поэтому результат кэшируется на
+// CountCacheTTL, и под нагрузкой одновременные вызовы выполняют не более
+// одного подсчета.
+//
+// Ошибки передаются клиенту с кодами состояния gRPC: ErrDeadlineExceeded —
+// codes.DeadlineExceeded, ErrReqProc — codes.Internal.
+func (s *GRPCServer) Count(ctx context.Context, req *api.Empty) (*api.CountResponse, error) {
+	n, err := s.count(ctx)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &api.CountResponse{Count: n}, nil
+}
+
+// count реализует метод Count, возвращая ошибки сервиса без преобразования в
+// ошибки gRPC.
+func (s *GRPCServer) count(ctx context.Context) (int64, error) {
+	c := &s.linkCount
+
+	// вызовы, пришедшие во время подсчета, дожидаются его результата, а не
+	// начинают собственный. Блокировка на время подсчета не удерживается,
+	// поэтому ожидание прерывается при отмене вызова. Если подсчет завершился
+	// ошибкой, то его повторяет один из ожидающих вызовов
+	for {
+		c.mu.Lock()
+		if !c.counted.IsZero() && time.Since(c.counted) < s.countCacheTTL() {
+			n := c.value
+			c.mu.Unlock()
+			return n, nil
+		}
+
+		done := c.done
+		if done == nil {
+			c.done = make(chan struct{})
+			c.mu.Unlock()
+			return s.countLinks(ctx)
+		}
+
+		c.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return 0, ErrDeadlineExceeded
+		case <-done:
+		}
+	}
+}
+
+// countLinks подсчитывает действующие ссылки, сохраняет результат в кэше и
+// сообщает о завершении подсчета вызовам, ожидающим его результата.
+func (s *GRPCServer) countLinks(ctx context.Context) (int64, error) {
+	c := &s.linkCount
+
+	start := time.Now()
+	var n int64
+	err := s.retry(ctx, "count_links", func(ctx context.Context) error {
+		return s.readDB().QueryRowContext(ctx, "SELECT count(*) FROM links WHERE expires_at IS NULL OR expires_at > $1;", start).Scan(&n)
+	})
+	s.observeQuery("count_links", start, "method", "Count")
+
+	c.mu.Lock()
+	if err == nil {
+		c.value, c.counted = n, time.Now()
+	}
+
+	close(c.done)
+	c.done = nil
+	c.mu.Unlock()
+
+	if err != nil {
+		return 0, s.requestError(ctx, "Count", err)
+	}
+
+	return n, nil
+}
+
+// countCacheTTL возвращает время, в течение которого метод Count возвращает
+// ранее подсчитанное количество ссылок.
+func (s *GRPCServer) countCacheTTL() time.Duration {
+	if s.CountCacheTTL > 0 {
+		return s.CountCacheTTL
+	}
+
+	return countCacheTTLDefault
+}
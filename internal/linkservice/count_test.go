@@ -0,0 +1,128 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCountCache(t *testing.T) {
+	// пока подсчитанное значение не устарело, база данных не используется,
+	// поэтому сервер не требует подключения
+	service := &GRPCServer{CountCacheTTL: time.Minute}
+	service.linkCount.value = 42
+	service.linkCount.counted = time.Now()
+
+	res, err := service.Count(context.Background(), &api.Empty{})
+	if err != nil {
+		t.Fatalf("Count method reported an error: %v", err)
+	}
+
+	if res.GetCount() != 42 {
+		t.Errorf("the cached count 42 was expected, but %d was received", res.GetCount())
+	}
+}
+
+func TestCountConcurrent(t *testing.T) {
+	release := make(chan struct{})
+	db := newMockDB(func(query string, args []driver.NamedValue) (mockResult, error) {
+		<-release
+		return mockResult{columns: []string{"count"}, rows: [][]driver.Value{{int64(7)}}}, nil
+	})
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	service.CountCacheTTL = time.Minute
+
+	const calls = 5
+	counts := make(chan int64, calls)
+
+	for i := 0; i < calls; i++ {
+		go func() {
+			res, err := service.Count(context.Background(), &api.Empty{})
+			if err != nil {
+				t.Errorf("Count method reported an error: %v", err)
+			}
+
+			counts <- res.GetCount()
+		}()
+	}
+
+	// вызов с истекшим сроком не дожидается выполняющегося подсчета
+	for {
+		service.linkCount.mu.Lock()
+		started := service.linkCount.done != nil
+		service.linkCount.mu.Unlock()
+
+		if started {
+			break
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := service.Count(ctx, &api.Empty{}); status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("the code %v was expected, but %v was received", codes.DeadlineExceeded, status.Code(err))
+	}
+
+	close(release)
+
+	for i := 0; i < calls; i++ {
+		if n := <-counts; n != 7 {
+			t.Errorf("the count 7 was expected, but %d was received", n)
+		}
+	}
+
+	// одновременные вызовы выполняют один подсчет
+	if queries := db.executed(); len(queries) != 1 {
+		t.Errorf("a single query was expected, but %d were executed", len(queries))
+	}
+}
+
+func TestCount(t *testing.T) {
+	// устанавливаем подключение к базе данных
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed connecting to the database: %v", err)
+	}
+
+	defer db.Close()
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	defer service.Close()
+
+	if _, err := service.Create(context.Background(), &api.URL{Url: "http://count.abc/" + generateRandomСharacters(6)}); err != nil {
+		t.Fatalf("Create method reported an error: %v", err)
+	}
+
+	var exp int64
+	if err := db.QueryRow("SELECT count(*) FROM links WHERE expires_at IS NULL OR expires_at > now();").Scan(&exp); err != nil {
+		t.Fatalf("failed to query the database: %v", err)
+	}
+
+	res, err := service.Count(context.Background(), &api.Empty{})
+	if err != nil {
+		t.Fatalf("Count method reported an error: %v", err)
+	}
+
+	if res.GetCount() < 1 || res.GetCount() > exp {
+		t.Errorf("the count %d was expected, but %d was received", exp, res.GetCount())
+	}
+}
@@ -0,0 +1,75 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql"
+	"net"
+	"unicode/utf8"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/ratelimit"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// максимальная длина сохраняемого значения user-agent в байтах; более длинные
+// значения обрезаются, чтобы клиент не мог раздуть таблицу ссылок
+const maxUserAgentLength = 512
+
+// creatorIP возвращает IP-адрес клиента, отправившего запрос с контекстом
+// ctx. Для запросов JSON/REST-шлюза, обращающегося к сервису по локальному
+// адресу, учитывается адрес его клиента из метаданных
+// ratelimit.ForwardedForKey так же, как при ограничении частоты запросов.
+// Если адрес клиента неизвестен или не является IP-адресом, например при
+// подключении через Unix-сокет, то возвращается недействительное значение,
+// которое сохраняется в базе данных как NULL.
+func creatorIP(ctx context.Context) sql.NullString {
+	host, ok := ratelimit.Forwarded(ctx, ratelimit.ForwardedForKey)
+	if !ok {
+		p, ok := peer.FromContext(ctx)
+		if !ok || p.Addr == nil {
+			return sql.NullString{}
+		}
+
+		var err error
+		if host, _, err = net.SplitHostPort(p.Addr.String()); err != nil {
+			host = p.Addr.String()
+		}
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return sql.NullString{}
+	}
+
+	return sql.NullString{String: ip.String(), Valid: true}
+}
+
+// creatorUserAgent возвращает значение метаданных user-agent запроса с
+// контекстом ctx, обрезанное до maxUserAgentLength байт. Для запросов
+// JSON/REST-шлюза вместо user-agent самого шлюза учитывается заголовок
+// User-Agent его клиента из метаданных ratelimit.ForwardedUserAgentKey. Если
+// метаданные не переданы, то возвращается недействительное значение.
+func creatorUserAgent(ctx context.Context) sql.NullString {
+	ua, ok := ratelimit.Forwarded(ctx, ratelimit.ForwardedUserAgentKey)
+	if !ok {
+		md, _ := metadata.FromIncomingContext(ctx)
+		values := md.Get("user-agent")
+		if len(values) == 0 || values[0] == "" {
+			return sql.NullString{}
+		}
+
+		ua = values[0]
+	}
+
+	if len(ua) > maxUserAgentLength {
+		// обрезаем по границе символа, чтобы не сохранить некорректный UTF-8
+		n := maxUserAgentLength
+		for n > 0 && !utf8.RuneStart(ua[n]) {
+			n--
+		}
+
+		ua = ua[:n]
+	}
+
+	return sql.NullString{String: ua, Valid: true}
+}
@@ -0,0 +1,175 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+	gateway "github.com/pavelzagorodnyuk/linkservice/internal/http"
+	"github.com/pavelzagorodnyuk/linkservice/internal/ratelimit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+func TestCreatorIP(t *testing.T) {
+	testCases := []struct {
+		name string
+		ctx  context.Context
+		exp  sql.NullString
+	}{
+		{name: "no_peer", ctx: context.Background()},
+		{name: "ipv4", ctx: peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("203.0.113.7"), Port: 51234}}),
+			exp: sql.NullString{String: "203.0.113.7", Valid: true}},
+		{name: "ipv6", ctx: peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("2001:db8::1"), Port: 51234}}),
+			exp: sql.NullString{String: "2001:db8::1", Valid: true}},
+		{name: "unix", ctx: peer.NewContext(context.Background(), &peer.Peer{Addr: &net.UnixAddr{Name: "/run/linkservice.sock", Net: "unix"}})},
+		// адрес, переданный шлюзом, учитывается только для запросов с
+		// локального адреса
+		{name: "forwarded", ctx: metadata.NewIncomingContext(peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 51234}}),
+			metadata.Pairs(ratelimit.ForwardedForKey, "203.0.113.7")), exp: sql.NullString{String: "203.0.113.7", Valid: true}},
+		{name: "forwarded_external", ctx: metadata.NewIncomingContext(peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("198.51.100.1"), Port: 51234}}),
+			metadata.Pairs(ratelimit.ForwardedForKey, "203.0.113.7")), exp: sql.NullString{String: "198.51.100.1", Valid: true}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if ip := creatorIP(testCase.ctx); ip != testCase.exp {
+				t.Errorf("the address %v was expected, but %v was received", testCase.exp, ip)
+			}
+		})
+	}
+}
+
+func TestCreatorUserAgent(t *testing.T) {
+	long := strings.Repeat("ж", maxUserAgentLength)
+
+	testCases := []struct {
+		name string
+		ctx  context.Context
+		exp  sql.NullString
+	}{
+		{name: "no_metadata", ctx: context.Background()},
+		{name: "no_user_agent", ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-api-key", "key"))},
+		{name: "user_agent", ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs("user-agent", "grpc-go/1.40.0")),
+			exp: sql.NullString{String: "grpc-go/1.40.0", Valid: true}},
+		// значение обрезается по границе двухбайтового символа
+		{name: "long", ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs("user-agent", long)),
+			exp: sql.NullString{String: long[:maxUserAgentLength], Valid: true}},
+		{name: "forwarded", ctx: metadata.NewIncomingContext(peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 51234}}),
+			metadata.Pairs("user-agent", "grpc-go/1.40.0", ratelimit.ForwardedUserAgentKey, "Mozilla/5.0")), exp: sql.NullString{String: "Mozilla/5.0", Valid: true}},
+		{name: "forwarded_external", ctx: metadata.NewIncomingContext(peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("198.51.100.1"), Port: 51234}}),
+			metadata.Pairs("user-agent", "grpc-go/1.40.0", ratelimit.ForwardedUserAgentKey, "Mozilla/5.0")), exp: sql.NullString{String: "grpc-go/1.40.0", Valid: true}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if ua := creatorUserAgent(testCase.ctx); ua != testCase.exp {
+				t.Errorf("the user agent %v was expected, but %v was received", testCase.exp, ua)
+			}
+		})
+	}
+}
+
+func TestCreateCreatorWithMockDB(t *testing.T) {
+	var ip, userAgent interface{}
+	db := newMockDB(func(query string, args []driver.NamedValue) (mockResult, error) {
+		switch {
+		case query == insertLinkQuery:
+			ip, userAgent = args[12].Value, args[13].Value
+			return linkRow(args[0].Value.(string)), nil
+		case strings.HasPrefix(query, "DELETE FROM links WHERE original_url"):
+			return mockResult{}, nil
+		default:
+			t.Fatalf("an unexpected query was received: %s", query)
+			return mockResult{}, nil
+		}
+	})
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("203.0.113.7"), Port: 51234}})
+	ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("user-agent", "grpc-go/1.40.0"))
+
+	if _, err := service.Create(ctx, &api.URL{Url: "http://creator.abc/"}); err != nil {
+		t.Fatalf("Create method reported an error: %v", err)
+	}
+
+	if ip != "203.0.113.7" || userAgent != "grpc-go/1.40.0" {
+		t.Errorf("the creator \"203.0.113.7\" with \"grpc-go/1.40.0\" was expected, but %v with %v was received", ip, userAgent)
+	}
+
+	// без сведений о клиенте ссылка создается с пустыми значениями
+	if _, err := service.Create(context.Background(), &api.URL{Url: "http://creator.abc/"}); err != nil {
+		t.Fatalf("Create method reported an error: %v", err)
+	}
+
+	if ip != nil || userAgent != nil {
+		t.Errorf("no creator was expected, but %v with %v was received", ip, userAgent)
+	}
+}
+
+func TestCreateCreatorThroughGateway(t *testing.T) {
+	var ip, userAgent interface{}
+	db := newMockDB(func(query string, args []driver.NamedValue) (mockResult, error) {
+		switch {
+		case query == insertLinkQuery:
+			ip, userAgent = args[12].Value, args[13].Value
+			return linkRow(args[0].Value.(string)), nil
+		case strings.HasPrefix(query, "DELETE FROM links WHERE original_url"):
+			return mockResult{}, nil
+		default:
+			t.Fatalf("an unexpected query was received: %s", query)
+			return mockResult{}, nil
+		}
+	})
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	// шлюз обращается к сервису по локальному адресу, как при запуске
+	// сервиса
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	srv := grpc.NewServer()
+	api.RegisterLinkServiceServer(srv, service)
+
+	go srv.Serve(l)
+	defer srv.Stop()
+
+	conn, err := grpc.Dial(l.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("failed to connect to the server: %v", err)
+	}
+
+	defer conn.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/links", strings.NewReader(`{"url": "http://creator.abc/"}`))
+	req.RemoteAddr = "203.0.113.7:51234"
+	req.Header.Set("User-Agent", "Mozilla/5.0 (X11; Linux x86_64)")
+
+	rec := httptest.NewRecorder()
+	gateway.NewGateway(conn).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("the code %v was expected, but %v was received: %s", http.StatusOK, rec.Code, rec.Body)
+	}
+
+	if ip != "203.0.113.7" || userAgent != "Mozilla/5.0 (X11; Linux x86_64)" {
+		t.Errorf("the creator \"203.0.113.7\" with \"Mozilla/5.0 (X11; Linux x86_64)\" was expected, but %v with %v was received", ip, userAgent)
+	}
+}
@@ -0,0 +1,34 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql"
+)
+
+// DB описывает методы базы данных, которые использует сервис. Ему
+// удовлетворяет *sql.DB, а в тестах — заглушка, не требующая подключения к
+// PostgreSQL.
+type DB interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+	PingContext(ctx context.Context) error
+}
+
+// preparer описывает метод подготовки запросов. Если база данных сервера его
+// не поддерживает, то запросы выполняются без подготовки.
+type preparer interface {
+	Prepare(query string) (*sql.Stmt, error)
+}
+
+// queryRow выполняет запрос query с аргументами args, возвращающий одну строку,
+// подготовленным запросом stmt, а если запрос не подготовлен — напрямую в
+// базе данных сервера.
+func (s *GRPCServer) queryRow(ctx context.Context, stmt *sql.Stmt, query string, args ...interface{}) *sql.Row {
+	if stmt != nil {
+		return stmt.QueryRowContext(ctx, args...)
+	}
+
+	return s.Database.QueryRowContext(ctx, query, args...)
+}
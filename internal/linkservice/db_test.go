@@ -0,0 +1,310 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+)
+
+// mockResult задает ответ заглушки базы данных на запрос: столбцы и строки
+// результата, а для изменяющих запросов — количество измененных строк.
+type mockResult struct {
+	columns  []string
+	rows     [][]driver.Value
+	affected int64
+}
+
+// mockDB — заглушка базы данных, позволяющая проверять методы сервиса без
+// PostgreSQL. Ответы на запросы возвращает функция handle, а выполненные
+// запросы сохраняются в порядке их выполнения. Строки *sql.Row и *sql.Rows
+// можно получить лишь от пакета database/sql, поэтому заглушка обращается к
+// нему через собственный драйвер.
+type mockDB struct {
+	db     *sql.DB
+	handle func(query string, args []driver.NamedValue) (mockResult, error)
+
+	mu      sync.Mutex
+	queries []string
+}
+
+// newMockDB создает заглушку базы данных, отвечающую на запросы функцией
+// handle.
+func newMockDB(handle func(query string, args []driver.NamedValue) (mockResult, error)) *mockDB {
+	m := &mockDB{handle: handle}
+	m.db = sql.OpenDB(mockConnector{m})
+
+	return m
+}
+
+func (m *mockDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return m.db.QueryRowContext(ctx, query, args...)
+}
+
+func (m *mockDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return m.db.ExecContext(ctx, query, args...)
+}
+
+func (m *mockDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return m.db.QueryContext(ctx, query, args...)
+}
+
+func (m *mockDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	return m.db.BeginTx(ctx, opts)
+}
+
+func (m *mockDB) PingContext(ctx context.Context) error {
+	return m.db.PingContext(ctx)
+}
+
+// executed возвращает выполненные заглушкой запросы.
+func (m *mockDB) executed() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]string(nil), m.queries...)
+}
+
+// run сохраняет запрос query и возвращает ответ на него.
+func (m *mockDB) run(query string, args []driver.NamedValue) (mockResult, error) {
+	m.mu.Lock()
+	m.queries = append(m.queries, query)
+	m.mu.Unlock()
+
+	return m.handle(query, args)
+}
+
+// mockConnector, mockConn, mockTx и mockRows реализуют драйвер database/sql,
+// передающий запросы заглушке
+type mockConnector struct{ m *mockDB }
+
+func (c mockConnector) Connect(context.Context) (driver.Conn, error) { return mockConn{c.m}, nil }
+func (c mockConnector) Driver() driver.Driver                        { return c }
+
+func (c mockConnector) Open(string) (driver.Conn, error) { return mockConn{c.m}, nil }
+
+type mockConn struct{ m *mockDB }
+
+func (c mockConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("mock: prepared statements are not supported")
+}
+
+func (c mockConn) Close() error              { return nil }
+func (c mockConn) Begin() (driver.Tx, error) { return mockTx{}, nil }
+
+func (c mockConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	res, err := c.m.run(query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mockRows{columns: res.columns, rows: res.rows}, nil
+}
+
+func (c mockConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	res, err := c.m.run(query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return driver.RowsAffected(res.affected), nil
+}
+
+type mockTx struct{}
+
+func (mockTx) Commit() error   { return nil }
+func (mockTx) Rollback() error { return nil }
+
+type mockRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *mockRows) Columns() []string { return r.columns }
+func (r *mockRows) Close() error      { return nil }
+
+func (r *mockRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+
+	return nil
+}
+
+// linkRow возвращает ответ на запрос, возвращающий короткую ссылку link.
+func linkRow(link string) mockResult {
+	return mockResult{columns: []string{"link"}, rows: [][]driver.Value{{link}}}
+}
+
+// urlRow возвращает ответ на запрос selectURLQuery для ссылки на URL url с
+// истекающим в момент expires сроком действия.
+func urlRow(url string, expires interface{}) mockResult {
+	return mockResult{
+		columns: []string{"original_url", "alphabet", "expires_at", "created_at", "limited", "version"},
+		rows:    [][]driver.Value{{url, nil, expires, time.Now(), false, int64(1)}},
+	}
+}
+
+func TestCreateWithMockDB(t *testing.T) {
+	errDB := errors.New("connection refused")
+
+	testCases := []struct {
+		name   string
+		insert func(args []driver.NamedValue) (mockResult, error)
+		exp    string
+		err    error
+	}{
+		{
+			name: "new_link",
+			insert: func(args []driver.NamedValue) (mockResult, error) {
+				return linkRow(args[0].Value.(string)), nil
+			},
+		},
+		{
+			name: "existing_link",
+			insert: func(args []driver.NamedValue) (mockResult, error) {
+				return linkRow("existing12"), nil
+			},
+			exp: "existing12",
+		},
+		{
+			name: "database_error",
+			insert: func(args []driver.NamedValue) (mockResult, error) {
+				return mockResult{}, errDB
+			},
+			err: ErrReqProc,
+		},
+		{
+			name: "collection_not_found",
+			insert: func(args []driver.NamedValue) (mockResult, error) {
+				return mockResult{}, &pq.Error{Code: foreignKeyViolation}
+			},
+			err: ErrCollectionNotFound,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			db := newMockDB(func(query string, args []driver.NamedValue) (mockResult, error) {
+				switch {
+				case query == insertLinkQuery:
+					return testCase.insert(args)
+				case strings.HasPrefix(query, "DELETE FROM links WHERE original_url"):
+					return mockResult{}, nil
+				default:
+					t.Fatalf("an unexpected query was received: %s", query)
+					return mockResult{}, nil
+				}
+			})
+
+			service, err := NewGRPCServer(db)
+			if err != nil {
+				t.Fatalf("failed to prepare the server: %v", err)
+			}
+
+			service.Logger = &recordLogger{}
+
+			link, err := service.Create(context.Background(), &api.URL{Url: "http://mock.abc/"})
+			if err = FromStatus(err); err != testCase.err {
+				t.Fatalf("an error with a value of \"%v\" was expected, but \"%v\" was received", testCase.err, err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if testCase.exp != "" && link.GetLink() != testCase.exp {
+				t.Errorf("the link \"%s\" was expected, but \"%s\" was received", testCase.exp, link.GetLink())
+			}
+
+			if !service.validLink(link.GetLink()) {
+				t.Errorf("a valid link was expected, but \"%s\" was received", link.GetLink())
+			}
+		})
+	}
+}
+
+func TestGetWithMockDB(t *testing.T) {
+	const link = "abcdefghij"
+	errDB := errors.New("connection refused")
+
+	testCases := []struct {
+		name   string
+		lookup func() (mockResult, error)
+		err    error
+	}{
+		{
+			name:   "found",
+			lookup: func() (mockResult, error) { return urlRow("http://mock.abc/", nil), nil },
+		},
+		{
+			name:   "not_found",
+			lookup: func() (mockResult, error) { return mockResult{columns: []string{"original_url"}}, nil },
+			err:    ErrURLNotFound,
+		},
+		{
+			name:   "expired",
+			lookup: func() (mockResult, error) { return urlRow("http://mock.abc/", time.Now().Add(-time.Hour)), nil },
+			err:    ErrLinkExpired,
+		},
+		{
+			name:   "database_error",
+			lookup: func() (mockResult, error) { return mockResult{}, errDB },
+			err:    ErrReqProc,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			db := newMockDB(func(query string, args []driver.NamedValue) (mockResult, error) {
+				switch {
+				case query == selectURLQuery:
+					return testCase.lookup()
+				case strings.HasPrefix(query, "UPDATE links SET visits"):
+					return mockResult{columns: []string{"expires_at"}, rows: [][]driver.Value{{nil}}}, nil
+				case strings.HasPrefix(query, "INSERT INTO link_hits"):
+					return mockResult{affected: 1}, nil
+				default:
+					t.Fatalf("an unexpected query was received: %s", query)
+					return mockResult{}, nil
+				}
+			})
+
+			service, err := NewGRPCServer(db)
+			if err != nil {
+				t.Fatalf("failed to prepare the server: %v", err)
+			}
+
+			service.Logger = &recordLogger{}
+
+			url, err := service.Get(context.Background(), &api.Link{Link: link})
+			if err = FromStatus(err); err != testCase.err {
+				t.Fatalf("an error with a value of \"%v\" was expected, but \"%v\" was received", testCase.err, err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if url.GetUrl() != "http://mock.abc/" {
+				t.Errorf("the URL \"http://mock.abc/\" was expected, but \"%s\" was received", url.GetUrl())
+			}
+
+			// переход по ссылке учитывается в счетчике и в почасовой статистике
+			if queries := db.executed(); len(queries) != 3 {
+				t.Errorf("3 queries were expected, but %d were executed: %q", len(queries), queries)
+			}
+		})
+	}
+}
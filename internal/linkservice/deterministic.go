@@ -0,0 +1,95 @@
+package linkservice
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"fmt"
+	"math/big"
+	"regexp"
+	"time"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+)
+
+// digestLength — длина хеша SHA-256 в base62: 256-битное число занимает не
+// более 43 символов
+const digestLength = 43
+
+// deterministicTemplate описывает короткие ссылки, получаемые при
+// Deterministic: начало хеша URL в base62 длиной до maxLinkLength символов
+var deterministicTemplate = regexp.MustCompile(fmt.Sprintf(`^[0-9A-Za-z]{1,%d}$`, maxLinkLength))
+
+// urlDigest возвращает хеш SHA-256 URL u, записанный в base62 и дополненный
+// слева нулями до digestLength символов.
+func urlDigest(u string) string {
+	sum := sha256.Sum256([]byte(u))
+	n := new(big.Int).SetBytes(sum[:])
+
+	base := big.NewInt(int64(len(base62Alphabet)))
+	mod := new(big.Int)
+
+	var buf [digestLength]byte
+	for i := len(buf) - 1; i >= 0; i-- {
+		n.DivMod(n, base, mod)
+		buf[i] = base62Alphabet[mod.Int64()]
+	}
+
+	return string(buf[:])
+}
+
+// deterministic сообщает, получается ли короткая ссылка для запроса req из
+// хеша URL. Запросы с указанным алфавитом обрабатываются обычным образом.
+func (s *GRPCServer) deterministic(req *api.URL) bool {
+	return s.CodeStrategy == Deterministic && req.GetAlphabet() == ""
+}
+
+// insertDeterministic добавляет запись для URL из запроса req и возвращает ее
+// короткую ссылку — первые LinkLength символов хеша URL в base62. Если ссылка
+// занята другим URL, то она удлиняется на один символ хеша, но не более чем до
+// maxLinkLength символов. Поэтому один и тот же URL всегда получает одну и ту
+// же ссылку без предварительного поиска в базе данных. Если дедупликация
+// включена и для URL запись уже существует, то возвращается ее короткая
+// ссылка, а второе значение равно false.
+func (s *GRPCServer) insertDeterministic(ctx context.Context, db queryRower, req *api.URL) (string, bool, error) {
+	digest := urlDigest(req.GetUrl())
+
+	collisions := 0
+	defer func() { s.observeCollisions("insert_deterministic", collisions, "url", req.GetUrl()) }()
+
+	for length := s.linkLength(); length <= maxLinkLength; length++ {
+		link := digest[:length]
+		candidate := link
+
+		// зарезервированные ссылки и ссылки с запрещенными словами не
+		// сохраняются, и ссылка сразу удлиняется
+		if s.isReserved(link) || s.isBlocked(link) {
+			collisions++
+			continue
+		}
+
+		start := time.Now()
+		err := db.QueryRowContext(ctx, "WITH inserted AS (INSERT INTO links (link, original_url, expires_at, collection_id, deduplicated, title, owner_id, sliding_ttl_seconds, max_uses, tags, namespace, creator_ip, creator_user_agent) "+
+			"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) ON CONFLICT DO NOTHING RETURNING link) "+
+			"SELECT link FROM inserted UNION ALL SELECT link FROM links WHERE $5 AND deduplicated AND namespace = $11 AND original_url = $2 LIMIT 1;",
+			link, req.GetUrl(), expiresAt(req), collectionID(req), s.deduplicate(req), nullString(req.GetTitle()), nullString(req.GetOwnerId()), slidingTTL(req), maxUses(req), tagsArray(req), req.GetNamespace(),
+			creatorIP(ctx), creatorUserAgent(ctx)).Scan(&link)
+		s.observeQuery("insert_deterministic", start, "url", req.GetUrl())
+
+		if err == nil {
+			return link, link == candidate, nil
+		}
+
+		if _, ok := violation(err, foreignKeyViolation); ok {
+			return "", false, ErrCollectionNotFound
+		}
+
+		if err != sql.ErrNoRows {
+			return "", false, err
+		}
+
+		collisions++
+	}
+
+	return "", false, fmt.Errorf("no free link was found among the prefixes of the digest %s", digest)
+}
@@ -0,0 +1,99 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+)
+
+func TestURLDigest(t *testing.T) {
+	digest := urlDigest("http://deterministic.abc/")
+
+	if len(digest) != digestLength {
+		t.Fatalf("a digest of %d characters was expected, but \"%s\" was received", digestLength, digest)
+	}
+
+	if !inAlphabet(base62Alphabet, digest) {
+		t.Errorf("the digest \"%s\" contains characters outside base62", digest)
+	}
+
+	if again := urlDigest("http://deterministic.abc/"); again != digest {
+		t.Errorf("the same digest \"%s\" was expected, but \"%s\" was received", digest, again)
+	}
+
+	if other := urlDigest("http://deterministic.abc/other"); other[:10] == digest[:10] {
+		t.Errorf("different prefixes were expected for different URLs, but \"%s\" was received for both", digest[:10])
+	}
+}
+
+func TestValidLinkDeterministic(t *testing.T) {
+	service := &GRPCServer{CodeStrategy: Deterministic}
+	digest := urlDigest("http://deterministic.abc/")
+
+	if link := digest[:maxLinkLength]; !service.validLink(link) {
+		t.Errorf("the extended link \"%s\" was expected to be valid", link)
+	}
+
+	if link := digest[:maxLinkLength+1]; service.validLink(link) {
+		t.Errorf("the link \"%s\" longer than %d characters was expected to be invalid", link, maxLinkLength)
+	}
+}
+
+func TestCreateDeterministic(t *testing.T) {
+	// устанавливаем подключение к базе данных
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed connecting to the database: %v", err)
+	}
+
+	defer db.Close()
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	defer service.Close()
+
+	service.CodeStrategy = Deterministic
+
+	url := "http://deterministic.abc/" + generateRandomСharacters(8)
+	digest := urlDigest(url)
+
+	link, err := service.Create(context.Background(), &api.URL{Url: url})
+	if err != nil {
+		t.Fatalf("Create method reported an error: %v", err)
+	}
+
+	if exp := digest[:service.linkLength()]; link.GetLink() != exp {
+		t.Errorf("the link \"%s\" was expected, but \"%s\" was received", exp, link.GetLink())
+	}
+
+	// ссылка на уже занятое начало хеша удлиняется
+	service.AllowDuplicates = true
+
+	extended, err := service.Create(context.Background(), &api.URL{Url: url})
+	if err != nil {
+		t.Fatalf("Create method reported an error: %v", err)
+	}
+
+	if exp := digest[:service.linkLength()+1]; extended.GetLink() != exp {
+		t.Errorf("the link \"%s\" was expected, but \"%s\" was received", exp, extended.GetLink())
+	}
+
+	u, err := service.Get(context.Background(), extended)
+	if err != nil {
+		t.Fatalf("Get method reported an error: %v", err)
+	}
+
+	if u.GetUrl() != url {
+		t.Errorf("the URL \"%s\" was expected, but \"%s\" was received", url, u.GetUrl())
+	}
+
+	if !strings.HasPrefix(extended.GetLink(), link.GetLink()) {
+		t.Errorf("the link \"%s\" was expected to extend \"%s\"", extended.GetLink(), link.GetLink())
+	}
+}
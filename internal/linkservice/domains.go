@@ -0,0 +1,71 @@
+package linkservice
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// matchDomain сообщает, соответствует ли хост host шаблону pattern. Шаблон
+// вида "*.example.com" соответствует любому поддомену example.com, но не
+// самому домену; шаблон без "*" соответствует только указанному хосту.
+// Регистр символов и завершающая точка хоста не учитываются.
+func matchDomain(pattern, host string) bool {
+	pattern = strings.ToLower(pattern)
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+
+	if suffix := strings.TrimPrefix(pattern, "*"); suffix != pattern {
+		return len(host) > len(suffix) && strings.HasSuffix(host, suffix)
+	}
+
+	return host == pattern
+}
+
+// matchAnyDomain сообщает, соответствует ли хост host хотя бы одному из
+// шаблонов patterns.
+func matchAnyDomain(patterns []string, host string) bool {
+	for _, pattern := range patterns {
+		if matchDomain(pattern, host) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkDomain проверяет, разрешено ли создавать короткие ссылки на хост URL
+// u. Хосты из DeniedDomains запрещены, даже если они указаны в
+// AllowedDomains; если AllowedDomains задан, то разрешены только указанные в
+// нем хосты. Запрещенные URL отклоняются с ошибкой ErrDomainNotAllowed.
+func (s *GRPCServer) checkDomain(u string) error {
+	if len(s.DeniedDomains) == 0 && len(s.AllowedDomains) == 0 {
+		return nil
+	}
+
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return ErrInvalidURL
+	}
+
+	host := parsed.Hostname()
+
+	if matchAnyDomain(s.DeniedDomains, host) {
+		return ErrDomainNotAllowed
+	}
+
+	if len(s.AllowedDomains) > 0 && !matchAnyDomain(s.AllowedDomains, host) {
+		return ErrDomainNotAllowed
+	}
+
+	return nil
+}
+
+// validateDomainPattern проверяет шаблон хоста pattern: символ "*" допускается
+// только в начале шаблона перед точкой.
+func validateDomainPattern(pattern string) error {
+	if strings.Contains(strings.TrimPrefix(pattern, "*."), "*") || strings.Trim(pattern, "*.") == "" {
+		return fmt.Errorf("linkservice: invalid domain pattern %q", pattern)
+	}
+
+	return nil
+}
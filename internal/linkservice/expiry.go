@@ -0,0 +1,120 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+)
+
+// expiresAt возвращает время истечения срока действия короткой ссылки,
+// создаваемой запросом req. Для ссылок со скользящим временем жизни оно
+// отсчитывается от момента создания так же, как фиксированное. Если время
+// жизни ссылки в запросе не задано, то возвращается недействительное
+// значение, которое сохраняется в базе данных как NULL — ссылка действует
+// бессрочно.
+func expiresAt(req *api.URL) sql.NullTime {
+	ttl := req.GetTtlSeconds()
+	if req.GetSlidingTtlSeconds() > 0 {
+		ttl = req.GetSlidingTtlSeconds()
+	}
+
+	if ttl <= 0 {
+		return sql.NullTime{}
+	}
+
+	return sql.NullTime{
+		Time:  time.Now().Add(time.Duration(ttl) * time.Second),
+		Valid: true,
+	}
+}
+
+// slidingTTL возвращает скользящее время жизни (в секундах) короткой ссылки,
+// создаваемой запросом req, в том виде, в котором оно хранится в столбце
+// sliding_ttl_seconds. Если оно не задано, то возвращается недействительное
+// значение.
+func slidingTTL(req *api.URL) sql.NullInt64 {
+	if req.GetSlidingTtlSeconds() <= 0 {
+		return sql.NullInt64{}
+	}
+
+	return sql.NullInt64{Int64: req.GetSlidingTtlSeconds(), Valid: true}
+}
+
+// checkTTL проверяет время жизни короткой ссылки, указанное в запросе req.
+// Время жизни не может быть отрицательным, а фиксированное и скользящее время
+// жизни не могут быть заданы одновременно.
+func checkTTL(req *api.URL) error {
+	if req.GetTtlSeconds() < 0 || req.GetSlidingTtlSeconds() < 0 {
+		return ErrInvalidTTL
+	}
+
+	if req.GetTtlSeconds() > 0 && req.GetSlidingTtlSeconds() > 0 {
+		return ErrInvalidTTL
+	}
+
+	return nil
+}
+
+// expired сообщает, истек ли срок действия короткой ссылки, действующей до
+// момента expires.
+func expired(expires sql.NullTime) bool {
+	return expires.Valid && !expires.Time.After(time.Now())
+}
+
+// deleteExpiredURL удаляет запись с истекшим сроком действия для URL url в
+// пространстве имен namespace, чтобы для него можно было создать новую
+// короткую ссылку, не дожидаясь периодической очистки.
+func deleteExpiredURL(ctx context.Context, db execer, namespace, url string) error {
+	_, err := db.ExecContext(ctx, "DELETE FROM links WHERE original_url = $1 AND namespace = $2 AND expires_at <= $3;", url, namespace, time.Now())
+	return err
+}
+
+// execer описывает общий для *sql.DB и *sql.Tx метод выполнения запросов,
+// не возвращающих строк.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// PurgeExpired с интервалом PurgeInterval удаляет из базы данных записи с
+// истекшим сроком действия. Метод блокируется до отмены контекста ctx, поэтому
+// его следует запускать в отдельной горутине. Если PurgeInterval не задан, то
+// метод сразу завершается.
+func (s *GRPCServer) PurgeExpired(ctx context.Context) {
+	if s.PurgeInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.PurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			n, err := s.purgeExpired(ctx)
+			if err != nil {
+				s.logger().Error("failed to purge expired links", "error", err)
+				continue
+			}
+
+			if n > 0 {
+				s.logger().Info("expired links purged", "count", n)
+			}
+		}
+	}
+}
+
+// purgeExpired удаляет из базы данных записи с истекшим сроком действия и
+// возвращает их количество.
+func (s *GRPCServer) purgeExpired(ctx context.Context) (int64, error) {
+	res, err := s.Database.ExecContext(ctx, "DELETE FROM links WHERE expires_at <= $1;", time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
@@ -0,0 +1,146 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+)
+
+func TestExpiry(t *testing.T) {
+	// устанавливаем подключение к базе данных
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed connecting to the database: %v", err)
+	}
+
+	defer db.Close()
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	defer service.Close()
+	url := "http://expiry.abc/" + generateRandomСharacters(6)
+
+	link, err := service.Create(context.Background(), &api.URL{Url: url, TtlSeconds: 3600})
+	if err != nil {
+		t.Fatalf("Create method reported an error: %v", err)
+	}
+
+	// пока срок действия не истек, ссылка должна разрешаться
+	if _, err := service.Get(context.Background(), link); err != nil {
+		t.Fatalf("Get method reported an error: %v", err)
+	}
+
+	// переносим срок действия ссылки в прошлое
+	_, err = db.Exec("UPDATE links SET expires_at = now() - interval '1 minute' WHERE link = $1;", link.GetLink())
+	if err != nil {
+		t.Fatalf("failed to update the database: %v", err)
+	}
+
+	_, err = service.Get(context.Background(), link)
+	if err = FromStatus(err); err != ErrLinkExpired {
+		t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrLinkExpired, err)
+	}
+
+	// очистка должна удалить запись с истекшим сроком действия
+	if _, err := service.purgeExpired(context.Background()); err != nil {
+		t.Fatalf("failed to purge expired links: %v", err)
+	}
+
+	var count int
+	if err := db.QueryRow("SELECT count(*) FROM links WHERE link = $1;", link.GetLink()).Scan(&count); err != nil {
+		t.Fatalf("failed to query the database: %v", err)
+	}
+
+	if count != 0 {
+		t.Errorf("it was expected that the expired link would be purged")
+	}
+
+	_, err = service.Create(context.Background(), &api.URL{Url: url, TtlSeconds: -1})
+	if err = FromStatus(err); err != ErrInvalidTTL {
+		t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrInvalidTTL, err)
+	}
+}
+
+func TestCheckTTL(t *testing.T) {
+	testCases := []struct {
+		name string
+		req  *api.URL
+		exp  error
+	}{
+		{name: "none", req: &api.URL{}},
+		{name: "fixed", req: &api.URL{TtlSeconds: 60}},
+		{name: "sliding", req: &api.URL{SlidingTtlSeconds: 60}},
+		{name: "negative_fixed", req: &api.URL{TtlSeconds: -1}, exp: ErrInvalidTTL},
+		{name: "negative_sliding", req: &api.URL{SlidingTtlSeconds: -1}, exp: ErrInvalidTTL},
+		{name: "both", req: &api.URL{TtlSeconds: 60, SlidingTtlSeconds: 60}, exp: ErrInvalidTTL},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if err := checkTTL(testCase.req); err != testCase.exp {
+				t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", testCase.exp, err)
+			}
+		})
+	}
+
+	// начальный срок действия ссылки со скользящим временем жизни
+	// отсчитывается от момента создания
+	if expires := expiresAt(&api.URL{SlidingTtlSeconds: 60}); !expires.Valid || time.Until(expires.Time) > time.Minute {
+		t.Errorf("an expiration time within a minute was expected, but %v was received", expires)
+	}
+}
+
+func TestSlidingTTL(t *testing.T) {
+	// устанавливаем подключение к базе данных
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed connecting to the database: %v", err)
+	}
+
+	defer db.Close()
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	defer service.Close()
+	url := "http://expiry.abc/sliding/" + generateRandomСharacters(6)
+
+	link, err := service.Create(context.Background(), &api.URL{Url: url, SlidingTtlSeconds: 3600})
+	if err != nil {
+		t.Fatalf("Create method reported an error: %v", err)
+	}
+
+	// приближаем срок действия ссылки, который переход должен продлить
+	_, err = db.Exec("UPDATE links SET expires_at = now() + interval '1 minute' WHERE link = $1;", link.GetLink())
+	if err != nil {
+		t.Fatalf("failed to update the database: %v", err)
+	}
+
+	if _, err := service.Get(context.Background(), link); err != nil {
+		t.Fatalf("Get method reported an error: %v", err)
+	}
+
+	var expires time.Time
+	var visits int64
+	err = db.QueryRow("SELECT expires_at, visits FROM links WHERE link = $1;", link.GetLink()).Scan(&expires, &visits)
+	if err != nil {
+		t.Fatalf("failed to query the database: %v", err)
+	}
+
+	if time.Until(expires) < 59*time.Minute {
+		t.Errorf("the expiration time was expected to be extended by an hour, but it is %v", expires)
+	}
+
+	if visits != 1 {
+		t.Errorf("1 visit was expected, but %d were counted", visits)
+	}
+}
@@ -0,0 +1,114 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// количество ссылок, которые метод Export запрашивает из базы данных одним
+// запросом
+var exportBatchSize = 1000
+
+// Export передает клиенту в потоке все короткие ссылки указанного в запросе
+// пространства имен, в том числе с истекшим сроком действия, вместе с их
+// оригинальными URL, временем создания, количеством переходов, временем
+// последнего перехода и пространством имен, например для резервного
+// копирования. Ссылки передаются в порядке возрастания и запрашиваются из базы
+// данных частями по exportBatchSize, поэтому таблица не загружается в память
+// целиком. Каждая часть выбирается отдельным запросом, так что ссылки,
+// добавленные или удаленные во время экспорта, могут как попасть в него, так и
+// нет. Экспорт прекращается при отмене вызова клиентом.
+//
+// Ошибки передаются клиенту с кодами состояния gRPC: ErrInvalidNamespace —
+// codes.InvalidArgument, ErrDeadlineExceeded — codes.DeadlineExceeded,
+// ErrReqProc — codes.Internal.
+func (s *GRPCServer) Export(req *api.ExportRequest, stream api.LinkService_ExportServer) error {
+	return statusError(s.export(req.GetNamespace(), stream))
+}
+
+// export реализует метод Export для пространства имен namespace, возвращая
+// ошибки сервиса без преобразования в ошибки gRPC.
+func (s *GRPCServer) export(namespace string, stream api.LinkService_ExportServer) error {
+	if err := checkNamespace(namespace); err != nil {
+		return err
+	}
+
+	ctx := stream.Context()
+
+	// каждая часть начинается после последней ссылки предыдущей части,
+	// поэтому выбирается по первичному ключу без смещения
+	after := ""
+
+	for {
+		if ctx.Err() != nil {
+			return ErrDeadlineExceeded
+		}
+
+		batch, err := s.exportBatch(ctx, namespace, after)
+		if err != nil {
+			return s.requestError(ctx, "Export", err, "namespace", namespace, "after", after)
+		}
+
+		for _, link := range batch {
+			if err := stream.Send(link); err != nil {
+				return s.requestError(ctx, "Export", err, "link", link.GetLink())
+			}
+		}
+
+		if len(batch) < exportBatchSize {
+			return nil
+		}
+
+		after = batch[len(batch)-1].GetLink()
+	}
+}
+
+// exportBatch возвращает не более exportBatchSize ссылок пространства имен
+// namespace, следующих за короткой ссылкой after.
+func (s *GRPCServer) exportBatch(ctx context.Context, namespace, after string) ([]*api.ExportedLink, error) {
+	start := time.Now()
+	defer s.observeQuery("export_links", start, "method", "Export", "after", after)
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.readDB().QueryContext(ctx, "SELECT link, original_url, created_at, visits, last_accessed_at FROM links WHERE namespace = $1 AND link > $2 ORDER BY link LIMIT $3;",
+		namespace, after, exportBatchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	batch := make([]*api.ExportedLink, 0, exportBatchSize)
+	for rows.Next() {
+		var link, url string
+		var createdAt time.Time
+		var visits int64
+		var lastAccessed sql.NullTime
+
+		if err := rows.Scan(&link, &url, &createdAt, &visits, &lastAccessed); err != nil {
+			return nil, err
+		}
+
+		exported := &api.ExportedLink{
+			Link:      link,
+			Url:       url,
+			CreatedAt: timestamppb.New(createdAt),
+			Visits:    visits,
+			Namespace: namespace,
+		}
+
+		if lastAccessed.Valid {
+			exported.LastAccessedAt = timestamppb.New(lastAccessed.Time)
+		}
+
+		batch = append(batch, exported)
+	}
+
+	return batch, rows.Err()
+}
@@ -0,0 +1,98 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+	"google.golang.org/grpc"
+)
+
+// exportStream запоминает ссылки, переданные методом Export
+type exportStream struct {
+	grpc.ServerStream
+
+	ctx   context.Context
+	links []*api.ExportedLink
+}
+
+func (s *exportStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *exportStream) Send(link *api.ExportedLink) error {
+	s.links = append(s.links, link)
+	return nil
+}
+
+func TestExportCancelled(t *testing.T) {
+	// отмененный вызов завершается до обращения к базе данных, поэтому сервер
+	// не требует подключения
+	service := &GRPCServer{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := FromStatus(service.Export(&api.ExportRequest{}, &exportStream{ctx: ctx})); err != ErrDeadlineExceeded {
+		t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrDeadlineExceeded, err)
+	}
+}
+
+func TestExport(t *testing.T) {
+	// устанавливаем подключение к базе данных
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed connecting to the database: %v", err)
+	}
+
+	defer db.Close()
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	defer service.Close()
+
+	link, err := service.Create(context.Background(), &api.URL{Url: "http://export.abc/" + generateRandomСharacters(6)})
+	if err != nil {
+		t.Fatalf("Create method reported an error: %v", err)
+	}
+
+	if _, err := service.Get(context.Background(), link); err != nil {
+		t.Fatalf("Get method reported an error: %v", err)
+	}
+
+	// маленький размер части проверяет переход между частями
+	defer func(size int) { exportBatchSize = size }(exportBatchSize)
+	exportBatchSize = 2
+
+	stream := &exportStream{ctx: context.Background()}
+	if err := service.Export(&api.ExportRequest{}, stream); err != nil {
+		t.Fatalf("Export method reported an error: %v", err)
+	}
+
+	var found *api.ExportedLink
+	seen := make(map[string]bool, len(stream.links))
+
+	for _, exported := range stream.links {
+		if seen[exported.GetLink()] {
+			t.Fatalf("the link \"%s\" was exported more than once", exported.GetLink())
+		}
+
+		seen[exported.GetLink()] = true
+
+		if exported.GetLink() == link.GetLink() {
+			found = exported
+		}
+	}
+
+	if found == nil {
+		t.Fatalf("the link \"%s\" was not exported", link.GetLink())
+	}
+
+	if found.GetVisits() != 1 || found.GetCreatedAt() == nil {
+		t.Errorf("one visit and the creation time were expected, but %d visits and %v were received", found.GetVisits(), found.GetCreatedAt())
+	}
+}
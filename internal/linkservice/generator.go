@@ -0,0 +1,53 @@
+package linkservice
+
+// CodeGenerator генерирует короткие ссылки длиной length. Сервер отбрасывает
+// зарезервированные ссылки и ссылки, содержащие слова из BlockedWords, а при
+// коллизии с занятой ссылкой запрашивает новую, поэтому генератор, все время
+// возвращающий одну и ту же ссылку, не позволит создать больше одной ссылки.
+type CodeGenerator interface {
+	Generate(length int) string
+}
+
+// CodeGeneratorFunc позволяет использовать обычную функцию в качестве
+// CodeGenerator, например детерминированный генератор в тестах.
+type CodeGeneratorFunc func(length int) string
+
+// Generate вызывает f(length).
+func (f CodeGeneratorFunc) Generate(length int) string {
+	return f(length)
+}
+
+// RandomCodes генерирует короткие ссылки из случайных символов Alphabet так
+// же, как generateRandomСharacters для алфавита по умолчанию. Символы
+// выбираются криптографически стойким генератором.
+type RandomCodes struct {
+	Alphabet string
+}
+
+// Generate возвращает строку длиной length случайных символов Alphabet.
+func (g RandomCodes) Generate(length int) string {
+	return generateFromAlphabet(g.Alphabet, length)
+}
+
+// codeGenerator возвращает генератор коротких ссылок алфавита по умолчанию.
+// Если CodeGenerator не задан, то ссылки составляются из случайных символов
+// этого алфавита.
+func (s *GRPCServer) codeGenerator() CodeGenerator {
+	if s.CodeGenerator != nil {
+		return s.CodeGenerator
+	}
+
+	return RandomCodes{Alphabet: s.alphabets()[""]}
+}
+
+// generateCode возвращает ссылку из символов alphabet без проверок
+// generateLink. Ссылки алфавита по умолчанию получаются от codeGenerator, а
+// ссылки алфавитов, выбранных клиентом, всегда составляются из случайных
+// символов выбранного алфавита.
+func (s *GRPCServer) generateCode(alphabet string) string {
+	if alphabet == s.alphabets()[""] {
+		return s.codeGenerator().Generate(s.linkLength())
+	}
+
+	return generateFromAlphabet(alphabet, s.linkLength())
+}
@@ -0,0 +1,84 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+)
+
+func TestRandomCodes(t *testing.T) {
+	service := &GRPCServer{Alphabet: "23456789abcdefghjkmnpqrstuvwxyz"}
+
+	// по умолчанию ссылки по-прежнему составляются из алфавита сервера
+	for i := 0; i < 100; i++ {
+		link := service.codeGenerator().Generate(service.linkLength())
+		if len([]rune(link)) != service.linkLength() || !inAlphabet(service.Alphabet, link) {
+			t.Fatalf("a link of %d characters of the alphabet was expected, but \"%s\" was received", service.linkLength(), link)
+		}
+	}
+}
+
+func TestCodeGeneratorWithMockDB(t *testing.T) {
+	// детерминированный генератор: первая ссылка занята, вторая свободна
+	codes := []string{"code.00001", "code.00002"}
+	next := 0
+	generator := CodeGeneratorFunc(func(length int) string {
+		code := codes[next%len(codes)]
+		next++
+		return code
+	})
+
+	var attempts []string
+	db := newMockDB(func(query string, args []driver.NamedValue) (mockResult, error) {
+		switch {
+		case query == insertLinkQuery:
+			link := args[0].Value.(string)
+			attempts = append(attempts, link)
+
+			if link == "code.00001" {
+				return mockResult{columns: []string{"link"}}, nil
+			}
+
+			return linkRow(link), nil
+		case strings.HasPrefix(query, "DELETE FROM links WHERE original_url"):
+			return mockResult{}, nil
+		default:
+			t.Fatalf("an unexpected query was received: %s", query)
+			return mockResult{}, nil
+		}
+	})
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	service.CodeGenerator = generator
+	service.LinkPattern = regexp.MustCompile(`^code\.[0-9]{5}$`)
+
+	if err := service.Validate(); err != nil {
+		t.Fatalf("the configuration was expected to be valid, but the error \"%v\" was received", err)
+	}
+
+	next = 0
+	link, err := service.Create(context.Background(), &api.URL{Url: "http://generator.abc/"})
+	if err != nil {
+		t.Fatalf("Create method reported an error: %v", err)
+	}
+
+	if link.GetLink() != "code.00002" || fmt.Sprint(attempts) != "[code.00001 code.00002]" {
+		t.Errorf("the link \"code.00002\" after a collision was expected, but \"%s\" was received after %v", link.GetLink(), attempts)
+	}
+
+	// ссылки, которые не принимались бы в запросах, отклоняются при проверке
+	// конфигурации
+	service.LinkPattern = nil
+	if err := service.Validate(); err == nil {
+		t.Errorf("an error was expected for a generator producing links outside the alphabet")
+	}
+}
@@ -0,0 +1,106 @@
+package linkservice
+
+import (
+	"context"
+	"time"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// intervals сопоставляет интервалам группировки переходов аргументы функции
+// date_trunc в PostgreSQL
+var intervals = map[api.Interval]string{
+	api.Interval_HOUR: "hour",
+	api.Interval_DAY:  "day",
+}
+
+// recordHit учитывает переход по короткой ссылке link из пространства имен
+// namespace в почасовой статистике.
+func (s *GRPCServer) recordHit(ctx context.Context, namespace, link string) error {
+	defer s.observeQuery("record_hit", time.Now(), "method", "Get", "namespace", namespace, "link", link)
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.Database.ExecContext(ctx, `INSERT INTO link_hits (namespace, link, hour, hits) VALUES ($1, $2, date_trunc('hour', now()), 1)
+		ON CONFLICT (namespace, link, hour) DO UPDATE SET hits = link_hits.hits + 1;`, namespace, link)
+	return err
+}
+
+// HitsOverTime возвращает количество переходов по коротким ссылкам за период
+// [from, to), сгруппированное по часам или по дням. Если в запросе указана
+// короткая ссылка, то учитываются только переходы по ней в указанном
+// пространстве имен, а если указан тег — только переходы по ссылкам этого
+// пространства имен с этим тегом. Тег сравнивается так же, как в методе
+// ListByTag. Интервалы без переходов в ответ не включаются.
+// Некорректный период передается клиенту как ошибка ErrInvalidTimeRange, а
+// некорректное пространство имен — как ErrInvalidNamespace с кодом состояния
+// codes.InvalidArgument. Прерванные по истечении срока запросы передаются как
+// ошибка ErrDeadlineExceeded с кодом codes.DeadlineExceeded.
+func (s *GRPCServer) HitsOverTime(ctx context.Context, req *api.TimeRangeRequest) (*api.TimeSeriesResponse, error) {
+	series, err := s.hitsOverTime(ctx, req)
+	return series, statusError(err)
+}
+
+// hitsOverTime реализует метод HitsOverTime, возвращая ошибки сервиса без
+// преобразования в ошибки gRPC.
+func (s *GRPCServer) hitsOverTime(ctx context.Context, req *api.TimeRangeRequest) (*api.TimeSeriesResponse, error) {
+	interval, ok := intervals[req.GetInterval()]
+	if !ok || req.GetFrom() == nil || req.GetTo() == nil {
+		return nil, ErrInvalidTimeRange
+	}
+
+	from, to := req.GetFrom().AsTime(), req.GetTo().AsTime()
+	if !from.Before(to) {
+		return nil, ErrInvalidTimeRange
+	}
+
+	if err := checkNamespace(req.GetNamespace()); err != nil {
+		return nil, err
+	}
+
+	// пустой тег не может быть сохранен, поэтому ему не соответствует ни одна
+	// ссылка
+	tag := normalizeTag(req.GetTag())
+	if tag == "" && req.GetTag() != "" {
+		return &api.TimeSeriesResponse{}, nil
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	// теги хранятся в таблице links, поэтому переходы по ссылкам с тегом
+	// отбираются соединением с ней по первичному ключу
+	rows, err := s.readDB().QueryContext(ctx, `SELECT date_trunc($1, h.hour) AS bucket, sum(h.hits) FROM link_hits h
+		WHERE h.hour >= $2 AND h.hour < $3 AND ($4 = '' AND $6 = '' OR h.namespace = $5) AND ($4 = '' OR h.link = $4)
+		AND ($6 = '' OR EXISTS (SELECT 1 FROM links l WHERE l.namespace = h.namespace AND l.link = h.link AND l.tags @> ARRAY[$6]::text[]))
+		GROUP BY bucket ORDER BY bucket;`, interval, from, to, req.GetLink(), req.GetNamespace(), tag)
+	if err != nil {
+		return nil, s.requestError(ctx, "HitsOverTime", err, "link", req.GetLink())
+	}
+
+	defer rows.Close()
+
+	res := &api.TimeSeriesResponse{}
+
+	for rows.Next() {
+		var start time.Time
+		var hits int64
+
+		if err := rows.Scan(&start, &hits); err != nil {
+			return nil, s.requestError(ctx, "HitsOverTime", err, "link", req.GetLink())
+		}
+
+		res.Points = append(res.Points, &api.TimeSeriesPoint{
+			Start: timestamppb.New(start),
+			Hits:  hits,
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, s.requestError(ctx, "HitsOverTime", err, "link", req.GetLink())
+	}
+
+	return res, nil
+}
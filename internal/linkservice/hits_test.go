@@ -0,0 +1,176 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestHitsOverTime(t *testing.T) {
+	// устанавливаем подключение к базе данных
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed connecting to the database: %v", err)
+	}
+
+	defer db.Close()
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	defer service.Close()
+
+	// заполняем статистику переходов по ссылке, которой нет в других тестах
+	link := generateRandomСharacters(lengthLink)
+	day := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	seeds := []struct {
+		hour time.Time
+		hits int64
+	}{
+		{day.Add(10 * time.Hour), 3},
+		{day.Add(11 * time.Hour), 2},
+		{day.Add(33 * time.Hour), 4},
+	}
+
+	for _, seed := range seeds {
+		_, err := db.Exec("INSERT INTO link_hits (link, hour, hits) VALUES ($1, $2, $3);", link, seed.hour, seed.hits)
+		if err != nil {
+			t.Fatalf("failed to update the database: %v", err)
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		interval api.Interval
+		expHits  []int64
+	}{
+		{name: "hour", interval: api.Interval_HOUR, expHits: []int64{3, 2, 4}},
+		{name: "day", interval: api.Interval_DAY, expHits: []int64{5, 4}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			res, err := service.HitsOverTime(context.Background(), &api.TimeRangeRequest{
+				From:     timestamppb.New(day),
+				To:       timestamppb.New(day.Add(48 * time.Hour)),
+				Interval: testCase.interval,
+				Link:     link,
+			})
+			if err != nil {
+				t.Fatalf("HitsOverTime method reported an error: %v", err)
+			}
+
+			if len(res.GetPoints()) != len(testCase.expHits) {
+				t.Fatalf("%d points were expected, but %d were received", len(testCase.expHits), len(res.GetPoints()))
+			}
+
+			for i, point := range res.GetPoints() {
+				if point.GetHits() != testCase.expHits[i] {
+					t.Errorf("%d hits were expected in the point #%d, but %d were received",
+						testCase.expHits[i], i, point.GetHits())
+				}
+			}
+		})
+	}
+
+	// переход методом Get должен учитываться в статистике
+	created, err := service.Create(context.Background(), &api.URL{Url: "http://hits.abc/" + link})
+	if err != nil {
+		t.Fatalf("Create method reported an error: %v", err)
+	}
+
+	if _, err := service.Get(context.Background(), created); err != nil {
+		t.Fatalf("Get method reported an error: %v", err)
+	}
+
+	res, err := service.HitsOverTime(context.Background(), &api.TimeRangeRequest{
+		From: timestamppb.New(time.Now().Add(-time.Hour)),
+		To:   timestamppb.New(time.Now().Add(time.Hour)),
+		Link: created.GetLink(),
+	})
+	if err != nil {
+		t.Fatalf("HitsOverTime method reported an error: %v", err)
+	}
+
+	if len(res.GetPoints()) != 1 || res.GetPoints()[0].GetHits() != 1 {
+		t.Errorf("a single hit was expected, but %v was received", res.GetPoints())
+	}
+
+	// переходы по ссылкам с тегом учитываются отдельно от остальных
+	tag := "hits-" + generateRandomСharacters(6)
+	tagged, err := service.Create(context.Background(), &api.URL{Url: "http://hits.abc/tagged/" + link, Tags: []string{tag}})
+	if err != nil {
+		t.Fatalf("Create method reported an error: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := service.Get(context.Background(), tagged); err != nil {
+			t.Fatalf("Get method reported an error: %v", err)
+		}
+	}
+
+	res, err = service.HitsOverTime(context.Background(), &api.TimeRangeRequest{
+		From: timestamppb.New(time.Now().Add(-time.Hour)),
+		To:   timestamppb.New(time.Now().Add(time.Hour)),
+		Tag:  " " + tag + " ",
+	})
+	if err != nil {
+		t.Fatalf("HitsOverTime method reported an error: %v", err)
+	}
+
+	if len(res.GetPoints()) != 1 || res.GetPoints()[0].GetHits() != 2 {
+		t.Errorf("2 hits of the tagged link were expected, but %v was received", res.GetPoints())
+	}
+
+	_, err = service.HitsOverTime(context.Background(), &api.TimeRangeRequest{
+		From: timestamppb.New(day),
+		To:   timestamppb.New(day),
+	})
+	if err = FromStatus(err); err != ErrInvalidTimeRange {
+		t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrInvalidTimeRange, err)
+	}
+}
+
+func TestHitsOverTimeTagWithMockDB(t *testing.T) {
+	var tags []interface{}
+	db := newMockDB(func(query string, args []driver.NamedValue) (mockResult, error) {
+		tags = append(tags, args[5].Value)
+		return mockResult{columns: []string{"bucket", "sum"}}, nil
+	})
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	req := &api.TimeRangeRequest{
+		From: timestamppb.New(time.Now().Add(-time.Hour)),
+		To:   timestamppb.New(time.Now()),
+		Tag:  " Spring ",
+	}
+
+	if _, err := service.HitsOverTime(context.Background(), req); err != nil {
+		t.Fatalf("HitsOverTime method reported an error: %v", err)
+	}
+
+	// тег, состоящий из пробельных символов, не может быть сохранен, поэтому
+	// база данных не запрашивается
+	req.Tag = "  "
+	res, err := service.HitsOverTime(context.Background(), req)
+	if err != nil {
+		t.Fatalf("HitsOverTime method reported an error: %v", err)
+	}
+
+	if len(tags) != 1 || tags[0] != "spring" || len(res.GetPoints()) != 0 {
+		t.Errorf("a single query with the tag \"spring\" was expected, but %v were executed", tags)
+	}
+}
@@ -0,0 +1,90 @@
+package linkservice
+
+import (
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+	"golang.org/x/net/idna"
+	"google.golang.org/protobuf/proto"
+)
+
+// asciiURL заменяет в URL из запроса req интернационализированное доменное
+// имя, например "пример.рф", его ASCII-формой в punycode
+// ("xn--e1afmkfd.xn--p1ai"), в которой URL проверяется и хранится в базе
+// данных. Поэтому URL с одним и тем же доменом, записанным в Unicode и в
+// punycode, получают одну и ту же короткую ссылку. Остальные части URL не
+// изменяются. Если URL изменяется, то возвращается копия запроса, исходный
+// запрос не изменяется. Если доменное имя не может быть преобразовано, то
+// возвращается ошибка ErrInvalidURL.
+func asciiURL(req *api.URL) (*api.URL, error) {
+	u, err := asciiHost(req.GetUrl())
+	if err != nil {
+		return nil, err
+	}
+
+	if u == req.GetUrl() {
+		return req, nil
+	}
+
+	req = proto.Clone(req).(*api.URL)
+	req.Url = u
+
+	return req, nil
+}
+
+// asciiHost заменяет в URL u хост, содержащий символы вне ASCII, его
+// ASCII-формой. URL, которые не удается разобрать, и URL с хостом в ASCII
+// возвращаются без изменений: их проверяет checkURL.
+func asciiHost(u string) (string, error) {
+	parsed, err := url.Parse(u)
+	if err != nil || parsed.Host == "" {
+		return u, nil
+	}
+
+	host := parsed.Hostname()
+	if isASCII(host) {
+		return u, nil
+	}
+
+	ascii, err := idna.Lookup.ToASCII(host)
+	if err != nil {
+		return "", ErrInvalidURL
+	}
+
+	// URL собирается заново не через url.URL.String, который кодировал бы
+	// недопустимые символы пути и запроса, а заменой хоста в исходной строке.
+	// Хост находится в части URL между первыми символами "//" и началом пути,
+	// запроса или фрагмента, после данных пользователя
+	begin := strings.Index(u, "//") + len("//")
+
+	end := len(u)
+	if i := strings.IndexAny(u[begin:], "/?#"); i >= 0 {
+		end = begin + i
+	}
+
+	if at := strings.LastIndex(u[begin:end], "@"); at >= 0 {
+		begin += at + len("@")
+	}
+
+	// хост, записанный в URL percent-кодированием, не совпадает с результатом
+	// разбора, и такой URL отклоняется при проверке
+	hostPort := u[begin:end]
+	if !strings.HasPrefix(hostPort, host) {
+		return u, nil
+	}
+
+	return u[:begin] + ascii + hostPort[len(host):] + u[end:], nil
+}
+
+// isASCII сообщает, состоит ли строка s только из символов ASCII.
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,99 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+)
+
+func TestASCIIHost(t *testing.T) {
+	testCases := []struct {
+		name     string
+		url      string
+		expURL   string
+		expError error
+	}{
+		{name: "cyrillic", url: "http://пример.рф/", expURL: "http://xn--e1afmkfd.xn--p1ai/"},
+		{name: "cyrillic_upper_case", url: "http://ПРИМЕР.РФ/", expURL: "http://xn--e1afmkfd.xn--p1ai/"},
+		{name: "cjk", url: "https://例子.测试/path?q=1#frag", expURL: "https://xn--fsqu00a.xn--0zwm56d/path?q=1#frag"},
+		{name: "mixed_labels", url: "https://www.пример.com", expURL: "https://www.xn--e1afmkfd.com"},
+		{name: "userinfo_and_port", url: "http://user@пример.рф:8080/a", expURL: "http://user@xn--e1afmkfd.xn--p1ai:8080/a"},
+		// путь не изменяется, даже если содержит символы вне ASCII
+		{name: "path_preserved", url: "http://пример.рф/путь", expURL: "http://xn--e1afmkfd.xn--p1ai/путь"},
+		{name: "ascii", url: "http://example.com/путь", expURL: "http://example.com/путь"},
+		{name: "punycode", url: "http://xn--e1afmkfd.xn--p1ai/", expURL: "http://xn--e1afmkfd.xn--p1ai/"},
+		{name: "unparsable", url: "http://пример.рф/%zz", expURL: "http://пример.рф/%zz"},
+		{name: "invalid_label", url: "http://при_мер.рф/", expError: ErrInvalidURL},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			u, err := asciiHost(testCase.url)
+			if err != testCase.expError {
+				t.Fatalf("an error with a value of \"%v\" was expected, but \"%v\" was received", testCase.expError, err)
+			}
+
+			if u != testCase.expURL {
+				t.Errorf("the URL \"%s\" was expected, but \"%s\" was received", testCase.expURL, u)
+			}
+		})
+	}
+}
+
+func TestCreateIDN(t *testing.T) {
+	// устанавливаем подключение к базе данных
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed connecting to the database: %v", err)
+	}
+
+	defer db.Close()
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	defer service.Close()
+
+	path := generateRandomСharacters(8)
+
+	testCases := []struct {
+		name    string
+		unicode string
+		ascii   string
+	}{
+		{name: "cyrillic", unicode: "http://пример.рф/" + path, ascii: "http://xn--e1afmkfd.xn--p1ai/" + path},
+		{name: "cjk", unicode: "http://例子.测试/" + path, ascii: "http://xn--fsqu00a.xn--0zwm56d/" + path},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			link, err := service.Create(context.Background(), &api.URL{Url: testCase.unicode})
+			if err != nil {
+				t.Fatalf("Create method reported an error: %v", err)
+			}
+
+			// та же ссылка возвращается для URL с доменом в punycode
+			same, err := service.Create(context.Background(), &api.URL{Url: testCase.ascii})
+			if err != nil {
+				t.Fatalf("Create method reported an error: %v", err)
+			}
+
+			if same.GetLink() != link.GetLink() {
+				t.Errorf("the link \"%s\" was expected, but \"%s\" was received", link.GetLink(), same.GetLink())
+			}
+
+			u, err := service.Get(context.Background(), link)
+			if err != nil {
+				t.Fatalf("Get method reported an error: %v", err)
+			}
+
+			if u.GetUrl() != testCase.ascii {
+				t.Errorf("the URL \"%s\" was expected, but \"%s\" was received", testCase.ascii, u.GetUrl())
+			}
+		})
+	}
+}
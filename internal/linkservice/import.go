@@ -0,0 +1,186 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"time"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// допустимое расхождение часов: моменты времени в сообщениях ImportRequest,
+// которые позже текущего момента больше чем на это значение, считаются
+// некорректными
+var maxImportClockSkew = 5 * time.Minute
+
+// Import добавляет короткие ссылки, переданные клиентом в потоке сообщений
+// ImportRequest, сохраняя указанные в них коды, например при переносе ссылок
+// из другого сервиса. Каждый код проверяется так же, как короткие ссылки в
+// запросах к методу Get, а URL — так же, как в методе Create. Ссылка
+// добавляется в пространство имен, указанное в ее сообщении. Коды, которые уже
+// заняты в этом пространстве имен, и URL, для которых в нем уже существует
+// короткая ссылка, не добавляются и учитываются в ответе как пропущенные.
+// Импортированные ссылки не имеют срока действия. Время создания, количество
+// переходов и время последнего перехода сохраняются, если они указаны, поэтому
+// ссылки, выгруженные методом Export, импортируются без потери статистики; без
+// времени создания ссылка считается созданной в момент импорта. Моменты
+// времени не могут быть в будущем, а время последнего перехода не может
+// предшествовать времени создания.
+//
+// Каждая ссылка добавляется отдельно, поэтому при ошибке ранее переданные
+// ссылки остаются в базе данных. Повторный импорт тех же ссылок пропускает уже
+// добавленные, поэтому после исправления ошибки его можно просто повторить.
+//
+// Ошибки передаются клиенту с кодами состояния gRPC: ErrInvalidLink,
+// ErrInvalidNamespace, ErrInvalidURL, ErrURLTooLong, ErrInvalidTime,
+// ErrInvalidTimeRange и ErrInvalidVisits — codes.InvalidArgument,
+// ErrAliasReserved — codes.AlreadyExists, ErrDomainNotAllowed —
+// codes.PermissionDenied, ErrMaintenance — codes.Unavailable,
+// ErrDeadlineExceeded — codes.DeadlineExceeded, ErrReqProc — codes.Internal.
+func (s *GRPCServer) Import(stream api.LinkService_ImportServer) error {
+	res, err := s.importLinks(stream)
+	if err != nil {
+		return statusError(err)
+	}
+
+	return stream.SendAndClose(res)
+}
+
+// importLinks реализует метод Import, возвращая ошибки сервиса без
+// преобразования в ошибки gRPC.
+func (s *GRPCServer) importLinks(stream api.LinkService_ImportServer) (*api.ImportResult, error) {
+	ctx := stream.Context()
+	res := &api.ImportResult{}
+
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return res, nil
+		}
+
+		// сообщение не удается получить, в частности, если клиент отменил
+		// вызов или соединение разорвано
+		if err != nil {
+			return nil, s.requestError(ctx, "Import", err, "inserted", res.Inserted, "skipped", res.Skipped)
+		}
+
+		// режим обслуживания может быть включен во время импорта
+		if err := s.checkMaintenance(); err != nil {
+			return nil, err
+		}
+
+		inserted, err := s.importLink(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		if inserted {
+			res.Inserted++
+		} else {
+			res.Skipped++
+		}
+	}
+}
+
+// importLink добавляет короткую ссылку из сообщения req и сообщает, была ли
+// она добавлена или пропущена из-за конфликта с существующей записью.
+func (s *GRPCServer) importLink(ctx context.Context, req *api.ImportRequest) (bool, error) {
+	if !s.validLink(req.GetLink()) {
+		return false, ErrInvalidLink
+	}
+
+	if err := checkNamespace(req.GetNamespace()); err != nil {
+		return false, err
+	}
+
+	if s.isReserved(req.GetLink()) {
+		return false, ErrAliasReserved
+	}
+
+	u, err := trimURL(&api.URL{Url: req.GetUrl()})
+	if err != nil {
+		return false, err
+	}
+
+	u, err = asciiURL(u)
+	if err != nil {
+		return false, err
+	}
+
+	if err := s.checkURL(u.GetUrl()); err != nil {
+		return false, err
+	}
+
+	if req.GetVisits() < 0 {
+		return false, ErrInvalidVisits
+	}
+
+	now := time.Now()
+
+	createdAt, err := importedTime(req.GetCreatedAt(), now)
+	if err != nil {
+		return false, err
+	}
+
+	lastAccessed, err := importedTime(req.GetLastAccessedAt(), now)
+	if err != nil {
+		return false, err
+	}
+
+	// без времени создания ссылка считается созданной в момент импорта, и
+	// время последнего перехода не сравнивается с ним
+	if createdAt.Valid && lastAccessed.Valid && lastAccessed.Time.Before(createdAt.Time) {
+		return false, ErrInvalidTimeRange
+	}
+
+	u, err = s.normalize(u)
+	if err != nil {
+		return false, err
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	start := time.Now()
+	r, err := s.Database.ExecContext(ctx, "INSERT INTO links (link, original_url, deduplicated, created_at, visits, last_accessed_at, namespace) "+
+		"VALUES ($1, $2, $3, COALESCE($4, now()), $5, $6, $7) ON CONFLICT DO NOTHING;",
+		req.GetLink(), u.GetUrl(), !s.AllowDuplicates, createdAt, req.GetVisits(), lastAccessed, req.GetNamespace())
+	s.observeQuery("import_link", start, "method", "Import", "link", req.GetLink())
+	if err != nil {
+		return false, s.requestError(ctx, "Import", err, "link", req.GetLink(), "namespace", req.GetNamespace(), "url", u.GetUrl())
+	}
+
+	n, err := r.RowsAffected()
+	if err != nil {
+		return false, s.requestError(ctx, "Import", err, "link", req.GetLink(), "namespace", req.GetNamespace(), "url", u.GetUrl())
+	}
+
+	if n == 0 {
+		return false, nil
+	}
+
+	s.linkFilter().add(req.GetLink())
+	return true, nil
+}
+
+// importedTime преобразует необязательный момент времени t из сообщения
+// ImportRequest в значение для базы данных; отсутствующий момент времени
+// соответствует NULL. Моменты времени позже now больше чем на
+// maxImportClockSkew отклоняются с ошибкой ErrInvalidTime.
+func importedTime(t *timestamppb.Timestamp, now time.Time) (sql.NullTime, error) {
+	if t == nil {
+		return sql.NullTime{}, nil
+	}
+
+	if err := t.CheckValid(); err != nil {
+		return sql.NullTime{}, ErrInvalidTime
+	}
+
+	if t.AsTime().After(now.Add(maxImportClockSkew)) {
+		return sql.NullTime{}, ErrInvalidTime
+	}
+
+	return sql.NullTime{Time: t.AsTime(), Valid: true}, nil
+}
@@ -0,0 +1,230 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// importStream передает серверу сообщения reqs и запоминает ответ метода
+// Import
+type importStream struct {
+	grpc.ServerStream
+
+	reqs []*api.ImportRequest
+	res  *api.ImportResult
+}
+
+func (s *importStream) Context() context.Context {
+	return context.Background()
+}
+
+func (s *importStream) Recv() (*api.ImportRequest, error) {
+	if len(s.reqs) == 0 {
+		return nil, io.EOF
+	}
+
+	req := s.reqs[0]
+	s.reqs = s.reqs[1:]
+
+	return req, nil
+}
+
+func (s *importStream) SendAndClose(res *api.ImportResult) error {
+	s.res = res
+	return nil
+}
+
+func TestImportInvalid(t *testing.T) {
+	// некорректные сообщения отклоняются до обращения к базе данных, поэтому
+	// сервер не требует подключения
+	service := &GRPCServer{}
+
+	testCases := []struct {
+		name    string
+		req     *api.ImportRequest
+		expCode codes.Code
+	}{
+		{name: "invalid_link", req: &api.ImportRequest{Link: "a b", Url: "http://import.abc/"}, expCode: codes.InvalidArgument},
+		{name: "reserved_link", req: &api.ImportRequest{Link: "metrics", Url: "http://import.abc/"}, expCode: codes.AlreadyExists},
+		{name: "invalid_url", req: &api.ImportRequest{Link: "imported", Url: "javascript:alert(1)"}, expCode: codes.InvalidArgument},
+		{name: "empty_url", req: &api.ImportRequest{Link: "imported"}, expCode: codes.InvalidArgument},
+		{name: "negative_visits", req: &api.ImportRequest{Link: "imported", Url: "http://import.abc/", Visits: -1}, expCode: codes.InvalidArgument},
+		{name: "invalid_namespace", req: &api.ImportRequest{Link: "imported", Url: "http://import.abc/", Namespace: "Brand"}, expCode: codes.InvalidArgument},
+		{name: "invalid_created_at", req: &api.ImportRequest{Link: "imported", Url: "http://import.abc/", CreatedAt: &timestamppb.Timestamp{Nanos: -1}}, expCode: codes.InvalidArgument},
+		{name: "future_created_at", req: &api.ImportRequest{Link: "imported", Url: "http://import.abc/", CreatedAt: timestamppb.New(time.Now().Add(time.Hour))}, expCode: codes.InvalidArgument},
+		{name: "future_last_accessed_at", req: &api.ImportRequest{Link: "imported", Url: "http://import.abc/", LastAccessedAt: timestamppb.New(time.Now().AddDate(10, 0, 0))}, expCode: codes.InvalidArgument},
+		{name: "last_accessed_before_created", req: &api.ImportRequest{Link: "imported", Url: "http://import.abc/",
+			CreatedAt: timestamppb.New(time.Now().Add(-time.Hour)), LastAccessedAt: timestamppb.New(time.Now().Add(-2 * time.Hour))}, expCode: codes.InvalidArgument},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			stream := &importStream{reqs: []*api.ImportRequest{testCase.req}}
+
+			if code := status.Code(service.Import(stream)); code != testCase.expCode {
+				t.Errorf("the code %v was expected, but %v was received", testCase.expCode, code)
+			}
+
+			if stream.res != nil {
+				t.Errorf("no response was expected, but %v was received", stream.res)
+			}
+		})
+	}
+}
+
+func TestImportNamespaceWithMockDB(t *testing.T) {
+	var namespaces []interface{}
+	db := newMockDB(func(query string, args []driver.NamedValue) (mockResult, error) {
+		if !strings.HasPrefix(query, "INSERT INTO links") {
+			t.Fatalf("an unexpected query was received: %s", query)
+		}
+
+		namespaces = append(namespaces, args[6].Value)
+		return mockResult{affected: 1}, nil
+	})
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	stream := &importStream{reqs: []*api.ImportRequest{
+		{Link: "imported", Url: "http://import.abc/brand", Namespace: "brand"},
+		{Link: "imported", Url: "http://import.abc/default"},
+	}}
+
+	if err := service.Import(stream); err != nil {
+		t.Fatalf("Import method reported an error: %v", err)
+	}
+
+	if exp := []interface{}{"brand", ""}; !reflect.DeepEqual(namespaces, exp) {
+		t.Errorf("the links were expected to be inserted into the namespaces %q, but %q were received", exp, namespaces)
+	}
+}
+
+func TestImport(t *testing.T) {
+	// устанавливаем подключение к базе данных
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed connecting to the database: %v", err)
+	}
+
+	defer db.Close()
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	defer service.Close()
+
+	existing, err := service.Create(context.Background(), &api.URL{Url: "http://import.abc/" + generateRandomСharacters(6)})
+	if err != nil {
+		t.Fatalf("Create method reported an error: %v", err)
+	}
+
+	code := "imp-" + generateRandomСharacters(8)
+	url := "http://import.abc/" + generateRandomСharacters(6)
+
+	stream := &importStream{reqs: []*api.ImportRequest{
+		{Link: code, Url: url},
+		// занятый код и повторный URL пропускаются
+		{Link: existing.GetLink(), Url: "http://import.abc/" + generateRandomСharacters(6)},
+		{Link: "imp-" + generateRandomСharacters(8), Url: url},
+	}}
+
+	if err := service.Import(stream); err != nil {
+		t.Fatalf("Import method reported an error: %v", err)
+	}
+
+	if stream.res.GetInserted() != 1 || stream.res.GetSkipped() != 2 {
+		t.Errorf("1 inserted and 2 skipped links were expected, but %d and %d were received", stream.res.GetInserted(), stream.res.GetSkipped())
+	}
+
+	res, err := service.Get(context.Background(), &api.Link{Link: code})
+	if err != nil {
+		t.Fatalf("Get method reported an error: %v", err)
+	}
+
+	if res.GetUrl() != url {
+		t.Errorf("the URL \"%s\" was expected, but \"%s\" was received", url, res.GetUrl())
+	}
+}
+
+func TestImportExportRoundTrip(t *testing.T) {
+	// устанавливаем подключение к базе данных
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed connecting to the database: %v", err)
+	}
+
+	defer db.Close()
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	defer service.Close()
+
+	// база данных хранит время с точностью до микросекунды
+	createdAt := time.Now().Add(-48 * time.Hour).Truncate(time.Microsecond)
+	lastAccessed := time.Now().Add(-time.Hour).Truncate(time.Microsecond)
+
+	original := &api.ImportRequest{
+		Link:           "imp-" + generateRandomСharacters(8),
+		Url:            "http://import.abc/" + generateRandomСharacters(6),
+		CreatedAt:      timestamppb.New(createdAt),
+		Visits:         42,
+		LastAccessedAt: timestamppb.New(lastAccessed),
+		Namespace:      "imported",
+	}
+
+	if err := service.Import(&importStream{reqs: []*api.ImportRequest{original}}); err != nil {
+		t.Fatalf("Import method reported an error: %v", err)
+	}
+
+	stream := &exportStream{ctx: context.Background()}
+	if err := service.Export(&api.ExportRequest{Namespace: original.GetNamespace()}, stream); err != nil {
+		t.Fatalf("Export method reported an error: %v", err)
+	}
+
+	var exported *api.ExportedLink
+	for _, link := range stream.links {
+		if link.GetLink() == original.GetLink() {
+			exported = link
+		}
+	}
+
+	if exported == nil {
+		t.Fatalf("the imported link \"%s\" was not exported", original.GetLink())
+	}
+
+	// экспортированную ссылку можно импортировать повторно без потери
+	// статистики
+	reimported := &api.ImportRequest{
+		Link:           exported.GetLink(),
+		Url:            exported.GetUrl(),
+		CreatedAt:      exported.GetCreatedAt(),
+		Visits:         exported.GetVisits(),
+		LastAccessedAt: exported.GetLastAccessedAt(),
+		Namespace:      exported.GetNamespace(),
+	}
+
+	if !proto.Equal(reimported, original) {
+		t.Errorf("the link %v was expected to be exported, but %v was received", original, reimported)
+	}
+}
@@ -0,0 +1,77 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// GetInfo возвращает все сведения об указанной в запросе короткой ссылке:
+// оригинальный URL, название, идентификатор владельца, теги, время создания и
+// окончания срока действия, количество переходов и ограничение их количества,
+// а также IP-адрес и user-agent создателя ссылки, если они известны.
+// Метод предназначен для административного интерфейса и, в отличие от метода
+// Get, не учитывает переход по ссылке. Сведения о создателе ссылки являются
+// персональными данными, поэтому при включенной проверке API-ключей метод
+// требует ключа с областью действия write. Ошибки передаются клиенту с теми же
+// кодами состояния gRPC, что и в методе Get.
+func (s *GRPCServer) GetInfo(ctx context.Context, req *api.Link) (*api.LinkInfo, error) {
+	info, err := s.getInfo(ctx, req)
+	return info, statusError(err)
+}
+
+// getInfo реализует метод GetInfo, возвращая ошибки сервиса без преобразования
+// в ошибки gRPC.
+func (s *GRPCServer) getInfo(ctx context.Context, req *api.Link) (*api.LinkInfo, error) {
+	// проверка переданной в запросе строки на соответствие требованиям
+	// короткой ссылки или пользовательского псевдонима
+	if !s.validLink(req.GetLink()) {
+		return nil, ErrInvalidLink
+	}
+
+	if err := checkNamespace(req.GetNamespace()); err != nil {
+		return nil, err
+	}
+
+	info := &api.LinkInfo{Link: req.GetLink()}
+
+	var title, owner, ip, userAgent sql.NullString
+	var createdAt time.Time
+	var expires sql.NullTime
+	var maxUses sql.NullInt64
+	var tags pq.StringArray
+
+	err := s.retry(ctx, "select_info", func(ctx context.Context) error {
+		return s.readDB().QueryRowContext(ctx, "SELECT original_url, title, owner_id, created_at, expires_at, visits, uses, max_uses, tags, creator_ip, creator_user_agent "+
+			"FROM links WHERE link = $1 AND namespace = $2;",
+			req.GetLink(), req.GetNamespace()).Scan(&info.Url, &title, &owner, &createdAt, &expires, &info.Visits, &info.Uses, &maxUses, &tags, &ip, &userAgent)
+	})
+
+	// ссылки с истекшим сроком действия считаются несуществующими, как и в
+	// методе Get
+	if err == sql.ErrNoRows || err == nil && expired(expires) {
+		return nil, ErrURLNotFound
+	}
+
+	if err != nil {
+		return nil, s.requestError(ctx, "GetInfo", err, "link", req.GetLink())
+	}
+
+	info.Title = title.String
+	info.OwnerId = owner.String
+	info.CreatedAt = timestamppb.New(createdAt)
+	info.MaxUses = maxUses.Int64
+	info.Tags = tags
+	info.CreatorIp = ip.String
+	info.CreatorUserAgent = userAgent.String
+
+	if expires.Valid {
+		info.ExpiresAt = timestamppb.New(expires.Time)
+	}
+
+	return info, nil
+}
@@ -0,0 +1,170 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+)
+
+func TestGetInfoWithMockDB(t *testing.T) {
+	const link = "abcdefghij"
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	expires := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	columns := []string{"original_url", "title", "owner_id", "created_at", "expires_at", "visits", "uses", "max_uses", "tags", "creator_ip", "creator_user_agent"}
+
+	testCases := []struct {
+		name string
+		row  []driver.Value
+		exp  *api.LinkInfo
+		err  error
+	}{
+		{
+			name: "full",
+			row:  []driver.Value{"http://info.abc/", "Info", "owner", created, expires, int64(7), int64(3), int64(10), []byte("{newsletter,social}"), "203.0.113.7", "grpc-go/1.40.0"},
+			exp: &api.LinkInfo{
+				Link: link, Url: "http://info.abc/", Title: "Info", OwnerId: "owner", Visits: 7, Uses: 3, MaxUses: 10,
+				Tags: []string{"newsletter", "social"}, CreatorIp: "203.0.113.7", CreatorUserAgent: "grpc-go/1.40.0",
+			},
+		},
+		{
+			name: "without_metadata",
+			row:  []driver.Value{"http://info.abc/", nil, nil, created, nil, int64(0), int64(0), nil, []byte("{}"), nil, nil},
+			exp:  &api.LinkInfo{Link: link, Url: "http://info.abc/", Tags: []string{}},
+		},
+		{
+			name: "expired",
+			row:  []driver.Value{"http://info.abc/", nil, nil, created, time.Now().Add(-time.Hour), int64(0), int64(0), nil, []byte("{}"), nil, nil},
+			err:  ErrURLNotFound,
+		},
+		{
+			name: "not_found",
+			err:  ErrURLNotFound,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			db := newMockDB(func(query string, args []driver.NamedValue) (mockResult, error) {
+				res := mockResult{columns: columns}
+				if testCase.row != nil {
+					res.rows = [][]driver.Value{testCase.row}
+				}
+
+				return res, nil
+			})
+
+			service, err := NewGRPCServer(db)
+			if err != nil {
+				t.Fatalf("failed to prepare the server: %v", err)
+			}
+
+			info, err := service.GetInfo(context.Background(), &api.Link{Link: link})
+			if err = FromStatus(err); err != testCase.err {
+				t.Fatalf("an error with a value of \"%v\" was expected, but \"%v\" was received", testCase.err, err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if !info.GetCreatedAt().AsTime().Equal(created) {
+				t.Errorf("the creation time %v was expected, but %v was received", created, info.GetCreatedAt().AsTime())
+			}
+
+			if testCase.row[4] == nil && info.GetExpiresAt() != nil {
+				t.Errorf("no expiry was expected, but %v was received", info.GetExpiresAt().AsTime())
+			} else if testCase.row[4] != nil && !info.GetExpiresAt().AsTime().Equal(expires) {
+				t.Errorf("the expiry %v was expected, but %v was received", expires, info.GetExpiresAt().AsTime())
+			}
+
+			info.CreatedAt, info.ExpiresAt = nil, nil
+			if info.GetLink() != testCase.exp.GetLink() || info.GetUrl() != testCase.exp.GetUrl() || info.GetTitle() != testCase.exp.GetTitle() ||
+				info.GetOwnerId() != testCase.exp.GetOwnerId() || info.GetVisits() != testCase.exp.GetVisits() ||
+				info.GetUses() != testCase.exp.GetUses() || info.GetMaxUses() != testCase.exp.GetMaxUses() ||
+				!reflect.DeepEqual(info.GetTags(), testCase.exp.GetTags()) ||
+				info.GetCreatorIp() != testCase.exp.GetCreatorIp() || info.GetCreatorUserAgent() != testCase.exp.GetCreatorUserAgent() {
+				t.Errorf("the info %v was expected, but %v was received", testCase.exp, info)
+			}
+		})
+	}
+}
+
+func TestGetInfoInvalidLink(t *testing.T) {
+	// ссылка проверяется до обращения к базе данных
+	service := &GRPCServer{}
+
+	_, err := service.GetInfo(context.Background(), &api.Link{Link: "no"})
+	if err = FromStatus(err); err != ErrInvalidLink {
+		t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrInvalidLink, err)
+	}
+}
+
+func TestGetInfo(t *testing.T) {
+	// устанавливаем подключение к базе данных
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed connecting to the database: %v", err)
+	}
+
+	defer db.Close()
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	defer service.Close()
+
+	url := "http://info.abc/" + generateRandomСharacters(8)
+
+	link, err := service.Create(context.Background(), &api.URL{Url: url, Title: "Info", OwnerId: "info-owner", Tags: []string{"Social"}, TtlSeconds: 3600, MaxUses: 5})
+	if err != nil {
+		t.Fatalf("Create method reported an error: %v", err)
+	}
+
+	if _, err := service.Get(context.Background(), link); err != nil {
+		t.Fatalf("Get method reported an error: %v", err)
+	}
+
+	info, err := service.GetInfo(context.Background(), link)
+	if err != nil {
+		t.Fatalf("GetInfo method reported an error: %v", err)
+	}
+
+	if info.GetUrl() != url || info.GetTitle() != "Info" || info.GetOwnerId() != "info-owner" {
+		t.Errorf("the URL \"%s\" of \"info-owner\" titled \"Info\" was expected, but %v was received", url, info)
+	}
+
+	if info.GetVisits() != 1 || info.GetUses() != 1 || info.GetMaxUses() != 5 {
+		t.Errorf("1 visit of 5 allowed uses was expected, but %v was received", info)
+	}
+
+	if !reflect.DeepEqual(info.GetTags(), []string{"social"}) {
+		t.Errorf("the tags [social] were expected, but %q were received", info.GetTags())
+	}
+
+	if info.GetExpiresAt() == nil || info.GetExpiresAt().AsTime().Before(time.Now()) {
+		t.Errorf("a future expiry was expected, but %v was received", info.GetExpiresAt())
+	}
+
+	// переход по ссылке методом GetInfo не учитывается
+	info, err = service.GetInfo(context.Background(), link)
+	if err != nil {
+		t.Fatalf("GetInfo method reported an error: %v", err)
+	}
+
+	if info.GetVisits() != 1 {
+		t.Errorf("1 visit was expected, but %d were received", info.GetVisits())
+	}
+
+	_, err = service.GetInfo(context.Background(), &api.Link{Link: generateRandomСharacters(10)})
+	if err = FromStatus(err); err != ErrURLNotFound {
+		t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrURLNotFound, err)
+	}
+}
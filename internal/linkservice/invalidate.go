@@ -0,0 +1,57 @@
+package linkservice
+
+import (
+	"context"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+)
+
+// InvalidateCache удаляет из кэша метода Get указанную в запросе короткую
+// ссылку из указанного пространства имен, а если в запросе установлено поле
+// all — все записи кэша независимо от пространства имен. Метод нужен, если
+// ссылки изменены в базе данных в обход сервиса: иначе Get продолжал бы
+// возвращать прежние URL, пока записи не будут вытеснены. Псевдоним удаляется
+// из кэша вместе с его записью в нижнем регистре, под которой его находит Get.
+// База данных при этом не запрашивается.
+//
+// Ошибки передаются клиенту с кодами состояния gRPC: ErrInvalidLink и
+// ErrInvalidNamespace — codes.InvalidArgument.
+func (s *GRPCServer) InvalidateCache(ctx context.Context, req *api.InvalidateRequest) (*api.Empty, error) {
+	if err := s.invalidateCache(req); err != nil {
+		return nil, statusError(err)
+	}
+
+	return &api.Empty{}, nil
+}
+
+// invalidateCache реализует метод InvalidateCache, возвращая ошибки сервиса
+// без преобразования в ошибки gRPC.
+func (s *GRPCServer) invalidateCache(req *api.InvalidateRequest) error {
+	namespace, link := req.GetNamespace(), req.GetLink()
+	if err := checkNamespace(namespace); err != nil {
+		return err
+	}
+
+	// очистка всего кэша запрашивается явно, чтобы запрос без ссылки не
+	// приводил к ней по ошибке
+	if req.GetAll() {
+		if link != "" || namespace != "" {
+			return ErrInvalidLink
+		}
+
+		s.linkCache().clear()
+		return nil
+	}
+
+	if !s.validLink(link) {
+		return ErrInvalidLink
+	}
+
+	s.linkCache().remove(namespacedLink(namespace, link))
+
+	if aliasTemplate.MatchString(link) {
+		s.linkCache().remove(namespacedLink(namespace, foldAlias(link)))
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestInvalidateCacheWithMockDB(t *testing.T) {
+	var lookups int
+	db := newMockDB(func(query string, args []driver.NamedValue) (mockResult, error) {
+		switch {
+		case query == selectURLQuery:
+			lookups++
+			return urlRow("http://invalidate.abc/"+args[1].Value.(string), nil), nil
+		case strings.HasPrefix(query, "UPDATE links SET visits"):
+			return mockResult{columns: []string{"expires_at"}, rows: [][]driver.Value{{nil}}}, nil
+		case strings.HasPrefix(query, "INSERT INTO link_hits"):
+			return mockResult{affected: 1}, nil
+		default:
+			t.Fatalf("an unexpected query was received: %s", query)
+			return mockResult{}, nil
+		}
+	})
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	service.CacheSize = 10
+
+	links := []*api.Link{{Link: "promo"}, {Link: "promo", Namespace: "brand"}, {Link: "other"}}
+
+	// get вызывает метод Get для ссылок links и возвращает количество
+	// обращений к базе данных за оригинальными URL
+	get := func(links ...*api.Link) int {
+		before := lookups
+		for _, link := range links {
+			if _, err := service.Get(context.Background(), link); err != nil {
+				t.Fatalf("Get method reported an error: %v", err)
+			}
+		}
+
+		return lookups - before
+	}
+
+	get(links...)
+	if n := get(links...); n != 0 {
+		t.Fatalf("the links were expected to be cached, but %d lookups were executed", n)
+	}
+
+	// псевдоним в другом регистре удаляет запись, под которой его находит Get
+	if _, err := service.InvalidateCache(context.Background(), &api.InvalidateRequest{Link: "Promo", Namespace: "brand"}); err != nil {
+		t.Fatalf("InvalidateCache method reported an error: %v", err)
+	}
+
+	if n := get(links[1]); n != 1 {
+		t.Errorf("the invalidated link was expected to be read from the database, but %d lookups were executed", n)
+	}
+
+	if n := get(links[0], links[2]); n != 0 {
+		t.Errorf("the other links were expected to stay cached, but %d lookups were executed", n)
+	}
+
+	if _, err := service.InvalidateCache(context.Background(), &api.InvalidateRequest{All: true}); err != nil {
+		t.Fatalf("InvalidateCache method reported an error: %v", err)
+	}
+
+	if n := get(links...); n != len(links) {
+		t.Errorf("all links were expected to be read from the database, but %d lookups were executed", n)
+	}
+
+	testCases := []struct {
+		name string
+		req  *api.InvalidateRequest
+	}{
+		// без поля all запрос без ссылки не очищает кэш
+		{name: "empty_link", req: &api.InvalidateRequest{}},
+		{name: "invalid_link", req: &api.InvalidateRequest{Link: "a b"}},
+		{name: "all_with_link", req: &api.InvalidateRequest{Link: "promo", All: true}},
+		{name: "invalid_namespace", req: &api.InvalidateRequest{Link: "promo", Namespace: "Brand"}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := service.InvalidateCache(context.Background(), testCase.req)
+			if code := status.Code(err); code != codes.InvalidArgument {
+				t.Errorf("the code %v was expected, but %v was received", codes.InvalidArgument, code)
+			}
+		})
+	}
+
+	if n := get(links...); n != 0 {
+		t.Errorf("the rejected requests were expected to keep the cache, but %d lookups were executed", n)
+	}
+}
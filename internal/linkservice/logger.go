@@ -0,0 +1,178 @@
+package linkservice
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Logger описывает журнал, в который сервер записывает сообщения. Аргументы
+// keyvals представляют собой чередующиеся ключи и значения полей сообщения,
+// как в log/slog, поэтому *slog.Logger удовлетворяет интерфейсу без
+// преобразований, а для других библиотек, например zap, достаточно простой
+// обертки.
+type Logger interface {
+	Debug(msg string, keyvals ...interface{})
+	Info(msg string, keyvals ...interface{})
+	Warn(msg string, keyvals ...interface{})
+	Error(msg string, keyvals ...interface{})
+}
+
+// stdLogger записывает сообщения в журнал стандартной библиотеки в виде
+// "уровень сообщение ключ=значение ...". Журнал стандартной библиотеки не
+// позволяет отключить отдельные уровни, поэтому отладочные сообщения не
+// записываются.
+type stdLogger struct{}
+
+func (stdLogger) Debug(msg string, keyvals ...interface{}) {}
+
+func (stdLogger) Info(msg string, keyvals ...interface{}) {
+	log.Println(formatEntry("INFO", msg, keyvals))
+}
+
+func (stdLogger) Warn(msg string, keyvals ...interface{}) {
+	log.Println(formatEntry("WARN", msg, keyvals))
+}
+
+func (stdLogger) Error(msg string, keyvals ...interface{}) {
+	log.Println(formatEntry("ERROR", msg, keyvals))
+}
+
+// formatEntry форматирует сообщение журнала msg уровня level с полями keyvals.
+// Значения, для которых не указан ключ, записываются с ключом "!BADKEY", как в
+// log/slog.
+func formatEntry(level, msg string, keyvals []interface{}) string {
+	var b strings.Builder
+	b.WriteString(level)
+	b.WriteByte(' ')
+	b.WriteString(msg)
+
+	for i := 0; i < len(keyvals); i += 2 {
+		key, value := "!BADKEY", keyvals[i]
+
+		if k, ok := keyvals[i].(string); ok && i+1 < len(keyvals) {
+			key, value = k, keyvals[i+1]
+		} else {
+			i--
+		}
+
+		fmt.Fprintf(&b, " %s=%q", key, fmt.Sprint(value))
+	}
+
+	return b.String()
+}
+
+// JSONLogger записывает сообщения в W по одному объекту JSON в строке с
+// полями "time", "level", "msg" и полями сообщения, например "method" и
+// "error", как log/slog.JSONHandler. Такие сообщения можно без разбора текста
+// загружать в системы сбора журналов. Отладочные сообщения записываются,
+// только если задан Verbose.
+type JSONLogger struct {
+	W       io.Writer
+	Verbose bool
+
+	mu sync.Mutex
+}
+
+// NewJSONLogger создает журнал, записывающий сообщения в формате JSON в w.
+func NewJSONLogger(w io.Writer) *JSONLogger {
+	return &JSONLogger{W: w}
+}
+
+func (l *JSONLogger) Debug(msg string, keyvals ...interface{}) {
+	if l.Verbose {
+		l.write("DEBUG", msg, keyvals)
+	}
+}
+
+func (l *JSONLogger) Info(msg string, keyvals ...interface{}) {
+	l.write("INFO", msg, keyvals)
+}
+
+func (l *JSONLogger) Warn(msg string, keyvals ...interface{}) {
+	l.write("WARN", msg, keyvals)
+}
+
+func (l *JSONLogger) Error(msg string, keyvals ...interface{}) {
+	l.write("ERROR", msg, keyvals)
+}
+
+// write записывает сообщение msg уровня level с полями keyvals одной строкой.
+// Строка формируется целиком до записи, поэтому сообщения, записываемые
+// одновременно, не перемешиваются.
+func (l *JSONLogger) write(level, msg string, keyvals []interface{}) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	writeJSONField(&b, "time", time.Now().UTC().Format(time.RFC3339Nano))
+	b.WriteByte(',')
+	writeJSONField(&b, "level", level)
+	b.WriteByte(',')
+	writeJSONField(&b, "msg", msg)
+
+	for i := 0; i < len(keyvals); i += 2 {
+		key, value := "!BADKEY", keyvals[i]
+
+		if k, ok := keyvals[i].(string); ok && i+1 < len(keyvals) {
+			key, value = k, keyvals[i+1]
+		} else {
+			i--
+		}
+
+		b.WriteByte(',')
+		writeJSONField(&b, key, value)
+	}
+
+	b.WriteString("}\n")
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.W.Write(b.Bytes())
+}
+
+// writeJSONField записывает в b поле JSON с ключом key и значением value.
+// Ошибки и значения, реализующие fmt.Stringer, например time.Duration,
+// записываются строкой, а значения, которые не удается представить в JSON, —
+// строкой, полученной fmt.Sprint.
+func writeJSONField(b *bytes.Buffer, key string, value interface{}) {
+	k, _ := json.Marshal(key)
+	b.Write(k)
+	b.WriteByte(':')
+
+	switch v := value.(type) {
+	case error:
+		value = v.Error()
+	case json.Marshaler:
+	case fmt.Stringer:
+		value = v.String()
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprint(value))
+	}
+
+	b.Write(data)
+}
+
+// logger возвращает журнал сервера. Если Logger не задан, то сообщения
+// записываются в журнал стандартной библиотеки.
+func (s *GRPCServer) logger() Logger {
+	if s.Logger != nil {
+		return s.Logger
+	}
+
+	return stdLogger{}
+}
+
+// logError записывает в журнал ошибку err, возникшую при обработке запроса
+// method. Поля keyvals дополняют сообщение сведениями о запросе, например,
+// короткой ссылкой или URL.
+func (s *GRPCServer) logError(method string, err error, keyvals ...interface{}) {
+	s.logger().Error("request failed", append([]interface{}{"method", method, "error", err}, keyvals...)...)
+}
@@ -0,0 +1,166 @@
+package linkservice
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// recordLogger запоминает записанные в журнал сообщения об ошибках,
+// предупреждения и отладочные сообщения
+type recordLogger struct {
+	entries [][]interface{}
+	warn    [][]interface{}
+	debug   [][]interface{}
+}
+
+func (l *recordLogger) Debug(msg string, keyvals ...interface{}) {
+	l.debug = append(l.debug, append([]interface{}{msg}, keyvals...))
+}
+
+func (l *recordLogger) Info(msg string, keyvals ...interface{}) {}
+
+func (l *recordLogger) Warn(msg string, keyvals ...interface{}) {
+	l.warn = append(l.warn, append([]interface{}{msg}, keyvals...))
+}
+
+func (l *recordLogger) Error(msg string, keyvals ...interface{}) {
+	l.entries = append(l.entries, append([]interface{}{msg}, keyvals...))
+}
+
+func TestLogger(t *testing.T) {
+	logger := &recordLogger{}
+	service := GRPCServer{Logger: logger}
+	dbErr := errors.New("connection reset")
+
+	err := service.requestError(context.Background(), "Get", dbErr, "link", "abc")
+	if err != ErrReqProc {
+		t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrReqProc, err)
+	}
+
+	exp := [][]interface{}{{"request failed", "method", "Get", "error", dbErr, "link", "abc"}}
+	if !reflect.DeepEqual(logger.entries, exp) {
+		t.Errorf("entries %v were expected, but %v were received", exp, logger.entries)
+	}
+
+	// ошибки отмененных запросов не записываются в журнал
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := service.requestError(ctx, "Get", dbErr); err != ErrDeadlineExceeded {
+		t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrDeadlineExceeded, err)
+	}
+
+	if len(logger.entries) != 1 {
+		t.Errorf("it was expected that the cancelled request would not be logged")
+	}
+}
+
+func TestFormatEntry(t *testing.T) {
+	testCases := []struct {
+		name    string
+		keyvals []interface{}
+		exp     string
+	}{
+		{
+			name:    "fields",
+			keyvals: []interface{}{"method", "Create", "attempts", 3},
+			exp:     `ERROR request failed method="Create" attempts="3"`,
+		},
+		{
+			name:    "missing_value",
+			keyvals: []interface{}{"method"},
+			exp:     `ERROR request failed !BADKEY="method"`,
+		},
+		{
+			name:    "non_string_key",
+			keyvals: []interface{}{42, "link", "abc"},
+			exp:     `ERROR request failed !BADKEY="42" link="abc"`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if entry := formatEntry("ERROR", "request failed", testCase.keyvals); entry != testCase.exp {
+				t.Errorf("entry %s was expected, but %s was received", testCase.exp, entry)
+			}
+		})
+	}
+}
+
+func TestJSONLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewJSONLogger(&buf)
+	service := GRPCServer{Logger: logger}
+
+	service.logError("Create", errors.New("connection reset"), "url", "http://json.abc/", "duration", 250*time.Millisecond, 42)
+	logger.Debug("skipped")
+
+	lines := bytes.Split(bytes.TrimSuffix(buf.Bytes(), []byte("\n")), []byte("\n"))
+	if len(lines) != 1 {
+		t.Fatalf("1 line was expected, but %d were received: %s", len(lines), buf.String())
+	}
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(lines[0], &entry); err != nil {
+		t.Fatalf("the line %s is not valid JSON: %v", lines[0], err)
+	}
+
+	exp := map[string]interface{}{
+		"level": "ERROR", "msg": "request failed", "method": "Create", "error": "connection reset",
+		"url": "http://json.abc/", "duration": "250ms", "!BADKEY": float64(42),
+	}
+
+	for key, value := range exp {
+		if entry[key] != value {
+			t.Errorf("the field \"%s\" with a value of %v was expected, but %v was received", key, value, entry[key])
+		}
+	}
+
+	if _, err := time.Parse(time.RFC3339Nano, fmt.Sprint(entry["time"])); err != nil {
+		t.Errorf("a timestamp was expected, but %v was received", entry["time"])
+	}
+
+	// отладочные сообщения записываются только при Verbose
+	buf.Reset()
+	logger.Verbose = true
+	logger.Debug("recorded")
+
+	if !bytes.Contains(buf.Bytes(), []byte(`"level":"DEBUG"`)) {
+		t.Errorf("a debug entry was expected, but %s was received", buf.String())
+	}
+}
+
+func TestObserveSlowQuery(t *testing.T) {
+	logger := &recordLogger{}
+	service := GRPCServer{Logger: logger, SlowQueryThreshold: 100 * time.Millisecond}
+
+	service.observeQuery("select_url", time.Now(), "link", "fast")
+	service.observeQuery("select_url", time.Now().Add(-time.Second), "method", "Get", "link", "slow")
+
+	if len(logger.warn) != 1 {
+		t.Fatalf("1 warning was expected, but %d were received: %v", len(logger.warn), logger.warn)
+	}
+
+	entry := logger.warn[0]
+	if entry[0] != "slow query" || entry[2] != "select_url" || !reflect.DeepEqual(entry[5:], []interface{}{"method", "Get", "link", "slow"}) {
+		t.Errorf("a warning about the slow query of the link \"slow\" was expected, but %v was received", entry)
+	}
+
+	if d, ok := entry[4].(time.Duration); !ok || d < time.Second {
+		t.Errorf("a duration of at least 1s was expected, but %v was received", entry[4])
+	}
+
+	// без порога медленные запросы не записываются
+	service.SlowQueryThreshold = 0
+	service.observeQuery("select_url", time.Now().Add(-time.Second), "link", "slow")
+
+	if len(logger.warn) != 1 {
+		t.Errorf("no warnings were expected without a threshold, but %v were received", logger.warn[1:])
+	}
+}
@@ -0,0 +1,67 @@
+package linkservice
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+)
+
+// SetMaintenance включает или выключает режим обслуживания, например на время
+// плановых работ с базой данных. В режиме обслуживания методы Create,
+// GetOrCreate, BatchCreate и Import отклоняют запросы с ошибкой
+// ErrMaintenance, не обращаясь к базе данных. Метод Get возвращает ту же
+// ошибку, если только не задан MaintenanceServeCached: тогда ссылки, найденные
+// в кэше, по-прежнему разрешаются, но переходы по ним не учитываются.
+// Остальные методы работают как обычно.
+func (s *GRPCServer) SetMaintenance(ctx context.Context, req *api.MaintenanceRequest) (*api.Empty, error) {
+	s.SetMaintenanceMode(req.GetEnabled())
+	return &api.Empty{}, nil
+}
+
+// SetMaintenanceMode включает или выключает режим обслуживания так же, как
+// метод SetMaintenance, например при запуске сервиса.
+func (s *GRPCServer) SetMaintenanceMode(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+
+	atomic.StoreInt32(&s.maintenance, v)
+}
+
+// inMaintenance сообщает, находится ли сервер в режиме обслуживания.
+func (s *GRPCServer) inMaintenance() bool {
+	return atomic.LoadInt32(&s.maintenance) != 0
+}
+
+// checkMaintenance возвращает ErrMaintenance, если сервер находится в режиме
+// обслуживания.
+func (s *GRPCServer) checkMaintenance() error {
+	if s.inMaintenance() {
+		return ErrMaintenance
+	}
+
+	return nil
+}
+
+// resolveCached находит короткую ссылку link из пространства имен namespace
+// так же, как resolve, но только в кэше. Если ссылки нет в кэше или
+// MaintenanceServeCached не задан, то возвращается ErrMaintenance.
+func (s *GRPCServer) resolveCached(namespace, link string) (cacheEntry, error) {
+	if !s.MaintenanceServeCached {
+		return cacheEntry{}, ErrMaintenance
+	}
+
+	if entry, ok := s.linkCache().get(namespacedLink(namespace, link)); ok {
+		return entry, nil
+	}
+
+	if folded := foldAlias(link); folded != link && aliasTemplate.MatchString(link) {
+		if entry, ok := s.linkCache().get(namespacedLink(namespace, folded)); ok {
+			return entry, nil
+		}
+	}
+
+	return cacheEntry{}, ErrMaintenance
+}
@@ -0,0 +1,120 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestMaintenance(t *testing.T) {
+	db := newMockDB(func(query string, args []driver.NamedValue) (mockResult, error) {
+		switch {
+		case query == selectURLQuery:
+			row := urlRow("http://maintenance.abc/"+args[0].Value.(string), nil)
+			row.rows[0][4] = args[0].Value == "capped"
+			return row, nil
+		case strings.HasPrefix(query, "UPDATE links SET visits"):
+			return mockResult{columns: []string{"expires_at"}, rows: [][]driver.Value{{nil}}}, nil
+		case strings.HasPrefix(query, "INSERT INTO link_hits"):
+			return mockResult{affected: 1}, nil
+		default:
+			t.Fatalf("an unexpected query was received: %s", query)
+			return mockResult{}, nil
+		}
+	})
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	service.CacheSize = 10
+
+	for _, link := range []string{"promo", "capped"} {
+		if _, err := service.Get(context.Background(), &api.Link{Link: link}); err != nil {
+			t.Fatalf("Get method reported an error: %v", err)
+		}
+	}
+
+	if _, err := service.SetMaintenance(context.Background(), &api.MaintenanceRequest{Enabled: true}); err != nil {
+		t.Fatalf("SetMaintenance method reported an error: %v", err)
+	}
+
+	url := &api.URL{Url: "http://maintenance.abc/new"}
+
+	testCases := []struct {
+		name        string
+		serveCached bool
+		call        func() error
+		expCode     codes.Code
+	}{
+		{name: "create", call: func() error {
+			_, err := service.Create(context.Background(), url)
+			return err
+		}, expCode: codes.Unavailable},
+		{name: "get_or_create", call: func() error {
+			_, err := service.GetOrCreate(context.Background(), url)
+			return err
+		}, expCode: codes.Unavailable},
+		{name: "batch_create", call: func() error {
+			_, err := service.BatchCreate(context.Background(), &api.URLList{Urls: []*api.URL{url}})
+			return err
+		}, expCode: codes.Unavailable},
+		{name: "import", call: func() error {
+			return service.Import(&importStream{reqs: []*api.ImportRequest{{Link: "imported", Url: url.GetUrl()}}})
+		}, expCode: codes.Unavailable},
+		{name: "get", call: func() error {
+			_, err := service.Get(context.Background(), &api.Link{Link: "promo"})
+			return err
+		}, expCode: codes.Unavailable},
+		{name: "get_cached", serveCached: true, call: func() error {
+			res, err := service.Get(context.Background(), &api.Link{Link: "promo"})
+			if exp := "http://maintenance.abc/promo"; err == nil && res.GetUrl() != exp {
+				t.Errorf("the URL \"%s\" was expected, but \"%s\" was received", exp, res.GetUrl())
+			}
+
+			return err
+		}, expCode: codes.OK},
+		{name: "get_cached_alias", serveCached: true, call: func() error {
+			_, err := service.Get(context.Background(), &api.Link{Link: "Promo"})
+			return err
+		}, expCode: codes.OK},
+		{name: "get_not_cached", serveCached: true, call: func() error {
+			_, err := service.Get(context.Background(), &api.Link{Link: "other"})
+			return err
+		}, expCode: codes.Unavailable},
+		// без учета перехода нельзя проверить ограничение max_uses
+		{name: "get_cached_limited", serveCached: true, call: func() error {
+			_, err := service.Get(context.Background(), &api.Link{Link: "capped"})
+			return err
+		}, expCode: codes.Unavailable},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			service.MaintenanceServeCached = testCase.serveCached
+			before := len(db.executed())
+
+			if code := status.Code(testCase.call()); code != testCase.expCode {
+				t.Errorf("the code %v was expected, but %v was received", testCase.expCode, code)
+			}
+
+			if queries := db.executed()[before:]; len(queries) != 0 {
+				t.Errorf("no queries were expected in maintenance mode, but %q were executed", queries)
+			}
+		})
+	}
+
+	if _, err := service.SetMaintenance(context.Background(), &api.MaintenanceRequest{}); err != nil {
+		t.Fatalf("SetMaintenance method reported an error: %v", err)
+	}
+
+	if _, err := service.Get(context.Background(), &api.Link{Link: "other"}); err != nil {
+		t.Errorf("Get method reported an error after maintenance: %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var (
+	// максимальная длина названия ссылки — размер столбца title в базе данных
+	maxTitleLength = 255
+
+	// максимальная длина идентификатора владельца ссылки — размер столбца
+	// owner_id в базе данных
+	maxOwnerLength = 64
+)
+
+// checkMetadata проверяет название и идентификатор владельца ссылки в запросе
+// req. Пустые значения допустимы и означают отсутствие метаданных.
+func checkMetadata(req *api.URL) error {
+	if utf8.RuneCountInString(req.GetTitle()) > maxTitleLength || utf8.RuneCountInString(req.GetOwnerId()) > maxOwnerLength {
+		return ErrInvalidMetadata
+	}
+
+	return nil
+}
+
+// nullString возвращает значение, которое сохраняется в базе данных как NULL,
+// если строка s пуста.
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// GetMetadata возвращает оригинальный URL указанной в запросе короткой ссылки
+// вместе с ее названием, идентификатором владельца, временем создания и
+// версией. В отличие от метода Get, переход по ссылке не учитывается. Ошибки
+// передаются клиенту с теми же кодами состояния gRPC, что и в методе Get.
+func (s *GRPCServer) GetMetadata(ctx context.Context, req *api.Link) (*api.LinkMetadata, error) {
+	metadata, err := s.getMetadata(ctx, req)
+	return metadata, statusError(err)
+}
+
+// getMetadata реализует метод GetMetadata, возвращая ошибки сервиса без
+// преобразования в ошибки gRPC.
+func (s *GRPCServer) getMetadata(ctx context.Context, req *api.Link) (*api.LinkMetadata, error) {
+	if !s.validLink(req.GetLink()) {
+		return nil, ErrInvalidLink
+	}
+
+	if err := checkNamespace(req.GetNamespace()); err != nil {
+		return nil, err
+	}
+
+	var url string
+	var title, owner sql.NullString
+	var createdAt time.Time
+	var expires sql.NullTime
+	var version int64
+
+	err := s.retry(ctx, "select_metadata", func(ctx context.Context) error {
+		return s.readDB().QueryRowContext(ctx, "SELECT original_url, title, owner_id, created_at, expires_at, version FROM links WHERE link = $1 AND namespace = $2;",
+			req.GetLink(), req.GetNamespace()).Scan(&url, &title, &owner, &createdAt, &expires, &version)
+	})
+
+	// ссылки с истекшим сроком действия считаются несуществующими, как и в
+	// методе Get
+	if err == sql.ErrNoRows || err == nil && expired(expires) {
+		return nil, ErrURLNotFound
+	}
+
+	if err != nil {
+		return nil, s.requestError(ctx, "GetMetadata", err, "link", req.GetLink())
+	}
+
+	return &api.LinkMetadata{
+		Link:      req.GetLink(),
+		Url:       url,
+		Title:     title.String,
+		OwnerId:   owner.String,
+		CreatedAt: timestamppb.New(createdAt),
+		Version:   version,
+	}, nil
+}
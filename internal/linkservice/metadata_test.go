@@ -0,0 +1,95 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+)
+
+func TestCheckMetadata(t *testing.T) {
+	testCases := []struct {
+		name     string
+		req      *api.URL
+		expError error
+	}{
+		{name: "empty", req: &api.URL{}, expError: nil},
+		{name: "at_limit", req: &api.URL{Title: strings.Repeat("т", maxTitleLength), OwnerId: strings.Repeat("o", maxOwnerLength)}, expError: nil},
+		{name: "long_title", req: &api.URL{Title: strings.Repeat("т", maxTitleLength+1)}, expError: ErrInvalidMetadata},
+		{name: "long_owner", req: &api.URL{OwnerId: strings.Repeat("o", maxOwnerLength+1)}, expError: ErrInvalidMetadata},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if err := checkMetadata(testCase.req); err != testCase.expError {
+				t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", testCase.expError, err)
+			}
+		})
+	}
+}
+
+func TestGetMetadata(t *testing.T) {
+	// устанавливаем подключение к базе данных
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed connecting to the database: %v", err)
+	}
+
+	defer db.Close()
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	defer service.Close()
+
+	testCases := []struct {
+		name string
+		req  *api.URL
+	}{
+		{name: "with_metadata", req: &api.URL{Url: "http://metadata.abc/" + generateRandomСharacters(6), Title: "Весенняя акция", OwnerId: "tenant-" + generateRandomСharacters(6)}},
+		{name: "without_metadata", req: &api.URL{Url: "http://metadata.abc/" + generateRandomСharacters(6)}},
+		{name: "alias", req: &api.URL{Url: "http://metadata.abc/" + generateRandomСharacters(6), Alias: "meta-" + generateRandomСharacters(6), Title: "Promo"}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			link, err := service.Create(context.Background(), testCase.req)
+			if err != nil {
+				t.Fatalf("Create method reported an error: %v", err)
+			}
+
+			res, err := service.GetMetadata(context.Background(), link)
+			if err != nil {
+				t.Fatalf("GetMetadata method reported an error: %v", err)
+			}
+
+			if res.GetLink() != link.GetLink() || res.GetUrl() != testCase.req.GetUrl() {
+				t.Errorf("the link \"%s\" to \"%s\" was expected, but \"%s\" to \"%s\" was received",
+					link.GetLink(), testCase.req.GetUrl(), res.GetLink(), res.GetUrl())
+			}
+
+			if res.GetTitle() != testCase.req.GetTitle() || res.GetOwnerId() != testCase.req.GetOwnerId() {
+				t.Errorf("the metadata \"%s\", \"%s\" was expected, but \"%s\", \"%s\" was received",
+					testCase.req.GetTitle(), testCase.req.GetOwnerId(), res.GetTitle(), res.GetOwnerId())
+			}
+
+			if res.GetCreatedAt() == nil {
+				t.Errorf("the creation time was expected in the response")
+			}
+		})
+	}
+
+	_, err = service.GetMetadata(context.Background(), &api.Link{Link: generateRandomСharacters(lengthLink)})
+	if err = FromStatus(err); err != ErrURLNotFound {
+		t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrURLNotFound, err)
+	}
+
+	_, err = service.Create(context.Background(), &api.URL{Url: "http://metadata.abc/", Title: strings.Repeat("t", maxTitleLength+1)})
+	if err = FromStatus(err); err != ErrInvalidMetadata {
+		t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrInvalidMetadata, err)
+	}
+}
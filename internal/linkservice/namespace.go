@@ -0,0 +1,32 @@
+package linkservice
+
+import "regexp"
+
+// namespaceTemplate представляет собой скомпилированное регулярное выражение
+// для проверки пространств имен: от 1 до 64 строчных латинских букв, цифр,
+// символов подчеркивания и дефисов
+var namespaceTemplate = regexp.MustCompile(`^[0-9a-z_-]{1,64}$`)
+
+// checkNamespace проверяет пространство имен namespace. Пустая строка
+// обозначает пространство имен по умолчанию, в котором хранятся ссылки,
+// созданные без указания пространства имен.
+func checkNamespace(namespace string) error {
+	if namespace != "" && !namespaceTemplate.MatchString(namespace) {
+		return ErrInvalidNamespace
+	}
+
+	return nil
+}
+
+// namespacedLink возвращает ключ короткой ссылки link в пространстве имен
+// namespace, различающий одинаковые ссылки разных пространств имен. Для
+// пространства имен по умолчанию ключом служит сама ссылка. Символ "/" не
+// может входить ни в ссылку, ни в пространство имен, поэтому ключи разных
+// ссылок не совпадают.
+func namespacedLink(namespace, link string) string {
+	if namespace == "" {
+		return link
+	}
+
+	return namespace + "/" + link
+}
@@ -0,0 +1,237 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+)
+
+func TestCheckNamespace(t *testing.T) {
+	testCases := []struct {
+		namespace string
+		err       error
+	}{
+		{namespace: "", err: nil},
+		{namespace: "brand-a", err: nil},
+		{namespace: "brand_2", err: nil},
+		{namespace: "Brand", err: ErrInvalidNamespace},
+		{namespace: "brand/a", err: ErrInvalidNamespace},
+		{namespace: strings.Repeat("a", 65), err: ErrInvalidNamespace},
+	}
+
+	for _, testCase := range testCases {
+		if err := checkNamespace(testCase.namespace); err != testCase.err {
+			t.Errorf("an error with a value of \"%v\" was expected for \"%s\", but \"%v\" was received", testCase.err, testCase.namespace, err)
+		}
+	}
+}
+
+func TestGetNamespaceWithMockDB(t *testing.T) {
+	const link = "abcdefghij"
+
+	// одна и та же короткая ссылка ведет на разные URL в разных пространствах
+	// имен
+	urls := map[string]string{"": "http://default.abc/", "brand-a": "http://brand-a.abc/"}
+
+	db := newMockDB(func(query string, args []driver.NamedValue) (mockResult, error) {
+		switch {
+		case query == selectURLQuery:
+			url, ok := urls[args[1].Value.(string)]
+			if !ok {
+				return mockResult{columns: []string{"original_url"}}, nil
+			}
+
+			return urlRow(url, nil), nil
+		case strings.HasPrefix(query, "UPDATE links SET visits"):
+			return mockResult{columns: []string{"expires_at"}, rows: [][]driver.Value{{nil}}}, nil
+		case strings.HasPrefix(query, "INSERT INTO link_hits"):
+			return mockResult{affected: 1}, nil
+		default:
+			t.Fatalf("an unexpected query was received: %s", query)
+			return mockResult{}, nil
+		}
+	})
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	// кэш также различает пространства имен
+	service.CacheSize = 10
+
+	for i := 0; i < 2; i++ {
+		for namespace, exp := range urls {
+			url, err := service.Get(context.Background(), &api.Link{Link: link, Namespace: namespace})
+			if err != nil {
+				t.Fatalf("Get method reported an error: %v", err)
+			}
+
+			if url.GetUrl() != exp {
+				t.Errorf("the URL \"%s\" was expected in the namespace \"%s\", but \"%s\" was received", exp, namespace, url.GetUrl())
+			}
+		}
+	}
+
+	_, err = service.Get(context.Background(), &api.Link{Link: link, Namespace: "brand-b"})
+	if err = FromStatus(err); err != ErrURLNotFound {
+		t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrURLNotFound, err)
+	}
+
+	_, err = service.Get(context.Background(), &api.Link{Link: link, Namespace: "Brand B"})
+	if err = FromStatus(err); err != ErrInvalidNamespace {
+		t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrInvalidNamespace, err)
+	}
+}
+
+func TestCreateNamespaceWithMockDB(t *testing.T) {
+	var stored string
+	db := newMockDB(func(query string, args []driver.NamedValue) (mockResult, error) {
+		switch {
+		case query == insertLinkQuery:
+			stored = args[11].Value.(string)
+			return linkRow(args[0].Value.(string)), nil
+		case strings.HasPrefix(query, "DELETE FROM links WHERE original_url"):
+			return mockResult{}, nil
+		default:
+			t.Fatalf("an unexpected query was received: %s", query)
+			return mockResult{}, nil
+		}
+	})
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	if _, err := service.Create(context.Background(), &api.URL{Url: "http://brand-a.abc/", Namespace: "brand-a"}); err != nil {
+		t.Fatalf("Create method reported an error: %v", err)
+	}
+
+	if stored != "brand-a" {
+		t.Errorf("the link was expected to be stored in the namespace \"brand-a\", but \"%s\" was received", stored)
+	}
+
+	_, err = service.Create(context.Background(), &api.URL{Url: "http://brand-a.abc/", Namespace: "brand a"})
+	if err = FromStatus(err); err != ErrInvalidNamespace {
+		t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrInvalidNamespace, err)
+	}
+
+	// пространство имен каждого URL пакетного запроса также проверяется
+	_, err = service.BatchCreate(context.Background(), &api.URLList{Urls: []*api.URL{{Url: "http://brand-a.abc/", Namespace: "brand a"}}})
+	if err = FromStatus(err); err != ErrInvalidNamespace {
+		t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrInvalidNamespace, err)
+	}
+}
+
+func TestGetBatchNamespaceWithMockDB(t *testing.T) {
+	db := newMockDB(func(query string, args []driver.NamedValue) (mockResult, error) {
+		if !strings.HasPrefix(query, "SELECT namespace, link, original_url, created_at FROM links") {
+			t.Fatalf("an unexpected query was received: %s", query)
+		}
+
+		// одна и та же ссылка существует лишь в пространстве имен brand-a
+		return mockResult{
+			columns: []string{"namespace", "link", "original_url", "created_at"},
+			rows:    [][]driver.Value{{"brand-a", "promo", "http://brand-a.abc/promo", time.Now()}},
+		}, nil
+	})
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	res, err := service.GetBatch(context.Background(), &api.LinkList{Links: []*api.Link{
+		{Link: "promo", Namespace: "brand-a"},
+		{Link: "promo"},
+		{Link: "promo", Namespace: "Brand A"},
+	}})
+	if err != nil {
+		t.Fatalf("GetBatch method reported an error: %v", err)
+	}
+
+	var urls []string
+	for _, u := range res.GetUrls() {
+		urls = append(urls, u.GetUrl())
+	}
+
+	if exp := []string{"http://brand-a.abc/promo", "", ""}; !reflect.DeepEqual(urls, exp) {
+		t.Errorf("the URLs %q were expected, but %q were received", exp, urls)
+	}
+}
+
+func TestNamespaces(t *testing.T) {
+	// устанавливаем подключение к базе данных
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed connecting to the database: %v", err)
+	}
+
+	defer db.Close()
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	defer service.Close()
+
+	// один и тот же псевдоним занимается в каждом пространстве имен
+	// независимо
+	alias := "brand-" + strings.ToLower(generateRandomСharacters(6))
+	urls := map[string]string{
+		"":        "http://brand.abc/" + generateRandomСharacters(6),
+		"brand-a": "http://brand-a.abc/" + generateRandomСharacters(6),
+		"brand-b": "http://brand-b.abc/" + generateRandomСharacters(6),
+	}
+
+	for namespace, url := range urls {
+		if _, err := service.Create(context.Background(), &api.URL{Url: url, Alias: alias, Namespace: namespace}); err != nil {
+			t.Fatalf("Create method reported an error in the namespace \"%s\": %v", namespace, err)
+		}
+	}
+
+	_, err = service.Create(context.Background(), &api.URL{Url: urls["brand-a"] + "/other", Alias: alias, Namespace: "brand-a"})
+	if err = FromStatus(err); err != ErrAliasTaken {
+		t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrAliasTaken, err)
+	}
+
+	for namespace, exp := range urls {
+		url, err := service.Get(context.Background(), &api.Link{Link: alias, Namespace: namespace})
+		if err != nil {
+			t.Fatalf("Get method reported an error in the namespace \"%s\": %v", namespace, err)
+		}
+
+		if url.GetUrl() != exp {
+			t.Errorf("the URL \"%s\" was expected in the namespace \"%s\", but \"%s\" was received", exp, namespace, url.GetUrl())
+		}
+	}
+
+	// один и тот же URL получает в разных пространствах имен разные ссылки
+	url := "http://shared.abc/" + generateRandomСharacters(6)
+
+	a, err := service.Create(context.Background(), &api.URL{Url: url, Namespace: "brand-a"})
+	if err != nil {
+		t.Fatalf("Create method reported an error: %v", err)
+	}
+
+	b, err := service.Create(context.Background(), &api.URL{Url: url, Namespace: "brand-b"})
+	if err != nil {
+		t.Fatalf("Create method reported an error: %v", err)
+	}
+
+	if _, err := service.Get(context.Background(), &api.Link{Link: a.GetLink(), Namespace: "brand-a"}); err != nil {
+		t.Errorf("Get method reported an error: %v", err)
+	}
+
+	if _, err := service.Get(context.Background(), &api.Link{Link: b.GetLink(), Namespace: "brand-b"}); err != nil {
+		t.Errorf("Get method reported an error: %v", err)
+	}
+}
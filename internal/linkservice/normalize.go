@@ -0,0 +1,122 @@
+package linkservice
+
+import (
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+	"google.golang.org/protobuf/proto"
+)
+
+// DuplicateSlashes определяет обработку повторяющихся символов "/" в пути URL
+// при создании коротких ссылок
+type DuplicateSlashes int
+
+const (
+	// PreserveSlashes сохраняет путь URL без изменений
+	PreserveSlashes DuplicateSlashes = iota
+
+	// CollapseSlashes заменяет повторяющиеся символы "/" в пути URL одним
+	// символом, так что URL, отличающиеся лишь количеством символов "/",
+	// получают одну и ту же короткую ссылку
+	CollapseSlashes
+
+	// RejectSlashes отклоняет URL с повторяющимися символами "/" в пути
+	RejectSlashes
+)
+
+// defaultPorts сопоставляет схемам URL порты, которые используются ими по
+// умолчанию и поэтому не влияют на адрес ресурса
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// slashesTemplate представляет собой скомпилированное регулярное выражение
+// для поиска повторяющихся символов "/"
+var slashesTemplate = regexp.MustCompile(`/{2,}`)
+
+// normalize приводит URL из запроса req к виду, в котором он сохраняется в
+// базе данных. Если URL изменяется, то возвращается копия запроса, исходный
+// запрос не изменяется. Если URL не может быть приведен к нужному виду, то
+// возвращается ошибка ErrInvalidURL.
+func (s *GRPCServer) normalize(req *api.URL) (*api.URL, error) {
+	url := normalizeURL(req.GetUrl())
+
+	if s.DuplicateSlashes != PreserveSlashes {
+		collapsed := collapseSlashes(url)
+
+		if collapsed != url && s.DuplicateSlashes == RejectSlashes {
+			return nil, ErrInvalidURL
+		}
+
+		url = collapsed
+	}
+
+	if url == req.GetUrl() {
+		return req, nil
+	}
+
+	req = proto.Clone(req).(*api.URL)
+	req.Url = url
+
+	return req, nil
+}
+
+// normalizeURL приводит эквивалентные записи URL u к одному виду: схема и хост
+// переводятся в нижний регистр, порт по умолчанию для схемы удаляется, а путь,
+// состоящий из одного символа "/", отбрасывается. URL без схемы, а также URL,
+// которые не удается разобрать, возвращаются без изменений.
+func normalizeURL(u string) string {
+	parsed, err := url.Parse(u)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return u
+	}
+
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	parsed.Host = strings.ToLower(parsed.Host)
+
+	if host, port, err := net.SplitHostPort(parsed.Host); err == nil && port == defaultPorts[parsed.Scheme] {
+		parsed.Host = host
+
+		// адрес IPv6 без порта должен оставаться в квадратных скобках
+		if strings.Contains(host, ":") {
+			parsed.Host = "[" + host + "]"
+		}
+	}
+
+	if parsed.Path == "/" && parsed.RawPath == "" {
+		parsed.Path = ""
+	}
+
+	return parsed.String()
+}
+
+// collapseSlashes заменяет повторяющиеся символы "/" в пути URL u одним
+// символом. Символы "//" после схемы, а также символы в запросе и во
+// фрагменте URL не изменяются.
+func collapseSlashes(u string) string {
+	// пропускаем схему вместе с символами "//"
+	start := 0
+	if i := strings.Index(u, "://"); i >= 0 {
+		start = i + len("://")
+	}
+
+	// путь начинается с первого символа "/" после хоста и заканчивается перед
+	// запросом или фрагментом
+	h := strings.IndexAny(u[start:], "/?#")
+	if h < 0 || u[start+h] != '/' {
+		return u
+	}
+
+	begin := start + h
+	end := len(u)
+
+	if q := strings.IndexAny(u[begin:], "?#"); q >= 0 {
+		end = begin + q
+	}
+
+	return u[:begin] + slashesTemplate.ReplaceAllString(u[begin:end], "/") + u[end:]
+}
@@ -0,0 +1,211 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	_ "github.com/lib/pq"
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+)
+
+func TestNormalizeSlashes(t *testing.T) {
+	testCases := []struct {
+		name     string
+		mode     DuplicateSlashes
+		url      string
+		expURL   string
+		expError error
+	}{
+		{
+			name:   "preserve",
+			mode:   PreserveSlashes,
+			url:    "http://example.com//a///b",
+			expURL: "http://example.com//a///b",
+		},
+		{
+			name:   "collapse",
+			mode:   CollapseSlashes,
+			url:    "http://example.com//a///b",
+			expURL: "http://example.com/a/b",
+		},
+		{
+			name:   "collapse_keeps_query",
+			mode:   CollapseSlashes,
+			url:    "https://example.com/a//b?next=//c#//d",
+			expURL: "https://example.com/a/b?next=//c#//d",
+		},
+		{
+			name:   "collapse_schemeless",
+			mode:   CollapseSlashes,
+			url:    "example.com//a",
+			expURL: "example.com/a",
+		},
+		{
+			name:     "reject",
+			mode:     RejectSlashes,
+			url:      "http://example.com//a",
+			expError: ErrInvalidURL,
+		},
+		{
+			name:   "reject_clean",
+			mode:   RejectSlashes,
+			url:    "http://example.com/a/b",
+			expURL: "http://example.com/a/b",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			service := GRPCServer{DuplicateSlashes: testCase.mode}
+			req := &api.URL{Url: testCase.url}
+
+			res, err := service.normalize(req)
+
+			if err != testCase.expError {
+				t.Fatalf("an error with a value of \"%v\" was expected, but \"%v\" was received",
+					testCase.expError, err)
+			}
+
+			if err == nil && res.GetUrl() != testCase.expURL {
+				t.Errorf("URL \"%s\" was expected, but \"%s\" was received", testCase.expURL, res.GetUrl())
+			}
+
+			// исходный запрос не должен изменяться
+			if req.GetUrl() != testCase.url {
+				t.Errorf("the original request was modified")
+			}
+		})
+	}
+}
+
+func TestNormalizeURL(t *testing.T) {
+	testCases := []struct {
+		name   string
+		url    string
+		expURL string
+	}{
+		{
+			name:   "case",
+			url:    "HTTP://Example.COM/Path",
+			expURL: "http://example.com/Path",
+		},
+		{
+			name:   "default_http_port",
+			url:    "http://example.com:80/a",
+			expURL: "http://example.com/a",
+		},
+		{
+			name:   "default_https_port",
+			url:    "https://example.com:443/a",
+			expURL: "https://example.com/a",
+		},
+		{
+			name:   "other_port",
+			url:    "https://example.com:80/a",
+			expURL: "https://example.com:80/a",
+		},
+		{
+			name:   "trailing_slash",
+			url:    "http://example.com/",
+			expURL: "http://example.com",
+		},
+		{
+			name:   "trailing_slash_with_query",
+			url:    "http://example.com/?q=1",
+			expURL: "http://example.com?q=1",
+		},
+		{
+			name:   "trailing_slash_in_path",
+			url:    "http://example.com/a/",
+			expURL: "http://example.com/a/",
+		},
+		{
+			name:   "schemeless",
+			url:    "Example.com/",
+			expURL: "Example.com/",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if url := normalizeURL(testCase.url); url != testCase.expURL {
+				t.Errorf("URL \"%s\" was expected, but \"%s\" was received", testCase.expURL, url)
+			}
+		})
+	}
+}
+
+func TestCreateNormalizesURL(t *testing.T) {
+	// устанавливаем подключение к базе данных
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed connecting to the database: %v", err)
+	}
+
+	defer db.Close()
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	defer service.Close()
+
+	host := "normalize-" + strings.ToLower(generateRandomСharacters(6)) + ".abc"
+
+	link, err := service.Create(context.Background(), &api.URL{Url: "http://" + host})
+	if err != nil {
+		t.Fatalf("Create method reported an error: %v", err)
+	}
+
+	for _, url := range []string{
+		"http://" + host + "/",
+		"HTTP://" + strings.ToUpper(host) + "/",
+		"http://" + host + ":80",
+	} {
+		res, err := service.Create(context.Background(), &api.URL{Url: url})
+		if err != nil {
+			t.Fatalf("Create method reported an error: %v", err)
+		}
+
+		if res.GetLink() != link.GetLink() {
+			t.Errorf("different abbreviated links were generated for the URL \"%s\"", url)
+		}
+	}
+}
+
+func TestCreateCollapsesSlashes(t *testing.T) {
+	// устанавливаем подключение к базе данных
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed connecting to the database: %v", err)
+	}
+
+	defer db.Close()
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	defer service.Close()
+
+	service.DuplicateSlashes = CollapseSlashes
+	path := generateRandomСharacters(6)
+
+	link1, err := service.Create(context.Background(), &api.URL{Url: "http://slashes.abc/a/" + path})
+	if err != nil {
+		t.Fatalf("Create method reported an error: %v", err)
+	}
+
+	link2, err := service.Create(context.Background(), &api.URL{Url: "http://slashes.abc//a///" + path})
+	if err != nil {
+		t.Fatalf("Create method reported an error: %v", err)
+	}
+
+	if link1.GetLink() != link2.GetLink() {
+		t.Errorf("different abbreviated links were generated for equivalent URLs")
+	}
+}
@@ -0,0 +1,179 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"time"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var (
+	// количество ссылок на странице ответа ListByOwner по умолчанию
+	pageSizeDefault = 100
+
+	// максимальное количество ссылок на странице ответа ListByOwner
+	maxPageSize = 1000
+
+	// количество ссылок, удаляемых методом DeleteByOwner одним запросом к
+	// базе данных
+	deleteChunkSize = 1000
+)
+
+// ListByOwner возвращает страницу ссылок указанного в запросе владельца из
+// указанного пространства имен, упорядоченных по короткой ссылке, вместе с их
+// метаданными. Если ссылок больше, чем помещается на странице, то ответ
+// содержит токен следующей страницы, который передается в page_token
+// следующего запроса. Размер страницы page_size по умолчанию равен 100 и не
+// превышает 1000. Для владельца без ссылок возвращается пустой список.
+//
+// Ошибки передаются клиенту с кодами состояния gRPC: ErrInvalidOwner,
+// ErrInvalidNamespace и ErrInvalidPageToken — codes.InvalidArgument,
+// ErrDeadlineExceeded — codes.DeadlineExceeded, ErrReqProc — codes.Internal.
+func (s *GRPCServer) ListByOwner(ctx context.Context, req *api.OwnerRequest) (*api.OwnerLinks, error) {
+	links, err := s.listByOwner(ctx, req)
+	return links, statusError(err)
+}
+
+// listByOwner реализует метод ListByOwner, возвращая ошибки сервиса без
+// преобразования в ошибки gRPC.
+func (s *GRPCServer) listByOwner(ctx context.Context, req *api.OwnerRequest) (*api.OwnerLinks, error) {
+	if req.GetOwnerId() == "" {
+		return nil, ErrInvalidOwner
+	}
+
+	if err := checkNamespace(req.GetNamespace()); err != nil {
+		return nil, err
+	}
+
+	// токен страницы содержит последнюю короткую ссылку предыдущей страницы,
+	// поэтому страница выбирается по индексу (owner_id, link) без смещения
+	after, err := base64.RawURLEncoding.DecodeString(req.GetPageToken())
+	if err != nil {
+		return nil, ErrInvalidPageToken
+	}
+
+	size := int(req.GetPageSize())
+	if size <= 0 {
+		size = pageSizeDefault
+	} else if size > maxPageSize {
+		size = maxPageSize
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	// запрашиваем на одну запись больше, чтобы узнать, есть ли следующая
+	// страница
+	rows, err := s.readDB().QueryContext(ctx, "SELECT link, original_url, title, created_at FROM links "+
+		"WHERE owner_id = $1 AND namespace = $5 AND link > $2 AND (expires_at IS NULL OR expires_at > $3) ORDER BY link LIMIT $4;",
+		req.GetOwnerId(), string(after), time.Now(), size+1, req.GetNamespace())
+	if err != nil {
+		return nil, s.requestError(ctx, "ListByOwner", err, "owner", req.GetOwnerId())
+	}
+
+	defer rows.Close()
+
+	res := &api.OwnerLinks{}
+	for rows.Next() {
+		var link, url string
+		var title sql.NullString
+		var createdAt time.Time
+
+		if err := rows.Scan(&link, &url, &title, &createdAt); err != nil {
+			return nil, s.requestError(ctx, "ListByOwner", err, "owner", req.GetOwnerId())
+		}
+
+		res.Links = append(res.Links, &api.LinkMetadata{
+			Link:      link,
+			Url:       url,
+			Title:     title.String,
+			OwnerId:   req.GetOwnerId(),
+			CreatedAt: timestamppb.New(createdAt),
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, s.requestError(ctx, "ListByOwner", err, "owner", req.GetOwnerId())
+	}
+
+	if len(res.Links) > size {
+		res.Links = res.Links[:size]
+		res.NextPageToken = base64.RawURLEncoding.EncodeToString([]byte(res.Links[size-1].GetLink()))
+	}
+
+	return res, nil
+}
+
+// DeleteByOwner удаляет все ссылки указанного в запросе владельца, в том числе
+// с истекшим сроком действия, и возвращает количество удаленных ссылок. Ссылки
+// удаляются из всех пространств имен частями, чтобы не блокировать надолго
+// большое количество строк, поэтому при ошибке часть ссылок может оказаться
+// уже удаленной. Для владельца без ссылок возвращается нулевое количество.
+// Поля page_size и page_token запроса не используются, а запрос с указанным
+// пространством имен отклоняется, чтобы не удалить ссылки других пространств
+// имен по ошибке.
+//
+// Ошибки передаются клиенту с кодами состояния gRPC: ErrInvalidOwner и
+// ErrInvalidNamespace — codes.InvalidArgument, ErrDeadlineExceeded —
+// codes.DeadlineExceeded, ErrReqProc — codes.Internal.
+func (s *GRPCServer) DeleteByOwner(ctx context.Context, req *api.OwnerRequest) (*api.DeleteCount, error) {
+	count, err := s.deleteByOwner(ctx, req)
+	return count, statusError(err)
+}
+
+// deleteByOwner реализует метод DeleteByOwner, возвращая ошибки сервиса без
+// преобразования в ошибки gRPC.
+func (s *GRPCServer) deleteByOwner(ctx context.Context, req *api.OwnerRequest) (*api.DeleteCount, error) {
+	if req.GetOwnerId() == "" {
+		return nil, ErrInvalidOwner
+	}
+
+	if req.GetNamespace() != "" {
+		return nil, ErrInvalidNamespace
+	}
+
+	res := &api.DeleteCount{}
+	for {
+		n, err := s.deleteOwnerChunk(ctx, req.GetOwnerId())
+		if err != nil {
+			return nil, s.requestError(ctx, "DeleteByOwner", err, "owner", req.GetOwnerId(), "deleted", res.Deleted)
+		}
+
+		res.Deleted += int64(n)
+		if n < deleteChunkSize {
+			return res, nil
+		}
+	}
+}
+
+// deleteOwnerChunk удаляет не более deleteChunkSize ссылок владельца owner из
+// всех пространств имен, исключает их из кэша метода Get и возвращает
+// количество удаленных ссылок.
+func (s *GRPCServer) deleteOwnerChunk(ctx context.Context, owner string) (int, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.Database.QueryContext(ctx, "DELETE FROM links WHERE (namespace, link) IN "+
+		"(SELECT namespace, link FROM links WHERE owner_id = $1 LIMIT $2) RETURNING namespace, link;", owner, deleteChunkSize)
+	if err != nil {
+		return 0, err
+	}
+
+	defer rows.Close()
+
+	var n int
+	for rows.Next() {
+		var namespace, link string
+		if err := rows.Scan(&namespace, &link); err != nil {
+			return n, err
+		}
+
+		s.linkCache().remove(namespacedLink(namespace, link))
+		n++
+	}
+
+	return n, rows.Err()
+}
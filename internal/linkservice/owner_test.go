@@ -0,0 +1,141 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+)
+
+func TestOwnerRequestValidation(t *testing.T) {
+	service := &GRPCServer{}
+
+	testCases := []struct {
+		name     string
+		req      *api.OwnerRequest
+		expError error
+	}{
+		{name: "no_owner", req: &api.OwnerRequest{}, expError: ErrInvalidOwner},
+		{name: "invalid_token", req: &api.OwnerRequest{OwnerId: "owner", PageToken: "not a token!"}, expError: ErrInvalidPageToken},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := service.ListByOwner(context.Background(), testCase.req)
+			if err = FromStatus(err); err != testCase.expError {
+				t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", testCase.expError, err)
+			}
+		})
+	}
+
+	_, err := service.DeleteByOwner(context.Background(), &api.OwnerRequest{})
+	if err = FromStatus(err); err != ErrInvalidOwner {
+		t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrInvalidOwner, err)
+	}
+}
+
+func TestOwnerLinks(t *testing.T) {
+	// устанавливаем подключение к базе данных
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed connecting to the database: %v", err)
+	}
+
+	defer db.Close()
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	defer service.Close()
+
+	owner := "owner-" + generateRandomСharacters(6)
+
+	created := make(map[string]string)
+	for i := 0; i < 5; i++ {
+		url := "http://owner.abc/" + generateRandomСharacters(6)
+
+		link, err := service.Create(context.Background(), &api.URL{Url: url, OwnerId: owner})
+		if err != nil {
+			t.Fatalf("Create method reported an error: %v", err)
+		}
+
+		created[link.GetLink()] = url
+	}
+
+	// обходим ссылки владельца страницами по две ссылки
+	listed := make(map[string]string)
+	req := &api.OwnerRequest{OwnerId: owner, PageSize: 2}
+
+	for pages := 1; ; pages++ {
+		res, err := service.ListByOwner(context.Background(), req)
+		if err != nil {
+			t.Fatalf("ListByOwner method reported an error: %v", err)
+		}
+
+		if len(res.GetLinks()) > 2 {
+			t.Fatalf("a page of at most 2 links was expected, but %d links were received", len(res.GetLinks()))
+		}
+
+		for _, link := range res.GetLinks() {
+			listed[link.GetLink()] = link.GetUrl()
+		}
+
+		if res.GetNextPageToken() == "" {
+			if pages != 3 {
+				t.Errorf("3 pages were expected, but %d were received", pages)
+			}
+
+			break
+		}
+
+		req.PageToken = res.GetNextPageToken()
+	}
+
+	if len(listed) != len(created) {
+		t.Errorf("%d links were expected, but %d were listed", len(created), len(listed))
+	}
+
+	for link, url := range created {
+		if listed[link] != url {
+			t.Errorf("the link \"%s\" to \"%s\" was expected in the list", link, url)
+		}
+	}
+
+	// у неизвестного владельца нет ссылок, но это не ошибка
+	res, err := service.ListByOwner(context.Background(), &api.OwnerRequest{OwnerId: "unknown-" + generateRandomСharacters(6)})
+	if err != nil {
+		t.Fatalf("ListByOwner method reported an error: %v", err)
+	}
+
+	if len(res.GetLinks()) != 0 || res.GetNextPageToken() != "" {
+		t.Errorf("an empty list was expected for an unknown owner")
+	}
+
+	count, err := service.DeleteByOwner(context.Background(), &api.OwnerRequest{OwnerId: owner})
+	if err != nil {
+		t.Fatalf("DeleteByOwner method reported an error: %v", err)
+	}
+
+	if count.GetDeleted() != int64(len(created)) {
+		t.Errorf("%d deleted links were expected, but %d were reported", len(created), count.GetDeleted())
+	}
+
+	for link := range created {
+		_, err := service.Get(context.Background(), &api.Link{Link: link})
+		if err = FromStatus(err); err != ErrURLNotFound {
+			t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrURLNotFound, err)
+		}
+	}
+
+	count, err = service.DeleteByOwner(context.Background(), &api.OwnerRequest{OwnerId: owner})
+	if err != nil {
+		t.Fatalf("DeleteByOwner method reported an error: %v", err)
+	}
+
+	if count.GetDeleted() != 0 {
+		t.Errorf("no deleted links were expected, but %d were reported", count.GetDeleted())
+	}
+}
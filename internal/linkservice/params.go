@@ -0,0 +1,80 @@
+package linkservice
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+	"google.golang.org/protobuf/proto"
+)
+
+// stripParams удаляет из URL запроса req параметры запроса, перечисленные в
+// поле strip_params, например параметры отслеживания utm_source. Если URL
+// изменяется, то возвращается копия запроса, исходный запрос не изменяется.
+func stripParams(req *api.URL) *api.URL {
+	if len(req.GetStripParams()) == 0 {
+		return req
+	}
+
+	u := stripQuery(req.GetUrl(), req.GetStripParams())
+	if u == req.GetUrl() {
+		return req
+	}
+
+	req = proto.Clone(req).(*api.URL)
+	req.Url = u
+
+	return req
+}
+
+// stripQuery удаляет из запроса URL u параметры с именами names. Остальные
+// параметры сохраняются в исходном порядке и без повторного кодирования, а
+// если параметров не остается, то удаляется и символ "?". Фрагмент URL не
+// изменяется.
+func stripQuery(u string, names []string) string {
+	q := strings.IndexByte(u, '?')
+	if q < 0 {
+		return u
+	}
+
+	// символ "?" во фрагменте не начинает запрос
+	if f := strings.IndexByte(u, '#'); f >= 0 && f < q {
+		return u
+	}
+
+	end := len(u)
+	if f := strings.IndexByte(u[q:], '#'); f >= 0 {
+		end = q + f
+	}
+
+	strip := make(map[string]bool, len(names))
+	for _, name := range names {
+		strip[name] = true
+	}
+
+	pairs := strings.Split(u[q+1:end], "&")
+	kept := pairs[:0:0]
+
+	for _, pair := range pairs {
+		key := pair
+		if i := strings.IndexByte(pair, '='); i >= 0 {
+			key = pair[:i]
+		}
+
+		if name, err := url.QueryUnescape(key); err == nil && strip[name] {
+			continue
+		}
+
+		kept = append(kept, pair)
+	}
+
+	if len(kept) == len(pairs) {
+		return u
+	}
+
+	if len(kept) == 0 {
+		return u[:q] + u[end:]
+	}
+
+	return u[:q+1] + strings.Join(kept, "&") + u[end:]
+}
@@ -0,0 +1,107 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+)
+
+func TestStripQuery(t *testing.T) {
+	utm := []string{"utm_source", "utm_medium", "utm_campaign"}
+
+	testCases := []struct {
+		name  string
+		url   string
+		names []string
+		exp   string
+	}{
+		{name: "no_names", url: "http://a.bc/?utm_source=x", names: nil, exp: "http://a.bc/?utm_source=x"},
+		{name: "no_query", url: "http://a.bc/path", names: utm, exp: "http://a.bc/path"},
+		{name: "all_stripped", url: "http://a.bc/path?utm_source=x&utm_medium=y", names: utm, exp: "http://a.bc/path"},
+		{name: "others_preserved", url: "http://a.bc/?utm_source=x&id=1&utm_campaign=z&b=%20", names: utm, exp: "http://a.bc/?id=1&b=%20"},
+		{name: "order_preserved", url: "http://a.bc/?z=1&utm_medium=y&a=2", names: utm, exp: "http://a.bc/?z=1&a=2"},
+		{name: "fragment_preserved", url: "http://a.bc/?utm_source=x&id=1#top", names: utm, exp: "http://a.bc/?id=1#top"},
+		{name: "fragment_after_stripped_query", url: "http://a.bc/?utm_source=x#top", names: utm, exp: "http://a.bc/#top"},
+		{name: "question_mark_in_fragment", url: "http://a.bc/#utm_source?utm_source=x", names: utm, exp: "http://a.bc/#utm_source?utm_source=x"},
+		{name: "encoded_name", url: "http://a.bc/?utm%5Fsource=x&id=1", names: utm, exp: "http://a.bc/?id=1"},
+		{name: "name_without_value", url: "http://a.bc/?utm_source&id=1", names: utm, exp: "http://a.bc/?id=1"},
+		{name: "case_sensitive", url: "http://a.bc/?UTM_SOURCE=x", names: utm, exp: "http://a.bc/?UTM_SOURCE=x"},
+		{name: "value_not_matched", url: "http://a.bc/?ref=utm_source", names: utm, exp: "http://a.bc/?ref=utm_source"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if result := stripQuery(testCase.url, testCase.names); result != testCase.exp {
+				t.Errorf("the URL \"%s\" was expected, but \"%s\" was received", testCase.exp, result)
+			}
+		})
+	}
+}
+
+func TestStripParams(t *testing.T) {
+	req := &api.URL{Url: "http://a.bc/?utm_source=x&id=1", StripParams: []string{"utm_source"}}
+
+	res := stripParams(req)
+	if res.GetUrl() != "http://a.bc/?id=1" {
+		t.Errorf("the URL \"http://a.bc/?id=1\" was expected, but \"%s\" was received", res.GetUrl())
+	}
+
+	// исходный запрос не изменяется
+	if req.GetUrl() != "http://a.bc/?utm_source=x&id=1" {
+		t.Errorf("the request was modified: \"%s\"", req.GetUrl())
+	}
+}
+
+func TestCreateStripParams(t *testing.T) {
+	testCases := []struct {
+		name   string
+		req    *api.URL
+		stored string
+	}{
+		{
+			name:   "stripped",
+			req:    &api.URL{Url: "https://news.abc/post?utm_source=mail&utm_medium=email&id=7", StripParams: []string{"utm_source", "utm_medium"}},
+			stored: "https://news.abc/post?id=7",
+		},
+		{
+			name:   "verbatim",
+			req:    &api.URL{Url: "https://news.abc/post?utm_source=mail&id=7"},
+			stored: "https://news.abc/post?utm_source=mail&id=7",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var stored string
+			db := newMockDB(func(query string, args []driver.NamedValue) (mockResult, error) {
+				if query == insertLinkQuery {
+					stored = args[1].Value.(string)
+					return linkRow(args[0].Value.(string)), nil
+				}
+
+				if strings.HasPrefix(query, "DELETE FROM links WHERE original_url") {
+					return mockResult{}, nil
+				}
+
+				t.Fatalf("an unexpected query was received: %s", query)
+				return mockResult{}, nil
+			})
+
+			service, err := NewGRPCServer(db)
+			if err != nil {
+				t.Fatalf("failed to prepare the server: %v", err)
+			}
+
+			if _, err := service.Create(context.Background(), testCase.req); err != nil {
+				t.Fatalf("Create method reported an error: %v", err)
+			}
+
+			if stored != testCase.stored {
+				t.Errorf("the URL \"%s\" was expected to be stored, but \"%s\" was received", testCase.stored, stored)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+)
+
+// пауза перед повторной попыткой пополнения пула после ошибки базы данных
+var poolRetryDelay = time.Second
+
+// tokenPool хранит заранее сгенерированные и зарезервированные в таблице
+// reserved_links короткие ссылки, которые метод Create использует вместо
+// генерации новых.
+type tokenPool struct {
+	tokens chan string
+
+	// refill сигнализирует о том, что из пула была взята короткая ссылка
+	refill chan struct{}
+}
+
+// linkPool возвращает пул коротких ссылок сервера или nil, если пул отключен
+// или короткие ссылки не генерируются случайно. Пул создается при первом
+// обращении, поэтому PoolSize должен быть задан до начала обработки запросов.
+func (s *GRPCServer) linkPool() *tokenPool {
+	s.poolOnce.Do(func() {
+		if s.PoolSize > 0 && s.CodeStrategy == RandomAlphanumeric {
+			s.pool = &tokenPool{
+				tokens: make(chan string, s.PoolSize),
+				refill: make(chan struct{}, 1),
+			}
+		}
+	})
+
+	return s.pool
+}
+
+// pop возвращает короткую ссылку из пула, не дожидаясь ее появления. Если пул
+// отключен или пуст, то возвращается false.
+func (p *tokenPool) pop() (string, bool) {
+	if p == nil {
+		return "", false
+	}
+
+	select {
+	case link := <-p.tokens:
+		// сообщаем о необходимости пополнить пул, не блокируясь, если
+		// сигнал уже отправлен
+		select {
+		case p.refill <- struct{}{}:
+		default:
+		}
+
+		return link, true
+
+	default:
+		return "", false
+	}
+}
+
+// push возвращает в пул неиспользованную короткую ссылку link. Если пул уже
+// заполнен, то возвращается false.
+func (p *tokenPool) push(link string) bool {
+	select {
+	case p.tokens <- link:
+		return true
+	default:
+		return false
+	}
+}
+
+// FillPool заполняет пул коротких ссылок размером PoolSize и пополняет его по
+// мере того, как метод Create использует ссылки. Ссылки генерируются из
+// алфавита по умолчанию и резервируются в базе данных, поэтому не выдаются
+// другим экземплярам сервиса. Метод блокируется до отмены контекста ctx, после
+// чего снимает резерв с неиспользованных ссылок, поэтому его следует запускать
+// в отдельной горутине. Если PoolSize не задан, то метод сразу завершается.
+func (s *GRPCServer) FillPool(ctx context.Context) {
+	pool := s.linkPool()
+	if pool == nil {
+		return
+	}
+
+	defer s.releasePool(pool)
+
+	for {
+		for len(pool.tokens) < cap(pool.tokens) {
+			link, err := s.reserveToken(ctx)
+
+			if err != nil && ctx.Err() != nil {
+				return
+			}
+
+			if err != nil {
+				s.logger().Error("failed to refill the link pool", "error", err)
+
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(poolRetryDelay):
+				}
+
+				continue
+			}
+
+			// метод Create может вернуть в пул неиспользованную ссылку, поэтому
+			// пул мог заполниться, пока резервировалась новая
+			if link != "" && !pool.push(link) {
+				s.releaseToken(link)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-pool.refill:
+		}
+	}
+}
+
+// reserveToken генерирует короткую ссылку и резервирует ее в базе данных.
+// Если ссылка уже занята или зарезервирована, то возвращается пустая строка.
+func (s *GRPCServer) reserveToken(ctx context.Context) (string, error) {
+	link := s.generateLink(s.alphabets()[""])
+
+	res, err := s.Database.ExecContext(ctx, "INSERT INTO reserved_links (link) SELECT $1 WHERE NOT EXISTS (SELECT 1 FROM links WHERE link = $1) ON CONFLICT DO NOTHING;", link)
+	if err != nil {
+		return "", err
+	}
+
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return "", err
+	}
+
+	return link, nil
+}
+
+// releasePool снимает резерв с оставшихся в пуле коротких ссылок.
+func (s *GRPCServer) releasePool(pool *tokenPool) {
+	for {
+		select {
+		case link := <-pool.tokens:
+			s.releaseToken(link)
+		default:
+			return
+		}
+	}
+}
+
+// releaseToken удаляет короткую ссылку link из таблицы зарезервированных
+// ссылок. Ошибка лишь записывается в журнал: оставшийся резерв не мешает
+// работе сервиса. Резерв снимается и после отмены запроса, взявшего ссылку из
+// пула, поэтому контекст запроса не используется.
+func (s *GRPCServer) releaseToken(link string) {
+	if _, err := s.Database.ExecContext(context.Background(), "DELETE FROM reserved_links WHERE link = $1;", link); err != nil {
+		s.logger().Error("failed to release a reserved link", "link", link, "error", err)
+	}
+}
+
+// createFromPool добавляет в базу данных запись для URL из запроса req,
+// используя короткую ссылку из пула, и сообщает, была ли добавлена новая
+// запись. Если пул пуст или взятая из него ссылка оказалась занята, то
+// последним логическим значением возвращается false, и ссылку следует
+// сгенерировать обычным образом.
+func (s *GRPCServer) createFromPool(ctx context.Context, req *api.URL) (*api.Link, bool, bool, error) {
+	pool := s.linkPool()
+
+	token, ok := pool.pop()
+	if !ok {
+		return nil, false, false, nil
+	}
+
+	var link string
+	err := s.queryRow(ctx, s.insertLinkStmt, insertLinkQuery, token, req.GetUrl(), req.GetAlphabet(), expiresAt(req), collectionID(req), s.deduplicate(req),
+		nullString(req.GetTitle()), nullString(req.GetOwnerId()), slidingTTL(req), maxUses(req), tagsArray(req), req.GetNamespace(),
+		creatorIP(ctx), creatorUserAgent(ctx)).Scan(&link)
+
+	// если для URL уже существовала короткая ссылка, то взятая из пула ссылка
+	// не использована и возвращается в пул
+	if err == nil && link != token && pool.push(token) {
+		return &api.Link{Link: link}, false, true, nil
+	}
+
+	// в остальных случаях ссылка больше не нужна в резерве
+	s.releaseToken(token)
+
+	if err == nil {
+		return &api.Link{Link: link}, link == token, true, nil
+	}
+
+	// запрос не возвращает строк, если взятая из пула ссылка оказалась занята
+	if err == sql.ErrNoRows {
+		return nil, false, false, nil
+	}
+
+	if _, ok := violation(err, foreignKeyViolation); ok {
+		return nil, false, true, ErrCollectionNotFound
+	}
+
+	return nil, false, true, s.requestError(ctx, "Create", err, "url", req.GetUrl())
+}
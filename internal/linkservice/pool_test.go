@@ -0,0 +1,100 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+)
+
+func TestPool(t *testing.T) {
+	// устанавливаем подключение к базе данных
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed connecting to the database: %v", err)
+	}
+
+	defer db.Close()
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	defer service.Close()
+
+	service.PoolSize = 3
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		service.FillPool(ctx)
+		close(done)
+	}()
+
+	// waitFull дожидается заполнения пула
+	waitFull := func() {
+		deadline := time.Now().Add(5 * time.Second)
+		for len(service.linkPool().tokens) < service.PoolSize {
+			if time.Now().After(deadline) {
+				t.Fatalf("the pool was not filled in time")
+			}
+
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	waitFull()
+
+	// запоминаем зарезервированные ссылки, чтобы убедиться, что метод Create
+	// использует одну из них
+	reserved := make(map[string]bool)
+
+	rows, err := db.Query("SELECT link FROM reserved_links;")
+	if err != nil {
+		t.Fatalf("failed to query the database: %v", err)
+	}
+
+	for rows.Next() {
+		var link string
+		if err := rows.Scan(&link); err != nil {
+			t.Fatalf("failed to query the database: %v", err)
+		}
+
+		reserved[link] = true
+	}
+
+	rows.Close()
+
+	link, err := service.Create(context.Background(), &api.URL{Url: "http://pool.abc/" + generateRandomСharacters(6)})
+	if err != nil {
+		t.Fatalf("Create method reported an error: %v", err)
+	}
+
+	if !reserved[link.GetLink()] {
+		t.Errorf("it was expected that the link would be taken from the pool")
+	}
+
+	var count int
+	if err := db.QueryRow("SELECT count(*) FROM reserved_links WHERE link = $1;", link.GetLink()).Scan(&count); err != nil {
+		t.Fatalf("failed to query the database: %v", err)
+	}
+
+	if count != 0 {
+		t.Errorf("it was expected that the used link would no longer be reserved")
+	}
+
+	// после использования ссылки пул должен пополниться
+	waitFull()
+
+	cancel()
+	<-done
+
+	if len(service.linkPool().tokens) != 0 {
+		t.Errorf("it was expected that the pool would be released after stopping")
+	}
+}
@@ -0,0 +1,149 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql"
+	"unicode/utf8"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+)
+
+// максимальная длина описания ссылки в предпросмотре — размер столбца
+// description таблицы link_previews
+var maxDescriptionLength = 1024
+
+// SetPreview сохраняет метаданные предпросмотра существующей короткой ссылки
+// из указанного в запросе пространства имен: название, описание и адрес
+// изображения, которые HTTP-интерфейс передает роботам социальных сетей и
+// мессенджеров в тегах Open Graph. Ссылка ищется так же, как в методе Get.
+// Ранее сохраненные метаданные заменяются, а пустые поля удаляют их. Поле url
+// запроса не используется. Ошибки передаются клиенту с кодами состояния gRPC:
+// ErrInvalidLink, ErrInvalidNamespace и ErrInvalidMetadata —
+// codes.InvalidArgument, ErrURLNotFound — codes.NotFound, ErrLinkExpired —
+// codes.FailedPrecondition, ErrDeadlineExceeded — codes.DeadlineExceeded,
+// ErrReqProc — codes.Internal.
+func (s *GRPCServer) SetPreview(ctx context.Context, req *api.Preview) (*api.Empty, error) {
+	if err := s.setPreview(ctx, req); err != nil {
+		return nil, statusError(err)
+	}
+
+	return &api.Empty{}, nil
+}
+
+// setPreview реализует метод SetPreview, возвращая ошибки сервиса без
+// преобразования в ошибки gRPC.
+func (s *GRPCServer) setPreview(ctx context.Context, req *api.Preview) error {
+	if !s.validLink(req.GetLink()) {
+		return ErrInvalidLink
+	}
+
+	namespace := req.GetNamespace()
+	if err := checkNamespace(namespace); err != nil {
+		return err
+	}
+
+	if err := s.checkPreview(req); err != nil {
+		return err
+	}
+
+	// запрошенная ссылка может отличаться от хранящейся регистром символов
+	entry, err := s.resolve(ctx, namespace, req.GetLink())
+	if err != nil {
+		return err
+	}
+
+	if expired(entry.expires) {
+		return ErrLinkExpired
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	// ссылка может быть удалена после поиска, тогда запись нарушает внешний
+	// ключ
+	_, err = s.Database.ExecContext(ctx, "INSERT INTO link_previews (namespace, link, title, description, image_url) VALUES ($1, $2, $3, $4, $5) "+
+		"ON CONFLICT (namespace, link) DO UPDATE SET title = EXCLUDED.title, description = EXCLUDED.description, image_url = EXCLUDED.image_url;",
+		namespace, entry.link, nullString(req.GetTitle()), nullString(req.GetDescription()), nullString(req.GetImageUrl()))
+	if _, ok := violation(err, foreignKeyViolation); ok {
+		return ErrURLNotFound
+	}
+
+	if err != nil {
+		return s.requestError(ctx, "SetPreview", err, "link", req.GetLink())
+	}
+
+	return nil
+}
+
+// checkPreview проверяет метаданные предпросмотра в запросе req. Адрес
+// изображения должен быть абсолютным URL с разрешенной схемой, но, в отличие
+// от оригинальных URL, может указывать на любой хост, например на CDN.
+func (s *GRPCServer) checkPreview(req *api.Preview) error {
+	if utf8.RuneCountInString(req.GetTitle()) > maxTitleLength || utf8.RuneCountInString(req.GetDescription()) > maxDescriptionLength {
+		return ErrInvalidMetadata
+	}
+
+	if image := req.GetImageUrl(); image != "" && (utf8.RuneCountInString(image) > maxURLLengthDefault || !s.validURL(image)) {
+		return ErrInvalidMetadata
+	}
+
+	return nil
+}
+
+// GetPreview возвращает оригинальный URL указанной в запросе короткой ссылки
+// вместе с метаданными ее предпросмотра. Если название предпросмотра не
+// задано методом SetPreview, то возвращается название ссылки, заданное при ее
+// создании. Как и в методе GetMetadata, переход по ссылке не учитывается,
+// поэтому метод подходит для ответов роботам, запрашивающим предпросмотр.
+// Ошибки передаются клиенту с теми же кодами состояния gRPC, что и в методе
+// Get.
+func (s *GRPCServer) GetPreview(ctx context.Context, req *api.Link) (*api.Preview, error) {
+	preview, err := s.getPreview(ctx, req)
+	return preview, statusError(err)
+}
+
+// getPreview реализует метод GetPreview, возвращая ошибки сервиса без
+// преобразования в ошибки gRPC.
+func (s *GRPCServer) getPreview(ctx context.Context, req *api.Link) (*api.Preview, error) {
+	if !s.validLink(req.GetLink()) {
+		return nil, ErrInvalidLink
+	}
+
+	namespace := req.GetNamespace()
+	if err := checkNamespace(namespace); err != nil {
+		return nil, err
+	}
+
+	entry, err := s.resolve(ctx, namespace, req.GetLink())
+	if err != nil {
+		return nil, err
+	}
+
+	if expired(entry.expires) {
+		return nil, ErrLinkExpired
+	}
+
+	var title, description, image sql.NullString
+	err = s.retry(ctx, "select_preview", func(ctx context.Context) error {
+		return s.readDB().QueryRowContext(ctx, "SELECT COALESCE(p.title, l.title), p.description, p.image_url FROM links l "+
+			"LEFT JOIN link_previews p ON p.namespace = l.namespace AND p.link = l.link WHERE l.link = $1 AND l.namespace = $2;",
+			entry.link, namespace).Scan(&title, &description, &image)
+	})
+
+	if err == sql.ErrNoRows {
+		return nil, ErrURLNotFound
+	}
+
+	if err != nil {
+		return nil, s.requestError(ctx, "GetPreview", err, "link", req.GetLink())
+	}
+
+	return &api.Preview{
+		Link:        entry.link,
+		Namespace:   namespace,
+		Title:       title.String,
+		Description: description.String,
+		ImageUrl:    image.String,
+		Url:         entry.url,
+	}, nil
+}
@@ -0,0 +1,95 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestPreviewWithMockDB(t *testing.T) {
+	// stored содержит метаданные предпросмотра, сохраненные запросом
+	// INSERT INTO link_previews: название, описание и адрес изображения
+	stored := map[string][]driver.Value{}
+
+	db := newMockDB(func(query string, args []driver.NamedValue) (mockResult, error) {
+		switch {
+		case query == selectURLQuery:
+			if args[0].Value != "promo" {
+				return mockResult{columns: []string{"original_url"}}, nil
+			}
+
+			return urlRow("http://preview.abc/", nil), nil
+		case strings.HasPrefix(query, "INSERT INTO link_previews"):
+			stored[namespacedLink(args[0].Value.(string), args[1].Value.(string))] = []driver.Value{args[2].Value, args[3].Value, args[4].Value}
+			return mockResult{affected: 1}, nil
+		case strings.HasPrefix(query, "SELECT COALESCE(p.title, l.title)"):
+			row, ok := stored[namespacedLink(args[1].Value.(string), args[0].Value.(string))]
+			if !ok || row[0] == nil {
+				// название ссылки, заданное при ее создании
+				row = []driver.Value{"Link title", nil, nil}
+			}
+
+			return mockResult{columns: []string{"title", "description", "image_url"}, rows: [][]driver.Value{row}}, nil
+		default:
+			t.Fatalf("an unexpected query was received: %s", query)
+			return mockResult{}, nil
+		}
+	})
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	preview, err := service.GetPreview(context.Background(), &api.Link{Link: "promo"})
+	if err != nil {
+		t.Fatalf("GetPreview method reported an error: %v", err)
+	}
+
+	if preview.GetTitle() != "Link title" || preview.GetUrl() != "http://preview.abc/" {
+		t.Errorf("the link title and URL were expected without a preview, but %v was received", preview)
+	}
+
+	// псевдоним в другом регистре сохраняет предпросмотр найденной ссылки
+	req := &api.Preview{Link: "Promo", Title: "Launch", Description: "All about the launch", ImageUrl: "https://cdn.preview.abc/card.png"}
+	if _, err := service.SetPreview(context.Background(), req); err != nil {
+		t.Fatalf("SetPreview method reported an error: %v", err)
+	}
+
+	preview, err = service.GetPreview(context.Background(), &api.Link{Link: "Promo"})
+	if err != nil {
+		t.Fatalf("GetPreview method reported an error: %v", err)
+	}
+
+	exp := &api.Preview{Link: "promo", Title: req.GetTitle(), Description: req.GetDescription(), ImageUrl: req.GetImageUrl(), Url: "http://preview.abc/"}
+	if !proto.Equal(preview, exp) {
+		t.Errorf("the preview %v was expected, but %v was received", exp, preview)
+	}
+
+	testCases := []struct {
+		name     string
+		req      *api.Preview
+		expError error
+	}{
+		{name: "invalid_link", req: &api.Preview{Link: "a b"}, expError: ErrInvalidLink},
+		{name: "invalid_namespace", req: &api.Preview{Link: "promo", Namespace: "Brand"}, expError: ErrInvalidNamespace},
+		{name: "long_title", req: &api.Preview{Link: "promo", Title: strings.Repeat("t", maxTitleLength+1)}, expError: ErrInvalidMetadata},
+		{name: "long_description", req: &api.Preview{Link: "promo", Description: strings.Repeat("d", maxDescriptionLength+1)}, expError: ErrInvalidMetadata},
+		{name: "script_image", req: &api.Preview{Link: "promo", ImageUrl: "javascript:alert(1)"}, expError: ErrInvalidMetadata},
+		{name: "relative_image", req: &api.Preview{Link: "promo", ImageUrl: "/card.png"}, expError: ErrInvalidMetadata},
+		{name: "unknown_link", req: &api.Preview{Link: "unknown"}, expError: ErrURLNotFound},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := service.SetPreview(context.Background(), testCase.req)
+			if err = FromStatus(err); err != testCase.expError {
+				t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", testCase.expError, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+)
+
+func TestReadDB(t *testing.T) {
+	primary, replica := &sql.DB{}, &sql.DB{}
+
+	if db := (&GRPCServer{Database: primary}).readDB(); db != primary {
+		t.Errorf("the primary database was expected without a replica")
+	}
+
+	if db := (&GRPCServer{Database: primary, ReadDB: replica}).readDB(); db != replica {
+		t.Errorf("the replica was expected")
+	}
+}
+
+func TestGetReadReplica(t *testing.T) {
+	// устанавливаем подключение к базе данных
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed connecting to the database: %v", err)
+	}
+
+	defer db.Close()
+
+	// в тестах роль реплики играет отдельный пул соединений с той же базой
+	// данных
+	replica, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed connecting to the database: %v", err)
+	}
+
+	defer replica.Close()
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	defer service.Close()
+
+	service.ReadDB = replica
+
+	url := "http://replica.abc/" + generateRandomСharacters(8)
+
+	link, err := service.Create(context.Background(), &api.URL{Url: url})
+	if err != nil {
+		t.Fatalf("Create method reported an error: %v", err)
+	}
+
+	u, err := service.Get(context.Background(), link)
+	if err != nil {
+		t.Fatalf("Get method reported an error: %v", err)
+	}
+
+	if u.GetUrl() != url {
+		t.Errorf("the URL \"%s\" was expected, but \"%s\" was received", url, u.GetUrl())
+	}
+
+	stats, err := service.Stats(context.Background(), link)
+	if err != nil {
+		t.Fatalf("Stats method reported an error: %v", err)
+	}
+
+	if stats.GetUrl() != url {
+		t.Errorf("the URL \"%s\" was expected, but \"%s\" was received", url, stats.GetUrl())
+	}
+}
@@ -0,0 +1,53 @@
+package linkservice
+
+import "strings"
+
+// DefaultReservedWords содержит слова, которые не могут быть короткими
+// ссылками, если для GRPCServer не задан собственный набор. Они совпадают с
+// путями HTTP-интерфейса сервиса и служебных обработчиков.
+var DefaultReservedWords = []string{"api", "v1", "metrics", "health"}
+
+// reservedWords возвращает слова, которые не могут быть короткими ссылками.
+func (s *GRPCServer) reservedWords() []string {
+	if s.ReservedWords != nil {
+		return s.ReservedWords
+	}
+
+	return DefaultReservedWords
+}
+
+// isReserved сообщает, совпадает ли короткая ссылка link с одним из
+// зарезервированных слов без учета регистра.
+func (s *GRPCServer) isReserved(link string) bool {
+	for _, word := range s.reservedWords() {
+		if strings.EqualFold(link, word) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// generateLink генерирует короткую ссылку из символов alphabet (для алфавита
+// по умолчанию — генератором CodeGenerator), не совпадающую ни с одним из
+// зарезервированных слов и не содержащую слов из BlockedWords. Ссылки, которые
+// фильтр Блума считает вероятно занятыми, отбрасываются, но не более
+// maxFilterSkips раз подряд: занятость ссылки окончательно проверяет база
+// данных.
+func (s *GRPCServer) generateLink(alphabet string) string {
+	filter := s.linkFilter()
+
+	for skipped := 0; ; {
+		link := s.generateCode(alphabet)
+		if s.isReserved(link) || s.isBlocked(link) {
+			continue
+		}
+
+		if skipped < maxFilterSkips && filter.mayContain(link) {
+			skipped++
+			continue
+		}
+
+		return link
+	}
+}
@@ -0,0 +1,41 @@
+package linkservice
+
+import "testing"
+
+func TestIsReserved(t *testing.T) {
+	testCases := []struct {
+		name     string
+		reserved []string
+		link     string
+		exp      bool
+	}{
+		{name: "default", reserved: nil, link: "health", exp: true},
+		{name: "case_insensitive", reserved: nil, link: "API", exp: true},
+		{name: "not_reserved", reserved: nil, link: "healthy", exp: false},
+		{name: "custom", reserved: []string{"admin"}, link: "admin", exp: true},
+		{name: "custom_replaces_default", reserved: []string{"admin"}, link: "metrics", exp: false},
+		{name: "disabled", reserved: []string{}, link: "v1", exp: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			service := &GRPCServer{ReservedWords: testCase.reserved}
+
+			if reserved := service.isReserved(testCase.link); reserved != testCase.exp {
+				t.Errorf("the result %v was expected, but %v was received", testCase.exp, reserved)
+			}
+		})
+	}
+}
+
+func TestGenerateLinkSkipsReserved(t *testing.T) {
+	// из двух символов длиной 2 можно составить всего четыре ссылки, поэтому
+	// зарезервированная ссылка без проверки генерировалась бы регулярно
+	service := &GRPCServer{LinkLength: 2}
+
+	for i := 0; i < 1000; i++ {
+		if link := service.generateLink("1v"); link == "v1" {
+			t.Fatalf("the reserved link \"%s\" was generated", link)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// количество попыток выполнить запрос к базе данных по умолчанию
+var maxDBAttemptsDefault = 3
+
+// пауза перед первой повторной попыткой по умолчанию; перед каждой следующей
+// попыткой она удваивается
+var dbRetryDelayDefault = 50 * time.Millisecond
+
+// retry выполняет запрос к базе данных fn и при временной ошибке повторяет его
+// с экспоненциально растущей паузой, но не более MaxDBAttempts попыток. Ошибки,
+// не являющиеся временными, например нарушения ограничений или sql.ErrNoRows,
+// возвращаются сразу. Повторные попытки прекращаются при отмене контекста ctx.
+// Каждая попытка получает собственный контекст, ограниченный StatementTimeout.
+// Имя запроса query используется в журнале.
+//
+// Повторять можно только запросы, повторное выполнение которых не меняет
+// результата: ошибка соединения может скрывать уже зафиксированное изменение.
+func (s *GRPCServer) retry(ctx context.Context, query string, fn func(ctx context.Context) error) error {
+	delay := s.dbRetryDelay()
+
+	for attempt := 1; ; attempt++ {
+		attemptCtx, cancel := s.withTimeout(ctx)
+		err := fn(attemptCtx)
+		cancel()
+
+		if err == nil || attempt >= s.maxDBAttempts() || !transient(err) {
+			return err
+		}
+
+		s.logger().Debug("transient database error", "query", query, "attempt", attempt, "error", err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+
+		delay *= 2
+	}
+}
+
+// transient сообщает, является ли ошибка базы данных err временной, то есть
+// может ли повторный запрос завершиться успешно: к таким ошибкам относятся
+// ошибки соединения, а также ошибки сериализации и взаимоблокировки
+// транзакций. Отмена запроса временной ошибкой не считается.
+func transient(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		// класс 08 объединяет ошибки соединения с сервером
+		return pqErr.Code == serializationFailure || pqErr.Code == deadlockDetected || pqErr.Code.Class() == "08"
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// maxDBAttempts возвращает максимальное количество попыток выполнить запрос
+// к базе данных.
+func (s *GRPCServer) maxDBAttempts() int {
+	if s.MaxDBAttempts > 0 {
+		return s.MaxDBAttempts
+	}
+
+	return maxDBAttemptsDefault
+}
+
+// dbRetryDelay возвращает паузу перед первой повторной попыткой выполнить
+// запрос к базе данных.
+func (s *GRPCServer) dbRetryDelay() time.Duration {
+	if s.DBRetryDelay > 0 {
+		return s.DBRetryDelay
+	}
+
+	return dbRetryDelayDefault
+}
@@ -0,0 +1,101 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func TestTransient(t *testing.T) {
+	testCases := []struct {
+		name string
+		err  error
+		exp  bool
+	}{
+		{name: "bad_conn", err: driver.ErrBadConn, exp: true},
+		{name: "wrapped_bad_conn", err: fmt.Errorf("query: %w", driver.ErrBadConn), exp: true},
+		{name: "connection_reset", err: &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}, exp: true},
+		{name: "connection_failure", err: &pq.Error{Code: "08006"}, exp: true},
+		{name: "serialization_failure", err: &pq.Error{Code: serializationFailure}, exp: true},
+		{name: "deadlock", err: &pq.Error{Code: deadlockDetected}, exp: true},
+		{name: "unique_violation", err: &pq.Error{Code: uniqueViolation}, exp: false},
+		{name: "foreign_key_violation", err: &pq.Error{Code: foreignKeyViolation}, exp: false},
+		{name: "no_rows", err: sql.ErrNoRows, exp: false},
+		{name: "canceled", err: context.Canceled, exp: false},
+		{name: "deadline_exceeded", err: context.DeadlineExceeded, exp: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if result := transient(testCase.err); result != testCase.exp {
+				t.Errorf("the result %v was expected, but %v was received", testCase.exp, result)
+			}
+		})
+	}
+}
+
+func TestRetry(t *testing.T) {
+	service := &GRPCServer{MaxDBAttempts: 3, DBRetryDelay: time.Millisecond, Logger: &recordLogger{}}
+
+	t.Run("transient_then_success", func(t *testing.T) {
+		calls := 0
+		err := service.retry(context.Background(), "test", func(context.Context) error {
+			calls++
+			if calls == 1 {
+				return driver.ErrBadConn
+			}
+
+			return nil
+		})
+
+		if err != nil || calls != 2 {
+			t.Errorf("a success on the second attempt was expected, but \"%v\" was received after %d attempts", err, calls)
+		}
+	})
+
+	t.Run("not_transient", func(t *testing.T) {
+		calls := 0
+		err := service.retry(context.Background(), "test", func(context.Context) error {
+			calls++
+			return sql.ErrNoRows
+		})
+
+		if err != sql.ErrNoRows || calls != 1 {
+			t.Errorf("a single attempt with \"%v\" was expected, but \"%v\" was received after %d attempts", sql.ErrNoRows, err, calls)
+		}
+	})
+
+	t.Run("attempts_exhausted", func(t *testing.T) {
+		calls := 0
+		err := service.retry(context.Background(), "test", func(context.Context) error {
+			calls++
+			return driver.ErrBadConn
+		})
+
+		if err != driver.ErrBadConn || calls != 3 {
+			t.Errorf("3 attempts ending with \"%v\" were expected, but \"%v\" was received after %d attempts", driver.ErrBadConn, err, calls)
+		}
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		calls := 0
+		err := service.retry(ctx, "test", func(context.Context) error {
+			calls++
+			return driver.ErrBadConn
+		})
+
+		if err != driver.ErrBadConn || calls != 1 {
+			t.Errorf("a single attempt was expected for a canceled request, but %d were made", calls)
+		}
+	})
+}
@@ -0,0 +1,131 @@
+package linkservice
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// safeBrowsingEndpoint — адрес метода threatMatches.find Google Safe Browsing
+// Lookup API v4
+const safeBrowsingEndpoint = "https://safebrowsing.googleapis.com/v4/threatMatches:find"
+
+// safeBrowsingThreats — типы угроз, при которых URL отклоняется
+var safeBrowsingThreats = []string{"MALWARE", "SOCIAL_ENGINEERING", "UNWANTED_SOFTWARE", "POTENTIALLY_HARMFUL_APPLICATION"}
+
+// SafeBrowsingChecker проверяет URL по спискам Google Safe Browsing с помощью
+// Lookup API v4. URL, найденные в списках вредоносных и фишинговых сайтов,
+// отклоняются с ошибкой ErrDomainNotAllowed.
+type SafeBrowsingChecker struct {
+	// APIKey — ключ Google API с доступом к Safe Browsing API
+	APIKey string
+
+	// ClientID передается в запросах как идентификатор клиента. Если не
+	// задан, то используется "linkservice"
+	ClientID string
+
+	// Client выполняет запросы к API. Если не задан, то используется
+	// http.DefaultClient; время ожидания ограничивается контекстом запроса
+	Client *http.Client
+
+	// Endpoint задает адрес API, например, для тестов. Если не задан, то
+	// используется адрес Google Safe Browsing
+	Endpoint string
+}
+
+// safeBrowsingRequest представляет собой тело запроса threatMatches.find
+type safeBrowsingRequest struct {
+	Client struct {
+		ClientID string `json:"clientId"`
+	} `json:"client"`
+	ThreatInfo struct {
+		ThreatTypes      []string `json:"threatTypes"`
+		PlatformTypes    []string `json:"platformTypes"`
+		ThreatEntryTypes []string `json:"threatEntryTypes"`
+		ThreatEntries    []struct {
+			URL string `json:"url"`
+		} `json:"threatEntries"`
+	} `json:"threatInfo"`
+}
+
+// safeBrowsingResponse представляет собой тело ответа threatMatches.find.
+// Для безопасных URL ответ не содержит совпадений.
+type safeBrowsingResponse struct {
+	Matches []json.RawMessage `json:"matches"`
+}
+
+// CheckURL проверяет URL u по спискам Google Safe Browsing.
+func (c *SafeBrowsingChecker) CheckURL(ctx context.Context, u string) error {
+	var body safeBrowsingRequest
+	body.Client.ClientID = c.clientID()
+	body.ThreatInfo.ThreatTypes = safeBrowsingThreats
+	body.ThreatInfo.PlatformTypes = []string{"ANY_PLATFORM"}
+	body.ThreatInfo.ThreatEntryTypes = []string{"URL"}
+	body.ThreatInfo.ThreatEntries = append(body.ThreatInfo.ThreatEntries, struct {
+		URL string `json:"url"`
+	}{URL: u})
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint(), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Goog-Api-Key", c.APIKey)
+
+	resp, err := c.client().Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("linkservice: safe browsing lookup failed with status %s", resp.Status)
+	}
+
+	var res safeBrowsingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return err
+	}
+
+	if len(res.Matches) > 0 {
+		return ErrDomainNotAllowed
+	}
+
+	return nil
+}
+
+// clientID возвращает идентификатор клиента, передаваемый в запросах.
+func (c *SafeBrowsingChecker) clientID() string {
+	if c.ClientID != "" {
+		return c.ClientID
+	}
+
+	return "linkservice"
+}
+
+// client возвращает HTTP-клиент, выполняющий запросы к API.
+func (c *SafeBrowsingChecker) client() *http.Client {
+	if c.Client != nil {
+		return c.Client
+	}
+
+	return http.DefaultClient
+}
+
+// endpoint возвращает адрес API.
+func (c *SafeBrowsingChecker) endpoint() string {
+	if c.Endpoint != "" {
+		return c.Endpoint
+	}
+
+	return safeBrowsingEndpoint
+}
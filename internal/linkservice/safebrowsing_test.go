@@ -0,0 +1,57 @@
+package linkservice
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSafeBrowsingChecker(t *testing.T) {
+	const malicious = "http://malware.abc/"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Goog-Api-Key") != "key" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		var req safeBrowsingRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.ThreatInfo.ThreatEntries) != 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if req.ThreatInfo.ThreatEntries[0].URL == malicious {
+			w.Write([]byte(`{"matches": [{"threatType": "MALWARE", "threat": {"url": "http://malware.abc/"}}]}`))
+			return
+		}
+
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	checker := &SafeBrowsingChecker{APIKey: "key", Endpoint: server.URL}
+
+	if err := checker.CheckURL(context.Background(), "http://safe.abc/"); err != nil {
+		t.Errorf("no error was expected for a safe URL, but \"%v\" was received", err)
+	}
+
+	if err := checker.CheckURL(context.Background(), malicious); err != ErrDomainNotAllowed {
+		t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrDomainNotAllowed, err)
+	}
+
+	// ошибки API не принимаются за вердикт о безопасности URL
+	wrongKey := &SafeBrowsingChecker{APIKey: "wrong", Endpoint: server.URL}
+	if err := wrongKey.CheckURL(context.Background(), "http://safe.abc/"); err == nil || err == ErrDomainNotAllowed {
+		t.Errorf("an API error was expected, but \"%v\" was received", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := checker.CheckURL(ctx, "http://safe.abc/"); err == nil {
+		t.Errorf("an error was expected for a cancelled request")
+	}
+}
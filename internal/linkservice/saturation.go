@@ -0,0 +1,98 @@
+package linkservice
+
+import (
+	"context"
+	"math"
+	"time"
+	"unicode/utf8"
+)
+
+// доля занятых коротких ссылок, при превышении которой по умолчанию в журнал
+// записывается предупреждение
+const saturationWarnThresholdDefault = 0.5
+
+// SaturationObserver получает долю ratio занятых коротких ссылок среди всех
+// возможных ссылок алфавита по умолчанию, например, для метрик Prometheus.
+type SaturationObserver interface {
+	ObserveSaturation(ratio float64)
+}
+
+// WatchSaturation с интервалом SaturationInterval подсчитывает долю занятых
+// коротких ссылок и передает ее Saturation. Если доля превышает
+// SaturationWarnThreshold, то в журнал записывается предупреждение: коллизии
+// при генерации ссылок становятся частыми, и длину ссылок LinkLength пора
+// увеличить. Метод блокируется до отмены контекста ctx, поэтому его следует
+// запускать в отдельной горутине. Если SaturationInterval не задан, то метод
+// сразу завершается.
+func (s *GRPCServer) WatchSaturation(ctx context.Context) {
+	if s.SaturationInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.SaturationInterval)
+	defer ticker.Stop()
+
+	for {
+		ratio, err := s.saturation(ctx)
+		switch {
+		case err != nil:
+			if ctx.Err() == nil {
+				s.logger().Error("failed to compute the code space saturation", "error", err)
+			}
+
+		default:
+			if s.Saturation != nil {
+				s.Saturation.ObserveSaturation(ratio)
+			}
+
+			if ratio > s.saturationWarnThreshold() {
+				s.logger().Warn("the code space is close to exhaustion", "saturation", ratio, "length", s.linkLength())
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// saturation возвращает долю занятых коротких ссылок длины LinkLength среди
+// всех ссылок, которые можно составить из алфавита по умолчанию. Пространства
+// имен не пересекаются, поэтому учитывается наиболее заполненное из них.
+// Ссылки, созданные с другими алфавитами, не учитываются, а псевдонимы той же
+// длины учитываются, поскольку занимают ссылки наравне со сгенерированными.
+func (s *GRPCServer) saturation(ctx context.Context) (float64, error) {
+	start := time.Now()
+	var used int64
+	err := s.Database.QueryRowContext(ctx, "SELECT coalesce(max(n), 0) FROM (SELECT count(*) AS n FROM links "+
+		"WHERE length(link) = $1 AND coalesce(alphabet, '') = '' GROUP BY namespace) AS counts;", s.linkLength()).Scan(&used)
+	s.observeQuery("count_saturation", start, "method", "WatchSaturation")
+	if err != nil {
+		return 0, err
+	}
+
+	return saturationRatio(used, s.alphabets()[""], s.linkLength()), nil
+}
+
+// saturationRatio возвращает отношение количества занятых ссылок used к
+// количеству всех ссылок длины length из символов alphabet.
+func saturationRatio(used int64, alphabet string, length int) float64 {
+	total := math.Pow(float64(utf8.RuneCountInString(alphabet)), float64(length))
+	if total == 0 {
+		return 0
+	}
+
+	return float64(used) / total
+}
+
+// saturationWarnThreshold возвращает долю занятых коротких ссылок, при
+// превышении которой в журнал записывается предупреждение.
+func (s *GRPCServer) saturationWarnThreshold() float64 {
+	if s.SaturationWarnThreshold > 0 {
+		return s.SaturationWarnThreshold
+	}
+
+	return saturationWarnThresholdDefault
+}
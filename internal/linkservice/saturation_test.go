@@ -0,0 +1,96 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+)
+
+// saturationFunc позволяет использовать функцию в качестве SaturationObserver
+type saturationFunc func(ratio float64)
+
+func (f saturationFunc) ObserveSaturation(ratio float64) {
+	f(ratio)
+}
+
+func TestSaturationRatio(t *testing.T) {
+	testCases := []struct {
+		name     string
+		used     int64
+		alphabet string
+		length   int
+		expRatio float64
+	}{
+		{name: "empty", used: 0, alphabet: "ab", length: 4, expRatio: 0},
+		{name: "half", used: 8, alphabet: "ab", length: 4, expRatio: 0.5},
+		{name: "full", used: 1000, alphabet: "0123456789", length: 3, expRatio: 1},
+		{name: "multibyte", used: 2, alphabet: "абвг", length: 1, expRatio: 0.5},
+		{name: "no_alphabet", used: 5, alphabet: "", length: 3, expRatio: 0},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			ratio := saturationRatio(testCase.used, testCase.alphabet, testCase.length)
+			if ratio != testCase.expRatio {
+				t.Errorf("a ratio of %v was expected, but %v was received", testCase.expRatio, ratio)
+			}
+		})
+	}
+}
+
+func TestWatchSaturationWithMockDB(t *testing.T) {
+	testCases := []struct {
+		name      string
+		used      int64
+		threshold float64
+		expWarn   bool
+	}{
+		{name: "below_threshold", used: 4, expWarn: false},
+		{name: "above_default_threshold", used: 9, expWarn: true},
+		{name: "above_custom_threshold", used: 4, threshold: 0.2, expWarn: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			db := newMockDB(func(query string, args []driver.NamedValue) (mockResult, error) {
+				if !strings.Contains(query, "length(link) = $1") {
+					t.Fatalf("unexpected query: %s", query)
+				}
+
+				return mockResult{columns: []string{"coalesce"}, rows: [][]driver.Value{{testCase.used}}}, nil
+			})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var observed []float64
+			logger := &recordLogger{}
+			service := GRPCServer{
+				Database:                db,
+				Alphabet:                "ab",
+				LinkLength:              4,
+				SaturationInterval:      time.Hour,
+				SaturationWarnThreshold: testCase.threshold,
+				Logger:                  logger,
+				// первое наблюдение останавливает фоновую задачу
+				Saturation: saturationFunc(func(ratio float64) {
+					observed = append(observed, ratio)
+					cancel()
+				}),
+			}
+
+			service.WatchSaturation(ctx)
+
+			expRatio := float64(testCase.used) / 16
+			if len(observed) != 1 || observed[0] != expRatio {
+				t.Fatalf("a single ratio of %v was expected, but %v was received", expRatio, observed)
+			}
+
+			if warned := len(logger.warn) > 0; warned != testCase.expWarn {
+				t.Errorf("a warning was expected to be %v, but it was %v", testCase.expWarn, warned)
+			}
+		})
+	}
+}
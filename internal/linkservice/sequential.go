@@ -0,0 +1,124 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+)
+
+// CodeStrategy определяет способ получения коротких ссылок для запросов, в
+// которых не указаны псевдоним и алфавит.
+type CodeStrategy int
+
+const (
+	// RandomAlphanumeric генерирует короткие ссылки из случайных символов
+	// алфавита по умолчанию длиной LinkLength
+	RandomAlphanumeric CodeStrategy = iota
+
+	// Base62Sequential получает короткие ссылки кодированием
+	// автоинкрементного идентификатора записи в base62. Такие ссылки короче
+	// случайных и не приводят к коллизиям, но позволяют перебрать все ссылки
+	// сервиса
+	Base62Sequential
+
+	// Deterministic получает короткие ссылки из хеша SHA-256 URL в base62,
+	// поэтому один и тот же URL всегда получает одну и ту же ссылку, в том
+	// числе на разных экземплярах сервиса и после повторного импорта. При
+	// коллизии ссылка удлиняется
+	Deterministic
+)
+
+// base62Alphabet содержит символы, используемые для кодирования
+// идентификаторов записей в base62
+const base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// sequentialTemplate описывает короткие ссылки, получаемые при
+// Base62Sequential: положительное 64-битное число в base62 занимает не более
+// 11 символов
+var sequentialTemplate = regexp.MustCompile(`^[0-9A-Za-z]{1,11}$`)
+
+// encodeBase62 кодирует неотрицательное число n в base62.
+func encodeBase62(n int64) string {
+	if n == 0 {
+		return base62Alphabet[:1]
+	}
+
+	var buf [11]byte
+	i := len(buf)
+
+	for n > 0 {
+		i--
+		buf[i] = base62Alphabet[n%62]
+		n /= 62
+	}
+
+	return string(buf[i:])
+}
+
+// sequential сообщает, получается ли короткая ссылка для запроса req
+// кодированием идентификатора записи. Запросы с указанным алфавитом
+// обрабатываются обычным образом.
+func (s *GRPCServer) sequential(req *api.URL) bool {
+	return s.CodeStrategy == Base62Sequential && req.GetAlphabet() == ""
+}
+
+// queryRower описывает общий для *sql.DB и *sql.Tx метод выполнения запросов,
+// возвращающих одну строку.
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// insertSequential добавляет запись для URL из запроса req и возвращает ее
+// короткую ссылку, полученную кодированием идентификатора записи. Если
+// дедупликация включена и для URL запись уже существует, то возвращается ее
+// короткая ссылка, а второе значение равно false. Ссылка может совпасть с
+// псевдонимом или зарезервированным словом, тогда используется следующий
+// идентификатор, но не более MaxCollisionRetries раз.
+func (s *GRPCServer) insertSequential(ctx context.Context, db queryRower, req *api.URL) (string, bool, error) {
+	attempt := 1
+	defer func() { s.observeCollisions("insert_sequential", attempt-1, "url", req.GetUrl()) }()
+
+	for ; ; attempt++ {
+		var id int64
+		if err := db.QueryRowContext(ctx, "SELECT nextval(pg_get_serial_sequence('links', 'id'));").Scan(&id); err != nil {
+			return "", false, err
+		}
+
+		link := encodeBase62(id)
+		candidate := link
+
+		// зарезервированная ссылка не сохраняется, а идентификатор просто
+		// пропускается
+		if s.isReserved(link) {
+			continue
+		}
+
+		start := time.Now()
+		err := db.QueryRowContext(ctx, "WITH inserted AS (INSERT INTO links (id, link, original_url, expires_at, collection_id, deduplicated, title, owner_id, sliding_ttl_seconds, max_uses, tags, namespace, creator_ip, creator_user_agent) "+
+			"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) ON CONFLICT DO NOTHING RETURNING link) "+
+			"SELECT link FROM inserted UNION ALL SELECT link FROM links WHERE $6 AND deduplicated AND namespace = $12 AND original_url = $3 LIMIT 1;",
+			id, link, req.GetUrl(), expiresAt(req), collectionID(req), s.deduplicate(req), nullString(req.GetTitle()), nullString(req.GetOwnerId()), slidingTTL(req), maxUses(req), tagsArray(req), req.GetNamespace(),
+			creatorIP(ctx), creatorUserAgent(ctx)).Scan(&link)
+		s.observeQuery("insert_sequential", start, "url", req.GetUrl())
+
+		if err == nil {
+			return link, link == candidate, nil
+		}
+
+		if _, ok := violation(err, foreignKeyViolation); ok {
+			return "", false, ErrCollectionNotFound
+		}
+
+		if err != sql.ErrNoRows {
+			return "", false, err
+		}
+
+		if attempt > s.maxCollisionRetries() {
+			return "", false, fmt.Errorf("no free link was generated in %d attempts", attempt)
+		}
+	}
+}
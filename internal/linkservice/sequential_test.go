@@ -0,0 +1,117 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"testing"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+)
+
+func TestEncodeBase62(t *testing.T) {
+	testCases := []struct {
+		n   int64
+		exp string
+	}{
+		{n: 0, exp: "0"},
+		{n: 9, exp: "9"},
+		{n: 10, exp: "A"},
+		{n: 61, exp: "z"},
+		{n: 62, exp: "10"},
+		{n: 3843, exp: "zz"},
+		{n: math.MaxInt64, exp: "AzL8n0Y58m7"},
+	}
+
+	for _, testCase := range testCases {
+		link := encodeBase62(testCase.n)
+		if link != testCase.exp {
+			t.Errorf("the link \"%s\" was expected for %d, but \"%s\" was received", testCase.exp, testCase.n, link)
+		}
+
+		if !sequentialTemplate.MatchString(link) {
+			t.Errorf("the link \"%s\" does not match the sequential template", link)
+		}
+	}
+}
+
+func TestValidLinkSequential(t *testing.T) {
+	service := &GRPCServer{}
+	if service.validLink("1z") {
+		t.Errorf("the link \"1z\" must be invalid with random links")
+	}
+
+	service.CodeStrategy = Base62Sequential
+	if !service.validLink("1z") {
+		t.Errorf("the link \"1z\" must be valid with sequential links")
+	}
+
+	if service.validLink("1z!") {
+		t.Errorf("the link \"1z!\" must be invalid with sequential links")
+	}
+}
+
+func TestCreateSequential(t *testing.T) {
+	// устанавливаем подключение к базе данных
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed connecting to the database: %v", err)
+	}
+
+	defer db.Close()
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	defer service.Close()
+
+	service.CodeStrategy = Base62Sequential
+
+	urls := []string{
+		"http://sequential.abc/" + generateRandomСharacters(6),
+		"http://sequential.abc/" + generateRandomСharacters(6),
+	}
+
+	var links []string
+	for _, url := range urls {
+		res, err := service.Create(context.Background(), &api.URL{Url: url})
+		if err != nil {
+			t.Fatalf("Create method reported an error: %v", err)
+		}
+
+		if !sequentialTemplate.MatchString(res.GetLink()) {
+			t.Errorf("the link \"%s\" is not a base62 identifier", res.GetLink())
+		}
+
+		links = append(links, res.GetLink())
+	}
+
+	// идентификаторы возрастают, поэтому более поздняя ссылка не короче
+	// предыдущей и отличается от нее
+	if len(links[1]) < len(links[0]) || links[0] == links[1] {
+		t.Errorf("the link \"%s\" was created after \"%s\"", links[1], links[0])
+	}
+
+	// повторный запрос с тем же URL возвращает ту же ссылку
+	res, err := service.Create(context.Background(), &api.URL{Url: urls[0]})
+	if err != nil {
+		t.Fatalf("Create method reported an error: %v", err)
+	}
+
+	if res.GetLink() != links[0] {
+		t.Errorf("the link \"%s\" was expected, but \"%s\" was received", links[0], res.GetLink())
+	}
+
+	for i, link := range links {
+		res, err := service.Get(context.Background(), &api.Link{Link: link})
+		if err != nil {
+			t.Fatalf("Get method reported an error: %v", err)
+		}
+
+		if res.GetUrl() != urls[i] {
+			t.Errorf("the URL \"%s\" was expected, but \"%s\" was received", urls[i], res.GetUrl())
+		}
+	}
+}
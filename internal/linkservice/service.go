@@ -2,26 +2,80 @@ package linkservice
 
 import (
 	"context"
+	"crypto/rand"
 	"database/sql"
 	"errors"
-	"log"
-	"math/rand"
+	"fmt"
 	"regexp"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/lib/pq"
 	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 var (
-	// длина коротких ссылок
+	// длина коротких ссылок по умолчанию
 	lengthLink = 10
 
-	// URLTemplate представляет собой скомпилированное регулярное выражение для
-	// проверки строки на соответствие требованиям URL
-	URLTemplate = regexp.MustCompile(`^(?:http(s)?:\/\/)?[\w.-]+(?:\.[\w\.-]+)+[\w\-\._~:/?#[\]@!\$&'\(\)\*\+,;=.]+$`)
+	// максимальная длина коротких ссылок — размер столбца link в базе данных
+	maxLinkLength = 32
 
-	// linkTemplate представляет собой скомпилированное регулярное выражение
-	// для проверки строки на соответствие требованиям короткой ссылки
-	linkTemplate = regexp.MustCompile(`^[0-9a-zA-Z_]{10}$`)
+	// количество повторных попыток добавить запись после коллизии коротких
+	// ссылок по умолчанию
+	maxCollisionRetriesDefault = 10
+
+	// hostTemplate представляет собой скомпилированное регулярное выражение
+	// для проверки имени хоста URL: имя состоит не менее чем из двух меток,
+	// разделенных точками, и может оканчиваться точкой
+	hostTemplate = regexp.MustCompile(`^[\w-]+(?:\.[\w-]+)+\.?$`)
+
+	// uriCharsTemplate представляет собой скомпилированное регулярное
+	// выражение для проверки того, что URL состоит только из допустимых в URI
+	// символов (RFC 3986), а символ "%" начинает корректную
+	// percent-кодированную последовательность
+	uriCharsTemplate = regexp.MustCompile(`^(?:[\w\-.~:/?#\[\]@!$&'()*+,;=]|%[0-9A-Fa-f]{2})*$`)
+
+	// aliasTemplate представляет собой скомпилированное регулярное выражение
+	// для проверки строки на соответствие требованиям пользовательского
+	// псевдонима короткой ссылки
+	aliasTemplate = regexp.MustCompile(`^[0-9a-zA-Z_-]{3,32}$`)
+
+	// urlConstraint — название ограничения уникальности оригинального URL в
+	// таблице links
+	urlConstraint = "original_url_unique"
+)
+
+// selectURLQuery запрашивает оригинальный URL короткой ссылки $1 из
+// пространства имен $2 и сведения, необходимые для ее разрешения методом Get
+const selectURLQuery = "SELECT original_url, alphabet, expires_at, created_at, max_uses IS NOT NULL, version FROM links WHERE link = $1 AND namespace = $2;"
+
+// lockAliasQuery захватывает до конца транзакции рекомендательную блокировку
+// псевдонима $2 с учетом пространства имен (см. namespacedLink). Блокировка
+// задается парой ключей: классом $1 и хешем псевдонима.
+const lockAliasQuery = "SELECT pg_advisory_xact_lock($1, hashtext($2));"
+
+// aliasLockClass отделяет блокировки псевдонимов от других рекомендательных
+// блокировок базы данных.
+const aliasLockClass = 0x6c696e6b
+
+// insertLinkQuery добавляет запись в пространство имен $12 со сведениями о
+// создателе $13 и $14 и возвращает ее короткую ссылку. Если дедупликация
+// включена ($6) и для URL в том же пространстве имен запись уже существует,
+// то возвращается ее короткая ссылка. Если короткая ссылка занята, то запрос
+// не возвращает строк
+const insertLinkQuery = "WITH inserted AS (INSERT INTO links (link, original_url, alphabet, expires_at, collection_id, deduplicated, title, owner_id, sliding_ttl_seconds, max_uses, tags, namespace, creator_ip, creator_user_agent) " +
+	"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) ON CONFLICT DO NOTHING RETURNING link) " +
+	"SELECT link FROM inserted UNION ALL SELECT link FROM links WHERE $6 AND deduplicated AND namespace = $12 AND original_url = $2 LIMIT 1;"
+
+// коды SQLSTATE ошибок PostgreSQL, обрабатываемых сервисом
+const (
+	uniqueViolation      pq.ErrorCode = "23505"
+	foreignKeyViolation  pq.ErrorCode = "23503"
+	serializationFailure pq.ErrorCode = "40001"
+	deadlockDetected     pq.ErrorCode = "40P01"
 )
 
 var (
@@ -40,110 +94,1191 @@ var (
 	// ErrURLNotFound возвращается в случаях, когда для указанной короткой
 	// ссылки не существует оригинальной ссылки URL
 	ErrURLNotFound = errors.New("linkservice: unknown abbreviated link — the original URL was not found")
+
+	// ErrInvalidAlias возвращается в случаях, когда gRPC-запрос содержит
+	// некорректный пользовательский псевдоним
+	ErrInvalidAlias = errors.New("linkservice: the request contains an invalid alias")
+
+	// ErrAliasTaken возвращается в случаях, когда указанный в gRPC-запросе
+	// пользовательский псевдоним уже занят
+	ErrAliasTaken = errors.New("linkservice: the alias is already taken")
+
+	// ErrInvalidAlphabet возвращается в случаях, когда в gRPC-запросе указан
+	// незарегистрированный алфавит
+	ErrInvalidAlphabet = errors.New("linkservice: the request contains an unknown alphabet")
+
+	// ErrBatchTooLarge возвращается в случаях, когда пакетный gRPC-запрос
+	// содержит больше элементов, чем допускается
+	ErrBatchTooLarge = errors.New("linkservice: the batch request contains too many items")
+
+	// ErrInvalidTTL возвращается в случаях, когда gRPC-запрос содержит
+	// отрицательное время жизни короткой ссылки, одновременно фиксированное
+	// и скользящее время жизни или уже наступивший срок действия
+	ErrInvalidTTL = errors.New("linkservice: the request contains an invalid TTL")
+
+	// ErrInvalidMaxUses возвращается в случаях, когда gRPC-запрос содержит
+	// отрицательное ограничение количества переходов по короткой ссылке
+	ErrInvalidMaxUses = errors.New("linkservice: the request contains a negative limit of uses")
+
+	// ErrLinkExhausted возвращается в случаях, когда количество переходов по
+	// короткой ссылке достигло ограничения max_uses
+	ErrLinkExhausted = errors.New("linkservice: the link has reached its limit of uses")
+
+	// ErrLinkExpired возвращается в случаях, когда срок действия короткой
+	// ссылки истек, но она еще не удалена из базы данных
+	ErrLinkExpired = errors.New("linkservice: the link has expired")
+
+	// ErrDeadlineExceeded возвращается в случаях, когда запрос отменен или
+	// срок его выполнения истекает раньше, чем удается его обработать
+	ErrDeadlineExceeded = errors.New("linkservice: the request deadline is exceeded")
+
+	// ErrInvalidTimeRange возвращается в случаях, когда gRPC-запрос содержит
+	// некорректный период времени
+	ErrInvalidTimeRange = errors.New("linkservice: the request contains an invalid time range")
+
+	// ErrInvalidTime возвращается в случаях, когда gRPC-запрос не содержит
+	// момента времени или содержит некорректный момент времени
+	ErrInvalidTime = errors.New("linkservice: the request contains an invalid time")
+
+	// ErrInvalidCollection возвращается в случаях, когда gRPC-запрос содержит
+	// некорректное название коллекции
+	ErrInvalidCollection = errors.New("linkservice: the request contains an invalid collection name")
+
+	// ErrCollectionNotFound возвращается в случаях, когда указанная в
+	// gRPC-запросе коллекция не существует
+	ErrCollectionNotFound = errors.New("linkservice: the collection was not found")
+
+	// ErrURLTaken возвращается в случаях, когда указанный в gRPC-запросе URL
+	// уже сопоставлен другой короткой ссылке
+	ErrURLTaken = errors.New("linkservice: the URL already has another abbreviated link")
+
+	// ErrURLTooLong возвращается в случаях, когда указанный в gRPC-запросе URL
+	// длиннее допустимого
+	ErrURLTooLong = errors.New("linkservice: the URL is too long")
+
+	// ErrInvalidMetadata возвращается в случаях, когда название или
+	// идентификатор владельца в gRPC-запросе длиннее допустимого
+	ErrInvalidMetadata = errors.New("linkservice: the request contains invalid link metadata")
+
+	// ErrInvalidTags возвращается в случаях, когда gRPC-запрос содержит
+	// пустой или слишком длинный тег либо слишком много тегов
+	ErrInvalidTags = errors.New("linkservice: the request contains invalid tags")
+
+	// ErrInvalidFormat возвращается в случаях, когда gRPC-запрос содержит
+	// неизвестный формат ссылки либо формат FULL_URL, а BaseURL не задан
+	ErrInvalidFormat = errors.New("linkservice: the request contains an unsupported link format")
+
+	// ErrInvalidNamespace возвращается в случаях, когда gRPC-запрос содержит
+	// некорректное пространство имен либо пространство имен указано в
+	// методе, который его не поддерживает
+	ErrInvalidNamespace = errors.New("linkservice: the request contains an invalid namespace")
+
+	// ErrInvalidOwner возвращается в случаях, когда в gRPC-запросе не указан
+	// идентификатор владельца ссылок
+	ErrInvalidOwner = errors.New("linkservice: the request contains an invalid owner id")
+
+	// ErrInvalidPageToken возвращается в случаях, когда gRPC-запрос содержит
+	// токен страницы, не выданный сервисом
+	ErrInvalidPageToken = errors.New("linkservice: the request contains an invalid page token")
+
+	// ErrInvalidVisits возвращается в случаях, когда gRPC-запрос содержит
+	// отрицательное количество переходов по ссылке
+	ErrInvalidVisits = errors.New("linkservice: the request contains an invalid number of visits")
+
+	// ErrAliasReserved возвращается в случаях, когда указанный в gRPC-запросе
+	// псевдоним совпадает с одним из зарезервированных слов
+	ErrAliasReserved = errors.New("linkservice: the alias is a reserved word")
+
+	// ErrDomainNotAllowed возвращается в случаях, когда хост указанного в
+	// gRPC-запросе URL запрещен или не разрешен настройками сервера либо URL
+	// отклонен проверкой репутации URLChecker
+	ErrDomainNotAllowed = errors.New("linkservice: short links to this domain are not allowed")
+
+	// ErrMethodDisabled возвращается в случаях, когда вызванный метод
+	// отключен настройками сервера
+	ErrMethodDisabled = errors.New("linkservice: the method is disabled on this server")
+
+	// ErrMaintenance возвращается в случаях, когда запрос не может быть
+	// обработан, потому что сервер переведен в режим обслуживания
+	ErrMaintenance = errors.New("linkservice: the server is under maintenance")
 )
 
+// GRPCServer реализует gRPC-сервис LinkService. Сервер должен создаваться
+// функцией NewGRPCServer и закрываться методом Close после остановки.
 type GRPCServer struct {
-	Database *sql.DB
+	Database DB
+
+	// ReadDB задает реплику базы данных, к которой обращаются читающие
+	// методы: Get, GetBatch, Stats, GetMetadata, HitsOverTime, Count, Export
+	// и методы получения списков ссылок и коллекций. Изменения, в том числе
+	// учет переходов по ссылкам, по-прежнему выполняются в Database. Реплика
+	// может отставать от основной базы данных, поэтому списки и статистика
+	// могут не сразу отражать изменения; ссылки, не найденные на реплике,
+	// метод Get запрашивает у Database. Если не задана, то все запросы
+	// выполняются в Database
+	ReadDB DB
+
+	// подготовленные запросы, используемые методами Create и Get
+	insertLinkStmt *sql.Stmt
+	selectURLStmt  *sql.Stmt
+
+	// LinkLength задает длину генерируемых коротких ссылок. Если не задана, то
+	// используется длина по умолчанию, равная 10 символам. Длина не может
+	// превышать 32 символа — размер столбца link в базе данных
+	LinkLength int
+
+	// AllowedSchemes содержит схемы URL, для которых создаются короткие
+	// ссылки. URL с другими схемами, а также URL без схемы отклоняются с
+	// ошибкой ErrInvalidURL. Если не задан, то используется DefaultSchemes
+	AllowedSchemes []string
+
+	// DefaultScheme задает схему, которая добавляется к URL без схемы, например
+	// "example.com/page", при создании ссылок и изменении URL, так что в базе
+	// данных хранятся только абсолютные URL. Схема должна входить в
+	// AllowedSchemes. Если не задана, то URL без схемы отклоняются
+	DefaultScheme string
+
+	// AllowedDomains содержит шаблоны хостов, на которые разрешено создавать
+	// короткие ссылки, например "example.com" или "*.example.com" для всех
+	// поддоменов. Если задан, то URL с другими хостами отклоняются с ошибкой
+	// ErrDomainNotAllowed. Если не задан, то разрешены все хосты, кроме
+	// указанных в DeniedDomains
+	AllowedDomains []string
+
+	// DeniedDomains содержит шаблоны хостов в том же формате, что и
+	// AllowedDomains, на которые запрещено создавать короткие ссылки. Запрет
+	// имеет приоритет над AllowedDomains
+	DeniedDomains []string
+
+	// URLChecker проверяет репутацию URL перед созданием короткой ссылки,
+	// например по спискам Google Safe Browsing (см. SafeBrowsingChecker).
+	// Отклоненные URL передаются клиенту как ErrDomainNotAllowed. Если не
+	// задан, то репутация URL не проверяется
+	URLChecker URLChecker
+
+	// MaxURLLength ограничивает длину URL в символах. Более длинные URL
+	// отклоняются с ошибкой ErrURLTooLong. Если не задано, то используется
+	// ограничение в 2048 символов; большее значение недопустимо, поскольку
+	// совпадает с размером столбца original_url в базе данных
+	MaxURLLength int
+
+	// Alphabet задает алфавит, из символов которого генерируются короткие
+	// ссылки, если в запросе алфавит не указан, например, без символов
+	// 0/O/1/l/I для ссылок, которые читают вслух. Заменяет алфавит с пустым
+	// именем из Alphabets. Если не задан, то используется алфавит из Alphabets
+	Alphabet string
+
+	// Alphabets содержит именованные алфавиты, из которых по выбору клиента
+	// генерируются короткие ссылки. Если не задан, то используется
+	// DefaultAlphabets
+	Alphabets map[string]string
+
+	// LinkPattern задает регулярное выражение, которому должны соответствовать
+	// короткие ссылки и псевдонимы в запросах, например для развертываний с
+	// собственной схемой ссылок. Строки, не соответствующие ему, отклоняются
+	// до обращения к базе данных. Если не задано, то допустимы ссылки,
+	// составленные из символов любого алфавита из Alphabets длиной
+	// LinkLength, псевдонимы, а также ссылки схемы CodeStrategy
+	LinkPattern *regexp.Regexp
+
+	// ReservedWords содержит слова, которые не могут быть короткими ссылками:
+	// такие ссылки не генерируются, а псевдонимы отклоняются с ошибкой
+	// ErrAliasReserved. Слова сравниваются без учета регистра. Если не задан,
+	// то используется DefaultReservedWords; пустой срез снимает ограничения
+	ReservedWords []string
+
+	// BlockedWords содержит слова, например нецензурные, которые не должны
+	// встречаться в генерируемых коротких ссылках: ссылка, содержащая такое
+	// слово, заменяется новой. Слова сравниваются без учета регистра, а способ
+	// сравнения задает BlockedWordsMatch. Пользовательские псевдонимы не
+	// проверяются. Если не задан, то ссылки не проверяются
+	BlockedWords []string
+
+	// BlockedWordsMatch определяет, отбрасываются ли ссылки, содержащие слово
+	// из BlockedWords (MatchSubstring, по умолчанию), или только ссылки,
+	// целиком совпадающие с ним (MatchFull)
+	BlockedWordsMatch BlockMatch
+
+	// CodeStrategy задает способ получения коротких ссылок для запросов без
+	// псевдонима и алфавита. По умолчанию ссылки генерируются случайно
+	// (RandomAlphanumeric)
+	CodeStrategy CodeStrategy
+
+	// CodeGenerator заменяет генератор случайных коротких ссылок алфавита по
+	// умолчанию, например для экспериментов со схемами ссылок или
+	// воспроизводимых тестов. Ссылки алфавитов, выбранных клиентом, по-прежнему
+	// генерируются случайно. Если генератор выдает ссылки, не составленные из
+	// символов алфавита по умолчанию, то следует задать и LinkPattern, иначе
+	// такие ссылки не будут разрешаться. Если не задан, то используется
+	// RandomCodes с алфавитом по умолчанию
+	CodeGenerator CodeGenerator
+
+	// AllowDuplicates отключает дедупликацию: каждый вызов Create и каждый URL
+	// в запросе BatchCreate получают новую короткую ссылку, даже если для URL
+	// ссылка уже существует. Ссылки, созданные в этом режиме, не возвращаются
+	// и после отключения режима. По умолчанию одному URL соответствует одна
+	// короткая ссылка
+	AllowDuplicates bool
+
+	// BulkDelete включает метод DeleteOlderThan, необратимо удаляющий ссылки
+	// всех владельцев. Метод не должен быть доступен без проверки API-ключей,
+	// поэтому по умолчанию он отключен и отклоняет запросы с ошибкой
+	// ErrMethodDisabled
+	BulkDelete bool
+
+	// MaxBatch ограничивает количество URL в одном запросе BatchCreate. Если
+	// не задано, то используется ограничение в 1000 URL
+	MaxBatch int
+
+	// PurgeInterval задает интервал, с которым метод PurgeExpired удаляет из
+	// базы данных записи с истекшим сроком действия. Если не задан, то записи
+	// не удаляются, но ссылки с истекшим сроком действия все равно не
+	// разрешаются методом Get
+	PurgeInterval time.Duration
+
+	// SaturationInterval задает интервал, с которым метод WatchSaturation
+	// подсчитывает долю занятых коротких ссылок. Если не задан, то доля не
+	// подсчитывается
+	SaturationInterval time.Duration
+
+	// SaturationWarnThreshold задает долю занятых коротких ссылок, при
+	// превышении которой метод WatchSaturation записывает в журнал
+	// предупреждение. Если не задана, то используется доля 0,5
+	SaturationWarnThreshold float64
+
+	// DuplicateSlashes определяет обработку повторяющихся символов "/" в пути
+	// URL. По умолчанию путь сохраняется без изменений
+	DuplicateSlashes DuplicateSlashes
+
+	// MinRetryTime задает минимальное время до истечения срока запроса, при
+	// котором после коллизии коротких ссылок предпринимается новая попытка
+	// добавления записи. Если времени осталось меньше, то запрос завершается
+	// с ошибкой ErrDeadlineExceeded
+	MinRetryTime time.Duration
+
+	// MaxCollisionRetries ограничивает количество повторных попыток добавить
+	// запись с новой короткой ссылкой, если сгенерированные ссылки уже
+	// заняты. Когда ссылок почти не остается, коллизии становятся частыми, и
+	// ограничение не дает запросу бесконечно генерировать новые ссылки: по
+	// его превышении возвращается ошибка ErrReqProc. Если не задано, то
+	// предпринимается не более 10 повторных попыток
+	MaxCollisionRetries int
+
+	// MaxDBAttempts ограничивает количество попыток выполнить читающий запрос
+	// к базе данных, завершившийся временной ошибкой, например разрывом
+	// соединения или ошибкой сериализации. Пауза перед повторной попыткой
+	// начинается с DBRetryDelay и удваивается с каждой попыткой. Если не
+	// задано, то предпринимается не более 3 попыток
+	MaxDBAttempts int
+
+	// DBRetryDelay задает паузу перед первой повторной попыткой выполнить
+	// запрос к базе данных. Если не задана, то используется 50 мс
+	DBRetryDelay time.Duration
+
+	// StatementTimeout ограничивает время выполнения каждого запроса к базе
+	// данных или транзакции при обработке gRPC-запроса независимо от срока,
+	// заданного клиентом. По его истечении запрос отменяется, и клиент
+	// получает ошибку ErrDeadlineExceeded. Если не задано, то время
+	// выполнения ограничено лишь сроком gRPC-запроса
+	StatementTimeout time.Duration
+
+	// SlowQueryThreshold задает длительность запроса к базе данных, при
+	// превышении которой запрос записывается в журнал с уровнем
+	// предупреждения. Если не задана, то медленные запросы не записываются
+	SlowQueryThreshold time.Duration
+
+	// Logger задает журнал, в который записываются ошибки обработки запросов
+	// и сообщения фоновых задач. Если не задан, то используется журнал
+	// стандартной библиотеки
+	Logger Logger
+
+	// Queries получает длительность запросов к базе данных, например, для
+	// метрик Prometheus. Если не задан, то длительность не учитывается
+	Queries QueryObserver
+
+	// Collisions получает количество коллизий коротких ссылок, то есть
+	// повторных попыток добавить запись с новой ссылкой. Если не задан, то
+	// коллизии лишь записываются в журнал с отладочным уровнем
+	Collisions CollisionObserver
+
+	// Saturation получает долю занятых коротких ссылок, подсчитываемую
+	// методом WatchSaturation. Если не задан, то доля лишь сравнивается с
+	// SaturationWarnThreshold
+	Saturation SaturationObserver
+
+	// BaseURL задает адрес, по которому доступны короткие ссылки, например
+	// "https://short.example". Если задан, то методы Create и BatchCreate
+	// дополнительно возвращают полный короткий URL в поле full_url. В базе
+	// данных по-прежнему хранится только короткая ссылка
+	BaseURL string
+
+	// CacheSize задает количество коротких ссылок, хранящихся в кэше метода
+	// Get. Если не задан, то кэширование отключено
+	CacheSize int
+
+	// MaintenanceServeCached разрешает методу Get в режиме обслуживания
+	// возвращать ссылки, найденные в кэше, не обращаясь к базе данных. По
+	// умолчанию в режиме обслуживания Get отклоняет все запросы
+	MaintenanceServeCached bool
+
+	// CascadeCollections определяет, удаляются ли вместе с коллекцией
+	// входящие в нее короткие ссылки. По умолчанию ссылки сохраняются и
+	// перестают относиться к какой-либо коллекции
+	CascadeCollections bool
+
+	// PoolSize задает количество заранее сгенерированных коротких ссылок,
+	// которые метод FillPool держит в пуле для метода Create. Пул используется
+	// только для алфавита по умолчанию и только при случайной генерации
+	// ссылок. Если не задан, то пул отключен
+	PoolSize int
+
+	// BloomCapacity задает ожидаемое количество коротких ссылок, на которое
+	// рассчитан фильтр Блума занятых ссылок. Сгенерированные ссылки, которые
+	// фильтр считает вероятно занятыми, отбрасываются до обращения к базе
+	// данных. Фильтр не знает о ссылках, созданных другими экземплярами
+	// сервиса, поэтому источником истины остается база данных. Если не
+	// задано, то фильтр отключен
+	BloomCapacity int
+
+	// BloomFalsePositiveRate задает долю ложноположительных ответов фильтра
+	// Блума при BloomCapacity ссылках; от нее зависит размер фильтра. Если не
+	// задана, то используется доля 0,01 — около 1,2 байта на ссылку
+	BloomFalsePositiveRate float64
+
+	// CountCacheTTL задает время, в течение которого метод Count возвращает
+	// ранее подсчитанное количество ссылок. Если не задано, то используется
+	// 10 секунд
+	CountCacheTTL time.Duration
+
+	// Build содержит сведения о сборке сервиса, которые возвращает метод
+	// Version
+	Build BuildInfo
+
+	cache     *lruCache
+	cacheOnce sync.Once
+
+	pool     *tokenPool
+	poolOnce sync.Once
+
+	filter     *bloomFilter
+	filterOnce sync.Once
+
+	blocked       *regexp.Regexp
+	blocklistOnce sync.Once
+
+	linkCount countCache
+
+	// maintenance не равен нулю, пока сервер находится в режиме обслуживания
+	maintenance int32
+
 	api.UnimplementedLinkServiceServer
 }
 
+// QueryObserver получает длительность запросов к базе данных. Запросы
+// различаются по короткому имени query, например "insert_link".
+type QueryObserver interface {
+	ObserveQuery(query string, d time.Duration)
+}
+
+// CollisionObserver получает количество коллизий n, возникших при добавлении
+// одной записи запросом query, например "insert_link".
+type CollisionObserver interface {
+	ObserveCollisions(query string, n int)
+}
+
+// NewGRPCServer создает сервер, работающий с базой данных db, и подготавливает
+// используемые им запросы, чтобы PostgreSQL не разбирал их при каждом вызове.
+// Если db не поддерживает подготовку запросов, как заглушки в тестах, то
+// запросы выполняются без нее.
+func NewGRPCServer(db DB) (*GRPCServer, error) {
+	s := &GRPCServer{Database: db}
+
+	p, ok := db.(preparer)
+	if !ok {
+		return s, nil
+	}
+
+	// queries сопоставляет подготавливаемые запросы полям сервера
+	queries := []struct {
+		stmt  **sql.Stmt
+		query string
+	}{
+		{&s.insertLinkStmt, insertLinkQuery},
+		{&s.selectURLStmt, selectURLQuery},
+	}
+
+	for _, q := range queries {
+		stmt, err := p.Prepare(q.query)
+		if err != nil {
+			s.Close()
+			return nil, fmt.Errorf("linkservice: failed to prepare the query %q: %w", q.query, err)
+		}
+
+		*q.stmt = stmt
+	}
+
+	return s, nil
+}
+
+// Validate проверяет параметры сервера: длину коротких ссылок и URL и алфавиты,
+// каждый из которых должен содержать не менее двух неповторяющихся символов.
+// Метод следует вызывать после задания параметров и до начала обработки
+// запросов.
+func (s *GRPCServer) Validate() error {
+	if s.LinkLength > maxLinkLength {
+		return fmt.Errorf("linkservice: the link length %d exceeds %d characters", s.LinkLength, maxLinkLength)
+	}
+
+	if s.MaxURLLength > maxURLLengthDefault {
+		return fmt.Errorf("linkservice: the maximum URL length %d exceeds %d characters", s.MaxURLLength, maxURLLengthDefault)
+	}
+
+	if s.BloomCapacity < 0 || s.BloomFalsePositiveRate < 0 || s.BloomFalsePositiveRate >= 1 {
+		return fmt.Errorf("linkservice: invalid Bloom filter settings: capacity %d, false positive rate %v", s.BloomCapacity, s.BloomFalsePositiveRate)
+	}
+
+	if s.SaturationWarnThreshold < 0 || s.SaturationWarnThreshold > 1 {
+		return fmt.Errorf("linkservice: the saturation warning threshold %v is not between 0 and 1", s.SaturationWarnThreshold)
+	}
+
+	if s.DefaultScheme != "" && !s.allowedScheme(s.DefaultScheme) {
+		return fmt.Errorf("linkservice: the default scheme %q is not allowed", s.DefaultScheme)
+	}
+
+	for _, pattern := range append(append([]string(nil), s.AllowedDomains...), s.DeniedDomains...) {
+		if err := validateDomainPattern(pattern); err != nil {
+			return err
+		}
+	}
+
+	alphabets := s.alphabets()
+	if _, ok := alphabets[""]; !ok {
+		return errors.New("linkservice: the default alphabet is not set")
+	}
+
+	// ссылки, которые сервис генерирует сам, должны разрешаться: ссылки
+	// алфавита по умолчанию проверяются по образцу, а ссылки CodeGenerator —
+	// по одной сгенерированной ссылке
+	if s.CodeGenerator == nil && s.LinkPattern != nil && !s.LinkPattern.MatchString(sampleLink(alphabets[""], s.linkLength())) {
+		return fmt.Errorf("linkservice: the link pattern %q does not match links of the default alphabet", s.LinkPattern)
+	}
+
+	if s.CodeGenerator != nil {
+		if link := s.CodeGenerator.Generate(s.linkLength()); !s.validLink(link) {
+			return fmt.Errorf("linkservice: the code generator produced the link %q that would not be accepted in requests", link)
+		}
+	}
+
+	for name, chars := range alphabets {
+		if err := validateAlphabet(name, chars); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Close закрывает подготовленные сервером запросы. База данных при этом не
+// закрывается.
+func (s *GRPCServer) Close() error {
+	var firstErr error
+
+	for _, stmt := range []*sql.Stmt{s.insertLinkStmt, s.selectURLStmt} {
+		if stmt == nil {
+			continue
+		}
+
+		if err := stmt.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// Create возвращает короткую ссылку для указанного в запросе URL. Если в
+// запросе задано время жизни ttl_seconds, то по его истечении ссылка перестает
+// разрешаться. Если для URL уже существует действующая ссылка, то возвращается
+// она вне зависимости от запрошенного времени жизни, если только не задан
+// AllowDuplicates. Новая ссылка добавляется в коллекцию collection_id, если
+// она указана; коллекция существующей ссылки не изменяется. Название title и
+// идентификатор владельца owner_id сохраняются вместе с новой ссылкой и могут
+// быть пустыми; метаданные существующей ссылки не изменяются. Теги tags
+// сохраняются без пробельных символов по краям и в нижнем регистре. Параметры
+// запроса URL, перечисленные в strip_params, удаляются перед сохранением. Если
+// задан URLChecker, то перед сохранением проверяется репутация URL. Если задан
+// BaseURL, то ответ также содержит полный короткий URL. При формате format,
+// равном FULL_URL, полный короткий URL возвращается и в поле link; этот формат
+// требует, чтобы был задан BaseURL.
+//
+// Ошибки передаются клиенту с кодами состояния gRPC: ErrInvalidURL,
+// ErrURLTooLong, ErrInvalidAlias, ErrInvalidAlphabet, ErrInvalidTTL,
+// ErrInvalidMaxUses, ErrInvalidMetadata, ErrInvalidTags и ErrInvalidFormat —
+// codes.InvalidArgument, ErrAliasTaken, ErrAliasReserved и ErrURLTaken —
+// codes.AlreadyExists, ErrCollectionNotFound — codes.NotFound,
+// ErrDomainNotAllowed — codes.PermissionDenied, ErrMaintenance —
+// codes.Unavailable, ErrDeadlineExceeded — codes.DeadlineExceeded, ErrReqProc
+// — codes.Internal.
 func (s *GRPCServer) Create(ctx context.Context, req *api.URL) (*api.Link, error) {
+	// формат проверяется до создания ссылки, чтобы не создавать ссылку,
+	// которую нельзя вернуть в запрошенном формате
+	if err := s.checkFormat(req.GetFormat()); err != nil {
+		return nil, statusError(err)
+	}
+
+	link, _, err := s.create(ctx, req)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	link.FullUrl = s.fullURL(link.GetLink())
+	if req.GetFormat() == api.LinkFormat_FULL_URL {
+		link.Link = link.GetFullUrl()
+	}
+
+	return link, nil
+}
+
+// GetOrCreate возвращает короткую ссылку для указанного в запросе URL так же,
+// как метод Create, и сообщает в поле created, была ли ссылка создана этим
+// вызовом или уже существовала. Поле created равно false и в случае, когда
+// ссылку для того же URL одновременно создал другой запрос.
+//
+// Ошибки передаются клиенту с теми же кодами состояния gRPC, что и в методе
+// Create.
+func (s *GRPCServer) GetOrCreate(ctx context.Context, req *api.URL) (*api.LinkResult, error) {
+	link, created, err := s.create(ctx, req)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &api.LinkResult{
+		Link:    link.GetLink(),
+		FullUrl: s.fullURL(link.GetLink()),
+		Created: created,
+	}, nil
+}
+
+// create реализует метод Create, возвращая ошибки сервиса без преобразования
+// в ошибки gRPC, и сообщает, была ли добавлена новая запись или возвращена
+// ссылка, уже существовавшая для URL.
+func (s *GRPCServer) create(ctx context.Context, req *api.URL) (*api.Link, bool, error) {
+	if err := s.checkMaintenance(); err != nil {
+		return nil, false, err
+	}
+
+	if err := checkNamespace(req.GetNamespace()); err != nil {
+		return nil, false, err
+	}
+
+	req, err := trimURL(req)
+	if err != nil {
+		return nil, false, err
+	}
+
+	req = s.withScheme(req)
+
+	// интернационализированные доменные имена проверяются и хранятся в
+	// ASCII-форме
+	req, err = asciiURL(req)
+	if err != nil {
+		return nil, false, err
+	}
+
 	// проверка переданной в запросе строки на соответствие требованиям URL
-	if !URLTemplate.MatchString(req.GetUrl()) {
-		return nil, ErrInvalidURL
+	if err := s.checkURL(req.GetUrl()); err != nil {
+		return nil, false, err
 	}
 
-	// проверяем, сгенерирована ли короткая ссылка для указанного URL
-	row := s.Database.QueryRow("SELECT link FROM links WHERE original_url = $1;", req.GetUrl())
+	// параметры отслеживания удаляются до поиска существующей ссылки, чтобы
+	// URL, отличающиеся лишь ими, получали одну и ту же короткую ссылку
+	req = stripParams(req)
 
-	var link string
-	err := row.Scan(&link)
+	if err := checkTTL(req); err != nil {
+		return nil, false, err
+	}
 
-	// если во время запроса произошла ошибка и она не является sql.ErrNoRows,
-	// то отправляем сообщение с невозможностью обработать запрос
-	if err != nil && err != sql.ErrNoRows {
-		log.Printf("Create method: %v\n", err)
-		return nil, ErrReqProc
+	if req.GetMaxUses() < 0 {
+		return nil, false, ErrInvalidMaxUses
 	}
 
-	// если ошибок (включая sql.ErrNoRows) во время запроса не произошло, то
-	// для указанного в запросе URL найдена сокращенная ссылка
-	if err == nil {
-		return &api.Link{Link: link}, nil
+	if err := checkMetadata(req); err != nil {
+		return nil, false, err
 	}
 
-	// генерируем для указанного URL короткую ссылку и добавляем новую запись
-	// в базу данных. Если подобная короткая ссылка уже существует, то
-	// генерируем новую и повторяем попытку добавления записи. Повторяем до
-	// тех пор, пока не добавится новая запись или не произойдет иная ошибка
+	req, err = normalizeTags(req)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// приводим URL к виду, в котором он хранится в базе данных, чтобы
+	// эквивалентные URL получали одну и ту же короткую ссылку
+	req, err = s.normalize(req)
+	if err != nil {
+		return nil, false, err
+	}
 
-	// UCViolation представляет собой текстовое описание ошибки, возникающей
-	// при нарушении ограничения уникальности в PostgreSQL
-	UCViolation := "pq: duplicate key value violates unique constraint \"link_pk\""
+	// репутация проверяется последней, так как проверка может обращаться к
+	// внешнему сервису, и ограничена лишь сроком gRPC-запроса
+	if err := s.checkReputation(ctx, "Create", req.GetUrl()); err != nil {
+		return nil, false, err
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	// если для URL существует ссылка с истекшим сроком действия, то удаляем
+	// ее, чтобы не возвращать ее клиенту и освободить URL для новой ссылки
+	if err = deleteExpiredURL(ctx, s.Database, req.GetNamespace(), req.GetUrl()); err != nil {
+		return nil, false, s.requestError(ctx, "Create", err, "url", req.GetUrl())
+	}
+
+	// если в запросе указан пользовательский псевдоним, то используем его в
+	// качестве короткой ссылки вместо случайно сгенерированной
+	if req.GetAlias() != "" {
+		link, created, err := s.createWithAlias(ctx, req)
+		if created {
+			s.linkFilter().add(link.GetLink())
+		}
+
+		return link, created, err
+	}
+
+	// определяем алфавит, из символов которого будет сгенерирована короткая
+	// ссылка
+	alphabet, ok := s.alphabets()[req.GetAlphabet()]
+	if !ok {
+		return nil, false, ErrInvalidAlphabet
+	}
 
-	for {
-		// генерируем для указанного URL короткую ссылку
-		link = generateRandomСharacters(lengthLink)
+	// при последовательной схеме короткая ссылка получается из идентификатора
+	// записи, и коллизии случайных ссылок исключены, а при детерминированной —
+	// из хеша URL
+	if s.sequential(req) || s.deterministic(req) {
+		insert := s.insertSequential
+		if s.deterministic(req) {
+			insert = s.insertDeterministic
+		}
+
+		link, created, err := insert(ctx, s.Database, req)
+		if err == ErrCollectionNotFound {
+			return nil, false, err
+		}
+
+		if err != nil {
+			return nil, false, s.requestError(ctx, "Create", err, "url", req.GetUrl())
+		}
 
-		_, err := s.Database.Exec("INSERT INTO links (link, original_url) VALUES ($1, $2);", link, req.GetUrl())
+		if created {
+			s.linkFilter().add(link)
+		}
+
+		return &api.Link{Link: link}, created, nil
+	}
 
-		// если произошла ошибка, которая не является шибкой UCViolation, то
-		// завершаем работу метода и сообщаем о ситуации
-		if err != nil && err.Error() != UCViolation {
-			log.Printf("Create method: %v\n", err)
-			return nil, ErrReqProc
+	// если включен пул, то используем заранее зарезервированную короткую
+	// ссылку, не генерируя ее на время обработки запроса
+	if req.GetAlphabet() == "" {
+		if res, created, ok, err := s.createFromPool(ctx, req); ok {
+			if created {
+				s.linkFilter().add(res.GetLink())
+			}
+
+			return res, created, err
 		}
+	}
+
+	// генерируем для указанного URL короткую ссылку и добавляем новую запись
+	// в базу данных. Если для URL уже существует запись, то запрос не
+	// добавляет новую, а возвращает существующую короткую ссылку. Если
+	// сгенерированная короткая ссылка уже занята, то запрос не возвращает
+	// строк, и попытка повторяется с новой ссылкой, но не более
+	// MaxCollisionRetries раз
+	var link, candidate string
+
+	attempt := 1
+	defer func() { s.observeCollisions("insert_link", attempt-1, "url", req.GetUrl()) }()
+
+	for ; ; attempt++ {
+		candidate = s.generateLink(alphabet)
+
+		start := time.Now()
+		err := s.queryRow(ctx, s.insertLinkStmt, insertLinkQuery, candidate,
+			req.GetUrl(), req.GetAlphabet(), expiresAt(req), collectionID(req), s.deduplicate(req),
+			nullString(req.GetTitle()), nullString(req.GetOwnerId()), slidingTTL(req), maxUses(req), tagsArray(req), req.GetNamespace(),
+			creatorIP(ctx), creatorUserAgent(ctx)).Scan(&link)
+		s.observeQuery("insert_link", start, "method", "Create", "url", req.GetUrl())
 
 		if err == nil {
 			break
 		}
+
+		if _, ok := violation(err, foreignKeyViolation); ok {
+			return nil, false, ErrCollectionNotFound
+		}
+
+		if err != sql.ErrNoRows {
+			return nil, false, s.requestError(ctx, "Create", err, "url", req.GetUrl())
+		}
+
+		if attempt > s.maxCollisionRetries() {
+			s.logError("Create", errors.New("no free link was generated"), "url", req.GetUrl(), "attempts", attempt)
+			return nil, false, ErrReqProc
+		}
+
+		// перед повторной попыткой убеждаемся, что запрос еще можно успеть
+		// обработать
+		if err := s.checkRetry(ctx); err != nil {
+			return nil, false, err
+		}
+	}
+
+	// запрос возвращает сгенерированную ссылку, только если добавил запись
+	if link == candidate {
+		s.linkFilter().add(link)
+	}
+
+	return &api.Link{Link: link}, link == candidate, nil
+}
+
+// createWithAlias добавляет в базу данных запись, в которой в качестве
+// короткой ссылки используется указанный в запросе пользовательский псевдоним,
+// приведенный к нижнему регистру, и сообщает, была ли запись добавлена. Если
+// псевдоним уже сопоставлен тому же URL, то возвращается существующая ссылка,
+// поэтому повторный запрос не считается конфликтом. Если псевдоним занят
+// ссылкой на другой URL, в том числе псевдонимом, отличающимся лишь регистром,
+// то возвращается ошибка ErrAliasTaken, если он зарезервирован —
+// ErrAliasReserved, а если URL уже сопоставлен другой короткой ссылке —
+// ErrURLTaken.
+func (s *GRPCServer) createWithAlias(ctx context.Context, req *api.URL) (*api.Link, bool, error) {
+	// проверка псевдонима на соответствие требованиям
+	if !s.validAlias(req.GetAlias()) {
+		return nil, false, ErrInvalidAlias
+	}
+
+	if s.isReserved(req.GetAlias()) {
+		return nil, false, ErrAliasReserved
+	}
+
+	alias := foldAlias(req.GetAlias())
+	namespace := req.GetNamespace()
+
+	// проверка и добавление псевдонима выполняются в транзакции под
+	// рекомендательной блокировкой по хешу псевдонима, поэтому одновременные
+	// запросы одного и того же псевдонима выполняются по очереди, а
+	// проигравшие получают ErrAliasTaken без неудачной вставки
+	tx, err := s.Database.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, false, s.requestError(ctx, "Create", err, "url", req.GetUrl())
+	}
+
+	// откатываем транзакцию, если она не была зафиксирована
+	defer tx.Rollback()
+
+	start := time.Now()
+	_, err = tx.ExecContext(ctx, lockAliasQuery, aliasLockClass, namespacedLink(namespace, alias))
+	s.observeQuery("lock_alias", start, "method", "Create", "alias", alias)
+
+	if err != nil {
+		return nil, false, s.requestError(ctx, "Create", err, "url", req.GetUrl())
+	}
+
+	// проверка выполняется отдельным запросом уже после получения блокировки,
+	// чтобы видеть записи, добавленные предыдущим ее владельцем
+	var stored string
+
+	start = time.Now()
+	err = tx.QueryRowContext(ctx, "SELECT original_url FROM links WHERE link = $1 AND namespace = $2;", alias, namespace).Scan(&stored)
+	s.observeQuery("check_alias", start, "method", "Create", "alias", alias)
+
+	switch {
+	case err == nil && stored == req.GetUrl():
+		// повторный запрос того же псевдонима для того же URL возвращает
+		// существующую ссылку, как и запрос без псевдонима
+		return &api.Link{Link: alias}, false, nil
+	case err == nil:
+		return nil, false, ErrAliasTaken
+	case err != sql.ErrNoRows:
+		return nil, false, s.requestError(ctx, "Create", err, "url", req.GetUrl())
+	}
+
+	start = time.Now()
+	_, err = tx.ExecContext(ctx, "INSERT INTO links (link, original_url, expires_at, collection_id, deduplicated, title, owner_id, sliding_ttl_seconds, max_uses, tags, namespace, creator_ip, creator_user_agent) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13);",
+		alias, req.GetUrl(), expiresAt(req), collectionID(req), s.deduplicate(req), nullString(req.GetTitle()), nullString(req.GetOwnerId()), slidingTTL(req), maxUses(req), tagsArray(req), namespace,
+		creatorIP(ctx), creatorUserAgent(ctx))
+	s.observeQuery("insert_alias", start, "method", "Create", "alias", alias)
+
+	// нарушение ограничения уникальности короткой ссылки означает, что
+	// псевдоним уже используется другой записью
+	if pqErr, ok := violation(err, uniqueViolation); ok && pqErr.Constraint == urlConstraint {
+		return nil, false, ErrURLTaken
+	} else if ok {
+		return nil, false, ErrAliasTaken
+	}
+
+	if _, ok := violation(err, foreignKeyViolation); ok {
+		return nil, false, ErrCollectionNotFound
+	}
+
+	if err != nil {
+		return nil, false, s.requestError(ctx, "Create", err, "url", req.GetUrl())
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, s.requestError(ctx, "Create", err, "url", req.GetUrl())
 	}
 
-	return &api.Link{Link: link}, nil
+	return &api.Link{Link: alias}, true, nil
 }
 
+// Get возвращает оригинальный URL для указанной в запросе короткой ссылки и
+// время ее создания. Ссылка ищется в указанном в запросе пространстве имен, а
+// если оно не указано — в пространстве имен по умолчанию. Если при создании
+// ссылки задано ограничение max_uses, то после max_uses переходов ссылка
+// перестает разрешаться. Ошибки передаются клиенту с кодами состояния gRPC:
+// ErrInvalidLink и ErrInvalidNamespace — codes.InvalidArgument,
+// ErrURLNotFound и ErrLinkExhausted — codes.NotFound, ErrLinkExpired —
+// codes.FailedPrecondition, ErrMaintenance — codes.Unavailable,
+// ErrDeadlineExceeded — codes.DeadlineExceeded, ErrReqProc — codes.Internal.
 func (s *GRPCServer) Get(ctx context.Context, req *api.Link) (*api.URL, error) {
+	url, err := s.get(ctx, req)
+	return url, statusError(err)
+}
+
+// get реализует метод Get, возвращая ошибки сервиса без преобразования в
+// ошибки gRPC.
+func (s *GRPCServer) get(ctx context.Context, req *api.Link) (*api.URL, error) {
 	// проверка переданной в запросе строки на соответствие требованиям
-	// короткой ссылки
-	if !linkTemplate.MatchString(req.GetLink()) {
+	// короткой ссылки или пользовательского псевдонима
+	if !s.validLink(req.GetLink()) {
 		return nil, ErrInvalidLink
 	}
 
-	// запрашиваем исходный URL по сокращенной ссылке
-	row := s.Database.QueryRow("SELECT original_url FROM links WHERE link = $1;", req.GetLink())
+	namespace := req.GetNamespace()
+	if err := checkNamespace(namespace); err != nil {
+		return nil, err
+	}
+
+	// в режиме обслуживания ссылки ищутся только в кэше, поскольку база
+	// данных может быть недоступна
+	maintenance := s.inMaintenance()
+
+	var entry cacheEntry
+	var err error
+	if maintenance {
+		entry, err = s.resolveCached(namespace, req.GetLink())
+	} else {
+		entry, err = s.resolve(ctx, namespace, req.GetLink())
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	// запрошенная ссылка может отличаться от найденной регистром символов
+	link := entry.link
+
+	// ссылки с истекшим сроком действия не разрешаются, даже если они еще не
+	// удалены из базы данных. Клиенту сообщается именно об истечении срока,
+	// чтобы он мог отличить такую ссылку от несуществующей
+	if expired(entry.expires) {
+		s.linkCache().remove(entry.key())
+		return nil, ErrLinkExpired
+	}
+
+	// переход не учитывается, поэтому ссылки с ограниченным количеством
+	// переходов в режиме обслуживания не разрешаются
+	if maintenance {
+		if entry.limited {
+			return nil, ErrMaintenance
+		}
+
+		return &api.URL{Url: entry.url, CreatedAt: timestamppb.New(entry.created), Version: entry.version}, nil
+	}
+
+	// учитываем переход по короткой ссылке и тем же запросом продлеваем срок
+	// действия ссылок со скользящим временем жизни. Ошибка при обновлении
+	// счетчиков не должна мешать возврату оригинального URL. Для ссылок с
+	// ограниченным количеством переходов этот же запрос атомарно проверяет и
+	// увеличивает счетчик uses, поэтому одновременные переходы не превышают
+	// ограничения
+	qctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	start := time.Now()
+	err = s.Database.QueryRowContext(qctx, "UPDATE links SET visits = visits + 1, uses = uses + 1, last_accessed_at = $2, "+
+		"expires_at = CASE WHEN sliding_ttl_seconds IS NULL THEN expires_at ELSE $2 + sliding_ttl_seconds * interval '1 second' END "+
+		"WHERE link = $1 AND namespace = $3 AND (max_uses IS NULL OR uses < max_uses) RETURNING expires_at;", link, start, namespace).Scan(&entry.expires)
+	s.observeQuery("update_visits", start, "method", "Get", "namespace", namespace, "link", link)
+
+	switch {
+	case err == nil:
+		// кэш должен знать продленный срок действия, иначе ссылка будет
+		// считаться истекшей по прежнему сроку
+		s.linkCache().put(entry)
+
+	case entry.limited && err == sql.ErrNoRows:
+		s.linkCache().remove(entry.key())
+		return nil, ErrLinkExhausted
+
+	case entry.limited:
+		// без учета перехода нельзя убедиться, что ограничение не превышено
+		return nil, s.requestError(ctx, "Get", err, "link", link)
+
+	case err == sql.ErrNoRows:
+		// ссылка удалена после поиска или в обход кэша, например другим
+		// экземпляром сервиса, и не должна разрешаться из кэша дальше
+		s.linkCache().remove(entry.key())
+		return nil, ErrURLNotFound
+
+	default:
+		s.logError("Get", err, "link", link)
+	}
+
+	if err := s.recordHit(ctx, namespace, link); err != nil {
+		s.logError("Get", err, "link", link)
+	}
+
+	return &api.URL{Url: entry.url, CreatedAt: timestamppb.New(entry.created), Version: entry.version}, nil
+}
+
+// cachedLookup возвращает оригинальный URL и срок действия короткой ссылки
+// link из пространства имен namespace из кэша, а при его промахе — из базы
+// данных.
+func (s *GRPCServer) cachedLookup(ctx context.Context, namespace, link string) (cacheEntry, error) {
+	if entry, ok := s.linkCache().get(namespacedLink(namespace, link)); ok {
+		return entry, nil
+	}
+
+	entry, err := s.lookup(ctx, namespace, link)
+	if err != nil {
+		return cacheEntry{}, err
+	}
+
+	s.linkCache().put(entry)
+	return entry, nil
+}
 
-	var url string
-	err := row.Scan(&url)
+// lookup запрашивает в базе данных оригинальный URL и срок действия короткой
+// ссылки link из пространства имен namespace.
+func (s *GRPCServer) lookup(ctx context.Context, namespace, link string) (cacheEntry, error) {
+	start := time.Now()
 
-	// если во время запроса произошла ошибка и она не является sql.ErrNoRows,
-	// то отправляем сообщение с невозможностью обработать запрос
-	if err != nil && err != sql.ErrNoRows {
-		log.Printf("Get method: %v\n", err)
-		return nil, ErrReqProc
+	entry := cacheEntry{namespace: namespace, link: link}
+	var alphabet sql.NullString
+	scan := func(row *sql.Row) error {
+		return row.Scan(&entry.url, &alphabet, &entry.expires, &entry.created, &entry.limited, &entry.version)
 	}
 
+	err := s.retry(ctx, "select_url", func(ctx context.Context) error {
+		if s.ReadDB == nil {
+			return scan(s.queryRow(ctx, s.selectURLStmt, selectURLQuery, link, namespace))
+		}
+
+		// реплика может еще не получить только что созданную ссылку, поэтому
+		// ссылка, не найденная на реплике, запрашивается у основной базы
+		// данных
+		err := scan(s.ReadDB.QueryRowContext(ctx, selectURLQuery, link, namespace))
+		if err == sql.ErrNoRows {
+			err = scan(s.queryRow(ctx, s.selectURLStmt, selectURLQuery, link, namespace))
+		}
+
+		return err
+	})
+	s.observeQuery("select_url", start, "namespace", namespace, "link", link)
+
 	// если записей в базе данных для данной сокращенной ссылки не найдено, то
 	// возвращаем соответствующую ошибку
 	if err == sql.ErrNoRows {
-		return nil, ErrURLNotFound
+		return cacheEntry{}, ErrURLNotFound
+	}
+
+	// если во время запроса произошла иная ошибка, то отправляем сообщение с
+	// невозможностью обработать запрос
+	if err != nil {
+		return cacheEntry{}, s.requestError(ctx, "Get", err, "link", link)
+	}
+
+	// если короткая ссылка была сгенерирована из символов известного алфавита,
+	// то проверяем ее на соответствие этому алфавиту (для псевдонимов
+	// алфавит не сохраняется). Длина при этом не проверяется, чтобы ссылки,
+	// созданные до изменения LinkLength, оставались доступными
+	if chars, ok := s.alphabets()[alphabet.String]; alphabet.Valid && ok && !inAlphabet(chars, link) {
+		return cacheEntry{}, ErrInvalidLink
+	}
+
+	return entry, nil
+}
+
+// checkRetry проверяет, можно ли предпринять повторную попытку добавления
+// записи в рамках запроса с контекстом ctx. Если запрос отменен или до
+// истечения его срока осталось меньше MinRetryTime, то возвращается ошибка
+// ErrDeadlineExceeded.
+func (s *GRPCServer) checkRetry(ctx context.Context) error {
+	if ctx.Err() != nil {
+		return ErrDeadlineExceeded
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < s.MinRetryTime {
+		return ErrDeadlineExceeded
+	}
+
+	return nil
+}
+
+// linkLength возвращает длину генерируемых коротких ссылок.
+func (s *GRPCServer) linkLength() int {
+	if s.LinkLength > 0 {
+		return s.LinkLength
 	}
 
-	return &api.URL{Url: url}, nil
+	return lengthLink
+}
+
+// maxCollisionRetries возвращает количество повторных попыток добавить запись
+// после коллизии коротких ссылок.
+func (s *GRPCServer) maxCollisionRetries() int {
+	if s.MaxCollisionRetries > 0 {
+		return s.MaxCollisionRetries
+	}
+
+	return maxCollisionRetriesDefault
 }
 
 // generateRandomCharacters генерирует строки длиной length случайных символов.
 // При генерации используются символы латинского алфавита в нижнем и верхнем
-// регистре, цифры и символ подчеркивания (_).
+// регистре, цифры и символ подчеркивания (_). Символы выбираются с помощью
+// криптографически стойкого генератора, поэтому сгенерированные строки нельзя
+// предсказать по ранее выданным.
 func generateRandomСharacters(length int) string {
-	// задаем исходный алфавит символов
-	alphabet := []rune("0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ_abcdefghijklmnopqrstuvwxyz")
+	return generateFromAlphabet(defaultAlphabet, length)
+}
 
-	rc := make([]rune, length)
+// generateFromAlphabet генерирует строки длиной length случайных символов
+// алфавита chars. Алфавит должен содержать не более 256 символов.
+func generateFromAlphabet(chars string, length int) string {
+	alphabet := []rune(chars)
+
+	// каждый символ выбирается по одному случайному байту. Байты, не меньшие
+	// limit — наибольшего кратного длине алфавита числа, не превышающего 256, —
+	// отбрасываются, чтобы все символы алфавита выбирались равновероятно
+	limit := 256 - 256%len(alphabet)
+
+	rc := make([]rune, 0, length)
+	buf := make([]byte, length)
 
 	// заполняем срез rc случайными символами алфавита
-	for i := 0; i < length; i++ {
-		rc[i] = alphabet[rand.Intn(len(alphabet))]
+	for len(rc) < length {
+		if _, err := rand.Read(buf); err != nil {
+			panic(fmt.Sprintf("linkservice: failed to read random bytes: %v", err))
+		}
+
+		for _, b := range buf {
+			if int(b) < limit && len(rc) < length {
+				rc = append(rc, alphabet[int(b)%len(alphabet)])
+			}
+		}
 	}
 
 	return string(rc)
 }
+
+// fullURL возвращает полный короткий URL для короткой ссылки link или пустую
+// строку, если BaseURL не задан.
+func (s *GRPCServer) fullURL(link string) string {
+	if s.BaseURL == "" {
+		return ""
+	}
+
+	return strings.TrimSuffix(s.BaseURL, "/") + "/" + link
+}
+
+// checkFormat проверяет, что сервер может вернуть ссылку в формате format.
+// Формат FULL_URL доступен, только если задан BaseURL.
+func (s *GRPCServer) checkFormat(format api.LinkFormat) error {
+	switch format {
+	case api.LinkFormat_CODE:
+		return nil
+	case api.LinkFormat_FULL_URL:
+		if s.BaseURL != "" {
+			return nil
+		}
+	}
+
+	return ErrInvalidFormat
+}
+
+// observeCollisions сообщает CollisionObserver количество коллизий n,
+// возникших при добавлении записи запросом query, и записывает его в журнал
+// с отладочным уровнем вместе с полями keyvals.
+func (s *GRPCServer) observeCollisions(query string, n int, keyvals ...interface{}) {
+	if n == 0 {
+		return
+	}
+
+	if s.Collisions != nil {
+		s.Collisions.ObserveCollisions(query, n)
+	}
+
+	s.logger().Debug("link collisions", append([]interface{}{"query", query, "retries", n}, keyvals...)...)
+}
+
+// observeQuery сообщает QueryObserver длительность запроса к базе данных
+// query, начатого в момент start. Если длительность превышает
+// SlowQueryThreshold, то запрос записывается в журнал с уровнем
+// предупреждения вместе с полями keyvals, например, методом и короткой
+// ссылкой, чтобы можно было найти запросы, которым не хватает индексов.
+func (s *GRPCServer) observeQuery(query string, start time.Time, keyvals ...interface{}) {
+	elapsed := time.Since(start)
+
+	if s.Queries != nil {
+		s.Queries.ObserveQuery(query, elapsed)
+	}
+
+	if s.SlowQueryThreshold > 0 && elapsed > s.SlowQueryThreshold {
+		s.logger().Warn("slow query", append([]interface{}{"query", query, "duration", elapsed}, keyvals...)...)
+	}
+}
+
+// CountLinks возвращает общее количество коротких ссылок в базе данных,
+// включая ссылки с истекшим сроком действия, которые еще не удалены.
+func (s *GRPCServer) CountLinks(ctx context.Context) (int64, error) {
+	var n int64
+	err := s.Database.QueryRowContext(ctx, "SELECT count(*) FROM links;").Scan(&n)
+	return n, err
+}
+
+// requestError возвращает ошибку сервиса, соответствующую ошибке базы данных
+// err, возникшей при обработке запроса method с контекстом ctx. Если запрос
+// отменен или срок его выполнения истек, то возвращается ErrDeadlineExceeded,
+// иначе ошибка записывается в журнал с полями keyvals и возвращается
+// ErrReqProc.
+func (s *GRPCServer) requestError(ctx context.Context, method string, err error, keyvals ...interface{}) error {
+	if ctx.Err() != nil || timedOut(err) {
+		return ErrDeadlineExceeded
+	}
+
+	s.logError(method, err, keyvals...)
+	return ErrReqProc
+}
+
+// violation сообщает, является ли err ошибкой PostgreSQL с кодом SQLSTATE code,
+// и возвращает ее, чтобы можно было определить нарушенное ограничение.
+func violation(err error, code pq.ErrorCode) (*pq.Error, bool) {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == code {
+		return pqErr, true
+	}
+
+	return nil, false
+}
+
+// readDB возвращает базу данных, к которой обращаются читающие методы:
+// реплику ReadDB, если она задана, или основную базу данных.
+func (s *GRPCServer) readDB() DB {
+	if s.ReadDB != nil {
+		return s.ReadDB
+	}
+
+	return s.Database
+}
@@ -3,10 +3,21 @@ package linkservice
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // параметры подключения к базе данных для проведения тестов
@@ -35,6 +46,27 @@ var TestCreateCases = []struct {
 		},
 		expError: ErrInvalidURL,
 	},
+	{
+		name: "ftp_scheme",
+		req: &api.URL{
+			Url: "ftp://files.example.com/file.txt",
+		},
+		expError: ErrInvalidURL,
+	},
+	{
+		name: "javascript_scheme",
+		req: &api.URL{
+			Url: "javascript:alert(1)",
+		},
+		expError: ErrInvalidURL,
+	},
+	{
+		name: "schemeless",
+		req: &api.URL{
+			Url: "golang.org/doc",
+		},
+		expError: ErrInvalidURL,
+	},
 }
 
 var TestCreateCasesTwo = []struct {
@@ -66,12 +98,18 @@ func TestCreate(t *testing.T) {
 
 	defer db.Close()
 
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	defer service.Close()
+
 	// проверка метода Create на тест-кейсах
 	for _, testCase := range TestCreateCases {
 		t.Run(testCase.name, func(t *testing.T) {
-
-			service := GRPCServer{Database: db}
 			res, err := service.Create(context.Background(), testCase.req)
+			err = FromStatus(err)
 
 			switch {
 			case err == nil && testCase.expError == nil:
@@ -82,7 +120,7 @@ func TestCreate(t *testing.T) {
 					return
 				}
 
-				if !linkTemplate.MatchString(res.GetLink()) {
+				if !alphabetTemplate(defaultAlphabet, lengthLink).MatchString(res.GetLink()) {
 					t.Errorf("the abbreviated link has an incorrect format")
 				}
 
@@ -105,9 +143,6 @@ func TestCreate(t *testing.T) {
 	// содержится один и тот же URL
 	for _, testCase := range TestCreateCasesTwo {
 		t.Run(testCase.req.GetUrl(), func(t *testing.T) {
-
-			service := GRPCServer{Database: db}
-
 			res, err := service.Create(context.Background(), testCase.req)
 
 			if err != nil {
@@ -133,6 +168,240 @@ func TestCreate(t *testing.T) {
 	}
 }
 
+func TestCreateConcurrent(t *testing.T) {
+	// устанавливаем подключение к базе данных
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed connecting to the database: %v", err)
+	}
+
+	defer db.Close()
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	defer service.Close()
+
+	url := "http://concurrent.abc/" + generateRandomСharacters(6)
+	links := make([]string, 50)
+
+	var wg sync.WaitGroup
+	for i := range links {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			res, err := service.Create(context.Background(), &api.URL{Url: url})
+			if err != nil {
+				t.Errorf("Create method reported an error: %v", err)
+				return
+			}
+
+			links[i] = res.GetLink()
+		}(i)
+	}
+
+	wg.Wait()
+
+	// все одновременные запросы должны получить одну и ту же ссылку
+	for i, link := range links {
+		if link != links[0] {
+			t.Errorf("request #%d received the link \"%s\" instead of \"%s\"", i, link, links[0])
+		}
+	}
+
+	var count int
+	if err := db.QueryRow("SELECT count(*) FROM links WHERE original_url = $1;", url).Scan(&count); err != nil {
+		t.Fatalf("failed to query the database: %v", err)
+	}
+
+	if count != 1 {
+		t.Errorf("one record was expected for the URL, but %d were found", count)
+	}
+}
+
+func TestCreateWithAlias(t *testing.T) {
+	// устанавливаем подключение к базе данных
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed connecting to the database: %v", err)
+	}
+
+	defer db.Close()
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	defer service.Close()
+
+	// псевдоним и URL дополняются случайными символами, чтобы тест можно было
+	// запускать повторно на одной и той же базе данных
+	alias := "promo-" + generateRandomСharacters(6)
+	url := "http://promo.abc/" + generateRandomСharacters(6)
+
+	testCases := []struct {
+		name     string
+		req      *api.URL
+		expError error
+	}{
+		{
+			name:     "free_alias",
+			req:      &api.URL{Url: url, Alias: alias},
+			expError: nil,
+		},
+		{
+			// повторный запрос того же псевдонима для того же URL не является
+			// конфликтом
+			name:     "same_url",
+			req:      &api.URL{Url: url, Alias: alias},
+			expError: nil,
+		},
+		{
+			name:     "taken_alias",
+			req:      &api.URL{Url: url + "/other", Alias: alias},
+			expError: ErrAliasTaken,
+		},
+		{
+			// псевдонимы, отличающиеся лишь регистром, совпадают
+			name:     "taken_alias_other_case",
+			req:      &api.URL{Url: url + "/case", Alias: strings.ToUpper(alias)},
+			expError: ErrAliasTaken,
+		},
+		{
+			name:     "invalid_alias",
+			req:      &api.URL{Url: url, Alias: "my promo!"},
+			expError: ErrInvalidAlias,
+		},
+		{
+			name:     "reserved_alias",
+			req:      &api.URL{Url: url + "/reserved", Alias: "Metrics"},
+			expError: ErrAliasReserved,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			res, err := service.Create(context.Background(), testCase.req)
+			err = FromStatus(err)
+
+			if err != testCase.expError {
+				t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received",
+					testCase.expError, err)
+				return
+			}
+
+			// псевдоним хранится в нижнем регистре
+			if err == nil && res.GetLink() != strings.ToLower(testCase.req.GetAlias()) {
+				t.Errorf("the link \"%s\" does not match the requested alias \"%s\"",
+					res.GetLink(), testCase.req.GetAlias())
+			}
+		})
+	}
+
+	// псевдоним должен разрешаться методом Get в исходный URL независимо от
+	// регистра символов
+	for _, link := range []string{alias, strings.ToLower(alias), strings.ToUpper(alias)} {
+		res, err := service.Get(context.Background(), &api.Link{Link: link})
+		if err != nil {
+			t.Fatalf("Get method reported an error: %v", err)
+		}
+
+		if res.GetUrl() != url {
+			t.Errorf("URL contained in the response does not match the expected one")
+		}
+	}
+}
+
+func TestCreateWithAlphabets(t *testing.T) {
+	// устанавливаем подключение к базе данных
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed connecting to the database: %v", err)
+	}
+
+	defer db.Close()
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	defer service.Close()
+
+	for _, name := range []string{"dense", "friendly"} {
+		t.Run(name, func(t *testing.T) {
+			url := "http://alphabet.abc/" + name + "/" + generateRandomСharacters(6)
+
+			res, err := service.Create(context.Background(), &api.URL{Url: url, Alphabet: name})
+			if err != nil {
+				t.Fatalf("Create method reported an error: %v", err)
+			}
+
+			if !alphabetTemplate(DefaultAlphabets[name], lengthLink).MatchString(res.GetLink()) {
+				t.Errorf("link \"%s\" does not match the \"%s\" alphabet", res.GetLink(), name)
+			}
+
+			resURL, err := service.Get(context.Background(), res)
+			if err != nil {
+				t.Fatalf("Get method reported an error: %v", err)
+			}
+
+			if resURL.GetUrl() != url {
+				t.Errorf("URL contained in the response does not match the expected one")
+			}
+		})
+	}
+
+	_, err = service.Create(context.Background(), &api.URL{Url: "http://alphabet.abc/", Alphabet: "unknown"})
+	if err = FromStatus(err); err != ErrInvalidAlphabet {
+		t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received",
+			ErrInvalidAlphabet, err)
+	}
+}
+
+func TestLinkLength(t *testing.T) {
+	// устанавливаем подключение к базе данных
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed connecting to the database: %v", err)
+	}
+
+	defer db.Close()
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	defer service.Close()
+
+	service.LinkLength = 6
+	url := "http://length.abc/" + generateRandomСharacters(6)
+
+	res, err := service.Create(context.Background(), &api.URL{Url: url})
+	if err != nil {
+		t.Fatalf("Create method reported an error: %v", err)
+	}
+
+	if !alphabetTemplate(defaultAlphabet, 6).MatchString(res.GetLink()) {
+		t.Errorf("link \"%s\" does not have the configured length", res.GetLink())
+	}
+
+	resURL, err := service.Get(context.Background(), res)
+	if err != nil {
+		t.Fatalf("Get method reported an error: %v", err)
+	}
+
+	if resURL.GetUrl() != url {
+		t.Errorf("URL contained in the response does not match the expected one")
+	}
+}
+
 var TestGetCases = []struct {
 	name     string
 	req      *api.Link
@@ -169,12 +438,17 @@ func TestGet(t *testing.T) {
 
 	defer db.Close()
 
-	// дополняем тест-кейсы необходимыми корректными короткими ссылками
-	service := GRPCServer{Database: db}
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	defer service.Close()
 
+	// дополняем тест-кейсы необходимыми корректными короткими ссылками
 	for i := range TestGetCases {
 		if TestGetCases[i].req == nil {
-			url := "http://abc.abc/"
+			url := "http://abc.abc"
 
 			res, err := service.Create(context.Background(), &api.URL{
 				Url: url,
@@ -192,9 +466,8 @@ func TestGet(t *testing.T) {
 	// проверка метода Get на тест-кейсах
 	for _, testCase := range TestGetCases {
 		t.Run(testCase.name, func(t *testing.T) {
-
-			service := GRPCServer{Database: db}
 			res, err := service.Get(context.Background(), testCase.req)
+			err = FromStatus(err)
 
 			switch {
 			case err == nil && testCase.expError == nil:
@@ -208,6 +481,10 @@ func TestGet(t *testing.T) {
 					t.Errorf("URL contained in the response does not match the expected one")
 				}
 
+				if created := res.GetCreatedAt().AsTime(); time.Since(created) > time.Hour {
+					t.Errorf("a recent creation time was expected, but %v was received", created)
+				}
+
 			case err != testCase.expError:
 				// получена ошибка err, отличная от той, что ожидалась
 				t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received",
@@ -229,9 +506,591 @@ func TestGenerateRandomСharacters(t *testing.T) {
 
 	for i := 0; i < n; i++ {
 		link := generateRandomСharacters(lengthLink)
-		if !linkTemplate.MatchString(link) {
+		if !alphabetTemplate(defaultAlphabet, lengthLink).MatchString(link) {
 			t.Errorf("link \"%s\" is incorrect", link)
 			t.FailNow()
 		}
 	}
 }
+
+func TestStatusError(t *testing.T) {
+	testCases := []struct {
+		err  error
+		code codes.Code
+	}{
+		{err: ErrInvalidURL, code: codes.InvalidArgument},
+		{err: ErrURLTooLong, code: codes.InvalidArgument},
+		{err: ErrInvalidMetadata, code: codes.InvalidArgument},
+		{err: ErrInvalidTags, code: codes.InvalidArgument},
+		{err: ErrInvalidFormat, code: codes.InvalidArgument},
+		{err: ErrInvalidNamespace, code: codes.InvalidArgument},
+		{err: ErrInvalidOwner, code: codes.InvalidArgument},
+		{err: ErrInvalidPageToken, code: codes.InvalidArgument},
+		{err: ErrInvalidVisits, code: codes.InvalidArgument},
+		{err: ErrInvalidLink, code: codes.InvalidArgument},
+		{err: ErrURLNotFound, code: codes.NotFound},
+		{err: ErrLinkExhausted, code: codes.NotFound},
+		{err: ErrLinkExpired, code: codes.FailedPrecondition},
+		{err: ErrInvalidTime, code: codes.InvalidArgument},
+		{err: ErrInvalidMaxUses, code: codes.InvalidArgument},
+		{err: ErrURLTaken, code: codes.AlreadyExists},
+		{err: ErrAliasReserved, code: codes.AlreadyExists},
+		{err: ErrDomainNotAllowed, code: codes.PermissionDenied},
+		{err: ErrMethodDisabled, code: codes.PermissionDenied},
+		{err: ErrMaintenance, code: codes.Unavailable},
+		{err: ErrBatchTooLarge, code: codes.ResourceExhausted},
+		{err: ErrReqProc, code: codes.Internal},
+	}
+
+	for _, testCase := range testCases {
+		err := statusError(testCase.err)
+
+		if code := status.Code(err); code != testCase.code {
+			t.Errorf("the code %v was expected for \"%v\", but %v was received", testCase.code, testCase.err, code)
+		}
+
+		if sentinel := FromStatus(err); sentinel != testCase.err {
+			t.Errorf("the error \"%v\" was expected, but \"%v\" was restored", testCase.err, sentinel)
+		}
+	}
+
+	if statusError(nil) != nil {
+		t.Errorf("it was expected that the status error for nil would be equal to nil")
+	}
+}
+
+func TestStatusErrorDetails(t *testing.T) {
+	// некорректные запросы отклоняются до обращения к базе данных, поэтому
+	// сервер не требует подключения
+	service := &GRPCServer{}
+
+	_, createErr := service.Create(context.Background(), &api.URL{Url: "javascript:alert(1)"})
+	_, getErr := service.Get(context.Background(), &api.Link{Link: "a b"})
+
+	testCases := []struct {
+		name     string
+		err      error
+		expField string
+	}{
+		{name: "create_invalid_url", err: createErr, expField: "url"},
+		{name: "get_invalid_link", err: getErr, expField: "link"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			st := status.Convert(testCase.err)
+			if st.Code() != codes.InvalidArgument {
+				t.Fatalf("the code %v was expected, but %v was received", codes.InvalidArgument, st.Code())
+			}
+
+			var violations []*errdetails.BadRequest_FieldViolation
+			for _, detail := range st.Details() {
+				if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+					violations = append(violations, badRequest.GetFieldViolations()...)
+				}
+			}
+
+			if len(violations) != 1 || violations[0].GetField() != testCase.expField || violations[0].GetDescription() == "" {
+				t.Errorf("a violation of the field \"%s\" was expected, but %v was received", testCase.expField, violations)
+			}
+		})
+	}
+
+	// ошибки без сведений о поле передаются без дополнительных сведений
+	if details := status.Convert(statusError(ErrReqProc)).Details(); len(details) != 0 {
+		t.Errorf("no details were expected, but %v were received", details)
+	}
+}
+
+func TestViolation(t *testing.T) {
+	unique := &pq.Error{Code: "23505", Constraint: "link_pk"}
+
+	testCases := []struct {
+		name  string
+		err   error
+		code  pq.ErrorCode
+		expOK bool
+	}{
+		{name: "unique", err: unique, code: uniqueViolation, expOK: true},
+		{name: "wrapped", err: fmt.Errorf("insert: %w", unique), code: uniqueViolation, expOK: true},
+		{name: "other_code", err: unique, code: foreignKeyViolation, expOK: false},
+		{name: "not_pq", err: errors.New(`pq: duplicate key value violates unique constraint "link_pk"`), code: uniqueViolation, expOK: false},
+		{name: "nil", err: nil, code: uniqueViolation, expOK: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			pqErr, ok := violation(testCase.err, testCase.code)
+			if ok != testCase.expOK {
+				t.Fatalf("the result %v was expected, but %v was received", testCase.expOK, ok)
+			}
+
+			if ok && pqErr.Constraint != unique.Constraint {
+				t.Errorf("constraint \"%s\" was expected, but \"%s\" was received", unique.Constraint, pqErr.Constraint)
+			}
+		})
+	}
+}
+
+func TestGenerateRandomСharactersDistribution(t *testing.T) {
+	// каждый символ алфавита в среднем должен встречаться perChar раз
+	var perChar = 1000
+
+	counts := make(map[rune]int)
+	for _, r := range generateRandomСharacters(perChar * len(defaultAlphabet)) {
+		counts[r]++
+	}
+
+	// допускаемое отклонение заведомо больше случайного разброса и выявляет
+	// лишь систематический перекос распределения
+	for _, r := range defaultAlphabet {
+		if counts[r] < perChar*7/10 || counts[r] > perChar*13/10 {
+			t.Errorf("character %q occurred %d times, about %d were expected", r, counts[r], perChar)
+		}
+	}
+}
+
+func TestCreateDeadline(t *testing.T) {
+	// устанавливаем подключение к базе данных
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed connecting to the database: %v", err)
+	}
+
+	defer db.Close()
+
+	// алфавит из одного символа и единичная длина ссылки приводят к тому, что
+	// каждая сгенерированная ссылка совпадает с уже существующей
+	_, err = db.Exec("INSERT INTO links (link, original_url) VALUES ('z', 'http://collision.abc/') ON CONFLICT DO NOTHING;")
+	if err != nil {
+		t.Fatalf("failed to update the database: %v", err)
+	}
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	defer service.Close()
+
+	service.LinkLength = 1
+	service.Alphabets = map[string]string{"": "z"}
+	service.MinRetryTime = 50 * time.Millisecond
+	service.MaxCollisionRetries = math.MaxInt32
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = service.Create(ctx, &api.URL{Url: "http://collision.abc/" + generateRandomСharacters(6)})
+
+	if code := status.Code(err); code != codes.DeadlineExceeded {
+		t.Errorf("the code %v was expected, but %v was received", codes.DeadlineExceeded, code)
+	}
+
+	// метод должен завершиться до истечения срока запроса с учетом MinRetryTime
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Errorf("Create method returned after %v, the deadline was not respected", elapsed)
+	}
+}
+
+// BenchmarkSelectURL сравнивает запрос оригинального URL, который PostgreSQL
+// разбирает при каждом вызове, с подготовленным запросом сервера.
+func TestCreateContextCancel(t *testing.T) {
+	// устанавливаем подключение к базе данных
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed connecting to the database: %v", err)
+	}
+
+	defer db.Close()
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	defer service.Close()
+
+	url := "http://cancel.abc/" + generateRandomСharacters(6)
+
+	// незафиксированная запись с тем же URL заставляет запрос метода Create
+	// ожидать завершения транзакции, имитируя медленный запрос
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("failed to begin a transaction: %v", err)
+	}
+
+	defer tx.Rollback()
+
+	_, err = tx.Exec("INSERT INTO links (link, original_url) VALUES ($1, $2);", generateRandomСharacters(lengthLink), url)
+	if err != nil {
+		t.Fatalf("failed to update the database: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = service.Create(ctx, &api.URL{Url: url})
+
+	if err = FromStatus(err); err != ErrDeadlineExceeded {
+		t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrDeadlineExceeded, err)
+	}
+
+	// отмена запроса должна прервать ожидание в базе данных
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Create method returned after %v, the query was not cancelled", elapsed)
+	}
+}
+
+func TestCreateMaxCollisionRetries(t *testing.T) {
+	// устанавливаем подключение к базе данных
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed connecting to the database: %v", err)
+	}
+
+	defer db.Close()
+
+	// алфавит из одного символа и единичная длина ссылки приводят к тому, что
+	// каждая сгенерированная ссылка совпадает с уже существующей
+	_, err = db.Exec("INSERT INTO links (link, original_url) VALUES ('z', 'http://collision.abc/') ON CONFLICT DO NOTHING;")
+	if err != nil {
+		t.Fatalf("failed to update the database: %v", err)
+	}
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	defer service.Close()
+
+	service.LinkLength = 1
+	service.Alphabets = map[string]string{"": "z"}
+	service.MaxCollisionRetries = 3
+
+	collisions := collisionCounter{}
+	service.Collisions = collisions
+
+	_, err = service.Create(context.Background(), &api.URL{Url: "http://collision.abc/" + generateRandomСharacters(6)})
+	if err = FromStatus(err); err != ErrReqProc {
+		t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrReqProc, err)
+	}
+
+	if n := collisions["insert_link"]; n != 3 {
+		t.Errorf("%d collisions were expected, but %d were received", 3, n)
+	}
+}
+
+// collisionCounter подсчитывает коллизии коротких ссылок по запросам
+type collisionCounter map[string]int
+
+func (c collisionCounter) ObserveCollisions(query string, n int) {
+	c[query] += n
+}
+
+func TestObserveCollisions(t *testing.T) {
+	collisions := collisionCounter{}
+	logger := &recordLogger{}
+	service := &GRPCServer{Collisions: collisions, Logger: logger}
+
+	// попытки без коллизий не учитываются
+	service.observeCollisions("insert_link", 0)
+	service.observeCollisions("insert_link", 2, "url", "http://collision.abc/")
+	service.observeCollisions("insert_link_batch", 1)
+
+	exp := collisionCounter{"insert_link": 2, "insert_link_batch": 1}
+	if !reflect.DeepEqual(collisions, exp) {
+		t.Errorf("collisions %v were expected, but %v were received", exp, collisions)
+	}
+
+	expDebug := [][]interface{}{
+		{"link collisions", "query", "insert_link", "retries", 2, "url", "http://collision.abc/"},
+		{"link collisions", "query", "insert_link_batch", "retries", 1},
+	}
+	if !reflect.DeepEqual(logger.debug, expDebug) {
+		t.Errorf("debug entries %v were expected, but %v were received", expDebug, logger.debug)
+	}
+}
+
+func BenchmarkSelectURL(b *testing.B) {
+	// устанавливаем подключение к базе данных
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		b.Fatalf("failed connecting to the database: %v", err)
+	}
+
+	defer db.Close()
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		b.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	defer service.Close()
+
+	link, err := service.Create(context.Background(), &api.URL{Url: "http://abc.abc/"})
+	if err != nil {
+		b.Fatalf("Create method reported an error: %v", err)
+	}
+
+	var url string
+	var alphabet sql.NullString
+	var expires sql.NullTime
+	var created time.Time
+
+	b.Run("raw", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			row := db.QueryRow("SELECT original_url, alphabet, expires_at, created_at FROM links WHERE link = $1;", link.GetLink())
+			if err := row.Scan(&url, &alphabet, &expires, &created); err != nil {
+				b.Fatalf("failed to query the database: %v", err)
+			}
+		}
+	})
+
+	b.Run("prepared", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			row := service.selectURLStmt.QueryRow(link.GetLink())
+			if err := row.Scan(&url, &alphabet, &expires, &created); err != nil {
+				b.Fatalf("failed to query the database: %v", err)
+			}
+		}
+	})
+}
+
+func TestCreateFullURL(t *testing.T) {
+	// устанавливаем подключение к базе данных
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed connecting to the database: %v", err)
+	}
+
+	defer db.Close()
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	defer service.Close()
+
+	url := &api.URL{Url: "http://full.abc/" + generateRandomСharacters(6)}
+
+	link, err := service.Create(context.Background(), url)
+	if err != nil {
+		t.Fatalf("an error occurred when creating a link: %v", err)
+	}
+
+	if link.GetFullUrl() != "" {
+		t.Errorf("an empty full URL was expected without BaseURL, but \"%s\" was received", link.GetFullUrl())
+	}
+
+	service.BaseURL = "https://short.example/"
+
+	link, err = service.Create(context.Background(), url)
+	if err != nil {
+		t.Fatalf("an error occurred when creating a link: %v", err)
+	}
+
+	if exp := "https://short.example/" + link.GetLink(); link.GetFullUrl() != exp {
+		t.Errorf("the full URL \"%s\" was expected, but \"%s\" was received", exp, link.GetFullUrl())
+	}
+
+	// в базе данных хранится только короткая ссылка
+	res, err := service.Get(context.Background(), &api.Link{Link: link.GetLink()})
+	if err != nil {
+		t.Fatalf("an error occurred when getting the URL: %v", err)
+	}
+
+	if res.GetUrl() != url.GetUrl() {
+		t.Errorf("the URL \"%s\" was expected, but \"%s\" was received", url.GetUrl(), res.GetUrl())
+	}
+}
+
+func TestFullURL(t *testing.T) {
+	testCases := []struct {
+		baseURL string
+		exp     string
+	}{
+		{baseURL: "", exp: ""},
+		{baseURL: "https://short.example", exp: "https://short.example/abcdefghij"},
+		{baseURL: "https://short.example/", exp: "https://short.example/abcdefghij"},
+		{baseURL: "https://example.com/s", exp: "https://example.com/s/abcdefghij"},
+	}
+
+	for _, testCase := range testCases {
+		service := &GRPCServer{BaseURL: testCase.baseURL}
+
+		if u := service.fullURL("abcdefghij"); u != testCase.exp {
+			t.Errorf("the full URL \"%s\" was expected, but \"%s\" was received", testCase.exp, u)
+		}
+	}
+}
+
+func TestCreateFormat(t *testing.T) {
+	const link = "abcdefghij"
+
+	testCases := []struct {
+		name    string
+		baseURL string
+		format  api.LinkFormat
+		exp     string
+		err     error
+	}{
+		{name: "code", format: api.LinkFormat_CODE, exp: link},
+		{name: "code_with_base_url", baseURL: "https://short.example", format: api.LinkFormat_CODE, exp: link},
+		{name: "full_url", baseURL: "https://short.example", format: api.LinkFormat_FULL_URL, exp: "https://short.example/" + link},
+		{name: "full_url_without_base_url", format: api.LinkFormat_FULL_URL, err: ErrInvalidFormat},
+		{name: "unknown_format", baseURL: "https://short.example", format: api.LinkFormat(7), err: ErrInvalidFormat},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			db := newMockDB(func(query string, args []driver.NamedValue) (mockResult, error) {
+				if query == insertLinkQuery {
+					return linkRow(link), nil
+				}
+
+				return mockResult{}, nil
+			})
+
+			service, err := NewGRPCServer(db)
+			if err != nil {
+				t.Fatalf("failed to prepare the server: %v", err)
+			}
+
+			service.BaseURL = testCase.baseURL
+
+			res, err := service.Create(context.Background(), &api.URL{Url: "http://format.abc/", Format: testCase.format})
+			if err = FromStatus(err); err != testCase.err {
+				t.Fatalf("an error with a value of \"%v\" was expected, but \"%v\" was received", testCase.err, err)
+			}
+
+			if err != nil {
+				// ссылка не создается, если ее нельзя вернуть в запрошенном
+				// формате
+				if queries := db.executed(); len(queries) != 0 {
+					t.Errorf("no queries were expected, but %q were executed", queries)
+				}
+
+				return
+			}
+
+			if res.GetLink() != testCase.exp {
+				t.Errorf("the link \"%s\" was expected, but \"%s\" was received", testCase.exp, res.GetLink())
+			}
+
+			if exp := service.fullURL(link); res.GetFullUrl() != exp {
+				t.Errorf("the full URL \"%s\" was expected, but \"%s\" was received", exp, res.GetFullUrl())
+			}
+		})
+	}
+}
+
+func TestAllowDuplicates(t *testing.T) {
+	// устанавливаем подключение к базе данных
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed connecting to the database: %v", err)
+	}
+
+	defer db.Close()
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	defer service.Close()
+
+	for _, allowDuplicates := range []bool{false, true} {
+		t.Run(fmt.Sprintf("allow_duplicates_%v", allowDuplicates), func(t *testing.T) {
+			service.AllowDuplicates = allowDuplicates
+			url := &api.URL{Url: "http://duplicates.abc/" + generateRandomСharacters(6)}
+
+			first, err := service.Create(context.Background(), url)
+			if err != nil {
+				t.Fatalf("Create method reported an error: %v", err)
+			}
+
+			second, err := service.Create(context.Background(), url)
+			if err != nil {
+				t.Fatalf("Create method reported an error: %v", err)
+			}
+
+			if same := first.GetLink() == second.GetLink(); same == allowDuplicates {
+				t.Errorf("repeated calls returned the links \"%s\" and \"%s\"", first.GetLink(), second.GetLink())
+			}
+
+			batch, err := service.BatchCreate(context.Background(), &api.URLList{Urls: []*api.URL{url, url}})
+			if err != nil {
+				t.Fatalf("BatchCreate method reported an error: %v", err)
+			}
+
+			links := batch.GetLinks()
+			if same := links[0].GetLink() == links[1].GetLink(); same == allowDuplicates {
+				t.Errorf("the batch returned the links \"%s\" and \"%s\"", links[0].GetLink(), links[1].GetLink())
+			}
+
+			// все созданные ссылки должны разрешаться в исходный URL
+			for _, link := range append(links, first, second) {
+				res, err := service.Get(context.Background(), &api.Link{Link: link.GetLink()})
+				if err != nil {
+					t.Fatalf("Get method reported an error: %v", err)
+				}
+
+				if res.GetUrl() != url.GetUrl() {
+					t.Errorf("the URL \"%s\" was expected, but \"%s\" was received", url.GetUrl(), res.GetUrl())
+				}
+			}
+		})
+	}
+}
+
+func TestGetOrCreate(t *testing.T) {
+	// устанавливаем подключение к базе данных
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed connecting to the database: %v", err)
+	}
+
+	defer db.Close()
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	defer service.Close()
+
+	req := &api.URL{Url: "http://getorcreate.abc/" + generateRandomСharacters(6)}
+
+	first, err := service.GetOrCreate(context.Background(), req)
+	if err != nil {
+		t.Fatalf("GetOrCreate method reported an error: %v", err)
+	}
+
+	if !first.GetCreated() {
+		t.Errorf("the link was expected to be created by the first call")
+	}
+
+	second, err := service.GetOrCreate(context.Background(), req)
+	if err != nil {
+		t.Fatalf("GetOrCreate method reported an error: %v", err)
+	}
+
+	if second.GetCreated() {
+		t.Errorf("the existing link was expected to be returned by the second call")
+	}
+
+	if second.GetLink() != first.GetLink() {
+		t.Errorf("the link \"%s\" was expected, but \"%s\" was received", first.GetLink(), second.GetLink())
+	}
+
+	_, err = service.GetOrCreate(context.Background(), &api.URL{Url: "this is not a URL"})
+	if err = FromStatus(err); err != ErrInvalidURL {
+		t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrInvalidURL, err)
+	}
+}
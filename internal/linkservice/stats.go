@@ -0,0 +1,55 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Stats возвращает статистику использования указанной в запросе короткой
+// ссылки: оригинальный URL, количество переходов по ссылке методом Get и время
+// ее создания. Ошибки передаются клиенту с теми же кодами состояния gRPC, что и
+// в методе Get.
+func (s *GRPCServer) Stats(ctx context.Context, req *api.Link) (*api.LinkStats, error) {
+	stats, err := s.stats(ctx, req)
+	return stats, statusError(err)
+}
+
+// stats реализует метод Stats, возвращая ошибки сервиса без преобразования в
+// ошибки gRPC.
+func (s *GRPCServer) stats(ctx context.Context, req *api.Link) (*api.LinkStats, error) {
+	// проверка переданной в запросе строки на соответствие требованиям
+	// короткой ссылки или пользовательского псевдонима
+	if !s.validLink(req.GetLink()) {
+		return nil, ErrInvalidLink
+	}
+
+	if err := checkNamespace(req.GetNamespace()); err != nil {
+		return nil, err
+	}
+
+	var url string
+	var visits int64
+	var createdAt time.Time
+	err := s.retry(ctx, "select_stats", func(ctx context.Context) error {
+		return s.readDB().QueryRowContext(ctx, "SELECT original_url, visits, created_at FROM links WHERE link = $1 AND namespace = $2;",
+			req.GetLink(), req.GetNamespace()).Scan(&url, &visits, &createdAt)
+	})
+
+	if err == sql.ErrNoRows {
+		return nil, ErrURLNotFound
+	}
+
+	if err != nil {
+		return nil, s.requestError(ctx, "Stats", err, "link", req.GetLink())
+	}
+
+	return &api.LinkStats{
+		Url:       url,
+		Visits:    visits,
+		CreatedAt: timestamppb.New(createdAt),
+	}, nil
+}
@@ -0,0 +1,63 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	_ "github.com/lib/pq"
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+)
+
+func TestStats(t *testing.T) {
+	// устанавливаем подключение к базе данных
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed connecting to the database: %v", err)
+	}
+
+	defer db.Close()
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	defer service.Close()
+	url := "http://stats.abc/" + generateRandomСharacters(6)
+
+	link, err := service.Create(context.Background(), &api.URL{Url: url})
+	if err != nil {
+		t.Fatalf("Create method reported an error: %v", err)
+	}
+
+	// каждый успешный вызов Get должен учитываться в статистике
+	visits := 3
+	for i := 0; i < visits; i++ {
+		if _, err := service.Get(context.Background(), link); err != nil {
+			t.Fatalf("Get method reported an error: %v", err)
+		}
+	}
+
+	stats, err := service.Stats(context.Background(), link)
+	if err != nil {
+		t.Fatalf("Stats method reported an error: %v", err)
+	}
+
+	if stats.GetUrl() != url {
+		t.Errorf("URL contained in the response does not match the expected one")
+	}
+
+	if stats.GetVisits() != int64(visits) {
+		t.Errorf("%d visits were expected, but %d were received", visits, stats.GetVisits())
+	}
+
+	if stats.GetCreatedAt().AsTime().IsZero() {
+		t.Errorf("it was expected that the creation time would be set")
+	}
+
+	_, err = service.Stats(context.Background(), &api.Link{Link: "123_abcABC"})
+	if err = FromStatus(err); err != ErrURLNotFound {
+		t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrURLNotFound, err)
+	}
+}
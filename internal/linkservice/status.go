@@ -0,0 +1,109 @@
+package linkservice
+
+import (
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// statusCodes сопоставляет ошибкам сервиса коды состояния gRPC, с которыми
+// они передаются клиентам
+var statusCodes = map[error]codes.Code{
+	ErrReqProc:            codes.Internal,
+	ErrInvalidURL:         codes.InvalidArgument,
+	ErrURLTooLong:         codes.InvalidArgument,
+	ErrInvalidLink:        codes.InvalidArgument,
+	ErrInvalidAlias:       codes.InvalidArgument,
+	ErrInvalidAlphabet:    codes.InvalidArgument,
+	ErrInvalidTTL:         codes.InvalidArgument,
+	ErrInvalidMaxUses:     codes.InvalidArgument,
+	ErrInvalidMetadata:    codes.InvalidArgument,
+	ErrInvalidTags:        codes.InvalidArgument,
+	ErrInvalidFormat:      codes.InvalidArgument,
+	ErrInvalidNamespace:   codes.InvalidArgument,
+	ErrInvalidOwner:       codes.InvalidArgument,
+	ErrInvalidPageToken:   codes.InvalidArgument,
+	ErrInvalidVisits:      codes.InvalidArgument,
+	ErrInvalidTimeRange:   codes.InvalidArgument,
+	ErrInvalidTime:        codes.InvalidArgument,
+	ErrInvalidCollection:  codes.InvalidArgument,
+	ErrURLNotFound:        codes.NotFound,
+	ErrCollectionNotFound: codes.NotFound,
+	ErrLinkExhausted:      codes.NotFound,
+	ErrLinkExpired:        codes.FailedPrecondition,
+	ErrAliasTaken:         codes.AlreadyExists,
+	ErrURLTaken:           codes.AlreadyExists,
+	ErrAliasReserved:      codes.AlreadyExists,
+	ErrBatchTooLarge:      codes.ResourceExhausted,
+	ErrDomainNotAllowed:   codes.PermissionDenied,
+	ErrMethodDisabled:     codes.PermissionDenied,
+	ErrDeadlineExceeded:   codes.DeadlineExceeded,
+	ErrMaintenance:        codes.Unavailable,
+}
+
+// fieldViolations сопоставляет ошибкам проверки запросов поле запроса, не
+// прошедшее проверку, и описание требований к нему
+var fieldViolations = map[error]*errdetails.BadRequest_FieldViolation{
+	ErrInvalidURL:       {Field: "url", Description: "the URL must be an absolute URL with an allowed scheme and a valid host"},
+	ErrURLTooLong:       {Field: "url", Description: "the URL exceeds the maximum length"},
+	ErrInvalidLink:      {Field: "link", Description: "the link must be a generated short link or a custom alias"},
+	ErrInvalidAlias:     {Field: "alias", Description: "the alias must contain 3 to 32 Latin letters, digits, underscores or hyphens"},
+	ErrInvalidAlphabet:  {Field: "alphabet", Description: "the alphabet is not configured on the server"},
+	ErrInvalidMaxUses:   {Field: "max_uses", Description: "the limit of uses must not be negative"},
+	ErrInvalidTags:      {Field: "tags", Description: "the link must have at most 32 non-empty tags of at most 64 characters"},
+	ErrInvalidFormat:    {Field: "format", Description: "the full URL format requires the server to be configured with a base URL"},
+	ErrInvalidNamespace: {Field: "namespace", Description: "the namespace must contain 1 to 64 lowercase Latin letters, digits, underscores or hyphens"},
+	ErrInvalidOwner:     {Field: "owner_id", Description: "the owner id must not be empty"},
+	ErrInvalidPageToken: {Field: "page_token", Description: "the page token must be taken from a previous response"},
+	ErrInvalidTime:      {Field: "time", Description: "the time must be set to a valid timestamp"},
+	ErrInvalidVisits:    {Field: "visits", Description: "the number of visits must not be negative"},
+}
+
+// statusError преобразует ошибку сервиса err в ошибку gRPC с соответствующим
+// кодом состояния. Текст ошибки сервиса сохраняется в сообщении статуса, что
+// позволяет восстановить исходную ошибку функцией FromStatus. Ошибки, для
+// которых код состояния не определен, передаются с кодом codes.Internal.
+// Ошибки проверки отдельных полей запроса дополнительно содержат сведения
+// google.rpc.BadRequest с именем поля и описанием нарушения.
+func statusError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	code, ok := statusCodes[err]
+	if !ok {
+		code = codes.Internal
+	}
+
+	st := status.New(code, err.Error())
+
+	if violation, ok := fieldViolations[err]; ok {
+		details := &errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{violation}}
+
+		// сведения лишь дополняют ошибку, поэтому при ошибке их добавления
+		// ошибка передается без них
+		if withDetails, err := st.WithDetails(details); err == nil {
+			st = withDetails
+		}
+	}
+
+	return st.Err()
+}
+
+// FromStatus возвращает ошибку сервиса, переданную в виде ошибки gRPC err.
+// Если err не является ошибкой gRPC или не соответствует ни одной из ошибок
+// сервиса, то err возвращается без изменений.
+func FromStatus(err error) error {
+	st, ok := status.FromError(err)
+	if !ok || err == nil {
+		return err
+	}
+
+	for sentinel, code := range statusCodes {
+		if st.Code() == code && st.Message() == sentinel.Error() {
+			return sentinel
+		}
+	}
+
+	return err
+}
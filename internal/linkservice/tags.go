@@ -0,0 +1,123 @@
+package linkservice
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/lib/pq"
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	// максимальная длина тега короткой ссылки
+	maxTagLength = 64
+
+	// максимальное количество тегов одной короткой ссылки
+	maxTags = 32
+)
+
+// normalizeTags приводит теги в запросе req к виду, в котором они хранятся в
+// базе данных: без пробельных символов по краям, в нижнем регистре и без
+// повторов. Если тег пуст, длиннее maxTagLength или тегов больше maxTags, то
+// возвращается ошибка ErrInvalidTags.
+func normalizeTags(req *api.URL) (*api.URL, error) {
+	if len(req.GetTags()) == 0 {
+		return req, nil
+	}
+
+	if len(req.GetTags()) > maxTags {
+		return nil, ErrInvalidTags
+	}
+
+	tags := make([]string, 0, len(req.GetTags()))
+	seen := make(map[string]bool, len(req.GetTags()))
+
+	for _, tag := range req.GetTags() {
+		tag = normalizeTag(tag)
+		if tag == "" || utf8.RuneCountInString(tag) > maxTagLength {
+			return nil, ErrInvalidTags
+		}
+
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+
+	req = proto.Clone(req).(*api.URL)
+	req.Tags = tags
+
+	return req, nil
+}
+
+// normalizeTag приводит тег tag к виду, в котором он хранится в базе данных.
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// tagsArray возвращает теги короткой ссылки, создаваемой запросом req, в том
+// виде, в котором они хранятся в столбце tags. Ссылке без тегов соответствует
+// пустой массив, а не NULL.
+func tagsArray(req *api.URL) pq.StringArray {
+	return append(pq.StringArray{}, req.GetTags()...)
+}
+
+// ListByTag возвращает короткие ссылки указанного в запросе пространства имен
+// с указанным тегом вместе с их оригинальными URL в порядке создания. Тег
+// сравнивается после удаления пробельных символов по краям и приведения к
+// нижнему регистру. Для неизвестного тега возвращается пустой список. Ошибки
+// передаются клиенту с кодами состояния gRPC: ErrInvalidNamespace —
+// codes.InvalidArgument, ErrDeadlineExceeded — codes.DeadlineExceeded,
+// ErrReqProc — codes.Internal.
+func (s *GRPCServer) ListByTag(ctx context.Context, req *api.TagRequest) (*api.MappingList, error) {
+	list, err := s.listByTag(ctx, req)
+	return list, statusError(err)
+}
+
+// listByTag реализует метод ListByTag, возвращая ошибки сервиса без
+// преобразования в ошибки gRPC.
+func (s *GRPCServer) listByTag(ctx context.Context, req *api.TagRequest) (*api.MappingList, error) {
+	if err := checkNamespace(req.GetNamespace()); err != nil {
+		return nil, err
+	}
+
+	res := &api.MappingList{}
+
+	// пустой тег не может быть сохранен, поэтому ему не соответствует ни одна
+	// ссылка
+	tag := normalizeTag(req.GetTag())
+	if tag == "" {
+		return res, nil
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	// условие tags @> ARRAY[$1] позволяет использовать индекс GIN по столбцу
+	// tags
+	rows, err := s.readDB().QueryContext(ctx, "SELECT link, original_url FROM links WHERE tags @> ARRAY[$1]::text[] AND namespace = $3 AND (expires_at IS NULL OR expires_at > $2) ORDER BY created_at, link;",
+		tag, time.Now(), req.GetNamespace())
+	if err != nil {
+		return nil, s.requestError(ctx, "ListByTag", err, "tag", tag)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var link, url string
+		if err := rows.Scan(&link, &url); err != nil {
+			return nil, s.requestError(ctx, "ListByTag", err, "tag", tag)
+		}
+
+		res.Mappings = append(res.Mappings, &api.Mapping{Link: link, Url: url})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, s.requestError(ctx, "ListByTag", err, "tag", tag)
+	}
+
+	return res, nil
+}
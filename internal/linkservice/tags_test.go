@@ -0,0 +1,116 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	testCases := []struct {
+		name string
+		tags []string
+		exp  []string
+		err  error
+	}{
+		{name: "no_tags", tags: nil, exp: nil},
+		{name: "normalized", tags: []string{"newsletter", "social"}, exp: []string{"newsletter", "social"}},
+		{name: "trimmed_and_lowercased", tags: []string{"  NewsLetter ", "SOCIAL"}, exp: []string{"newsletter", "social"}},
+		{name: "duplicates", tags: []string{"social", "Social ", "newsletter"}, exp: []string{"social", "newsletter"}},
+		{name: "cyrillic", tags: []string{"Рассылка"}, exp: []string{"рассылка"}},
+		{name: "empty", tags: []string{"social", "  "}, err: ErrInvalidTags},
+		{name: "too_long", tags: []string{strings.Repeat("a", maxTagLength+1)}, err: ErrInvalidTags},
+		{name: "too_many", tags: make([]string, maxTags+1), err: ErrInvalidTags},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			req := &api.URL{Url: "http://tags.abc/", Tags: testCase.tags}
+
+			res, err := normalizeTags(req)
+			if err != testCase.err {
+				t.Fatalf("an error with a value of \"%v\" was expected, but \"%v\" was received", testCase.err, err)
+			}
+
+			if err == nil && !reflect.DeepEqual(res.GetTags(), testCase.exp) {
+				t.Errorf("the tags %q were expected, but %q were received", testCase.exp, res.GetTags())
+			}
+		})
+	}
+}
+
+func TestCreateInvalidTags(t *testing.T) {
+	// теги проверяются до обращения к базе данных
+	service := &GRPCServer{}
+
+	_, err := service.Create(context.Background(), &api.URL{Url: "http://tags.abc/", Tags: []string{""}})
+	if err = FromStatus(err); err != ErrInvalidTags {
+		t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrInvalidTags, err)
+	}
+}
+
+func TestListByTag(t *testing.T) {
+	// устанавливаем подключение к базе данных
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed connecting to the database: %v", err)
+	}
+
+	defer db.Close()
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	defer service.Close()
+
+	tag := "tag-" + generateRandomСharacters(8)
+
+	var exp []*api.Mapping
+	for i := 0; i < 3; i++ {
+		url := "http://tags.abc/" + generateRandomСharacters(8)
+
+		link, err := service.Create(context.Background(), &api.URL{Url: url, Tags: []string{" " + strings.ToUpper(tag) + " ", "social"}})
+		if err != nil {
+			t.Fatalf("Create method reported an error: %v", err)
+		}
+
+		exp = append(exp, &api.Mapping{Link: link.GetLink(), Url: url})
+	}
+
+	// ссылка без тега не должна попасть в список
+	if _, err := service.Create(context.Background(), &api.URL{Url: "http://tags.abc/" + generateRandomСharacters(8)}); err != nil {
+		t.Fatalf("Create method reported an error: %v", err)
+	}
+
+	list, err := service.ListByTag(context.Background(), &api.TagRequest{Tag: strings.ToUpper(tag)})
+	if err != nil {
+		t.Fatalf("ListByTag method reported an error: %v", err)
+	}
+
+	if len(list.GetMappings()) != len(exp) {
+		t.Fatalf("%d links were expected, but %d were received", len(exp), len(list.GetMappings()))
+	}
+
+	for i, mapping := range list.GetMappings() {
+		if mapping.GetLink() != exp[i].GetLink() || mapping.GetUrl() != exp[i].GetUrl() {
+			t.Errorf("the link \"%s\" of \"%s\" was expected, but \"%s\" of \"%s\" was received",
+				exp[i].GetLink(), exp[i].GetUrl(), mapping.GetLink(), mapping.GetUrl())
+		}
+	}
+
+	// для неизвестного тега возвращается пустой список, а не ошибка
+	list, err = service.ListByTag(context.Background(), &api.TagRequest{Tag: "unknown-" + generateRandomСharacters(8)})
+	if err != nil {
+		t.Fatalf("ListByTag method reported an error: %v", err)
+	}
+
+	if len(list.GetMappings()) != 0 {
+		t.Errorf("an empty list was expected for an unknown tag, but %d links were received", len(list.GetMappings()))
+	}
+}
@@ -0,0 +1,34 @@
+package linkservice
+
+import (
+	"context"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// код SQLSTATE ошибки, с которой PostgreSQL прерывает отмененный запрос
+const queryCanceled pq.ErrorCode = "57014"
+
+// withTimeout возвращает контекст запроса к базе данных, срок которого
+// ограничен StatementTimeout, а также функцию его отмены, которую необходимо
+// вызвать по завершении запроса. Если StatementTimeout не задан, то
+// возвращается исходный контекст ctx.
+func (s *GRPCServer) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.StatementTimeout <= 0 {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, s.StatementTimeout)
+}
+
+// timedOut сообщает, прерван ли запрос к базе данных, завершившийся ошибкой
+// err, из-за истечения срока или отмены его контекста.
+func timedOut(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return true
+	}
+
+	_, ok := violation(err, queryCanceled)
+	return ok
+}
@@ -0,0 +1,108 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func TestTimedOut(t *testing.T) {
+	testCases := []struct {
+		name string
+		err  error
+		exp  bool
+	}{
+		{name: "deadline_exceeded", err: context.DeadlineExceeded, exp: true},
+		{name: "wrapped_deadline_exceeded", err: fmt.Errorf("query: %w", context.DeadlineExceeded), exp: true},
+		{name: "canceled", err: context.Canceled, exp: true},
+		{name: "query_canceled", err: &pq.Error{Code: queryCanceled}, exp: true},
+		{name: "unique_violation", err: &pq.Error{Code: uniqueViolation}, exp: false},
+		{name: "no_rows", err: sql.ErrNoRows, exp: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if result := timedOut(testCase.err); result != testCase.exp {
+				t.Errorf("the result %v was expected, but %v was received", testCase.exp, result)
+			}
+		})
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		service := &GRPCServer{}
+
+		ctx, cancel := service.withTimeout(context.Background())
+		defer cancel()
+
+		if _, ok := ctx.Deadline(); ok {
+			t.Errorf("no deadline was expected without StatementTimeout")
+		}
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		service := &GRPCServer{StatementTimeout: time.Minute}
+
+		ctx, cancel := service.withTimeout(context.Background())
+		defer cancel()
+
+		deadline, ok := ctx.Deadline()
+		if !ok || time.Until(deadline) > time.Minute {
+			t.Errorf("a deadline within a minute was expected, but %v was received", deadline)
+		}
+	})
+
+	t.Run("request_error", func(t *testing.T) {
+		service := &GRPCServer{Logger: &recordLogger{}}
+
+		err := service.requestError(context.Background(), "test", &pq.Error{Code: queryCanceled})
+		if err != ErrDeadlineExceeded {
+			t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrDeadlineExceeded, err)
+		}
+	})
+}
+
+func TestStatementTimeout(t *testing.T) {
+	// устанавливаем подключение к базе данных
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed connecting to the database: %v", err)
+	}
+
+	defer db.Close()
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	defer service.Close()
+
+	service.StatementTimeout = 50 * time.Millisecond
+
+	// искусственно медленный запрос должен быть прерван по истечении
+	// StatementTimeout, а не выполняться до конца
+	ctx := context.Background()
+	start := time.Now()
+	err = service.retry(ctx, "test", func(ctx context.Context) error {
+		_, err := service.Database.ExecContext(ctx, "SELECT pg_sleep(5);")
+		return err
+	})
+
+	if err == nil {
+		t.Fatalf("the slow query was expected to be interrupted")
+	}
+
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("the query was expected to be interrupted after 50ms, but it took %v", elapsed)
+	}
+
+	if err := service.requestError(ctx, "test", err); err != ErrDeadlineExceeded {
+		t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrDeadlineExceeded, err)
+	}
+}
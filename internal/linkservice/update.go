@@ -0,0 +1,198 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+)
+
+// UpdateURL сопоставляет существующей короткой ссылке из указанного в запросе
+// пространства имен новый оригинальный URL. Ссылка ищется так же, как в методе
+// Get, поэтому псевдонимы сравниваются без учета регистра. Время жизни и
+// статистика ссылки сохраняются, а ее версия увеличивается. Ошибки передаются
+// клиенту с кодами состояния gRPC: ErrInvalidLink, ErrInvalidNamespace,
+// ErrInvalidURL и ErrURLTooLong — codes.InvalidArgument, ErrURLNotFound —
+// codes.NotFound, ErrURLTaken — codes.AlreadyExists, ErrDomainNotAllowed —
+// codes.PermissionDenied, ErrDeadlineExceeded — codes.DeadlineExceeded,
+// ErrReqProc — codes.Internal.
+func (s *GRPCServer) UpdateURL(ctx context.Context, req *api.UpdateRequest) (*api.Empty, error) {
+	if err := s.updateURL(ctx, req); err != nil {
+		return nil, statusError(err)
+	}
+
+	return &api.Empty{}, nil
+}
+
+// updateURL реализует метод UpdateURL, возвращая ошибки сервиса без
+// преобразования в ошибки gRPC.
+func (s *GRPCServer) updateURL(ctx context.Context, req *api.UpdateRequest) error {
+	// проверка переданной в запросе строки на соответствие требованиям
+	// короткой ссылки или пользовательского псевдонима
+	if !s.validLink(req.GetLink()) {
+		return ErrInvalidLink
+	}
+
+	namespace := req.GetNamespace()
+	if err := checkNamespace(namespace); err != nil {
+		return err
+	}
+
+	// новый URL хранится в том же виде, что и URL, добавленные методом Create
+	u, err := asciiURL(s.withScheme(&api.URL{Url: req.GetUrl()}))
+	if err != nil {
+		return err
+	}
+
+	if err := s.checkURL(u.GetUrl()); err != nil {
+		return err
+	}
+
+	u, err = s.normalize(u)
+	if err != nil {
+		return err
+	}
+
+	if err := s.checkReputation(ctx, "UpdateURL", u.GetUrl()); err != nil {
+		return err
+	}
+
+	// запрошенная ссылка может отличаться от хранящейся регистром символов
+	entry, err := s.resolve(ctx, namespace, req.GetLink())
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	// запись с истекшим сроком действия не должна мешать сопоставить URL
+	// другой ссылке того же пространства имен
+	if err := deleteExpiredURL(ctx, s.Database, namespace, u.GetUrl()); err != nil {
+		return s.requestError(ctx, "UpdateURL", err, "link", req.GetLink(), "url", req.GetUrl())
+	}
+
+	res, err := s.Database.ExecContext(ctx, "UPDATE links SET original_url = $1, version = version + 1 WHERE link = $2 AND namespace = $3;", u.GetUrl(), entry.link, namespace)
+
+	// каждому URL соответствует лишь одна короткая ссылка
+	if _, ok := violation(err, uniqueViolation); ok {
+		return ErrURLTaken
+	}
+
+	if err != nil {
+		return s.requestError(ctx, "UpdateURL", err, "link", req.GetLink(), "url", req.GetUrl())
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return s.requestError(ctx, "UpdateURL", err, "link", req.GetLink(), "url", req.GetUrl())
+	}
+
+	if n == 0 {
+		return ErrURLNotFound
+	}
+
+	// кэш метода Get не должен возвращать прежний URL
+	s.linkCache().remove(entry.key())
+
+	return nil
+}
+
+// UpdateExpiry изменяет срок действия существующей короткой ссылки из
+// указанного в запросе пространства имен, которая ищется так же, как в методе
+// Get. Новый срок задается моментом expires_at или временем жизни ttl_seconds,
+// отсчитываемым от момента запроса; если не задано ни то, ни другое, то ссылка
+// становится бессрочной. Скользящее время жизни ссылки при этом отменяется.
+// Ссылки с истекшим сроком действия считаются несуществующими. Ошибки
+// передаются клиенту с кодами состояния gRPC: ErrInvalidLink,
+// ErrInvalidNamespace и ErrInvalidTTL — codes.InvalidArgument,
+// ErrURLNotFound — codes.NotFound, ErrDeadlineExceeded —
+// codes.DeadlineExceeded, ErrReqProc — codes.Internal.
+func (s *GRPCServer) UpdateExpiry(ctx context.Context, req *api.ExpiryRequest) (*api.Empty, error) {
+	if err := s.updateExpiry(ctx, req); err != nil {
+		return nil, statusError(err)
+	}
+
+	return &api.Empty{}, nil
+}
+
+// updateExpiry реализует метод UpdateExpiry, возвращая ошибки сервиса без
+// преобразования в ошибки gRPC.
+func (s *GRPCServer) updateExpiry(ctx context.Context, req *api.ExpiryRequest) error {
+	// проверка переданной в запросе строки на соответствие требованиям
+	// короткой ссылки или пользовательского псевдонима
+	if !s.validLink(req.GetLink()) {
+		return ErrInvalidLink
+	}
+
+	if err := checkNamespace(req.GetNamespace()); err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	expires, err := newExpiry(req, now)
+	if err != nil {
+		return err
+	}
+
+	// запрошенная ссылка может отличаться от хранящейся регистром символов
+	entry, err := s.resolve(ctx, req.GetNamespace(), req.GetLink())
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	res, err := s.Database.ExecContext(ctx, "UPDATE links SET expires_at = $1, sliding_ttl_seconds = NULL "+
+		"WHERE link = $2 AND namespace = $4 AND (expires_at IS NULL OR expires_at > $3);", expires, entry.link, now, entry.namespace)
+	if err != nil {
+		return s.requestError(ctx, "UpdateExpiry", err, "link", req.GetLink())
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return s.requestError(ctx, "UpdateExpiry", err, "link", req.GetLink())
+	}
+
+	if n == 0 {
+		return ErrURLNotFound
+	}
+
+	// кэш метода Get не должен использовать прежний срок действия
+	s.linkCache().remove(entry.key())
+
+	return nil
+}
+
+// newExpiry возвращает срок действия короткой ссылки, заданный запросом req
+// относительно момента now, в том виде, в котором он хранится в столбце
+// expires_at. Срок задается либо моментом expires_at в будущем, либо
+// неотрицательным временем жизни ttl_seconds; в остальных случаях
+// возвращается ошибка ErrInvalidTTL.
+func newExpiry(req *api.ExpiryRequest, now time.Time) (sql.NullTime, error) {
+	if req.GetTtlSeconds() < 0 || (req.ExpiresAt != nil && req.GetTtlSeconds() > 0) {
+		return sql.NullTime{}, ErrInvalidTTL
+	}
+
+	if req.ExpiresAt != nil {
+		if err := req.GetExpiresAt().CheckValid(); err != nil {
+			return sql.NullTime{}, ErrInvalidTTL
+		}
+
+		expires := req.GetExpiresAt().AsTime()
+		if !expires.After(now) {
+			return sql.NullTime{}, ErrInvalidTTL
+		}
+
+		return sql.NullTime{Time: expires, Valid: true}, nil
+	}
+
+	if req.GetTtlSeconds() > 0 {
+		return sql.NullTime{Time: now.Add(time.Duration(req.GetTtlSeconds()) * time.Second), Valid: true}, nil
+	}
+
+	return sql.NullTime{}, nil
+}
@@ -0,0 +1,322 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestUpdateURL(t *testing.T) {
+	// устанавливаем подключение к базе данных
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed connecting to the database: %v", err)
+	}
+
+	defer db.Close()
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	defer service.Close()
+
+	service.CacheSize = 10
+	suffix := generateRandomСharacters(6)
+
+	link, err := service.Create(context.Background(), &api.URL{Url: "http://update.abc/old/" + suffix})
+	if err != nil {
+		t.Fatalf("Create method reported an error: %v", err)
+	}
+
+	// заполняем кэш прежним URL
+	before, err := service.Get(context.Background(), link)
+	if err != nil {
+		t.Fatalf("Get method reported an error: %v", err)
+	}
+
+	url := "http://update.abc/new/" + suffix
+
+	if _, err := service.UpdateURL(context.Background(), &api.UpdateRequest{Link: link.GetLink(), Url: url}); err != nil {
+		t.Fatalf("UpdateURL method reported an error: %v", err)
+	}
+
+	res, err := service.Get(context.Background(), link)
+	if err != nil {
+		t.Fatalf("Get method reported an error: %v", err)
+	}
+
+	if res.GetUrl() != url {
+		t.Errorf("URL \"%s\" was expected, but \"%s\" was received", url, res.GetUrl())
+	}
+
+	if res.GetVersion() != before.GetVersion()+1 {
+		t.Errorf("version %d was expected after the update, but %d was received", before.GetVersion()+1, res.GetVersion())
+	}
+
+	other, err := service.Create(context.Background(), &api.URL{Url: "http://update.abc/other/" + suffix})
+	if err != nil {
+		t.Fatalf("Create method reported an error: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		req      *api.UpdateRequest
+		expError error
+	}{
+		{
+			name:     "invalid_link",
+			req:      &api.UpdateRequest{Link: "!", Url: url},
+			expError: ErrInvalidLink,
+		},
+		{
+			name:     "invalid_url",
+			req:      &api.UpdateRequest{Link: link.GetLink(), Url: "this is not a URL"},
+			expError: ErrInvalidURL,
+		},
+		{
+			name:     "unknown_link",
+			req:      &api.UpdateRequest{Link: "unknown-" + suffix, Url: "http://update.abc/unknown/" + suffix},
+			expError: ErrURLNotFound,
+		},
+		{
+			name:     "url_taken",
+			req:      &api.UpdateRequest{Link: other.GetLink(), Url: url},
+			expError: ErrURLTaken,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := service.UpdateURL(context.Background(), testCase.req)
+			if err = FromStatus(err); err != testCase.expError {
+				t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", testCase.expError, err)
+			}
+		})
+	}
+}
+
+func TestUpdateNamespacedLinkWithMockDB(t *testing.T) {
+	// запросы изменения записей должны учитывать пространство имен ссылки
+	var namespaces []interface{}
+	db := newMockDB(func(query string, args []driver.NamedValue) (mockResult, error) {
+		switch {
+		case query == selectURLQuery:
+			namespaces = append(namespaces, args[1].Value)
+			return urlRow("http://update.abc/old", nil), nil
+		case strings.HasPrefix(query, "DELETE FROM links WHERE original_url"):
+			namespaces = append(namespaces, args[1].Value)
+			return mockResult{}, nil
+		case strings.HasPrefix(query, "UPDATE links SET original_url"):
+			namespaces = append(namespaces, args[2].Value)
+			return mockResult{affected: 1}, nil
+		case strings.HasPrefix(query, "UPDATE links SET expires_at"):
+			namespaces = append(namespaces, args[3].Value)
+			return mockResult{affected: 1}, nil
+		default:
+			t.Fatalf("an unexpected query was received: %s", query)
+			return mockResult{}, nil
+		}
+	})
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	_, err = service.UpdateURL(context.Background(), &api.UpdateRequest{Link: "promo", Url: "http://update.abc/brand", Namespace: "brand-a"})
+	if err != nil {
+		t.Fatalf("UpdateURL method reported an error: %v", err)
+	}
+
+	_, err = service.UpdateExpiry(context.Background(), &api.ExpiryRequest{Link: "promo", TtlSeconds: 60, Namespace: "brand-a"})
+	if err != nil {
+		t.Fatalf("UpdateExpiry method reported an error: %v", err)
+	}
+
+	if len(namespaces) != 5 {
+		t.Fatalf("5 queries were expected, but %d were executed: %q", len(namespaces), db.executed())
+	}
+
+	for _, namespace := range namespaces {
+		if namespace != "brand-a" {
+			t.Errorf("the namespace \"brand-a\" was expected, but %v was received", namespace)
+		}
+	}
+
+	_, err = service.UpdateURL(context.Background(), &api.UpdateRequest{Link: "promo", Url: "http://update.abc/brand", Namespace: "Brand A"})
+	if err = FromStatus(err); err != ErrInvalidNamespace {
+		t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrInvalidNamespace, err)
+	}
+}
+
+func TestUpdateURLAliasWithMockDB(t *testing.T) {
+	// псевдоним хранится в нижнем регистре
+	url := "http://update.abc/old"
+	var updated []interface{}
+
+	db := newMockDB(func(query string, args []driver.NamedValue) (mockResult, error) {
+		switch {
+		case query == selectURLQuery:
+			if args[0].Value != "promo" {
+				return mockResult{columns: []string{"original_url"}}, nil
+			}
+
+			return urlRow(url, nil), nil
+		case strings.HasPrefix(query, "UPDATE links SET visits"):
+			return mockResult{columns: []string{"expires_at"}, rows: [][]driver.Value{{nil}}}, nil
+		case strings.HasPrefix(query, "INSERT INTO link_hits"), strings.HasPrefix(query, "DELETE FROM links WHERE original_url"):
+			return mockResult{}, nil
+		case strings.HasPrefix(query, "UPDATE links SET original_url"):
+			url = args[0].Value.(string)
+			updated = append(updated, args[1].Value)
+			return mockResult{affected: 1}, nil
+		default:
+			t.Fatalf("an unexpected query was received: %s", query)
+			return mockResult{}, nil
+		}
+	})
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	service.CacheSize = 10
+
+	// заполняем кэш прежним URL
+	if _, err := service.Get(context.Background(), &api.Link{Link: "Promo"}); err != nil {
+		t.Fatalf("Get method reported an error: %v", err)
+	}
+
+	_, err = service.UpdateURL(context.Background(), &api.UpdateRequest{Link: "PROMO", Url: "http://update.abc/new"})
+	if err != nil {
+		t.Fatalf("UpdateURL method reported an error: %v", err)
+	}
+
+	if len(updated) != 1 || updated[0] != "promo" {
+		t.Errorf("the link \"promo\" was expected to be updated, but %v was updated", updated)
+	}
+
+	// прежний URL не должен остаться в кэше
+	res, err := service.Get(context.Background(), &api.Link{Link: "promo"})
+	if err != nil {
+		t.Fatalf("Get method reported an error: %v", err)
+	}
+
+	if res.GetUrl() != "http://update.abc/new" {
+		t.Errorf("URL \"http://update.abc/new\" was expected, but \"%s\" was received", res.GetUrl())
+	}
+}
+
+func TestNewExpiry(t *testing.T) {
+	now := time.Now()
+
+	testCases := []struct {
+		name    string
+		req     *api.ExpiryRequest
+		expires sql.NullTime
+		err     error
+	}{
+		{name: "permanent", req: &api.ExpiryRequest{}, expires: sql.NullTime{}},
+		{name: "ttl", req: &api.ExpiryRequest{TtlSeconds: 60}, expires: sql.NullTime{Time: now.Add(time.Minute), Valid: true}},
+		{name: "expires_at", req: &api.ExpiryRequest{ExpiresAt: timestamppb.New(now.Add(time.Hour))}, expires: sql.NullTime{Time: now.Add(time.Hour), Valid: true}},
+		{name: "negative_ttl", req: &api.ExpiryRequest{TtlSeconds: -1}, err: ErrInvalidTTL},
+		{name: "past_expires_at", req: &api.ExpiryRequest{ExpiresAt: timestamppb.New(now.Add(-time.Hour))}, err: ErrInvalidTTL},
+		{name: "both", req: &api.ExpiryRequest{TtlSeconds: 60, ExpiresAt: timestamppb.New(now.Add(time.Hour))}, err: ErrInvalidTTL},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			expires, err := newExpiry(testCase.req, now)
+			if err != testCase.err {
+				t.Fatalf("an error with a value of \"%v\" was expected, but \"%v\" was received", testCase.err, err)
+			}
+
+			if expires.Valid != testCase.expires.Valid || !expires.Time.Equal(testCase.expires.Time) {
+				t.Errorf("the expiry %v was expected, but %v was received", testCase.expires, expires)
+			}
+		})
+	}
+}
+
+func TestUpdateExpiry(t *testing.T) {
+	// устанавливаем подключение к базе данных
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed connecting to the database: %v", err)
+	}
+
+	defer db.Close()
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	defer service.Close()
+
+	service.CacheSize = 10
+
+	link, err := service.Create(context.Background(), &api.URL{Url: "http://expiry.abc/" + generateRandomСharacters(8)})
+	if err != nil {
+		t.Fatalf("Create method reported an error: %v", err)
+	}
+
+	// заполняем кэш бессрочной ссылкой
+	if _, err := service.Get(context.Background(), link); err != nil {
+		t.Fatalf("Get method reported an error: %v", err)
+	}
+
+	// срок действия, наступающий почти сразу, должен учитываться методом Get
+	// несмотря на кэш
+	_, err = service.UpdateExpiry(context.Background(), &api.ExpiryRequest{Link: link.GetLink(), ExpiresAt: timestamppb.New(time.Now().Add(time.Second))})
+	if err != nil {
+		t.Fatalf("UpdateExpiry method reported an error: %v", err)
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+
+	if _, err := service.Get(context.Background(), link); FromStatus(err) != ErrURLNotFound {
+		t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrURLNotFound, FromStatus(err))
+	}
+
+	// истекшая ссылка считается несуществующей, и ее срок нельзя продлить
+	_, err = service.UpdateExpiry(context.Background(), &api.ExpiryRequest{Link: link.GetLink()})
+	if err = FromStatus(err); err != ErrURLNotFound {
+		t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrURLNotFound, err)
+	}
+
+	// нулевое время жизни делает ссылку с ограниченным сроком бессрочной
+	link, err = service.Create(context.Background(), &api.URL{Url: "http://expiry.abc/" + generateRandomСharacters(8), TtlSeconds: 60})
+	if err != nil {
+		t.Fatalf("Create method reported an error: %v", err)
+	}
+
+	if _, err := service.UpdateExpiry(context.Background(), &api.ExpiryRequest{Link: link.GetLink()}); err != nil {
+		t.Fatalf("UpdateExpiry method reported an error: %v", err)
+	}
+
+	var expires sql.NullTime
+	if err := db.QueryRow("SELECT expires_at FROM links WHERE link = $1;", link.GetLink()).Scan(&expires); err != nil {
+		t.Fatalf("failed to read the expiry: %v", err)
+	}
+
+	if expires.Valid {
+		t.Errorf("the link was expected to be permanent, but it expires at %v", expires.Time)
+	}
+
+	// несуществующая ссылка
+	_, err = service.UpdateExpiry(context.Background(), &api.ExpiryRequest{Link: generateRandomСharacters(10), TtlSeconds: 60})
+	if err = FromStatus(err); err != ErrURLNotFound {
+		t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrURLNotFound, err)
+	}
+}
@@ -0,0 +1,148 @@
+package linkservice
+
+import (
+	"net"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+	"google.golang.org/protobuf/proto"
+)
+
+// максимальная длина URL по умолчанию — размер столбца original_url в базе
+// данных
+var maxURLLengthDefault = 2048
+
+// DefaultSchemes содержит схемы URL, для которых создаются короткие ссылки,
+// если для GRPCServer не задан собственный набор
+var DefaultSchemes = []string{"http", "https"}
+
+// schemes возвращает схемы URL, для которых создаются короткие ссылки.
+func (s *GRPCServer) schemes() []string {
+	if s.AllowedSchemes != nil {
+		return s.AllowedSchemes
+	}
+
+	return DefaultSchemes
+}
+
+// validURL сообщает, можно ли создать короткую ссылку для URL u. URL
+// разбирается пакетом net/url, после чего проверяются его компоненты: URL
+// должен содержать одну из разрешенных схем, поэтому URL без схемы и URL вида
+// "javascript:alert(1)", которые HTTP-интерфейс сервиса передал бы браузеру,
+// отклоняются; хост должен быть доменным именем или IP-адресом. Путь, запрос
+// и фрагмент могут содержать любые допустимые в URI символы, в том числе
+// percent-кодированные последовательности вида "%20", а пробелы и другие
+// недопустимые символы должны быть закодированы.
+func (s *GRPCServer) validURL(u string) bool {
+	parsed, err := url.Parse(u)
+	if err != nil || parsed.Host == "" || parsed.Opaque != "" {
+		return false
+	}
+
+	if !s.allowedScheme(parsed.Scheme) || !validHost(parsed.Hostname()) {
+		return false
+	}
+
+	// url.Parse допускает в пути и запросе пробелы и некорректные
+	// percent-кодированные последовательности, поэтому символы проверяются
+	// в исходной строке
+	return uriCharsTemplate.MatchString(u)
+}
+
+// allowedScheme сообщает, создаются ли короткие ссылки для URL со схемой
+// scheme. Схемы сравниваются без учета регистра.
+func (s *GRPCServer) allowedScheme(scheme string) bool {
+	for _, allowed := range s.schemes() {
+		if strings.EqualFold(scheme, allowed) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// withScheme возвращает запрос req, в котором к URL без схемы, например
+// "example.com/page" или "//example.com/page", добавлена схема DefaultScheme.
+// Если схема не задана или URL уже содержит схему, в том числе не разрешенную,
+// как "javascript:alert(1)", то запрос возвращается без изменений.
+func (s *GRPCServer) withScheme(req *api.URL) *api.URL {
+	u := req.GetUrl()
+	if s.DefaultScheme == "" || hasScheme(u) {
+		return req
+	}
+
+	if strings.HasPrefix(u, "//") {
+		u = s.DefaultScheme + ":" + u
+	} else {
+		u = s.DefaultScheme + "://" + u
+	}
+
+	req = proto.Clone(req).(*api.URL)
+	req.Url = u
+
+	return req
+}
+
+// hasScheme сообщает, содержит ли URL u схему. Схемой считается начало URL до
+// символа ":" в первом сегменте, если за ним не следует цифра: в URL вида
+// "example.com:8080/page" символ ":" отделяет порт хоста.
+func hasScheme(u string) bool {
+	end := strings.IndexAny(u, "/?#")
+	if end < 0 {
+		end = len(u)
+	}
+
+	i := strings.IndexByte(u[:end], ':')
+	return i >= 0 && (i+1 == len(u) || u[i+1] < '0' || u[i+1] > '9')
+}
+
+// validHost сообщает, является ли хост host доменным именем или IP-адресом.
+func validHost(host string) bool {
+	return hostTemplate.MatchString(host) || net.ParseIP(host) != nil
+}
+
+// maxURLLength возвращает максимальную длину URL в символах.
+func (s *GRPCServer) maxURLLength() int {
+	if s.MaxURLLength > 0 {
+		return s.MaxURLLength
+	}
+
+	return maxURLLengthDefault
+}
+
+// checkURL проверяет URL u из запроса. Слишком длинные URL отклоняются с
+// ошибкой ErrURLTooLong до разбора, некорректные — с ошибкой ErrInvalidURL, а
+// URL с запрещенными хостами — с ошибкой ErrDomainNotAllowed.
+func (s *GRPCServer) checkURL(u string) error {
+	if utf8.RuneCountInString(u) > s.maxURLLength() {
+		return ErrURLTooLong
+	}
+
+	if !s.validURL(u) {
+		return ErrInvalidURL
+	}
+
+	return s.checkDomain(u)
+}
+
+// trimURL возвращает запрос req с URL без начальных и конечных пробельных
+// символов, например, скопированных вместе с URL. Если запрос равен nil или
+// URL пуст либо состоит только из пробельных символов, то возвращается
+// ErrInvalidURL.
+func trimURL(req *api.URL) (*api.URL, error) {
+	trimmed := strings.TrimSpace(req.GetUrl())
+	if trimmed == "" {
+		return nil, ErrInvalidURL
+	}
+
+	if trimmed == req.GetUrl() {
+		return req, nil
+	}
+
+	req = proto.Clone(req).(*api.URL)
+	req.Url = trimmed
+
+	return req, nil
+}
@@ -0,0 +1,27 @@
+package linkservice
+
+import (
+	"database/sql"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+)
+
+// maxUses возвращает ограничение количества переходов по короткой ссылке,
+// создаваемой запросом req, в том виде, в котором оно хранится в столбце
+// max_uses. Если ограничение не задано, то возвращается недействительное
+// значение.
+func maxUses(req *api.URL) sql.NullInt64 {
+	if req.GetMaxUses() <= 0 {
+		return sql.NullInt64{}
+	}
+
+	return sql.NullInt64{Int64: req.GetMaxUses(), Valid: true}
+}
+
+// deduplicate сообщает, возвращается ли для URL из запроса req уже
+// существующая короткая ссылка. Ссылки с ограниченным количеством переходов
+// не дедуплицируются: каждый запрос получает собственную ссылку, иначе
+// клиент мог бы получить ссылку, переходы по которой уже исчерпаны.
+func (s *GRPCServer) deduplicate(req *api.URL) bool {
+	return !s.AllowDuplicates && req.GetMaxUses() <= 0
+}
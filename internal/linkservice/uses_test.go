@@ -0,0 +1,141 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"testing"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+)
+
+func TestDeduplicate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		service *GRPCServer
+		req     *api.URL
+		exp     bool
+	}{
+		{name: "default", service: &GRPCServer{}, req: &api.URL{}, exp: true},
+		{name: "allow_duplicates", service: &GRPCServer{AllowDuplicates: true}, req: &api.URL{}, exp: false},
+		{name: "max_uses", service: &GRPCServer{}, req: &api.URL{MaxUses: 1}, exp: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if dedup := testCase.service.deduplicate(testCase.req); dedup != testCase.exp {
+				t.Errorf("the result %v was expected, but %v was received", testCase.exp, dedup)
+			}
+		})
+	}
+}
+
+func TestCreateInvalidMaxUses(t *testing.T) {
+	// ограничение проверяется до обращения к базе данных
+	service := &GRPCServer{}
+
+	_, err := service.Create(context.Background(), &api.URL{Url: "http://uses.abc/", MaxUses: -1})
+	if err = FromStatus(err); err != ErrInvalidMaxUses {
+		t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrInvalidMaxUses, err)
+	}
+}
+
+func TestGetMaxUses(t *testing.T) {
+	// устанавливаем подключение к базе данных
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed connecting to the database: %v", err)
+	}
+
+	defer db.Close()
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	defer service.Close()
+
+	url := "http://uses.abc/" + generateRandomСharacters(8)
+
+	link, err := service.Create(context.Background(), &api.URL{Url: url, MaxUses: 2})
+	if err != nil {
+		t.Fatalf("Create method reported an error: %v", err)
+	}
+
+	// ссылки с ограничением не дедуплицируются
+	other, err := service.Create(context.Background(), &api.URL{Url: url, MaxUses: 2})
+	if err != nil {
+		t.Fatalf("Create method reported an error: %v", err)
+	}
+
+	if other.GetLink() == link.GetLink() {
+		t.Errorf("a new link was expected for the limited link, but \"%s\" was received again", link.GetLink())
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := service.Get(context.Background(), link); err != nil {
+			t.Fatalf("Get method reported an error: %v", err)
+		}
+	}
+
+	_, err = service.Get(context.Background(), link)
+	if err = FromStatus(err); err != ErrLinkExhausted {
+		t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrLinkExhausted, err)
+	}
+}
+
+func TestGetMaxUsesConcurrent(t *testing.T) {
+	// устанавливаем подключение к базе данных
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed connecting to the database: %v", err)
+	}
+
+	defer db.Close()
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	defer service.Close()
+
+	const maxUses, requests = 5, 20
+
+	link, err := service.Create(context.Background(), &api.URL{Url: "http://uses.abc/" + generateRandomСharacters(8), MaxUses: maxUses})
+	if err != nil {
+		t.Fatalf("Create method reported an error: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded := 0
+
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, err := service.Get(context.Background(), link)
+			if err != nil {
+				if err = FromStatus(err); err != ErrLinkExhausted {
+					t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", ErrLinkExhausted, err)
+				}
+
+				return
+			}
+
+			mu.Lock()
+			succeeded++
+			mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+
+	if succeeded != maxUses {
+		t.Errorf("%d successful requests were expected, but %d were received", maxUses, succeeded)
+	}
+}
@@ -0,0 +1,47 @@
+package linkservice
+
+import (
+	"context"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+)
+
+// BuildInfo содержит сведения о сборке сервиса. Значения задаются при сборке
+// флагом -ldflags и передаются серверу из пакета main
+type BuildInfo struct {
+	// Version задает версию сборки, например "v1.4.0"
+	Version string
+
+	// Commit задает хеш коммита, из которого собран сервис
+	Commit string
+
+	// Time задает время сборки
+	Time string
+}
+
+// Version возвращает сведения о сборке сервиса и сообщает, доступна ли база
+// данных. Метод предназначен для быстрой проверки работающего экземпляра,
+// поэтому недоступность базы данных не считается ошибкой, а отражается в
+// поле database_available.
+func (s *GRPCServer) Version(ctx context.Context, req *api.Empty) (*api.VersionInfo, error) {
+	info := &api.VersionInfo{
+		Version:           s.Build.Version,
+		Commit:            s.Build.Commit,
+		BuildTime:         s.Build.Time,
+		DatabaseAvailable: true,
+	}
+
+	pctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if err := s.Database.PingContext(pctx); err != nil {
+		if ctx.Err() != nil {
+			return nil, statusError(ErrDeadlineExceeded)
+		}
+
+		s.logError("Version", err)
+		info.DatabaseAvailable = false
+	}
+
+	return info, nil
+}
@@ -0,0 +1,67 @@
+package linkservice
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/pavelzagorodnyuk/linkservice/internal/api"
+)
+
+func TestVersion(t *testing.T) {
+	// устанавливаем подключение к базе данных
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed connecting to the database: %v", err)
+	}
+
+	defer db.Close()
+
+	service, err := NewGRPCServer(db)
+	if err != nil {
+		t.Fatalf("failed to prepare the server: %v", err)
+	}
+
+	defer service.Close()
+
+	service.Build = BuildInfo{Version: "v1.2.3", Commit: "abc1234", Time: "2021-10-01T12:00:00Z"}
+
+	info, err := service.Version(context.Background(), &api.Empty{})
+	if err != nil {
+		t.Fatalf("Version method reported an error: %v", err)
+	}
+
+	if info.GetVersion() != "v1.2.3" || info.GetCommit() != "abc1234" || info.GetBuildTime() != "2021-10-01T12:00:00Z" {
+		t.Errorf("the build information of the server was expected, but %v was received", info)
+	}
+
+	if !info.GetDatabaseAvailable() {
+		t.Errorf("the database was expected to be available")
+	}
+}
+
+func TestVersionDatabaseUnavailable(t *testing.T) {
+	// закрытая база данных недоступна, но метод все равно сообщает сведения
+	// о сборке
+	db, err := sql.Open("postgres", DBConnParamsForTests)
+	if err != nil {
+		t.Fatalf("failed connecting to the database: %v", err)
+	}
+
+	db.Close()
+
+	service := &GRPCServer{Database: db, Build: BuildInfo{Version: "v1.2.3"}, Logger: &recordLogger{}}
+
+	info, err := service.Version(context.Background(), &api.Empty{})
+	if err != nil {
+		t.Fatalf("Version method reported an error: %v", err)
+	}
+
+	if info.GetVersion() != "v1.2.3" {
+		t.Errorf("the version \"v1.2.3\" was expected, but \"%s\" was received", info.GetVersion())
+	}
+
+	if info.GetDatabaseAvailable() {
+		t.Errorf("the closed database was expected to be unavailable")
+	}
+}
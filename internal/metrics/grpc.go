@@ -0,0 +1,131 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// ServerMetrics содержит метрики gRPC-сервера и базы данных сервиса. Имена и
+// метки метрик gRPC совпадают с метриками go-grpc-prometheus, поэтому для них
+// подходят существующие панели и правила оповещений.
+type ServerMetrics struct {
+	// handled подсчитывает завершенные вызовы методов с разбивкой по коду
+	// состояния, в том числе успешные и ошибочные вызовы Create и Get
+	handled *CounterVec
+
+	// handling содержит распределение длительности вызовов методов
+	handling *HistogramVec
+
+	// queries содержит распределение длительности запросов к базе данных
+	queries *HistogramVec
+
+	// collisions подсчитывает повторные попытки добавить запись после
+	// коллизии коротких ссылок
+	collisions *CounterVec
+
+	// saturation показывает долю занятых коротких ссылок среди всех
+	// возможных ссылок алфавита по умолчанию
+	saturation *Gauge
+
+	// Links показывает общее количество коротких ссылок в базе данных
+	Links *Gauge
+}
+
+// NewServerMetrics создает метрики сервера и регистрирует их в реестре r.
+func NewServerMetrics(r *Registry) *ServerMetrics {
+	return &ServerMetrics{
+		handled: r.NewCounterVec("grpc_server_handled_total",
+			"Total number of RPCs completed on the server, regardless of success or failure.",
+			"grpc_type", "grpc_service", "grpc_method", "grpc_code"),
+
+		handling: r.NewHistogramVec("grpc_server_handling_seconds",
+			"Histogram of response latency (seconds) of gRPC that had been application-level handled by the server.",
+			DefBuckets, "grpc_type", "grpc_service", "grpc_method"),
+
+		queries: r.NewHistogramVec("linkservice_db_query_duration_seconds",
+			"Histogram of database query latency (seconds).",
+			DefBuckets, "query"),
+
+		collisions: r.NewCounterVec("linkservice_link_collisions_total",
+			"Total number of short link regenerations caused by collisions with taken links.",
+			"query"),
+
+		saturation: r.NewGauge("linkservice_code_space_saturation",
+			"Ratio of taken short links to all possible short links of the configured length and default alphabet."),
+
+		Links: r.NewGauge("linkservice_links", "Total number of short links stored in the database."),
+	}
+}
+
+// observe учитывает вызов метода fullMethod типа kind, завершившийся ошибкой
+// err через время d после начала.
+func (m *ServerMetrics) observe(kind, fullMethod string, err error, d time.Duration) {
+	service, method := splitMethod(fullMethod)
+
+	m.handled.Inc(kind, service, method, status.Code(err).String())
+	m.handling.Observe(d.Seconds(), kind, service, method)
+}
+
+// UnaryInterceptor возвращает перехватчик унарных gRPC-запросов, учитывающий
+// их в метриках.
+func (m *ServerMetrics) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		res, err := handler(ctx, req)
+
+		m.observe("unary", info.FullMethod, err, time.Since(start))
+		return res, err
+	}
+}
+
+// StreamInterceptor возвращает перехватчик потоковых gRPC-запросов,
+// учитывающий их в метриках.
+func (m *ServerMetrics) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		kind := "bidi_stream"
+		switch {
+		case info.IsClientStream && !info.IsServerStream:
+			kind = "client_stream"
+		case !info.IsClientStream && info.IsServerStream:
+			kind = "server_stream"
+		}
+
+		start := time.Now()
+		err := handler(srv, ss)
+
+		m.observe(kind, info.FullMethod, err, time.Since(start))
+		return err
+	}
+}
+
+// ObserveQuery учитывает запрос к базе данных query длительностью d.
+func (m *ServerMetrics) ObserveQuery(query string, d time.Duration) {
+	m.queries.Observe(d.Seconds(), query)
+}
+
+// ObserveCollisions учитывает n коллизий коротких ссылок, возникших при
+// добавлении записи запросом query.
+func (m *ServerMetrics) ObserveCollisions(query string, n int) {
+	m.collisions.Add(float64(n), query)
+}
+
+// ObserveSaturation учитывает долю ratio занятых коротких ссылок.
+func (m *ServerMetrics) ObserveSaturation(ratio float64) {
+	m.saturation.Set(ratio)
+}
+
+// splitMethod разделяет полное имя метода вида "/package.Service/Method" на
+// имя службы и имя метода.
+func splitMethod(fullMethod string) (string, string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+
+	if i := strings.LastIndex(fullMethod, "/"); i >= 0 {
+		return fullMethod[:i], fullMethod[i+1:]
+	}
+
+	return "unknown", fullMethod
+}
@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryInterceptor(t *testing.T) {
+	m := NewServerMetrics(NewRegistry())
+	interceptor := m.UnaryInterceptor()
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/api.LinkService/Get"}
+	for _, err := range []error{nil, nil, status.Error(codes.NotFound, "not found")} {
+		err := err
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			return req, err
+		}
+
+		if _, got := interceptor(context.Background(), "req", info, handler); got != err {
+			t.Errorf("an error with a value of \"%v\" was expected, but \"%v\" was received", err, got)
+		}
+	}
+
+	for code, exp := range map[string]float64{"OK": 2, "NotFound": 1, "Internal": 0} {
+		if v := m.handled.Value("unary", "api.LinkService", "Get", code); v != exp {
+			t.Errorf("%s: a value of %v was expected, but %v was received", code, exp, v)
+		}
+	}
+
+	if n := m.handling.Count("unary", "api.LinkService", "Get"); n != 3 {
+		t.Errorf("a count of %v was expected, but %v was received", 3, n)
+	}
+}
+
+func TestStreamInterceptor(t *testing.T) {
+	m := NewServerMetrics(NewRegistry())
+
+	info := &grpc.StreamServerInfo{FullMethod: "/grpc.health.v1.Health/Watch", IsServerStream: true}
+	handler := func(srv interface{}, ss grpc.ServerStream) error {
+		return status.Error(codes.Canceled, "canceled")
+	}
+
+	m.StreamInterceptor()(nil, nil, info, handler)
+
+	if v := m.handled.Value("server_stream", "grpc.health.v1.Health", "Watch", "Canceled"); v != 1 {
+		t.Errorf("a value of %v was expected, but %v was received", 1, v)
+	}
+}
+
+func TestObserveQuery(t *testing.T) {
+	m := NewServerMetrics(NewRegistry())
+	m.ObserveQuery("select_url", 10*time.Millisecond)
+
+	if n := m.queries.Count("select_url"); n != 1 {
+		t.Errorf("a count of %v was expected, but %v was received", 1, n)
+	}
+}
+
+func TestObserveCollisions(t *testing.T) {
+	m := NewServerMetrics(NewRegistry())
+	m.ObserveCollisions("insert_link", 2)
+	m.ObserveCollisions("insert_link", 1)
+
+	if v := m.collisions.Value("insert_link"); v != 3 {
+		t.Errorf("a value of %v was expected, but %v was received", 3, v)
+	}
+}
+
+func TestObserveSaturation(t *testing.T) {
+	m := NewServerMetrics(NewRegistry())
+	m.ObserveSaturation(0.25)
+
+	if v := m.saturation.Value(); v != 0.25 {
+		t.Errorf("a value of %v was expected, but %v was received", 0.25, v)
+	}
+}
+
+func TestSplitMethod(t *testing.T) {
+	for fullMethod, exp := range map[string][2]string{
+		"/api.LinkService/Create": {"api.LinkService", "Create"},
+		"Create":                  {"unknown", "Create"},
+	} {
+		if service, method := splitMethod(fullMethod); service != exp[0] || method != exp[1] {
+			t.Errorf("\"%s\" and \"%s\" were expected, but \"%s\" and \"%s\" were received", exp[0], exp[1], service, method)
+		}
+	}
+}
@@ -0,0 +1,347 @@
+// Package metrics реализует метрики сервиса в текстовом формате Prometheus.
+// Пакет поддерживает счетчики, показатели и гистограммы с метками в объеме,
+// необходимом сервису, и не зависит от клиентской библиотеки Prometheus.
+//
+// Клиентская библиотека client_golang и go-grpc-prometheus не используются
+// намеренно: сервис зависит только от драйвера PostgreSQL, gRPC и protobuf, а
+// эти библиотеки добавили бы в сборку несколько десятков транзитивных
+// зависимостей ради нескольких метрик. Имена, метки и границы интервалов
+// гистограмм совпадают с принятыми в этих библиотеках, поэтому при переходе на
+// них панели и правила оповещений менять не придется.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefBuckets содержит границы интервалов гистограмм по умолчанию (в секундах),
+// совпадающие с границами клиентской библиотеки Prometheus
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// collector описывает метрику, которую можно записать в текстовом формате
+// Prometheus.
+type collector interface {
+	write(w io.Writer)
+}
+
+// Registry хранит метрики и предоставляет их по HTTP в текстовом формате
+// Prometheus.
+type Registry struct {
+	mu         sync.Mutex
+	collectors []collector
+}
+
+// NewRegistry создает пустой реестр метрик.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.collectors = append(r.collectors, c)
+}
+
+// WriteText записывает все метрики реестра в w в текстовом формате Prometheus.
+func (r *Registry) WriteText(w io.Writer) {
+	r.mu.Lock()
+	collectors := append([]collector(nil), r.collectors...)
+	r.mu.Unlock()
+
+	for _, c := range collectors {
+		c.write(w)
+	}
+}
+
+// ServeHTTP отвечает на запрос Prometheus значениями всех метрик реестра.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.WriteText(w)
+}
+
+// desc содержит общие для всех метрик сведения
+type desc struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+}
+
+func (d desc) writeHeader(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.name, escapeHelp(d.help), d.name, d.kind)
+}
+
+// key объединяет значения меток в ключ, по которому хранятся значения
+// метрики. Символ \xff не может встретиться в корректной строке UTF-8.
+func (d desc) key(values []string) string {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", d.name, len(d.labels), len(values)))
+	}
+
+	return strings.Join(values, "\xff")
+}
+
+// labelPairs форматирует метки со значениями values, дополненные метками
+// extra, в виде {name="value",...}.
+func (d desc) labelPairs(values []string, extra ...string) string {
+	if len(d.labels) == 0 && len(extra) == 0 {
+		return ""
+	}
+
+	pairs := make([]string, 0, len(d.labels)+len(extra)/2)
+	for i, name := range d.labels {
+		pairs = append(pairs, name+`="`+labelEscaper.Replace(values[i])+`"`)
+	}
+
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, extra[i]+`="`+labelEscaper.Replace(extra[i+1])+`"`)
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// sortedKeys возвращает ключи значений метрики в порядке сортировки, чтобы
+// вывод не зависел от порядка обхода map.
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+// CounterVec представляет собой набор счетчиков, различающихся значениями
+// меток.
+type CounterVec struct {
+	desc
+
+	mu     sync.Mutex
+	values map[string]float64
+	labels map[string][]string
+}
+
+// NewCounterVec создает набор счетчиков name с метками labels и регистрирует
+// его в реестре.
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{
+		desc:   desc{name: name, help: help, kind: "counter", labels: labels},
+		values: make(map[string]float64),
+		labels: make(map[string][]string),
+	}
+
+	r.register(c)
+	return c
+}
+
+// Inc увеличивает на единицу счетчик со значениями меток values.
+func (c *CounterVec) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add увеличивает на v счетчик со значениями меток values. Отрицательные
+// значения игнорируются: счетчик может только возрастать.
+func (c *CounterVec) Add(v float64, values ...string) {
+	if v < 0 {
+		return
+	}
+
+	k := c.key(values)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.values[k] += v
+	c.labels[k] = values
+}
+
+// Value возвращает значение счетчика со значениями меток values.
+func (c *CounterVec) Value(values ...string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.values[c.key(values)]
+}
+
+func (c *CounterVec) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.writeHeader(w)
+	for _, k := range sortedKeys(c.labels) {
+		fmt.Fprintf(w, "%s%s %s\n", c.name, c.labelPairs(c.labels[k]), formatValue(c.values[k]))
+	}
+}
+
+// Gauge представляет собой показатель, значение которого может как
+// возрастать, так и убывать.
+type Gauge struct {
+	desc
+
+	mu    sync.Mutex
+	value float64
+}
+
+// NewGauge создает показатель name и регистрирует его в реестре.
+func (r *Registry) NewGauge(name, help string) *Gauge {
+	g := &Gauge{desc: desc{name: name, help: help, kind: "gauge"}}
+
+	r.register(g)
+	return g
+}
+
+// Set задает значение показателя.
+func (g *Gauge) Set(v float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.value = v
+}
+
+// Value возвращает значение показателя.
+func (g *Gauge) Value() float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.value
+}
+
+func (g *Gauge) write(w io.Writer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.writeHeader(w)
+	fmt.Fprintf(w, "%s %s\n", g.name, formatValue(g.value))
+}
+
+// HistogramVec представляет собой набор гистограмм, различающихся значениями
+// меток.
+type HistogramVec struct {
+	desc
+	buckets []float64
+
+	mu     sync.Mutex
+	hists  map[string]*histogram
+	labels map[string][]string
+}
+
+// histogram содержит количество наблюдений в каждом интервале (без
+// накопления), их сумму и общее количество
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// NewHistogramVec создает набор гистограмм name с границами интервалов
+// buckets и метками labels и регистрирует его в реестре. Если границы не
+// заданы, то используются DefBuckets.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefBuckets
+	}
+
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+
+	h := &HistogramVec{
+		desc:    desc{name: name, help: help, kind: "histogram", labels: labels},
+		buckets: buckets,
+		hists:   make(map[string]*histogram),
+		labels:  make(map[string][]string),
+	}
+
+	r.register(h)
+	return h
+}
+
+// Observe добавляет наблюдение v в гистограмму со значениями меток values.
+func (h *HistogramVec) Observe(v float64, values ...string) {
+	k := h.key(values)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	hist, ok := h.hists[k]
+	if !ok {
+		hist = &histogram{counts: make([]uint64, len(h.buckets))}
+		h.hists[k] = hist
+		h.labels[k] = values
+	}
+
+	// наблюдения больше последней границы учитываются только в интервале
+	// +Inf, то есть в общем количестве
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		hist.counts[i]++
+	}
+
+	hist.sum += v
+	hist.count++
+}
+
+// Count возвращает количество наблюдений в гистограмме со значениями меток
+// values.
+func (h *HistogramVec) Count(values ...string) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if hist, ok := h.hists[h.key(values)]; ok {
+		return hist.count
+	}
+
+	return 0
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.writeHeader(w)
+	for _, k := range sortedKeys(h.labels) {
+		hist, values := h.hists[k], h.labels[k]
+
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += hist.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelPairs(values, "le", formatValue(bound)), cumulative)
+		}
+
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelPairs(values, "le", "+Inf"), hist.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, h.labelPairs(values), formatValue(hist.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, h.labelPairs(values), hist.count)
+	}
+}
+
+// formatValue форматирует значение метрики так, как этого требует текстовый
+// формат Prometheus.
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+// labelEscaper экранирует обратную косую черту, кавычки и перевод строки в
+// значениях меток
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeHelp экранирует обратную косую черту и перевод строки в описании
+// метрики.
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
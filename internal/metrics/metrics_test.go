@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"math"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWriteText(t *testing.T) {
+	r := NewRegistry()
+
+	requests := r.NewCounterVec("requests_total", "Total number of requests.", "method", "code")
+	requests.Inc("Get", "OK")
+	requests.Inc("Get", "OK")
+	requests.Add(3, "Create", "Internal")
+	requests.Add(-1, "Create", "Internal")
+
+	links := r.NewGauge("links", "Number of links.")
+	links.Set(42)
+
+	latency := r.NewHistogramVec("latency_seconds", "Request latency.", []float64{1, 0.1}, "query")
+	latency.Observe(0.05, "select")
+	latency.Observe(0.5, "select")
+	latency.Observe(5, "select")
+
+	var b strings.Builder
+	r.WriteText(&b)
+
+	exp := `# HELP requests_total Total number of requests.
+# TYPE requests_total counter
+requests_total{method="Create",code="Internal"} 3
+requests_total{method="Get",code="OK"} 2
+# HELP links Number of links.
+# TYPE links gauge
+links 42
+# HELP latency_seconds Request latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{query="select",le="0.1"} 1
+latency_seconds_bucket{query="select",le="1"} 2
+latency_seconds_bucket{query="select",le="+Inf"} 3
+latency_seconds_sum{query="select"} 5.55
+latency_seconds_count{query="select"} 3
+`
+	if b.String() != exp {
+		t.Errorf("the output\n%s\nwas expected, but\n%s\nwas received", exp, b.String())
+	}
+
+	if v := requests.Value("Get", "OK"); v != 2 {
+		t.Errorf("a value of %v was expected, but %v was received", 2, v)
+	}
+
+	if n := latency.Count("select"); n != 3 {
+		t.Errorf("a count of %v was expected, but %v was received", 3, n)
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	r := NewRegistry()
+	r.NewGauge("links", "Number of links.").Set(1)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("an unexpected content type \"%s\" was received", ct)
+	}
+
+	if !strings.Contains(rec.Body.String(), "\nlinks 1\n") {
+		t.Errorf("the gauge value was expected in the response, but \"%s\" was received", rec.Body.String())
+	}
+}
+
+func TestEscaping(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("escaped_total", "Line\\one\nline two.", "label").Inc("a\"b\\c\nd")
+
+	var b strings.Builder
+	r.WriteText(&b)
+
+	for _, exp := range []string{
+		`# HELP escaped_total Line\\one\nline two.`,
+		`escaped_total{label="a\"b\\c\nd"} 1`,
+	} {
+		if !strings.Contains(b.String(), exp) {
+			t.Errorf("the line \"%s\" was expected in the output\n%s", exp, b.String())
+		}
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	for v, exp := range map[float64]string{
+		0:            "0",
+		0.25:         "0.25",
+		1e21:         "1e+21",
+		math.Inf(1):  "+Inf",
+		math.Inf(-1): "-Inf",
+		math.NaN():   "NaN",
+	} {
+		if s := formatValue(v); s != exp {
+			t.Errorf("a value of \"%s\" was expected, but \"%s\" was received", exp, s)
+		}
+	}
+}
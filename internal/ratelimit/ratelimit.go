@@ -0,0 +1,188 @@
+// Package ratelimit ограничивает частоту gRPC-запросов от каждого клиента
+// алгоритмом маркерной корзины (token bucket).
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// ForwardedForKey содержит ключ метаданных, в котором шлюз, обращающийся к
+// сервису по локальному адресу, передает IP-адрес своего клиента. Значение
+// учитывается только в запросах с локального адреса, чтобы внешние клиенты не
+// могли обойти ограничение, подставив чужой адрес.
+const ForwardedForKey = "x-forwarded-for"
+
+// ForwardedUserAgentKey содержит ключ метаданных, в котором шлюз передает
+// заголовок User-Agent своего клиента: метаданные user-agent gRPC-клиент
+// заполняет собственным значением. Значение учитывается на тех же условиях,
+// что и ForwardedForKey.
+const ForwardedUserAgentKey = "x-forwarded-user-agent"
+
+// интервал, с которым из памяти удаляются корзины клиентов, давно не
+// отправлявших запросов
+var sweepInterval = time.Minute
+
+// bucket содержит состояние корзины одного клиента
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter ограничивает частоту запросов от каждого клиента, различая клиентов
+// по IP-адресу. Клиент может отправить Burst запросов подряд, после чего
+// корзина пополняется со скоростью Rate запросов в секунду. Limiter должен
+// создаваться функцией New.
+type Limiter struct {
+	// Rate задает допустимое количество запросов в секунду
+	Rate float64
+
+	// Burst задает максимальное количество запросов, которые клиент может
+	// отправить подряд
+	Burst int
+
+	// Methods содержит полные имена методов вида "/api.LinkService/Create",
+	// на которые распространяется ограничение. Если не задан, то
+	// ограничиваются все методы
+	Methods map[string]bool
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+
+	// now возвращает текущее время; заменяется в тестах
+	now func() time.Time
+}
+
+// New создает ограничитель, пропускающий от каждого клиента rate запросов в
+// секунду и не более burst запросов подряд.
+func New(rate float64, burst int) *Limiter {
+	return &Limiter{
+		Rate:    rate,
+		Burst:   burst,
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Allow сообщает, может ли клиент key отправить запрос, и, если может,
+// расходует на запрос один маркер его корзины.
+func (l *Limiter) Allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.Burst), last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * l.Rate
+	if b.tokens > float64(l.Burst) {
+		b.tokens = float64(l.Burst)
+	}
+
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+
+	b.tokens--
+	return true
+}
+
+// sweep удаляет корзины, которые к моменту now заполнились бы полностью: они
+// ничем не отличаются от корзин новых клиентов. Удаление выполняется не чаще
+// sweepInterval.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.Rate >= float64(l.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// UnaryInterceptor возвращает перехватчик унарных gRPC-запросов, отклоняющий
+// запросы сверх допустимой частоты с кодом состояния codes.ResourceExhausted.
+func (l *Limiter) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if l.Methods != nil && !l.Methods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		if !l.Allow(clientIP(ctx)) {
+			return nil, status.Error(codes.ResourceExhausted, "ratelimit: too many requests")
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// clientIP возвращает IP-адрес клиента, отправившего запрос с контекстом ctx.
+// Для запросов с локального адреса учитывается адрес из метаданных
+// ForwardedForKey. Для адресов без порта, например, адресов Unix-сокетов,
+// возвращается адрес целиком, а при отсутствии сведений о клиенте — пустая
+// строка.
+func clientIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+
+	if forwarded, ok := Forwarded(ctx, ForwardedForKey); ok {
+		return forwarded
+	}
+
+	return host
+}
+
+// Forwarded возвращает значение метаданных key запроса с контекстом ctx, если
+// запрос получен с локального адреса, то есть от шлюза, работающего в том же
+// процессе или на том же хосте. Значения из запросов внешних клиентов не
+// учитываются, чтобы клиент не мог выдать себя за другого.
+func Forwarded(ctx context.Context, key string) (string, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return "", false
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return "", false
+	}
+
+	if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+		return "", false
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(key)
+	if len(values) == 0 || values[0] == "" {
+		return "", false
+	}
+
+	return values[0], true
+}
@@ -0,0 +1,154 @@
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// clock представляет собой управляемые тестом часы
+type clock struct {
+	t time.Time
+}
+
+func (c *clock) now() time.Time {
+	return c.t
+}
+
+func (c *clock) advance(d time.Duration) {
+	c.t = c.t.Add(d)
+}
+
+// peerContext возвращает контекст запроса от клиента с адресом addr.
+func peerContext(addr string) context.Context {
+	tcpAddr, _ := net.ResolveTCPAddr("tcp", addr)
+	return peer.NewContext(context.Background(), &peer.Peer{Addr: tcpAddr})
+}
+
+func TestUnaryInterceptor(t *testing.T) {
+	c := &clock{t: time.Unix(0, 0)}
+
+	l := New(2, 3)
+	l.now = c.now
+
+	interceptor := l.UnaryInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/api.LinkService/Create"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+
+	call := func(addr string) codes.Code {
+		_, err := interceptor(peerContext(addr), nil, info, handler)
+		return status.Code(err)
+	}
+
+	// клиент может отправить Burst запросов подряд
+	for i := 0; i < 3; i++ {
+		if code := call("10.0.0.1:5000"); code != codes.OK {
+			t.Fatalf("request %d: the code %v was expected, but %v was received", i+1, codes.OK, code)
+		}
+	}
+
+	if code := call("10.0.0.1:5000"); code != codes.ResourceExhausted {
+		t.Errorf("the code %v was expected, but %v was received", codes.ResourceExhausted, code)
+	}
+
+	// клиенты различаются по IP-адресу, но не по порту
+	if code := call("10.0.0.1:6000"); code != codes.ResourceExhausted {
+		t.Errorf("the code %v was expected for another port, but %v was received", codes.ResourceExhausted, code)
+	}
+
+	if code := call("10.0.0.2:5000"); code != codes.OK {
+		t.Errorf("the code %v was expected for another client, but %v was received", codes.OK, code)
+	}
+
+	// за полсекунды корзина пополняется одним маркером
+	c.advance(500 * time.Millisecond)
+
+	if code := call("10.0.0.1:5000"); code != codes.OK {
+		t.Errorf("the code %v was expected after refill, but %v was received", codes.OK, code)
+	}
+
+	if code := call("10.0.0.1:5000"); code != codes.ResourceExhausted {
+		t.Errorf("the code %v was expected, but %v was received", codes.ResourceExhausted, code)
+	}
+}
+
+func TestMethods(t *testing.T) {
+	l := New(1, 1)
+	l.Methods = map[string]bool{"/api.LinkService/Create": true}
+
+	interceptor := l.UnaryInterceptor()
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+
+	ctx := peerContext("10.0.0.1:5000")
+	get := &grpc.UnaryServerInfo{FullMethod: "/api.LinkService/Get"}
+
+	for i := 0; i < 5; i++ {
+		if _, err := interceptor(ctx, nil, get, handler); err != nil {
+			t.Fatalf("an unlimited method reported an error: %v", err)
+		}
+	}
+
+	create := &grpc.UnaryServerInfo{FullMethod: "/api.LinkService/Create"}
+	interceptor(ctx, nil, create, handler)
+
+	if _, err := interceptor(ctx, nil, create, handler); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("the code %v was expected, but %v was received", codes.ResourceExhausted, status.Code(err))
+	}
+}
+
+func TestSweep(t *testing.T) {
+	c := &clock{t: time.Unix(0, 0)}
+
+	l := New(1, 2)
+	l.now = c.now
+
+	l.Allow("a")
+	l.Allow("b")
+	l.Allow("b")
+
+	// через минуту обе корзины заполнены и удаляются при следующем запросе
+	c.advance(sweepInterval)
+	l.Allow("c")
+
+	if _, ok := l.buckets["a"]; ok {
+		t.Errorf("the full bucket was expected to be removed")
+	}
+
+	if len(l.buckets) != 1 {
+		t.Errorf("1 bucket was expected, but %d were found", len(l.buckets))
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	if ip := clientIP(peerContext("[::1]:5000")); ip != "::1" {
+		t.Errorf("the address \"%s\" was expected, but \"%s\" was received", "::1", ip)
+	}
+
+	// адрес из метаданных учитывается только для запросов с локального адреса
+	md := metadata.Pairs(ForwardedForKey, "203.0.113.7")
+
+	ctx := metadata.NewIncomingContext(peerContext("127.0.0.1:5000"), md)
+	if ip := clientIP(ctx); ip != "203.0.113.7" {
+		t.Errorf("the address \"%s\" was expected, but \"%s\" was received", "203.0.113.7", ip)
+	}
+
+	ctx = metadata.NewIncomingContext(peerContext("10.0.0.1:5000"), md)
+	if ip := clientIP(ctx); ip != "10.0.0.1" {
+		t.Errorf("the address \"%s\" was expected, but \"%s\" was received", "10.0.0.1", ip)
+	}
+
+	if ip := clientIP(context.Background()); ip != "" {
+		t.Errorf("an empty address was expected, but \"%s\" was received", ip)
+	}
+}